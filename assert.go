@@ -0,0 +1,96 @@
+package generic
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// AssertImplements is a compile-time conformance check. The call doesn't
+// compile unless v's type implements I, so pass a nil pointer or zero
+// value of the candidate type:
+//
+//	var _ = generic.AssertImplements[generic.Queue[int]]((*MyQueue)(nil))
+//
+// Go can't express "T implements I" as a constraint on two type
+// parameters, so the candidate goes through the argument instead.
+func AssertImplements[I any](v I) struct{} {
+	return struct{}{}
+}
+
+// Implements reports whether T or *T implements the interface I.
+func Implements[I, T any]() bool {
+	return len(MissingMethods[I, T]()) == 0
+}
+
+// MissingMethods lists the methods of interface I that neither T nor *T
+// provides with a matching signature, described as "Name: reason". It
+// panics if I is not an interface type.
+func MissingMethods[I, T any]() []string {
+	iface := reflect.TypeFor[I]()
+	if iface.Kind() != reflect.Interface {
+		panic(fmt.Errorf("generic: %v is not an interface", iface))
+	}
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() != reflect.Interface && typ.Kind() != reflect.Pointer {
+		typ = reflect.PointerTo(typ)
+	}
+	var missing []string
+	for i := range iface.NumMethod() {
+		want := iface.Method(i)
+		got, ok := typ.MethodByName(want.Name)
+		if !ok {
+			missing = append(missing, want.Name+": missing")
+			continue
+		}
+		if sig := methodSignature(got, typ.Kind() != reflect.Interface); sig != methodSignature(want, false) {
+			missing = append(missing, fmt.Sprintf("%s: has %s, want %s", want.Name, sig, methodSignature(want, false)))
+		}
+	}
+	return missing
+}
+
+// DescribeImplements returns nil if T or *T implements I, and otherwise an
+// error listing every missing or mismatched method.
+func DescribeImplements[I, T any]() error {
+	missing := MissingMethods[I, T]()
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("generic: %v does not implement %v: %s",
+		reflect.TypeFor[T](), reflect.TypeFor[I](), strings.Join(missing, "; "))
+}
+
+// methodSignature formats m's signature, dropping the receiver of methods
+// taken from a concrete type.
+func methodSignature(m reflect.Method, hasReceiver bool) string {
+	var b strings.Builder
+	b.WriteString("func(")
+	start := 0
+	if hasReceiver {
+		start = 1
+	}
+	for i := start; i < m.Type.NumIn(); i++ {
+		if i > start {
+			b.WriteString(", ")
+		}
+		if m.Type.IsVariadic() && i == m.Type.NumIn()-1 {
+			b.WriteString("..." + m.Type.In(i).Elem().String())
+		} else {
+			b.WriteString(m.Type.In(i).String())
+		}
+	}
+	b.WriteString(")")
+	switch m.Type.NumOut() {
+	case 0:
+	case 1:
+		b.WriteString(" " + m.Type.Out(0).String())
+	default:
+		outs := make([]string, m.Type.NumOut())
+		for i := range outs {
+			outs[i] = m.Type.Out(i).String()
+		}
+		b.WriteString(" (" + strings.Join(outs, ", ") + ")")
+	}
+	return b.String()
+}
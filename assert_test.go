@@ -0,0 +1,69 @@
+package generic
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+var (
+	_ = AssertImplements[Queue[int]]((*FiFo[int])(nil))
+	_ = AssertImplements[Queue[int]]((*FilterQueue[int])(nil))
+	_ = AssertImplements[Queue[int]]((*Recorder[int])(nil))
+	_ = AssertImplements[Codec[int]](JSONCodec[int]{})
+	_ = AssertImplements[Codec[int]](GobCodec[int]{})
+	_ = AssertImplements[Balancer[int]]((*RoundRobin[int])(nil))
+	_ = AssertImplements[Clock](SystemClock)
+)
+
+type halfQueue struct{}
+
+func (halfQueue) Put(ctx context.Context, x int) error { return nil }
+func (halfQueue) TryPut(x string) bool                 { return false }
+
+type ptrQueue struct{ FiFo[int] }
+
+func TestImplements(t *testing.T) {
+	if !Implements[Queue[int], FiFo[int]]() {
+		t.Fatal("*FiFo implements Queue, so FiFo should count")
+	}
+	if !Implements[Queue[int], ptrQueue]() {
+		t.Fatal("promoted pointer methods should count")
+	}
+	if Implements[Queue[int], halfQueue]() {
+		t.Fatal("halfQueue should not implement Queue")
+	}
+	if !Implements[Codec[string], Codec[string]]() {
+		t.Fatal("an interface implements itself")
+	}
+}
+
+func TestMissingMethods(t *testing.T) {
+	missing := MissingMethods[Queue[int], halfQueue]()
+	want := []string{
+		"Get: missing",
+		"IsEmpty: missing",
+		"Size: missing",
+		"TryGet: missing",
+		"TryPut: has func(string) bool, want func(int) bool",
+	}
+	if strings.Join(missing, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got %q\nwant %q", missing, want)
+	}
+	err := DescribeImplements[Queue[int], halfQueue]()
+	if err == nil || !strings.Contains(err.Error(), "TryPut: has func(string) bool") {
+		t.Fatalf("unexpected description %v", err)
+	}
+	if err := DescribeImplements[Queue[int], FiFo[int]](); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMissingMethods_NotInterface(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for a non-interface I")
+		}
+	}()
+	MissingMethods[int, int]()
+}
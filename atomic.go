@@ -1,25 +1,51 @@
 package generic
 
 import (
+	"context"
 	"fmt"
+	"iter"
+	"reflect"
 	"sync/atomic"
+	"unsafe"
 )
 
+// Atomic holds a T that is read and written atomically. Create one with
+// MakeAtomic; the zero Atomic loads the zero T and ignores stores.
 type Atomic[T any] struct {
-	load           func() T
-	store          func(x T)
-	swap           func(x T) T
-	compareAndSwap func(old, new T) bool
+	load               func() (T, bool)
+	store              func(x T)
+	swap               func(x T) T
+	compareAndSwap     func(old, new T) bool
+	compareAndSwapFunc func(old, new T, eq func(a, b T) bool) bool
+	watch              func() (changed <-chan struct{}, v T, ok bool)
 }
 
+// Load returns the current value, or the zero T if none has been stored.
 func (a Atomic[T]) Load() T {
+	v, _ := a.LoadOK()
+	return v
+}
+
+// LoadOK returns the current value and whether one has been stored.
+func (a Atomic[T]) LoadOK() (T, bool) {
 	if a.load == nil {
 		var v T
-		return v
+		return v, false
 	}
 	return a.load()
 }
 
+// StrictLoad is Load for callers that treat reading before the first store
+// as a bug: it panics if no value has been stored.
+func (a Atomic[T]) StrictLoad() T {
+	v, ok := a.LoadOK()
+	if !ok {
+		var dv T
+		panic(fmt.Errorf("generic: Load of unset Atomic[%T]", dv))
+	}
+	return v
+}
+
 func (a Atomic[T]) Store(x T) {
 	if a.store == nil {
 		return
@@ -41,29 +67,252 @@ func (a Atomic[T]) CompareAndSwap(old, new T) bool {
 	return a.compareAndSwap(old, new)
 }
 
+// CompareAndSwapFunc stores new if eq reports the current value equal to
+// old, for types that aren't comparable with == or whose equality is
+// semantic. Like CompareAndSwap it fails before the first store.
+func (a Atomic[T]) CompareAndSwapFunc(old, new T, eq func(a, b T) bool) bool {
+	if a.compareAndSwapFunc == nil {
+		return false
+	}
+	return a.compareAndSwapFunc(old, new, eq)
+}
+
+// Watch returns a sequence of the values held by a. The current value is
+// yielded first if one has been stored; each subsequent Store, Swap or
+// successful CompareAndSwap yields the latest value. Updates that arrive
+// faster than the consumer iterates are coalesced, so only the most recent
+// value is observed. The sequence ends when ctx is done.
+func (a Atomic[T]) Watch(ctx context.Context) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if a.watch == nil {
+			return
+		}
+		for {
+			// Take the change channel before reading so an update racing
+			// with yield is never missed.
+			changed, v, ok := a.watch()
+			if ok && !yield(v) {
+				return
+			}
+			select {
+			case <-changed:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// atomicNotifier wakes watchers on change. The channel is only allocated
+// while someone is waiting, so stores without watchers stay cheap.
+type atomicNotifier struct {
+	ch atomic.Pointer[chan struct{}]
+}
+
+func (n *atomicNotifier) wait() <-chan struct{} {
+	for {
+		if p := n.ch.Load(); p != nil {
+			return *p
+		}
+		ch := make(chan struct{})
+		if n.ch.CompareAndSwap(nil, &ch) {
+			return ch
+		}
+	}
+}
+
+func (n *atomicNotifier) notify() {
+	if n.ch.Load() == nil {
+		return
+	}
+	if p := n.ch.Swap(nil); p != nil {
+		close(*p)
+	}
+}
+
+// MakeAtomic returns an Atomic holding the optional default value. Integer
+// and pointer types are stored directly in an atomic word; other types are
+// boxed behind a pointer.
 func MakeAtomic[T any](maybeDefaultValue ...T) Atomic[T] {
-	var a atomic.Value
+	switch t := reflect.TypeFor[T](); t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var cell atomic.Uint64
+		return makeWordAtomic(&cell, intToWord[T](t.Size()), intFromWord[T](t.Size()), maybeDefaultValue)
+	case reflect.Pointer, reflect.UnsafePointer:
+		var cell atomic.Pointer[byte]
+		return makeWordAtomic(&cell, ptrToWord[T], ptrFromWord[T], maybeDefaultValue)
+	}
+	return makeBoxedAtomic(maybeDefaultValue)
+}
+
+func makeBoxedAtomic[T any](maybeDefaultValue []T) Atomic[T] {
+	// Values are boxed behind a pointer rather than in an atomic.Value so
+	// CompareAndSwapFunc can swap on pointer identity when T isn't
+	// comparable.
+	var a atomic.Pointer[T]
+	var n atomicNotifier
 	if len(maybeDefaultValue) > 0 {
-		a.Store(maybeDefaultValue[0])
+		a.Store(&maybeDefaultValue[0])
+	}
+	compareAndSwapFunc := func(old, new T, eq func(a, b T) bool) bool {
+		for {
+			p := a.Load()
+			if p == nil || !eq(*p, old) {
+				return false
+			}
+			if a.CompareAndSwap(p, &new) {
+				n.notify()
+				return true
+			}
+		}
 	}
 	return Atomic[T]{
-		load: func() T {
-			v, ok := a.Load().(T)
-			if !ok {
-				var dv T
-				panic(fmt.Errorf("expected %T, got %T", dv, v))
+		load: func() (T, bool) {
+			if p := a.Load(); p != nil {
+				return *p, true
 			}
-			return v
+			var zero T
+			return zero, false
+		},
+		store: func(x T) {
+			a.Store(&x)
+			n.notify()
 		},
-		store: func(x T) { a.Store(x) },
 		swap: func(x T) T {
-			v, ok := a.Swap(x).(T)
-			if !ok {
-				var dv T
-				panic(fmt.Errorf("expected %T, got %T", dv, v))
+			old := a.Swap(&x)
+			n.notify()
+			if old == nil {
+				var zero T
+				return zero
 			}
-			return v
+			return *old
 		},
-		compareAndSwap: func(old, new T) bool { return a.CompareAndSwap(old, new) },
+		compareAndSwap: func(old, new T) bool {
+			// Matches atomic.Value: comparing values of a non-comparable
+			// type panics.
+			return compareAndSwapFunc(old, new, func(a, b T) bool { return any(a) == any(b) })
+		},
+		compareAndSwapFunc: compareAndSwapFunc,
+		watch: func() (<-chan struct{}, T, bool) {
+			changed := n.wait()
+			if p := a.Load(); p != nil {
+				return changed, *p, true
+			}
+			var zero T
+			return changed, zero, false
+		},
+	}
+}
+
+// atomicWord is the subset of atomic.Uint64 and atomic.Pointer used by
+// makeWordAtomic.
+type atomicWord[W any] interface {
+	Load() W
+	Store(W)
+	Swap(W) W
+	CompareAndSwap(old, new W) bool
+}
+
+// makeWordAtomic stores T in a single atomic word, avoiding the interface
+// boxing of atomic.Value. The set flag preserves atomic.Value's behavior
+// before the first store.
+func makeWordAtomic[T, W any](cell atomicWord[W], to func(T) W, from func(W) T, maybeDefaultValue []T) Atomic[T] {
+	var set atomic.Bool
+	var n atomicNotifier
+	if len(maybeDefaultValue) > 0 {
+		cell.Store(to(maybeDefaultValue[0]))
+		set.Store(true)
+	}
+	return Atomic[T]{
+		load: func() (T, bool) {
+			if !set.Load() {
+				var zero T
+				return zero, false
+			}
+			return from(cell.Load()), true
+		},
+		store: func(x T) {
+			cell.Store(to(x))
+			if !set.Load() {
+				set.Store(true)
+			}
+			n.notify()
+		},
+		swap: func(x T) T {
+			old := cell.Swap(to(x))
+			wasSet := set.Load() || set.Swap(true)
+			n.notify()
+			if !wasSet {
+				var zero T
+				return zero
+			}
+			return from(old)
+		},
+		compareAndSwap: func(old, new T) bool {
+			if !set.Load() || !cell.CompareAndSwap(to(old), to(new)) {
+				return false
+			}
+			n.notify()
+			return true
+		},
+		compareAndSwapFunc: func(old, new T, eq func(a, b T) bool) bool {
+			for {
+				if !set.Load() {
+					return false
+				}
+				w := cell.Load()
+				if !eq(from(w), old) {
+					return false
+				}
+				if cell.CompareAndSwap(w, to(new)) {
+					n.notify()
+					return true
+				}
+			}
+		},
+		watch: func() (<-chan struct{}, T, bool) {
+			changed := n.wait()
+			if !set.Load() {
+				var zero T
+				return changed, zero, false
+			}
+			return changed, from(cell.Load()), true
+		},
+	}
+}
+
+func intToWord[T any](size uintptr) func(T) uint64 {
+	switch size {
+	case 1:
+		return func(x T) uint64 { return uint64(*(*uint8)(unsafe.Pointer(&x))) }
+	case 2:
+		return func(x T) uint64 { return uint64(*(*uint16)(unsafe.Pointer(&x))) }
+	case 4:
+		return func(x T) uint64 { return uint64(*(*uint32)(unsafe.Pointer(&x))) }
+	default:
+		return func(x T) uint64 { return *(*uint64)(unsafe.Pointer(&x)) }
+	}
+}
+
+func intFromWord[T any](size uintptr) func(uint64) T {
+	switch size {
+	case 1:
+		return func(w uint64) (x T) { *(*uint8)(unsafe.Pointer(&x)) = uint8(w); return }
+	case 2:
+		return func(w uint64) (x T) { *(*uint16)(unsafe.Pointer(&x)) = uint16(w); return }
+	case 4:
+		return func(w uint64) (x T) { *(*uint32)(unsafe.Pointer(&x)) = uint32(w); return }
+	default:
+		return func(w uint64) (x T) { *(*uint64)(unsafe.Pointer(&x)) = w; return }
 	}
 }
+
+func ptrToWord[T any](x T) *byte {
+	return *(**byte)(unsafe.Pointer(&x))
+}
+
+func ptrFromWord[T any](p *byte) (x T) {
+	*(**byte)(unsafe.Pointer(&x)) = p
+	return x
+}
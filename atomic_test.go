@@ -1,8 +1,13 @@
 package generic
 
 import (
+	"context"
+	"maps"
+	"slices"
 	"sync"
 	"testing"
+	"time"
+	"unsafe"
 )
 
 func TestAtomic_Load_Store(t *testing.T) {
@@ -147,6 +152,59 @@ func TestAtomic_CompareAndSwap(t *testing.T) {
 	})
 }
 
+func TestAtomic_CompareAndSwapFunc(t *testing.T) {
+	t.Run("slice", func(t *testing.T) {
+		av := MakeAtomic([]int{1, 2})
+		if av.CompareAndSwapFunc([]int{1, 3}, []int{9}, slices.Equal) {
+			t.Fatal("expected swap to fail")
+		}
+		if !av.CompareAndSwapFunc([]int{1, 2}, []int{1, 2, 3}, slices.Equal) {
+			t.Fatal("expected successful swap")
+		}
+		if got := av.Load(); !slices.Equal(got, []int{1, 2, 3}) {
+			t.Fatalf("got %v", got)
+		}
+	})
+
+	t.Run("word", func(t *testing.T) {
+		av := MakeAtomic(-3)
+		abs := func(a, b int) bool { return max(a, -a) == max(b, -b) }
+		if !av.CompareAndSwapFunc(3, 4, abs) || av.Load() != 4 {
+			t.Fatalf("got %d", av.Load())
+		}
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		av := MakeAtomic[map[string]int]()
+		if av.CompareAndSwapFunc(nil, map[string]int{}, maps.Equal) {
+			t.Fatal("CompareAndSwapFunc should fail before first store")
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		av := MakeAtomic([]int{})
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 100 {
+					for {
+						old := av.Load()
+						if av.CompareAndSwapFunc(old, append(slices.Clip(old), len(old)), slices.Equal) {
+							break
+						}
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		if got := av.Load(); len(got) != 800 || got[799] != 799 {
+			t.Fatalf("len = %d", len(got))
+		}
+	})
+}
+
 func TestAtomic_ConcurrentAccess(t *testing.T) {
 	t.Run("concurrent stores and loads", func(t *testing.T) {
 		av := MakeAtomic(0)
@@ -322,6 +380,171 @@ func TestAtomic_ValueSemantics(t *testing.T) {
 	})
 }
 
+func TestAtomic_Watch(t *testing.T) {
+	t.Run("yields current and subsequent values", func(t *testing.T) {
+		av := MakeAtomic(1)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		got := make(chan int)
+		go func() {
+			defer close(got)
+			for v := range av.Watch(ctx) {
+				got <- v
+			}
+		}()
+
+		if v := <-got; v != 1 {
+			t.Fatalf("expected initial value 1, got %d", v)
+		}
+		av.Store(2)
+		if v := <-got; v != 2 {
+			t.Fatalf("expected 2 after Store, got %d", v)
+		}
+		av.Swap(3)
+		if v := <-got; v != 3 {
+			t.Fatalf("expected 3 after Swap, got %d", v)
+		}
+		av.CompareAndSwap(3, 4)
+		if v := <-got; v != 4 {
+			t.Fatalf("expected 4 after CompareAndSwap, got %d", v)
+		}
+
+		cancel()
+		select {
+		case _, ok := <-got:
+			if ok {
+				t.Fatal("expected watch to end after cancel")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("watch did not stop after cancel")
+		}
+	})
+
+	t.Run("skips unset value", func(t *testing.T) {
+		av := MakeAtomic[string]()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		got := make(chan string, 1)
+		go func() {
+			for v := range av.Watch(ctx) {
+				got <- v
+				return
+			}
+		}()
+
+		select {
+		case v := <-got:
+			t.Fatalf("unexpected value before first store: %q", v)
+		case <-time.After(10 * time.Millisecond):
+		}
+		av.Store("ready")
+		if v := <-got; v != "ready" {
+			t.Fatalf("expected 'ready', got %q", v)
+		}
+	})
+
+	t.Run("coalesces bursts", func(t *testing.T) {
+		av := MakeAtomic(0)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var seen []int
+		for v := range av.Watch(ctx) {
+			seen = append(seen, v)
+			if v == 0 {
+				for i := 1; i <= 100; i++ {
+					av.Store(i)
+				}
+				continue
+			}
+			if v == 100 {
+				break
+			}
+		}
+		if len(seen) != 2 || seen[1] != 100 {
+			t.Fatalf("expected burst to coalesce to [0 100], got %v", seen)
+		}
+	})
+
+	t.Run("zero Atomic ends immediately", func(t *testing.T) {
+		var av Atomic[int]
+		for range av.Watch(context.Background()) {
+			t.Fatal("zero Atomic should not yield")
+		}
+	})
+}
+
+func TestAtomic_WordTypes(t *testing.T) {
+	t.Run("small integers round trip", func(t *testing.T) {
+		i8 := MakeAtomic[int8](-5)
+		if got := i8.Swap(-128); got != -5 {
+			t.Fatalf("expected -5, got %d", got)
+		}
+		if got := i8.Load(); got != -128 {
+			t.Fatalf("expected -128, got %d", got)
+		}
+
+		u16 := MakeAtomic[uint16](65535)
+		if !u16.CompareAndSwap(65535, 1) {
+			t.Fatal("expected successful swap")
+		}
+		if got := u16.Load(); got != 1 {
+			t.Fatalf("expected 1, got %d", got)
+		}
+
+		i32 := MakeAtomic[int32](-1)
+		if i32.CompareAndSwap(1, 2) {
+			t.Fatal("expected swap to fail")
+		}
+		if got := i32.Load(); got != -1 {
+			t.Fatalf("expected -1, got %d", got)
+		}
+	})
+
+	t.Run("named integer", func(t *testing.T) {
+		d := MakeAtomic(time.Second)
+		d.Store(-time.Minute)
+		if got := d.Load(); got != -time.Minute {
+			t.Fatalf("expected -1m, got %v", got)
+		}
+	})
+
+	t.Run("pointers", func(t *testing.T) {
+		x, y := new(int), new(int)
+		p := MakeAtomic(x)
+		if !p.CompareAndSwap(x, y) {
+			t.Fatal("expected successful swap")
+		}
+		if got := p.Swap(nil); got != y {
+			t.Fatalf("expected %p, got %p", y, got)
+		}
+		if got := p.Load(); got != nil {
+			t.Fatalf("expected nil, got %p", got)
+		}
+
+		u := MakeAtomic(unsafe.Pointer(x))
+		if got := u.Load(); got != unsafe.Pointer(x) {
+			t.Fatalf("expected %p, got %p", x, got)
+		}
+	})
+
+	t.Run("unset matches boxed behavior", func(t *testing.T) {
+		av := MakeAtomic[int64]()
+		if av.CompareAndSwap(0, 1) {
+			t.Fatal("CompareAndSwap should fail before first store")
+		}
+		if got, ok := av.LoadOK(); got != 0 || ok {
+			t.Fatalf("LoadOK before first store = %d, %v", got, ok)
+		}
+		av.Store(7)
+		if got, ok := av.LoadOK(); got != 7 || !ok {
+			t.Fatalf("expected 7, got %d, %v", got, ok)
+		}
+	})
+}
+
 func BenchmarkAtomic_Load(b *testing.B) {
 	av := MakeAtomic(42)
 
@@ -380,3 +603,53 @@ func BenchmarkAtomic_Concurrent_Store(b *testing.B) {
 		}
 	})
 }
+
+func BenchmarkAtomic_Store_Boxed(b *testing.B) {
+	av := makeBoxedAtomic([]int{0})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		av.Store(i)
+	}
+}
+
+func BenchmarkAtomic_Swap_Boxed(b *testing.B) {
+	av := makeBoxedAtomic([]int{0})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		av.Swap(i)
+	}
+}
+
+func TestAtomic_Unset(t *testing.T) {
+	t.Run("word", func(t *testing.T) { testAtomicUnset(t, MakeAtomic[int](), 5) })
+	t.Run("boxed", func(t *testing.T) { testAtomicUnset(t, MakeAtomic[string](), "x") })
+	t.Run("zero", func(t *testing.T) {
+		var av Atomic[int]
+		if got, ok := av.LoadOK(); got != 0 || ok {
+			t.Fatalf("LoadOK on zero Atomic = %d, %v", got, ok)
+		}
+	})
+}
+
+func testAtomicUnset[T comparable](t *testing.T, av Atomic[T], v T) {
+	var zero T
+	if got := av.Load(); got != zero {
+		t.Fatalf("Load before first store = %v", got)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected StrictLoad to panic before first store")
+			}
+		}()
+		av.StrictLoad()
+	}()
+	if old := av.Swap(v); old != zero {
+		t.Fatalf("Swap before first store returned %v", old)
+	}
+	if got := av.StrictLoad(); got != v {
+		t.Fatalf("StrictLoad = %v, want %v", got, v)
+	}
+}
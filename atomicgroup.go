@@ -0,0 +1,108 @@
+package generic
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// AtomicGroup ties several Atomics together so LoadAll can read them as one
+// consistent snapshot without a mutex, for exporting related stats that
+// must not be published torn. Each write to a member is seen whole; use
+// Write to make several writes appear together. Writes stay lock-free;
+// LoadAll retries while a write overlaps it, so it may spin under a
+// constant stream of writes.
+//
+// The zero AtomicGroup is ready to use. Add members with GroupAtomic.
+type AtomicGroup struct {
+	// version counts completed writes and writers those in flight; a read
+	// is consistent if neither changed around it.
+	version atomic.Uint64
+	writers atomic.Int64
+	loads   atomic.Pointer[[]func() any]
+}
+
+// GroupAtomic returns an Atomic like MakeAtomic whose writes are tracked by
+// g, and appends it to the values reported by g.LoadAll.
+func GroupAtomic[T any](g *AtomicGroup, maybeDefaultValue ...T) Atomic[T] {
+	a := MakeAtomic(maybeDefaultValue...)
+	load := func() any { return a.Load() }
+	for {
+		old := g.loads.Load()
+		var loads []func() any
+		if old != nil {
+			loads = append(loads, *old...)
+		}
+		loads = append(loads, load)
+		if g.loads.CompareAndSwap(old, &loads) {
+			break
+		}
+	}
+	return Atomic[T]{
+		load: a.load,
+		store: func(x T) {
+			g.write(func() { a.store(x) })
+		},
+		swap: func(x T) (old T) {
+			g.write(func() { old = a.swap(x) })
+			return old
+		},
+		compareAndSwap: func(old, new T) (ok bool) {
+			g.write(func() { ok = a.compareAndSwap(old, new) })
+			return ok
+		},
+		compareAndSwapFunc: func(old, new T, eq func(a, b T) bool) (ok bool) {
+			g.write(func() { ok = a.compareAndSwapFunc(old, new, eq) })
+			return ok
+		},
+		watch: a.watch,
+	}
+}
+
+// Write calls fn, which typically stores to several members, so that Read
+// and LoadAll see either all of its writes or none of them.
+func (g *AtomicGroup) Write(fn func()) {
+	g.write(fn)
+}
+
+func (g *AtomicGroup) write(fn func()) {
+	g.writers.Add(1)
+	defer func() {
+		g.version.Add(1)
+		g.writers.Add(-1)
+	}()
+	fn()
+}
+
+// Read calls fn until it runs with no member written meanwhile, so the
+// members fn loads form a consistent snapshot. fn may run several times
+// and must only read.
+func (g *AtomicGroup) Read(fn func()) {
+	for spins := 0; ; spins++ {
+		if spins > 0 && spins%64 == 0 {
+			runtime.Gosched()
+		}
+		v := g.version.Load()
+		if g.writers.Load() != 0 {
+			continue
+		}
+		fn()
+		if g.writers.Load() == 0 && g.version.Load() == v {
+			return
+		}
+	}
+}
+
+// LoadAll returns a consistent snapshot of every member's value, in the
+// order they were added.
+func (g *AtomicGroup) LoadAll() []any {
+	var values []any
+	g.Read(func() {
+		values = values[:0]
+		if loads := g.loads.Load(); loads != nil {
+			for _, load := range *loads {
+				values = append(values, load())
+			}
+		}
+	})
+	return values
+}
@@ -0,0 +1,61 @@
+package generic
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestAtomicGroup_LoadAll(t *testing.T) {
+	var g AtomicGroup
+	if got := g.LoadAll(); len(got) != 0 {
+		t.Fatalf("empty group = %v", got)
+	}
+	count := GroupAtomic(&g, 1)
+	name := GroupAtomic[string](&g)
+	name.Store("jobs")
+	if old := count.Swap(2); old != 1 {
+		t.Fatalf("Swap = %d", old)
+	}
+	if !count.CompareAndSwap(2, 3) || !name.CompareAndSwapFunc("jobs", "tasks", func(a, b string) bool { return a == b }) {
+		t.Fatal("CompareAndSwap failed")
+	}
+	got := g.LoadAll()
+	if len(got) != 2 || got[0] != 3 || got[1] != "tasks" {
+		t.Fatalf("LoadAll = %v", got)
+	}
+}
+
+func TestAtomicGroup_Consistent(t *testing.T) {
+	// Writers keep sum == total; a torn read would see them differ.
+	var g AtomicGroup
+	sum := GroupAtomic(&g, 0)
+	total := GroupAtomic(&g, 0)
+	var mu sync.Mutex
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !stop.Load() {
+				// The mutex only orders the writers; readers don't take it.
+				mu.Lock()
+				g.Write(func() {
+					sum.Store(sum.Load() + 1)
+					total.Store(total.Load() + 1)
+				})
+				mu.Unlock()
+			}
+		}()
+	}
+	for range 2000 {
+		got := g.LoadAll()
+		if s, n := got[0].(int), got[1].(int); s != n {
+			stop.Store(true)
+			t.Fatalf("torn snapshot: sum %d, total %d", s, n)
+		}
+	}
+	stop.Store(true)
+	wg.Wait()
+}
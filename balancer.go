@@ -0,0 +1,205 @@
+package generic
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Balancer picks one of a fixed set of resources for each unit of work. The
+// returned done func must be called exactly once when the work finishes,
+// with the error it produced, so load- and latency-aware balancers can learn.
+type Balancer[T any] interface {
+	Pick(ctx context.Context) (x T, done func(error))
+}
+
+func noopDone(error) {}
+
+func mustHaveItems(n int) {
+	if n == 0 {
+		panic("generic: balancer needs at least one item")
+	}
+}
+
+// RoundRobin cycles through its items in order.
+type RoundRobin[T any] struct {
+	items []T
+	next  atomic.Uint64
+}
+
+func NewRoundRobin[T any](items ...T) *RoundRobin[T] {
+	mustHaveItems(len(items))
+	return &RoundRobin[T]{items: items}
+}
+
+func (b *RoundRobin[T]) Pick(context.Context) (T, func(error)) {
+	i := b.next.Add(1) - 1
+	return b.items[i%uint64(len(b.items))], noopDone
+}
+
+// Weighted pairs an item with its relative share of picks.
+type Weighted[T any] struct {
+	Item   T
+	Weight int
+}
+
+// WeightedRoundRobin distributes picks in proportion to item weights using the
+// smooth algorithm from nginx, which interleaves heavy items instead of
+// picking them in bursts.
+type WeightedRoundRobin[T any] struct {
+	mu      sync.Mutex
+	items   []Weighted[T]
+	current []int
+	total   int
+}
+
+func NewWeightedRoundRobin[T any](items ...Weighted[T]) *WeightedRoundRobin[T] {
+	mustHaveItems(len(items))
+	b := &WeightedRoundRobin[T]{items: items, current: make([]int, len(items))}
+	for _, it := range items {
+		if it.Weight < 0 {
+			panic("generic: negative balancer weight")
+		}
+		b.total += it.Weight
+	}
+	if b.total == 0 {
+		panic("generic: balancer weights sum to zero")
+	}
+	return b
+}
+
+func (b *WeightedRoundRobin[T]) Pick(context.Context) (T, func(error)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	best := 0
+	for i, it := range b.items {
+		b.current[i] += it.Weight
+		if b.current[i] > b.current[best] {
+			best = i
+		}
+	}
+	b.current[best] -= b.total
+	return b.items[best].Item, noopDone
+}
+
+// LeastOutstanding picks the item with the fewest unfinished picks, breaking
+// ties by rotating through items.
+type LeastOutstanding[T any] struct {
+	items       []T
+	outstanding []atomic.Int64
+	next        atomic.Uint64
+}
+
+func NewLeastOutstanding[T any](items ...T) *LeastOutstanding[T] {
+	mustHaveItems(len(items))
+	return &LeastOutstanding[T]{items: items, outstanding: make([]atomic.Int64, len(items))}
+}
+
+func (b *LeastOutstanding[T]) Pick(context.Context) (T, func(error)) {
+	n := len(b.items)
+	start := int(b.next.Add(1) % uint64(n))
+	best, bestLoad := start, int64(math.MaxInt64)
+	for k := range n {
+		i := (start + k) % n
+		if load := b.outstanding[i].Load(); load < bestLoad {
+			best, bestLoad = i, load
+		}
+	}
+	b.outstanding[best].Add(1)
+	var once sync.Once
+	return b.items[best], func(error) {
+		once.Do(func() { b.outstanding[best].Add(-1) })
+	}
+}
+
+// Outstanding returns the number of unfinished picks per item.
+func (b *LeastOutstanding[T]) Outstanding() []int64 {
+	out := make([]int64, len(b.outstanding))
+	for i := range b.outstanding {
+		out[i] = b.outstanding[i].Load()
+	}
+	return out
+}
+
+// latencyDecay is the EWMA weight given to each new latency sample.
+const latencyDecay = 0.3
+
+type latencyStats struct {
+	ewma        atomic.Uint64 // math.Float64bits of nanoseconds
+	outstanding atomic.Int64
+}
+
+func (s *latencyStats) cost() float64 {
+	// Unmeasured items cost nothing so each gets tried early on.
+	return math.Float64frombits(s.ewma.Load()) * float64(s.outstanding.Load()+1)
+}
+
+func (s *latencyStats) observe(sample float64) {
+	for {
+		old := s.ewma.Load()
+		avg := math.Float64frombits(old)
+		next := sample
+		if avg != 0 {
+			next = avg + latencyDecay*(sample-avg)
+		}
+		if s.ewma.CompareAndSwap(old, math.Float64bits(next)) {
+			return
+		}
+	}
+}
+
+// EWMALatency picks between two random items the one with the lower
+// expected cost, where cost is the moving-average latency scaled by the
+// number of outstanding picks ("power of two choices"). Failed calls are
+// recorded as at least twice the current average so erroring items are
+// avoided.
+type EWMALatency[T any] struct {
+	items []T
+	stats []latencyStats
+	now   func() time.Time
+}
+
+func NewEWMALatency[T any](items ...T) *EWMALatency[T] {
+	mustHaveItems(len(items))
+	return &EWMALatency[T]{items: items, stats: make([]latencyStats, len(items)), now: time.Now}
+}
+
+func (b *EWMALatency[T]) Pick(context.Context) (T, func(error)) {
+	i := 0
+	if n := len(b.items); n > 1 {
+		i = rand.IntN(n)
+		j := rand.IntN(n - 1)
+		if j >= i {
+			j++
+		}
+		if b.stats[j].cost() < b.stats[i].cost() {
+			i = j
+		}
+	}
+	s := &b.stats[i]
+	s.outstanding.Add(1)
+	start := b.now()
+	var once sync.Once
+	return b.items[i], func(err error) {
+		once.Do(func() {
+			s.outstanding.Add(-1)
+			sample := float64(b.now().Sub(start))
+			if err != nil {
+				sample = max(sample, 2*math.Float64frombits(s.ewma.Load()))
+			}
+			s.observe(sample)
+		})
+	}
+}
+
+// Latencies returns the current moving-average latency per item.
+func (b *EWMALatency[T]) Latencies() []time.Duration {
+	out := make([]time.Duration, len(b.stats))
+	for i := range b.stats {
+		out[i] = time.Duration(math.Float64frombits(b.stats[i].ewma.Load()))
+	}
+	return out
+}
@@ -0,0 +1,154 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var (
+	_ Balancer[string] = (*RoundRobin[string])(nil)
+	_ Balancer[string] = (*WeightedRoundRobin[string])(nil)
+	_ Balancer[string] = (*LeastOutstanding[string])(nil)
+	_ Balancer[string] = (*EWMALatency[string])(nil)
+)
+
+func TestRoundRobin(t *testing.T) {
+	b := NewRoundRobin("a", "b", "c")
+	ctx := context.Background()
+	var got []string
+	for range 6 {
+		x, done := b.Pick(ctx)
+		done(nil)
+		got = append(got, x)
+	}
+	want := []string{"a", "b", "c", "a", "b", "c"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestWeightedRoundRobin(t *testing.T) {
+	b := NewWeightedRoundRobin(
+		Weighted[string]{Item: "a", Weight: 5},
+		Weighted[string]{Item: "b", Weight: 1},
+		Weighted[string]{Item: "c", Weight: 1},
+	)
+	ctx := context.Background()
+	counts := map[string]int{}
+	var seq []string
+	for range 7 {
+		x, _ := b.Pick(ctx)
+		counts[x]++
+		seq = append(seq, x)
+	}
+	if counts["a"] != 5 || counts["b"] != 1 || counts["c"] != 1 {
+		t.Fatalf("unexpected distribution %v", counts)
+	}
+	// Smooth WRR never picks the heavy item more than 3 times in a row here.
+	want := []string{"a", "a", "b", "a", "c", "a", "a"}
+	for i := range want {
+		if seq[i] != want[i] {
+			t.Fatalf("expected smooth sequence %v, got %v", want, seq)
+		}
+	}
+}
+
+func TestWeightedRoundRobin_InvalidWeights(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for zero total weight")
+		}
+	}()
+	NewWeightedRoundRobin(Weighted[int]{Item: 1, Weight: 0})
+}
+
+func TestLeastOutstanding(t *testing.T) {
+	b := NewLeastOutstanding("a", "b")
+	ctx := context.Background()
+
+	x1, done1 := b.Pick(ctx)
+	x2, done2 := b.Pick(ctx)
+	if x1 == x2 {
+		t.Fatalf("expected different items for concurrent picks, got %s twice", x1)
+	}
+	done1(nil)
+	done1(nil) // repeated done is ignored
+
+	x3, done3 := b.Pick(ctx)
+	if x3 != x1 {
+		t.Fatalf("expected idle item %s, got %s", x1, x3)
+	}
+	done2(nil)
+	done3(errors.New("failed"))
+
+	for i, n := range b.Outstanding() {
+		if n != 0 {
+			t.Errorf("item %d has %d outstanding after all done", i, n)
+		}
+	}
+}
+
+func TestEWMALatency_PrefersFastItem(t *testing.T) {
+	b := NewEWMALatency("fast", "slow")
+	clock := &fakeNow{t: time.Unix(0, 0)}
+	b.now = clock.now
+	ctx := context.Background()
+
+	// Teach the balancer the latencies.
+	for range 20 {
+		x, done := b.Pick(ctx)
+		if x == "fast" {
+			clock.t = clock.t.Add(time.Millisecond)
+		} else {
+			clock.t = clock.t.Add(100 * time.Millisecond)
+		}
+		done(nil)
+	}
+
+	fast := 0
+	for range 100 {
+		x, done := b.Pick(ctx)
+		if x == "fast" {
+			fast++
+		}
+		done(nil)
+	}
+	if fast != 100 {
+		t.Errorf("expected fast item to win every comparison, won %d/100", fast)
+	}
+
+	lat := b.Latencies()
+	if lat[0] >= lat[1] {
+		t.Errorf("expected fast latency < slow latency, got %v", lat)
+	}
+}
+
+func TestEWMALatency_ErrorsPenalize(t *testing.T) {
+	b := NewEWMALatency("only")
+	clock := &fakeNow{t: time.Unix(0, 0)}
+	b.now = clock.now
+	ctx := context.Background()
+
+	_, done := b.Pick(ctx)
+	clock.t = clock.t.Add(10 * time.Millisecond)
+	done(nil)
+
+	_, done = b.Pick(ctx)
+	done(errors.New("boom"))
+	if lat := b.Latencies()[0]; lat <= 10*time.Millisecond {
+		t.Errorf("expected error to raise latency above 10ms, got %v", lat)
+	}
+}
+
+func TestBalancer_EmptyPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for empty balancer")
+		}
+	}()
+	NewRoundRobin[string]()
+}
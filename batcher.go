@@ -0,0 +1,142 @@
+package generic
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Batcher takes items from a FiFo in batches and hands each batch to a
+// flush function, sizing the batches to suit the sink. After every flush a
+// feedback controller adjusts the batch size between MinSize and MaxSize:
+// it grows additively while full batches flush within TargetLatency and
+// shrinks in proportion to the overshoot when they don't, and halves the
+// size while the error rate is above MaxErrorRate.
+type Batcher[T any] struct {
+	// MinSize and MaxSize bound the batch size. They default to 1 and 1000.
+	MinSize, MaxSize int
+	// TargetLatency is the flush latency to aim for. Zero lets the size
+	// grow to MaxSize unless errors shrink it.
+	TargetLatency time.Duration
+	// MaxErrorRate is the tolerated fraction of failing flushes, averaged
+	// over recent batches. Zero ignores errors.
+	MaxErrorRate float64
+	// Clock times the flushes. Defaults to SystemClock.
+	Clock Clock
+	// Logger records failed flushes, whose items are dropped. Defaults to
+	// DefaultLogger.
+	Logger Logger
+
+	flush func(ctx context.Context, batch []T) error
+
+	mu    sync.Mutex
+	stats BatcherStats
+}
+
+// BatcherStats reports a Batcher's controller state and throughput.
+type BatcherStats struct {
+	Size        int           `json:"size"`
+	Batches     int64         `json:"batches"`
+	Items       int64         `json:"items"`
+	Errors      int64         `json:"errors"`
+	ErrorRate   float64       `json:"error_rate"`
+	LastLatency time.Duration `json:"last_latency"`
+}
+
+// batcherErrorWeight is the weight of the latest flush in the error rate
+// average.
+const batcherErrorWeight = 0.2
+
+func NewBatcher[T any](flush func(ctx context.Context, batch []T) error) *Batcher[T] {
+	return &Batcher[T]{flush: flush}
+}
+
+func (b *Batcher[T]) bounds() (lo, hi int) {
+	lo, hi = max(b.MinSize, 1), b.MaxSize
+	if hi <= 0 {
+		hi = 1000
+	}
+	return lo, max(hi, lo)
+}
+
+// Size returns the batch size the next batch will be taken with.
+func (b *Batcher[T]) Size() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.size()
+}
+
+// size returns the current batch size. The caller must hold b.mu.
+func (b *Batcher[T]) size() int {
+	lo, hi := b.bounds()
+	if b.stats.Size == 0 {
+		return lo
+	}
+	return min(max(b.stats.Size, lo), hi)
+}
+
+// Run flushes batches taken from q until ctx is done or q is closed and
+// drained, then returns the error from GetBatch. Panics in flush count as
+// failures.
+func (b *Batcher[T]) Run(ctx context.Context, q *FiFo[T]) error {
+	clock := b.Clock
+	if clock == nil {
+		clock = SystemClock
+	}
+	for {
+		batch, err := q.GetBatch(ctx, b.Size())
+		if err != nil {
+			return err
+		}
+		start := clock.Now()
+		err = recoverError(func() error { return b.flush(ctx, batch) })
+		b.observe(len(batch), clock.Now().Sub(start), err)
+		if err != nil {
+			loggerOrDefault(b.Logger).Error(ctx, "generic: batch flush failed", slog.Int("items", len(batch)), slog.Any("error", err))
+		}
+	}
+}
+
+// observe records a flush of n items and adjusts the batch size.
+func (b *Batcher[T]) observe(n int, latency time.Duration, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	size := b.size()
+	lo, hi := b.bounds()
+	s := &b.stats
+	s.Batches++
+	s.Items += int64(n)
+	s.LastLatency = latency
+	failed := 0.0
+	if err != nil {
+		s.Errors++
+		failed = 1
+	}
+	s.ErrorRate += batcherErrorWeight * (failed - s.ErrorRate)
+
+	switch {
+	case b.MaxErrorRate > 0 && s.ErrorRate > b.MaxErrorRate:
+		size /= 2
+	case b.TargetLatency > 0 && latency > b.TargetLatency:
+		size = int(float64(size) * float64(b.TargetLatency) / float64(latency))
+	case n >= size:
+		// Only a full batch says the sink could take a bigger one.
+		size += max(1, size/8)
+	}
+	s.Size = min(max(size, lo), hi)
+}
+
+// Stats returns the current batch size and counters.
+func (b *Batcher[T]) Stats() BatcherStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := b.stats
+	s.Size = b.size()
+	return s
+}
+
+// Inspect reports Stats for DebugHandler.
+func (b *Batcher[T]) Inspect() any {
+	return b.Stats()
+}
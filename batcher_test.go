@@ -0,0 +1,101 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatcher_Controller(t *testing.T) {
+	b := NewBatcher[int](nil)
+	b.MinSize, b.MaxSize = 2, 40
+	b.TargetLatency = 100 * time.Millisecond
+	if b.Size() != 2 {
+		t.Fatalf("initial Size = %d", b.Size())
+	}
+
+	// Full, fast batches grow the size up to MaxSize.
+	for range 100 {
+		b.observe(b.Size(), 10*time.Millisecond, nil)
+	}
+	if b.Size() != 40 {
+		t.Fatalf("Size after fast batches = %d, want 40", b.Size())
+	}
+
+	// A partial batch says nothing about capacity.
+	b.MaxSize = 100
+	b.observe(5, time.Millisecond, nil)
+	if b.Size() != 40 {
+		t.Fatalf("Size after partial batch = %d, want 40", b.Size())
+	}
+
+	// Twice the target latency halves it.
+	b.observe(40, 200*time.Millisecond, nil)
+	if b.Size() != 20 {
+		t.Fatalf("Size after slow batch = %d, want 20", b.Size())
+	}
+	// But never below MinSize.
+	b.observe(20, 10*time.Second, nil)
+	if b.Size() != 2 {
+		t.Fatalf("Size after very slow batch = %d, want 2", b.Size())
+	}
+}
+
+func TestBatcher_ErrorRate(t *testing.T) {
+	b := NewBatcher[int](nil)
+	b.MinSize, b.MaxSize = 1, 64
+	b.MaxErrorRate = 0.1
+	for range 50 {
+		b.observe(b.Size(), time.Millisecond, nil)
+	}
+	if b.Size() != 64 {
+		t.Fatalf("Size = %d, want 64", b.Size())
+	}
+	b.observe(64, time.Millisecond, errors.New("sink down"))
+	if b.Size() != 32 {
+		t.Fatalf("Size after error = %d, want 32", b.Size())
+	}
+	s := b.Stats()
+	if s.Errors != 1 || s.ErrorRate <= 0.1 || s.Batches != 51 {
+		t.Fatalf("Stats = %+v", s)
+	}
+}
+
+func TestBatcher_Run(t *testing.T) {
+	clock := newTestClock()
+	q := NewFiFo[int]()
+	var mu sync.Mutex
+	var sizes []int
+	b := NewBatcher(func(ctx context.Context, batch []int) error {
+		mu.Lock()
+		sizes = append(sizes, len(batch))
+		mu.Unlock()
+		clock.Advance(time.Duration(len(batch)) * 10 * time.Millisecond)
+		return nil
+	})
+	b.MaxSize = 100
+	b.TargetLatency = 50 * time.Millisecond
+	b.Clock = clock
+
+	for i := range 200 {
+		q.Put(context.Background(), i)
+	}
+	q.Close()
+	if err := b.Run(context.Background(), q); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Run = %v", err)
+	}
+	s := b.Stats()
+	if s.Items != 200 {
+		t.Fatalf("flushed %d items, want 200", s.Items)
+	}
+	// At 10ms an item the controller settles around 5 items a batch.
+	mu.Lock()
+	defer mu.Unlock()
+	for _, n := range sizes[len(sizes)-5:] {
+		if n > 6 {
+			t.Fatalf("late batch sizes %v exceed the latency target", sizes[len(sizes)-5:])
+		}
+	}
+}
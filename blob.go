@@ -0,0 +1,381 @@
+package generic
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var (
+	ErrBlobNotFound = errors.New("blob not found")
+	ErrBlobTooLarge = errors.New("blob exceeds size limit")
+)
+
+// Hash is the SHA-256 of a blob's content and its address in a BlobStore.
+// It marshals as hex, so it can travel in an Envelope in place of the
+// payload itself.
+type Hash [sha256.Size]byte
+
+// ParseHash parses the hex form returned by Hash.String.
+func ParseHash(s string) (Hash, error) {
+	var h Hash
+	if len(s) != 2*len(h) {
+		return h, fmt.Errorf("generic: invalid hash %q", s)
+	}
+	if _, err := hex.Decode(h[:], []byte(s)); err != nil {
+		return h, fmt.Errorf("generic: invalid hash %q: %w", s, err)
+	}
+	return h, nil
+}
+
+func (h Hash) String() string { return hex.EncodeToString(h[:]) }
+
+// IsZero reports whether h is the zero Hash, which addresses no blob.
+func (h Hash) IsZero() bool { return h == Hash{} }
+
+func (h Hash) MarshalText() ([]byte, error) { return []byte(h.String()), nil }
+
+func (h *Hash) UnmarshalText(b []byte) error {
+	v, err := ParseHash(string(b))
+	if err != nil {
+		return err
+	}
+	*h = v
+	return nil
+}
+
+// BlobStore keeps immutable blobs addressed by the SHA-256 of their
+// content, for payloads too large to pass through a queue. Storing the same
+// content twice yields the same Hash and one copy.
+type BlobStore interface {
+	// Put stores everything read from r and returns its hash. It fails
+	// with ErrBlobTooLarge if r holds more than the store accepts.
+	Put(ctx context.Context, r io.Reader) (Hash, error)
+	// Get opens the blob with hash h, or fails with ErrBlobNotFound.
+	Get(ctx context.Context, h Hash) (io.ReadCloser, error)
+	Exists(ctx context.Context, h Hash) (bool, error)
+	// Delete removes the blob. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, h Hash) error
+}
+
+// BlobInfo describes a stored blob.
+type BlobInfo struct {
+	Hash Hash      `json:"hash"`
+	Size int64     `json:"size"`
+	Time time.Time `json:"time"` // when the blob was last put
+}
+
+// BlobLister is a BlobStore that can enumerate its blobs, as CollectBlobs
+// requires.
+type BlobLister interface {
+	BlobStore
+	Blobs(ctx context.Context) iter.Seq2[BlobInfo, error]
+}
+
+// CollectBlobs deletes every blob that referenced reports false for and
+// that was last put more than grace ago, returning how many were deleted.
+// The grace period protects blobs that were just stored and are not yet
+// referenced.
+func CollectBlobs(ctx context.Context, s BlobLister, referenced func(Hash) bool, grace time.Duration) (int, error) {
+	cutoff := time.Now().Add(-grace)
+	deleted := 0
+	for info, err := range s.Blobs(ctx) {
+		if err != nil {
+			return deleted, err
+		}
+		if referenced(info.Hash) || info.Time.After(cutoff) {
+			continue
+		}
+		if err := s.Delete(ctx, info.Hash); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// readBlob reads r fully, enforcing maxSize when positive.
+func readBlob(ctx context.Context, r io.Reader, maxSize int64) ([]byte, error) {
+	b, err := ReadAllContext(ctx, r, maxSize)
+	if errors.Is(err, ErrReadLimit) {
+		return nil, ErrBlobTooLarge
+	}
+	return b, err
+}
+
+// MemoryBlobStore is a BlobStore held in memory, for tests and small
+// deployments. The zero value is ready to use.
+type MemoryBlobStore struct {
+	// MaxSize limits each blob in bytes. Zero means no limit.
+	MaxSize int64
+
+	mu    sync.RWMutex
+	blobs map[Hash]memoryBlob
+}
+
+type memoryBlob struct {
+	data []byte
+	at   time.Time
+}
+
+func (s *MemoryBlobStore) Put(ctx context.Context, r io.Reader) (Hash, error) {
+	data, err := readBlob(ctx, r, s.MaxSize)
+	if err != nil {
+		return Hash{}, err
+	}
+	h := Hash(sha256.Sum256(data))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.blobs == nil {
+		s.blobs = make(map[Hash]memoryBlob)
+	}
+	if b, ok := s.blobs[h]; ok {
+		data = b.data
+	}
+	s.blobs[h] = memoryBlob{data: data, at: time.Now()}
+	return h, nil
+}
+
+func (s *MemoryBlobStore) Get(ctx context.Context, h Hash) (io.ReadCloser, error) {
+	s.mu.RLock()
+	b, ok := s.blobs[h]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrBlobNotFound
+	}
+	return io.NopCloser(bytes.NewReader(b.data)), nil
+}
+
+func (s *MemoryBlobStore) Exists(ctx context.Context, h Hash) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.blobs[h]
+	return ok, nil
+}
+
+func (s *MemoryBlobStore) Delete(ctx context.Context, h Hash) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.blobs, h)
+	return nil
+}
+
+// Blobs yields every blob. It works on a copy, so the store may be
+// modified while iterating.
+func (s *MemoryBlobStore) Blobs(ctx context.Context) iter.Seq2[BlobInfo, error] {
+	return func(yield func(BlobInfo, error) bool) {
+		s.mu.RLock()
+		infos := make([]BlobInfo, 0, len(s.blobs))
+		for h, b := range s.blobs {
+			infos = append(infos, BlobInfo{Hash: h, Size: int64(len(b.data)), Time: b.at})
+		}
+		s.mu.RUnlock()
+		for _, info := range infos {
+			if !yield(info, nil) {
+				return
+			}
+		}
+	}
+}
+
+// FileBlobStore is a BlobStore keeping each blob in its own file under a
+// directory, fanned out by the first byte of the hash. Blobs are written to
+// a temporary file and renamed into place, so readers never see a partial
+// blob.
+type FileBlobStore struct {
+	// MaxSize limits each blob in bytes. Zero means no limit.
+	MaxSize int64
+
+	dir string
+}
+
+// NewFileBlobStore returns a store rooted at dir, creating it if needed.
+func NewFileBlobStore(dir string) (*FileBlobStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileBlobStore{dir: dir}, nil
+}
+
+func (s *FileBlobStore) path(h Hash) string {
+	name := h.String()
+	return filepath.Join(s.dir, name[:2], name)
+}
+
+func (s *FileBlobStore) Put(ctx context.Context, r io.Reader) (h Hash, err error) {
+	tmp, err := os.CreateTemp(s.dir, ".put-*")
+	if err != nil {
+		return h, err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+	hasher := sha256.New()
+	src := r
+	if s.MaxSize > 0 {
+		src = io.LimitReader(r, s.MaxSize+1)
+	}
+	n, err := CopyContext(ctx, io.MultiWriter(tmp, hasher), src)
+	if err != nil {
+		return h, err
+	}
+	if s.MaxSize > 0 && n > s.MaxSize {
+		return h, ErrBlobTooLarge
+	}
+	if err := tmp.Close(); err != nil {
+		return h, err
+	}
+	hasher.Sum(h[:0])
+	dst := s.path(h)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return h, err
+	}
+	// Identical content may already be there; replacing it is harmless
+	// and refreshes its time for CollectBlobs.
+	return h, os.Rename(tmp.Name(), dst)
+}
+
+func (s *FileBlobStore) Get(ctx context.Context, h Hash) (io.ReadCloser, error) {
+	f, err := os.Open(s.path(h))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	return f, err
+}
+
+func (s *FileBlobStore) Exists(ctx context.Context, h Hash) (bool, error) {
+	_, err := os.Stat(s.path(h))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *FileBlobStore) Delete(ctx context.Context, h Hash) error {
+	err := os.Remove(s.path(h))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Blobs yields every blob, using each file's modification time as its
+// time. Leftover temporary files and unrelated names are skipped.
+func (s *FileBlobStore) Blobs(ctx context.Context) iter.Seq2[BlobInfo, error] {
+	return func(yield func(BlobInfo, error) bool) {
+		dirs, err := os.ReadDir(s.dir)
+		if err != nil {
+			yield(BlobInfo{}, err)
+			return
+		}
+		for _, d := range dirs {
+			if !d.IsDir() || len(d.Name()) != 2 {
+				continue
+			}
+			files, err := os.ReadDir(filepath.Join(s.dir, d.Name()))
+			if err != nil {
+				if !yield(BlobInfo{}, err) {
+					return
+				}
+				continue
+			}
+			for _, f := range files {
+				if err := ctx.Err(); err != nil {
+					yield(BlobInfo{}, err)
+					return
+				}
+				h, err := ParseHash(f.Name())
+				if err != nil {
+					continue
+				}
+				fi, err := f.Info()
+				if errors.Is(err, fs.ErrNotExist) {
+					continue
+				}
+				if err != nil {
+					if !yield(BlobInfo{Hash: h}, err) {
+						return
+					}
+					continue
+				}
+				if !yield(BlobInfo{Hash: h, Size: fi.Size(), Time: fi.ModTime()}, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// BlobWriter streams whatever is written to it into a BlobStore. Use it as
+// an UploadManager sink, where the finished upload's Hash is recorded in
+// UploadSession.Blob.
+type BlobWriter struct {
+	pw     *io.PipeWriter
+	cancel context.CancelFunc
+	done   chan struct{}
+	hash   Hash
+	err    error
+}
+
+// NewBlobWriter starts a Put into s that completes when the writer is
+// closed.
+func NewBlobWriter(ctx context.Context, s BlobStore) *BlobWriter {
+	ctx, cancel := context.WithCancel(ctx)
+	pr, pw := io.Pipe()
+	w := &BlobWriter{pw: pw, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		w.hash, w.err = s.Put(ctx, pr)
+		// Unblock writes if Put gave up early.
+		pr.CloseWithError(cmp.Or(w.err, io.ErrClosedPipe))
+	}()
+	return w
+}
+
+func (w *BlobWriter) Write(p []byte) (int, error) {
+	return w.pw.Write(p)
+}
+
+// Close finishes the blob and returns the error from storing it.
+func (w *BlobWriter) Close() error {
+	w.pw.Close()
+	<-w.done
+	w.cancel()
+	return w.err
+}
+
+// Abort discards the blob.
+func (w *BlobWriter) Abort() error {
+	w.cancel()
+	w.pw.CloseWithError(context.Canceled)
+	<-w.done
+	return nil
+}
+
+// Hash returns the stored blob's hash once Close has succeeded.
+func (w *BlobWriter) Hash() Hash {
+	return w.hash
+}
+
+// BlobUploads returns an open function for NewUploadManager that streams
+// each upload into s. Uploads outlive the request that created them, so
+// cancellation of that request's context is ignored.
+func BlobUploads[T any](s BlobStore) func(ctx context.Context, meta T, size int64) (io.WriteCloser, error) {
+	return func(ctx context.Context, _ T, _ int64) (io.WriteCloser, error) {
+		return NewBlobWriter(context.WithoutCancel(ctx), s), nil
+	}
+}
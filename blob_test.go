@@ -0,0 +1,199 @@
+package generic
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func blobStores(t *testing.T) map[string]BlobLister {
+	fs, err := NewFileBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]BlobLister{"memory": &MemoryBlobStore{}, "file": fs}
+}
+
+func TestBlobStore_PutGet(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range blobStores(t) {
+		t.Run(name, func(t *testing.T) {
+			h, err := s.Put(ctx, strings.NewReader("hello"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if h != Hash(sha256.Sum256([]byte("hello"))) {
+				t.Fatalf("hash = %s", h)
+			}
+			again, _ := s.Put(ctx, strings.NewReader("hello"))
+			if again != h {
+				t.Fatal("same content got a different hash")
+			}
+			rc, err := s.Get(ctx, h)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, _ := io.ReadAll(rc)
+			rc.Close()
+			if string(b) != "hello" {
+				t.Fatalf("Get = %q", b)
+			}
+			if ok, _ := s.Exists(ctx, h); !ok {
+				t.Fatal("Exists = false")
+			}
+			n := 0
+			for info, err := range s.Blobs(ctx) {
+				if err != nil || info.Hash != h || info.Size != 5 {
+					t.Fatalf("Blobs yielded %+v, %v", info, err)
+				}
+				n++
+			}
+			if n != 1 {
+				t.Fatalf("Blobs yielded %d entries", n)
+			}
+			if err := s.Delete(ctx, h); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Delete(ctx, h); err != nil {
+				t.Fatalf("second Delete = %v", err)
+			}
+			if _, err := s.Get(ctx, h); !errors.Is(err, ErrBlobNotFound) {
+				t.Fatalf("Get after Delete = %v", err)
+			}
+		})
+	}
+}
+
+func TestBlobStore_MaxSize(t *testing.T) {
+	ctx := context.Background()
+	mem := &MemoryBlobStore{MaxSize: 4}
+	if _, err := mem.Put(ctx, strings.NewReader("hello")); !errors.Is(err, ErrBlobTooLarge) {
+		t.Fatalf("memory Put = %v", err)
+	}
+	dir := t.TempDir()
+	fs, _ := NewFileBlobStore(dir)
+	fs.MaxSize = 4
+	if _, err := fs.Put(ctx, strings.NewReader("hello")); !errors.Is(err, ErrBlobTooLarge) {
+		t.Fatalf("file Put = %v", err)
+	}
+	if _, err := fs.Put(ctx, strings.NewReader("hi")); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".put-") {
+			t.Fatalf("temporary file %s left behind", e.Name())
+		}
+	}
+}
+
+func TestCollectBlobs(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range blobStores(t) {
+		t.Run(name, func(t *testing.T) {
+			keep, _ := s.Put(ctx, strings.NewReader("keep"))
+			drop, _ := s.Put(ctx, strings.NewReader("drop"))
+			referenced := func(h Hash) bool { return h == keep }
+			if n, err := CollectBlobs(ctx, s, referenced, time.Hour); err != nil || n != 0 {
+				t.Fatalf("collect within grace = %d, %v", n, err)
+			}
+			if n, err := CollectBlobs(ctx, s, referenced, -time.Second); err != nil || n != 1 {
+				t.Fatalf("collect = %d, %v", n, err)
+			}
+			if ok, _ := s.Exists(ctx, drop); ok {
+				t.Fatal("unreferenced blob survived")
+			}
+			if ok, _ := s.Exists(ctx, keep); !ok {
+				t.Fatal("referenced blob collected")
+			}
+		})
+	}
+}
+
+func TestFileBlobStore_BlobsStatError(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root ignores directory permissions")
+	}
+	ctx := context.Background()
+	s, err := NewFileBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := s.Put(ctx, strings.NewReader("blob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Readable but not searchable: the listing works, stat doesn't.
+	dir := filepath.Dir(s.path(h))
+	if err := os.Chmod(dir, 0o400); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(dir, 0o700) })
+	for info, err := range s.Blobs(ctx) {
+		if !errors.Is(err, os.ErrPermission) || info.Hash != h {
+			t.Fatalf("Blobs yielded %+v, %v", info, err)
+		}
+		return
+	}
+	t.Fatal("Blobs yielded nothing")
+}
+
+func TestHash_JSONInEnvelope(t *testing.T) {
+	h := Hash(sha256.Sum256([]byte("payload")))
+	b, err := json.Marshal(Envelope[Hash]{Item: h})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e Envelope[Hash]
+	if err := json.Unmarshal(b, &e); err != nil || e.Item != h {
+		t.Fatalf("round trip = %s, %v", e.Item, err)
+	}
+	if _, err := ParseHash("xyz"); err == nil {
+		t.Fatal("ParseHash accepted garbage")
+	}
+}
+
+func TestBlobUploads(t *testing.T) {
+	ctx := context.Background()
+	store, _ := NewFileBlobStore(t.TempDir())
+	m := NewUploadManager(BlobUploads[string](store))
+	var got Hash
+	m.OnComplete = func(_ context.Context, s *UploadSession[string]) error {
+		got = s.Blob
+		return nil
+	}
+	s, err := m.Create(ctx, "file.bin", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Append(ctx, s.ID, 0, strings.NewReader("01234"))
+	if _, err := m.Append(ctx, s.ID, 5, strings.NewReader("56789")); err != nil {
+		t.Fatal(err)
+	}
+	if got != Hash(sha256.Sum256([]byte("0123456789"))) {
+		t.Fatalf("Blob = %s", got)
+	}
+
+	aborted, _ := m.Create(ctx, "other.bin", 10)
+	m.Append(ctx, aborted.ID, 0, bytes.NewReader([]byte("xx")))
+	m.Abort(aborted.ID)
+	n := 0
+	for range store.Blobs(ctx) {
+		n++
+	}
+	if n != 1 {
+		t.Fatalf("store holds %d blobs after abort, want 1", n)
+	}
+	matches, _ := filepath.Glob(filepath.Join(store.dir, ".put-*"))
+	if len(matches) != 0 {
+		t.Fatalf("temporary files left: %v", matches)
+	}
+}
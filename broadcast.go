@@ -0,0 +1,177 @@
+package generic
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// BroadcastPolicy decides what Broadcast.Put does for a subscriber whose
+// queue is full.
+type BroadcastPolicy int
+
+const (
+	// BroadcastBlock makes Put wait until the slowest subscriber has room.
+	// It is the default.
+	BroadcastBlock BroadcastPolicy = iota
+	// BroadcastDrop skips the item for a subscriber that is full; the
+	// skipped items are counted in Dropped.
+	BroadcastDrop
+	// BroadcastDisconnect unsubscribes a subscriber that is full and closes
+	// its queue, so it drains what it has and then sees ErrClosed.
+	BroadcastDisconnect
+)
+
+// Broadcast fans one stream of items out to every subscriber: each
+// subscriber gets its own bounded queue receiving every item put after it
+// subscribed.
+type Broadcast[T any] struct {
+	capacity     int
+	policy       BroadcastPolicy
+	mu           sync.Mutex
+	subs         map[*FiFo[T]]func() bool
+	closed       bool
+	dropped      atomic.Int64
+	disconnected atomic.Int64
+
+	// onEmpty, if set, is called after the last subscriber is removed.
+	onEmpty func()
+}
+
+// NewBroadcast returns a Broadcast whose subscriber queues hold up to
+// capacity items each, handling a full one according to policy. A
+// capacity of zero or less means unbounded, so policy never applies.
+func NewBroadcast[T any](capacity int, policy BroadcastPolicy) *Broadcast[T] {
+	return &Broadcast[T]{capacity: capacity, policy: policy, subs: make(map[*FiFo[T]]func() bool)}
+}
+
+// Subscribe returns a queue receiving every item put from now on. The
+// subscription ends, and the queue is closed, once ctx is done, the
+// subscriber is disconnected or the Broadcast is closed.
+func (b *Broadcast[T]) Subscribe(ctx context.Context) Queue[T] {
+	return b.subscribe(ctx)
+}
+
+func (b *Broadcast[T]) subscribe(ctx context.Context) *FiFo[T] {
+	overflow := OverflowBlock
+	if b.policy == BroadcastDrop {
+		overflow = OverflowDropNewest
+	}
+	q := NewBoundedFiFo[T](b.capacity, overflow)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		q.Close()
+		return q
+	}
+	b.subs[q] = context.AfterFunc(ctx, func() { b.unsubscribe(q) })
+	return q
+}
+
+// unsubscribe removes q and closes it, reporting whether it was
+// subscribed.
+func (b *Broadcast[T]) unsubscribe(q *FiFo[T]) bool {
+	b.mu.Lock()
+	stop, ok := b.subs[q]
+	delete(b.subs, q)
+	empty := len(b.subs) == 0
+	b.mu.Unlock()
+	if ok {
+		stop()
+		q.Close()
+		if empty && b.onEmpty != nil {
+			b.onEmpty()
+		}
+	}
+	return ok
+}
+
+// Put delivers x to every current subscriber. It returns ErrClosed once
+// the Broadcast is closed, and under BroadcastBlock ctx.Err() if ctx is
+// done before every subscriber took x; subscribers reached by then keep
+// it.
+func (b *Broadcast[T]) Put(ctx context.Context, x T) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+	subs := make([]*FiFo[T], 0, len(b.subs))
+	for q := range b.subs {
+		subs = append(subs, q)
+	}
+	b.mu.Unlock()
+
+	for _, q := range subs {
+		switch b.policy {
+		case BroadcastDrop:
+			if !q.TryPut(x) && !q.closing() {
+				b.dropped.Add(1)
+			}
+		case BroadcastDisconnect:
+			if !q.TryPut(x) && b.unsubscribe(q) {
+				b.disconnected.Add(1)
+			}
+		default:
+			// A subscriber that went away while Put waited has a closed
+			// queue, which is not an error.
+			if err := q.Put(ctx, x); err != nil && err != ErrClosed {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Subscribers returns the number of current subscribers.
+func (b *Broadcast[T]) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// Dropped returns how many deliveries BroadcastDrop skipped.
+func (b *Broadcast[T]) Dropped() int64 {
+	return b.dropped.Load()
+}
+
+// Disconnected returns how many subscribers BroadcastDisconnect removed.
+func (b *Broadcast[T]) Disconnected() int64 {
+	return b.disconnected.Load()
+}
+
+// Close ends every subscription. Subscribers drain their queues and then
+// see ErrClosed; later Puts fail with ErrClosed and later Subscribes
+// return a closed queue.
+func (b *Broadcast[T]) Close() error {
+	b.mu.Lock()
+	subs := b.subs
+	b.subs = make(map[*FiFo[T]]func() bool)
+	b.closed = true
+	b.mu.Unlock()
+	for q, stop := range subs {
+		stop()
+		q.Close()
+	}
+	return nil
+}
+
+// BroadcastStats is the debug view of a Broadcast returned by Inspect.
+type BroadcastStats struct {
+	Subscribers  int   `json:"subscribers"`
+	Dropped      int64 `json:"dropped,omitempty"`
+	Disconnected int64 `json:"disconnected,omitempty"`
+	Closed       bool  `json:"closed,omitempty"`
+}
+
+// Inspect reports the broadcast state for DebugHandler.
+func (b *Broadcast[T]) Inspect() any {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return BroadcastStats{
+		Subscribers:  len(b.subs),
+		Dropped:      b.dropped.Load(),
+		Disconnected: b.disconnected.Load(),
+		Closed:       b.closed,
+	}
+}
@@ -0,0 +1,91 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBroadcast_EveryConsumer(t *testing.T) {
+	ctx := context.Background()
+	b := NewBroadcast[int](0, BroadcastBlock)
+	b.Put(ctx, 0) // no subscribers yet
+	a, c := b.Subscribe(ctx), b.Subscribe(ctx)
+	for i := 1; i <= 3; i++ {
+		b.Put(ctx, i)
+	}
+	for _, q := range []Queue[int]{a, c} {
+		for want := 1; want <= 3; want++ {
+			if x, err := q.Get(ctx); err != nil || x != want {
+				t.Fatalf("Get = %d, %v, want %d", x, err, want)
+			}
+		}
+	}
+
+	b.Close()
+	if _, err := a.Get(ctx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Get after Close = %v", err)
+	}
+	if err := b.Put(ctx, 4); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Put after Close = %v", err)
+	}
+}
+
+func TestBroadcast_Unsubscribe(t *testing.T) {
+	ctx := context.Background()
+	b := NewBroadcast[int](1, BroadcastBlock)
+	subCtx, cancel := context.WithCancel(ctx)
+	q := b.Subscribe(subCtx)
+	b.Put(ctx, 1)
+
+	// Put blocks on the full subscriber until it goes away.
+	done := make(chan error, 1)
+	go func() { done <- b.Put(ctx, 2) }()
+	select {
+	case <-done:
+		t.Fatal("Put did not block on a full subscriber")
+	case <-time.After(10 * time.Millisecond):
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Put = %v", err)
+	}
+	waitFor(t, func() bool { return b.Subscribers() == 0 })
+	if x, _ := q.Get(ctx); x != 1 {
+		t.Fatalf("Get = %d", x)
+	}
+	if _, err := q.Get(ctx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Get after unsubscribe = %v", err)
+	}
+}
+
+func TestBroadcast_SlowSubscriberPolicies(t *testing.T) {
+	ctx := context.Background()
+
+	drop := NewBroadcast[int](2, BroadcastDrop)
+	slow, fast := drop.Subscribe(ctx), drop.Subscribe(ctx)
+	for i := range 4 {
+		drop.Put(ctx, i)
+		fast.Get(ctx)
+	}
+	if slow.Size() != 2 || drop.Dropped() != 2 {
+		t.Fatalf("size %d, dropped %d", slow.Size(), drop.Dropped())
+	}
+
+	disc := NewBroadcast[int](1, BroadcastDisconnect)
+	slow, fast = disc.Subscribe(ctx), disc.Subscribe(ctx)
+	for i := range 3 {
+		disc.Put(ctx, i)
+		fast.Get(ctx)
+	}
+	if st := disc.Inspect().(BroadcastStats); st.Subscribers != 1 || st.Disconnected != 1 {
+		t.Fatalf("Inspect = %+v", st)
+	}
+	if x, _ := slow.Get(ctx); x != 0 {
+		t.Fatalf("Get = %d", x)
+	}
+	if _, err := slow.Get(ctx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("disconnected Get = %v", err)
+	}
+}
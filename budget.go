@@ -0,0 +1,114 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// Number is the set of types a Budget can count.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Budget is a cost allowance carried in a context, generalizing deadlines
+// to resources like queries or subrequests. Budgets are keyed by T, so
+// define a type per resource (type Queries int) to carry several at once.
+// A budget attached under another one also draws from it, so a callee can
+// be given a smaller share without escaping the caller's limit.
+type Budget[T Number] struct {
+	parent *Budget[T]
+
+	mu    sync.Mutex
+	limit T
+	spent T
+}
+
+type budgetKey[T Number] struct{}
+
+// WithBudget returns a context carrying a budget of n units of T.
+func WithBudget[T Number](ctx context.Context, n T) context.Context {
+	b := &Budget[T]{limit: n, parent: BudgetFromContext[T](ctx)}
+	return context.WithValue(ctx, budgetKey[T]{}, b)
+}
+
+// BudgetFromContext returns the innermost budget of T in ctx, or nil.
+func BudgetFromContext[T Number](ctx context.Context) *Budget[T] {
+	b, _ := ctx.Value(budgetKey[T]{}).(*Budget[T])
+	return b
+}
+
+// Spend charges k units of T to every budget in ctx. If any would be
+// exceeded, nothing is charged and the error wraps ErrBudgetExceeded. A
+// context without a budget of T allows everything.
+func Spend[T Number](ctx context.Context, k T) error {
+	return BudgetFromContext[T](ctx).Spend(k)
+}
+
+// Remaining returns the units of T left in ctx's budgets, the least across
+// nested budgets, and false if ctx has no budget of T.
+func Remaining[T Number](ctx context.Context) (T, bool) {
+	b := BudgetFromContext[T](ctx)
+	if b == nil {
+		return 0, false
+	}
+	return b.Remaining(), true
+}
+
+// Spend charges k units to b and its parents, all or nothing. A nil Budget
+// allows everything.
+func (b *Budget[T]) Spend(k T) error {
+	if b == nil {
+		return nil
+	}
+	// Lock child to root; every Spend locks in the same order.
+	var locked []*Budget[T]
+	defer func() {
+		for _, l := range locked {
+			l.mu.Unlock()
+		}
+	}()
+	for l := b; l != nil; l = l.parent {
+		l.mu.Lock()
+		locked = append(locked, l)
+		if l.spent+k > l.limit {
+			return fmt.Errorf("%w: spending %v with %v of %v left", ErrBudgetExceeded, k, l.limit-l.spent, l.limit)
+		}
+	}
+	for _, l := range locked {
+		l.spent += k
+	}
+	return nil
+}
+
+// Remaining returns the units left, the least across b and its parents.
+func (b *Budget[T]) Remaining() T {
+	left := b.own()
+	for l := b.parent; l != nil; l = l.parent {
+		left = min(left, l.own())
+	}
+	return left
+}
+
+func (b *Budget[T]) own() T {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.limit - b.spent
+}
+
+// Spent returns the units charged to b itself.
+func (b *Budget[T]) Spent() T {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.spent
+}
+
+// Limit returns the units b was created with.
+func (b *Budget[T]) Limit() T {
+	return b.limit
+}
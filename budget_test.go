@@ -0,0 +1,97 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+type queryBudget int
+
+type costBudget float64
+
+func TestBudget_Spend(t *testing.T) {
+	ctx := WithBudget(context.Background(), queryBudget(3))
+	for range 3 {
+		if err := Spend(ctx, queryBudget(1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Spend(ctx, queryBudget(1)); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected ErrBudgetExceeded, got %v", err)
+	}
+	if left, ok := Remaining[queryBudget](ctx); !ok || left != 0 {
+		t.Fatalf("expected 0 left, got %v %v", left, ok)
+	}
+}
+
+func TestBudget_Unbudgeted(t *testing.T) {
+	ctx := context.Background()
+	if err := Spend(ctx, queryBudget(1_000_000)); err != nil {
+		t.Fatalf("no budget should allow everything, got %v", err)
+	}
+	if _, ok := Remaining[queryBudget](ctx); ok {
+		t.Fatal("Remaining should report no budget")
+	}
+}
+
+func TestBudget_IndependentTypes(t *testing.T) {
+	ctx := WithBudget(context.Background(), queryBudget(1))
+	ctx = WithBudget(ctx, costBudget(0.5))
+	if err := Spend(ctx, costBudget(0.25)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Spend(ctx, queryBudget(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Spend(ctx, costBudget(0.5)); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected cost budget exceeded, got %v", err)
+	}
+	if left, _ := Remaining[costBudget](ctx); left != 0.25 {
+		t.Fatalf("expected 0.25 left, got %v", left)
+	}
+}
+
+func TestBudget_Nested(t *testing.T) {
+	parent := WithBudget(context.Background(), queryBudget(5))
+	child := WithBudget(parent, queryBudget(10))
+	if left, _ := Remaining[queryBudget](child); left != 5 {
+		t.Fatalf("child should be capped by its parent, got %v", left)
+	}
+	if err := Spend(child, queryBudget(4)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Spend(child, queryBudget(2)); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("parent limit should apply to the child, got %v", err)
+	}
+	if got := BudgetFromContext[queryBudget](child).Spent(); got != 4 {
+		t.Fatalf("failed spend must not be charged, spent %v", got)
+	}
+	if left, _ := Remaining[queryBudget](parent); left != 1 {
+		t.Fatalf("child spending should draw on the parent, %v left", left)
+	}
+}
+
+func TestBudget_Concurrent(t *testing.T) {
+	ctx := WithBudget(context.Background(), queryBudget(100))
+	child := WithBudget(ctx, queryBudget(100))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	ok := 0
+	for range 200 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if Spend(child, queryBudget(1)) == nil {
+				mu.Lock()
+				ok++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if ok != 100 {
+		t.Fatalf("expected exactly 100 successful spends, got %d", ok)
+	}
+}
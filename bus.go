@@ -0,0 +1,111 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	// ErrNoHandler is returned by Dispatch when no handler is registered for
+	// the command type.
+	ErrNoHandler = errors.New("no handler for command")
+	// ErrHandlerType is returned by Dispatch when the handler registered for
+	// the command type returns a different response type.
+	ErrHandlerType = errors.New("handler response type mismatch")
+)
+
+// BusHandler is a type-erased command handler as seen by middleware.
+type BusHandler func(ctx context.Context, cmd any) (any, error)
+
+// BusMiddleware wraps every Dispatch on a Bus, e.g. for validation, retries
+// or tracing. It sees commands and responses as any; type-switch on cmd to
+// act on specific commands.
+type BusMiddleware func(next BusHandler) BusHandler
+
+// Bus routes commands to handlers by the command's static type. Register
+// handlers with Handle and send commands with Dispatch. The zero value is
+// ready to use.
+type Bus struct {
+	mu         sync.RWMutex
+	handlers   map[reflect.Type]busEntry
+	middleware []BusMiddleware
+}
+
+type busEntry struct {
+	resp reflect.Type
+	fn   BusHandler
+}
+
+// Use appends middleware. The first middleware added is the outermost.
+func (b *Bus) Use(mw ...BusMiddleware) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.middleware = append(b.middleware, mw...)
+}
+
+// Handle registers fn as the handler for TCmd. It panics if TCmd already
+// has a handler.
+func Handle[TCmd, TResp any](b *Bus, fn func(ctx context.Context, cmd TCmd) (TResp, error)) {
+	cmdType := reflect.TypeFor[TCmd]()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.handlers[cmdType]; ok {
+		panic(fmt.Errorf("generic: handler for %v already registered", cmdType))
+	}
+	if b.handlers == nil {
+		b.handlers = make(map[reflect.Type]busEntry)
+	}
+	b.handlers[cmdType] = busEntry{
+		resp: reflect.TypeFor[TResp](),
+		fn: func(ctx context.Context, cmd any) (any, error) {
+			c, ok := cmd.(TCmd)
+			if !ok {
+				// A nil interface command, or one replaced by middleware.
+				return nil, fmt.Errorf("%w: %v handler got %T", ErrHandlerType, cmdType, cmd)
+			}
+			return fn(ctx, c)
+		},
+	}
+}
+
+// Dispatch sends cmd through the bus middleware to the handler registered
+// for TCmd.
+func Dispatch[TCmd, TResp any](ctx context.Context, b *Bus, cmd TCmd) (TResp, error) {
+	var zero TResp
+	cmdType := reflect.TypeFor[TCmd]()
+	b.mu.RLock()
+	entry, ok := b.handlers[cmdType]
+	middleware := b.middleware
+	b.mu.RUnlock()
+	if !ok {
+		return zero, fmt.Errorf("%w: %v", ErrNoHandler, cmdType)
+	}
+	if entry.resp != reflect.TypeFor[TResp]() {
+		return zero, fmt.Errorf("%w: %v handler returns %v, not %v", ErrHandlerType, cmdType, entry.resp, reflect.TypeFor[TResp]())
+	}
+	h := entry.fn
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	resp, err := h(ctx, cmd)
+	if r, ok := resp.(TResp); ok {
+		return r, err
+	}
+	return zero, err
+}
+
+// ValidateCommands is middleware that calls Validate on commands that have
+// one, failing the dispatch without reaching the handler on error.
+func ValidateCommands(next BusHandler) BusHandler {
+	return func(ctx context.Context, cmd any) (any, error) {
+		if v, ok := cmd.(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return nil, err
+			}
+		}
+		return next(ctx, cmd)
+	}
+}
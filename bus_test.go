@@ -0,0 +1,100 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+type createUser struct{ Name string }
+
+func (c createUser) Validate() error {
+	if c.Name == "" {
+		return errors.New("name required")
+	}
+	return nil
+}
+
+type deleteUser struct{ ID int }
+
+func TestBus_Dispatch(t *testing.T) {
+	var bus Bus
+	Handle(&bus, func(ctx context.Context, cmd createUser) (int, error) {
+		return len(cmd.Name), nil
+	})
+	Handle(&bus, func(ctx context.Context, cmd deleteUser) (struct{}, error) {
+		return struct{}{}, errors.New("not found")
+	})
+
+	ctx := context.Background()
+	if id, err := Dispatch[createUser, int](ctx, &bus, createUser{"ada"}); err != nil || id != 3 {
+		t.Fatalf("expected 3, got %d, %v", id, err)
+	}
+	if _, err := Dispatch[deleteUser, struct{}](ctx, &bus, deleteUser{1}); err == nil || err.Error() != "not found" {
+		t.Fatalf("expected handler error, got %v", err)
+	}
+	if _, err := Dispatch[string, int](ctx, &bus, "x"); !errors.Is(err, ErrNoHandler) {
+		t.Fatalf("expected ErrNoHandler, got %v", err)
+	}
+	if _, err := Dispatch[createUser, string](ctx, &bus, createUser{"ada"}); !errors.Is(err, ErrHandlerType) {
+		t.Fatalf("expected ErrHandlerType, got %v", err)
+	}
+}
+
+func TestBus_DuplicateHandlerPanics(t *testing.T) {
+	var bus Bus
+	h := func(ctx context.Context, cmd deleteUser) (bool, error) { return true, nil }
+	Handle(&bus, h)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic on duplicate handler")
+		}
+	}()
+	Handle(&bus, h)
+}
+
+func TestBus_Middleware(t *testing.T) {
+	var bus Bus
+	var trace []string
+	tag := func(name string) BusMiddleware {
+		return func(next BusHandler) BusHandler {
+			return func(ctx context.Context, cmd any) (any, error) {
+				trace = append(trace, name)
+				return next(ctx, cmd)
+			}
+		}
+	}
+	bus.Use(tag("outer"), ValidateCommands, tag("inner"))
+	Handle(&bus, func(ctx context.Context, cmd createUser) (string, error) {
+		trace = append(trace, "handler")
+		return "ok", nil
+	})
+
+	ctx := context.Background()
+	if _, err := Dispatch[createUser, string](ctx, &bus, createUser{}); err == nil || err.Error() != "name required" {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if got := trace; !slices.Equal(got, []string{"outer"}) {
+		t.Fatalf("validation should stop the chain, got %v", got)
+	}
+
+	trace = nil
+	if resp, err := Dispatch[createUser, string](ctx, &bus, createUser{"bob"}); err != nil || resp != "ok" {
+		t.Fatalf("expected ok, got %q, %v", resp, err)
+	}
+	if got := trace; !slices.Equal(got, []string{"outer", "inner", "handler"}) {
+		t.Fatalf("unexpected middleware order %v", got)
+	}
+}
+
+func TestBus_NilInterfaceCommand(t *testing.T) {
+	var b Bus
+	Handle(&b, func(_ context.Context, cmd error) (string, error) { return cmd.Error(), nil })
+	if _, err := Dispatch[error, string](context.Background(), &b, nil); !errors.Is(err, ErrHandlerType) {
+		t.Fatalf("Dispatch(nil) = %v", err)
+	}
+	if got, err := Dispatch[error, string](context.Background(), &b, errors.New("ok")); err != nil || got != "ok" {
+		t.Fatalf("Dispatch = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,20 @@
+package generic
+
+import "time"
+
+// Clock abstracts time so timer-based types can be tested deterministically.
+// Types only subtract and compare Now readings, so a Clock whose readings
+// carry a monotonic component, like SystemClock, makes their TTLs immune to
+// wall-clock steps.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the Clock backed by package time.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
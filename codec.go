@@ -0,0 +1,44 @@
+package generic
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec converts items to and from bytes for storage or transport.
+type Codec[T any] interface {
+	Marshal(x T) ([]byte, error)
+	Unmarshal(b []byte) (T, error)
+}
+
+// JSONCodec encodes items with encoding/json.
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Marshal(x T) ([]byte, error) {
+	return json.Marshal(x)
+}
+
+func (JSONCodec[T]) Unmarshal(b []byte) (T, error) {
+	var x T
+	err := json.Unmarshal(b, &x)
+	return x, err
+}
+
+// GobCodec encodes items with encoding/gob. Each item is encoded
+// independently, so type information is repeated in every payload.
+type GobCodec[T any] struct{}
+
+func (GobCodec[T]) Marshal(x T) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&x); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec[T]) Unmarshal(b []byte) (T, error) {
+	var x T
+	err := gob.NewDecoder(bytes.NewReader(b)).Decode(&x)
+	return x, err
+}
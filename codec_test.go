@@ -0,0 +1,44 @@
+package generic
+
+import (
+	"reflect"
+	"testing"
+)
+
+type codecItem struct {
+	ID   int
+	Name string
+	Tags []string
+}
+
+func testCodecRoundTrip(t *testing.T, c Codec[codecItem]) {
+	t.Helper()
+	in := codecItem{ID: 1, Name: "one", Tags: []string{"a", "b"}}
+	b, err := c.Marshal(in)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	out, err := c.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Fatalf("round trip mismatch: %+v != %+v", in, out)
+	}
+}
+
+func TestJSONCodec(t *testing.T) {
+	testCodecRoundTrip(t, JSONCodec[codecItem]{})
+
+	if _, err := (JSONCodec[codecItem]{}).Unmarshal([]byte("{")); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestGobCodec(t *testing.T) {
+	testCodecRoundTrip(t, GobCodec[codecItem]{})
+
+	if _, err := (GobCodec[codecItem]{}).Unmarshal([]byte("garbage")); err == nil {
+		t.Error("expected error for invalid gob")
+	}
+}
@@ -0,0 +1,172 @@
+package generic
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Compressor compresses byte slices for CompressedCodec. Both methods
+// append their output to dst. A zstd implementation can wrap an encoder's
+// EncodeAll and a decoder's DecodeAll.
+type Compressor interface {
+	Compress(dst, src []byte) ([]byte, error)
+	Decompress(dst, src []byte) ([]byte, error)
+}
+
+// GzipCompressor is a Compressor using compress/gzip. Writers and readers
+// are pooled, so it is cheap to call per item.
+type GzipCompressor struct {
+	level   int
+	writers SyncPool[*gzip.Writer]
+	readers SyncPool[*gzip.Reader]
+}
+
+// NewGzipCompressor returns a gzip Compressor at level, one of the
+// compress/gzip level constants.
+func NewGzipCompressor(level int) (*GzipCompressor, error) {
+	if _, err := gzip.NewWriterLevel(io.Discard, level); err != nil {
+		return nil, err
+	}
+	return &GzipCompressor{level: level}, nil
+}
+
+func (c *GzipCompressor) Compress(dst, src []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	w := c.writers.Get()
+	if w == nil {
+		w, _ = gzip.NewWriterLevel(buf, c.level)
+	} else {
+		w.Reset(buf)
+	}
+	defer c.writers.Put(w)
+	if _, err := w.Write(src); err != nil {
+		return dst, err
+	}
+	if err := w.Close(); err != nil {
+		return dst, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *GzipCompressor) Decompress(dst, src []byte) ([]byte, error) {
+	r := c.readers.Get()
+	var err error
+	if r == nil {
+		r, err = gzip.NewReader(bytes.NewReader(src))
+	} else {
+		err = r.Reset(bytes.NewReader(src))
+	}
+	if err != nil {
+		return dst, err
+	}
+	defer c.readers.Put(r)
+	buf := bytes.NewBuffer(dst)
+	// Bound the output so a small malicious payload cannot expand without
+	// limit.
+	n, err := buf.ReadFrom(io.LimitReader(r, maxFrameSize+1))
+	if err != nil {
+		return dst, err
+	}
+	if n > maxFrameSize {
+		return dst, fmt.Errorf("%w: decompressed size exceeds %d bytes", ErrFrameTooLarge, maxFrameSize)
+	}
+	return buf.Bytes(), nil
+}
+
+// Payload markers written by CompressedCodec.
+const (
+	payloadRaw byte = iota
+	payloadCompressed
+)
+
+// CompressedCodec wraps a Codec, compressing payloads of at least MinSize
+// bytes. Smaller payloads are stored raw, since compression headers would
+// make them larger. Each payload starts with a marker byte, so MinSize can
+// change without breaking existing data.
+//
+// Wrap a BatchCodec to compress many items together, which compresses
+// small items far better than doing it per item.
+type CompressedCodec[T any] struct {
+	Codec      Codec[T]
+	Compressor Compressor
+	MinSize    int
+}
+
+var _ Codec[int] = CompressedCodec[int]{}
+
+func (c CompressedCodec[T]) Marshal(x T) ([]byte, error) {
+	data, err := c.Codec.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < c.MinSize {
+		return append([]byte{payloadRaw}, data...), nil
+	}
+	return c.Compressor.Compress([]byte{payloadCompressed}, data)
+}
+
+func (c CompressedCodec[T]) Unmarshal(b []byte) (T, error) {
+	var zero T
+	if len(b) == 0 {
+		return zero, io.ErrUnexpectedEOF
+	}
+	switch b[0] {
+	case payloadRaw:
+		return c.Codec.Unmarshal(b[1:])
+	case payloadCompressed:
+		data, err := c.Compressor.Decompress(nil, b[1:])
+		if err != nil {
+			return zero, err
+		}
+		return c.Codec.Unmarshal(data)
+	default:
+		return zero, fmt.Errorf("compressed codec: unknown payload marker %d", b[0])
+	}
+}
+
+// BatchCodec encodes a slice of items as one payload of length-prefixed
+// items, each encoded with Codec.
+type BatchCodec[T any] struct {
+	Codec Codec[T]
+}
+
+var _ Codec[[]int] = BatchCodec[int]{}
+
+func (c BatchCodec[T]) Marshal(xs []T) ([]byte, error) {
+	out := binary.AppendUvarint(nil, uint64(len(xs)))
+	for _, x := range xs {
+		data, err := c.Codec.Marshal(x)
+		if err != nil {
+			return nil, err
+		}
+		out = binary.AppendUvarint(out, uint64(len(data)))
+		out = append(out, data...)
+	}
+	return out, nil
+}
+
+func (c BatchCodec[T]) Unmarshal(b []byte) ([]T, error) {
+	count, n := binary.Uvarint(b)
+	// Every item needs at least one length byte.
+	if n <= 0 || count > uint64(len(b)-n) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b = b[n:]
+	xs := make([]T, 0, count)
+	for range count {
+		size, n := binary.Uvarint(b)
+		if n <= 0 || size > uint64(len(b)-n) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		x, err := c.Codec.Unmarshal(b[n : n+int(size)])
+		if err != nil {
+			return nil, err
+		}
+		xs = append(xs, x)
+		b = b[n+int(size):]
+	}
+	return xs, nil
+}
@@ -0,0 +1,110 @@
+package generic
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGzipCompressor(t *testing.T) {
+	c, err := NewGzipCompressor(gzip.BestSpeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := []byte(strings.Repeat("queue ", 1000))
+	for range 3 { // exercise pooled writers and readers
+		z, err := c.Compress([]byte("hdr"), src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(z, []byte("hdr")) || len(z) >= len(src)/10 {
+			t.Fatalf("expected compressed output after dst, got %d bytes", len(z))
+		}
+		out, err := c.Decompress(nil, z[3:])
+		if err != nil || !bytes.Equal(out, src) {
+			t.Fatalf("round trip failed: %v", err)
+		}
+	}
+	if _, err := c.Decompress(nil, []byte("not gzip")); err == nil {
+		t.Fatal("expected error for invalid input")
+	}
+	if _, err := NewGzipCompressor(42); err == nil {
+		t.Fatal("expected error for invalid level")
+	}
+}
+
+func TestCompressedCodec(t *testing.T) {
+	gz, _ := NewGzipCompressor(gzip.DefaultCompression)
+	codec := CompressedCodec[ioRecord]{Codec: JSONCodec[ioRecord]{}, Compressor: gz, MinSize: 64}
+
+	small := ioRecord{1, "x"}
+	b, err := codec.Marshal(small)
+	if err != nil || b[0] != payloadRaw {
+		t.Fatalf("small payloads should be stored raw, got %v, %v", b, err)
+	}
+	if got, err := codec.Unmarshal(b); err != nil || got != small {
+		t.Fatalf("expected %+v, got %+v, %v", small, got, err)
+	}
+
+	big := ioRecord{2, strings.Repeat("abc", 200)}
+	b, err = codec.Marshal(big)
+	if err != nil || b[0] != payloadCompressed || len(b) > 100 {
+		t.Fatalf("large payloads should be compressed, got %d bytes, %v", len(b), err)
+	}
+	if got, err := codec.Unmarshal(b); err != nil || got != big {
+		t.Fatalf("round trip failed: %v", err)
+	}
+
+	if _, err := codec.Unmarshal([]byte{9}); err == nil {
+		t.Fatal("expected error for unknown marker")
+	}
+	if _, err := codec.Unmarshal(nil); err == nil {
+		t.Fatal("expected error for empty payload")
+	}
+}
+
+func TestCompressedCodec_DecompressionLimit(t *testing.T) {
+	gz, _ := NewGzipCompressor(gzip.BestCompression)
+	bomb, err := gz.Compress(nil, make([]byte, maxFrameSize+1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gz.Decompress(nil, bomb); !errors.Is(err, ErrFrameTooLarge) {
+		t.Fatalf("expected ErrFrameTooLarge, got %v", err)
+	}
+}
+
+func TestBatchCodec(t *testing.T) {
+	gz, _ := NewGzipCompressor(gzip.DefaultCompression)
+	codec := CompressedCodec[[]ioRecord]{Codec: BatchCodec[ioRecord]{Codec: JSONCodec[ioRecord]{}}, Compressor: gz}
+	batch := []ioRecord{{1, "a"}, {2, "b"}, {3, "c"}}
+	b, err := codec.Marshal(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := codec.Unmarshal(b)
+	if err != nil || len(got) != 3 || got[2] != batch[2] {
+		t.Fatalf("round trip failed: %v, %v", got, err)
+	}
+
+	raw, _ := BatchCodec[int]{Codec: JSONCodec[int]{}}.Marshal([]int{1, 2})
+	if _, err := (BatchCodec[int]{Codec: JSONCodec[int]{}}).Unmarshal(raw[:len(raw)-1]); err == nil {
+		t.Fatal("expected error for truncated batch")
+	}
+	if got, err := (BatchCodec[int]{Codec: JSONCodec[int]{}}).Unmarshal([]byte{0}); err != nil || len(got) != 0 {
+		t.Fatalf("expected empty batch, got %v, %v", got, err)
+	}
+}
+
+func BenchmarkCompressedCodec(b *testing.B) {
+	gz, _ := NewGzipCompressor(gzip.BestSpeed)
+	codec := CompressedCodec[ioRecord]{Codec: JSONCodec[ioRecord]{}, Compressor: gz}
+	x := ioRecord{1, strings.Repeat("payload ", 50)}
+	b.ReportAllocs()
+	for b.Loop() {
+		data, _ := codec.Marshal(x)
+		codec.Unmarshal(data)
+	}
+}
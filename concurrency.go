@@ -0,0 +1,136 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrConcurrencyLimit is reported when a key already has as many requests
+// in flight as it is allowed.
+var ErrConcurrencyLimit = errors.New("concurrency limit reached")
+
+// KeyedConcurrency limits how much work is in flight per key, such as per
+// tenant or client, so one key cannot take every slot. Keys without work in
+// flight use no memory.
+type KeyedConcurrency[K comparable] struct {
+	// RetryAfter is the delay suggested to rejected HTTP clients. Defaults
+	// to one second.
+	RetryAfter time.Duration
+
+	limit    int
+	mu       sync.Mutex
+	limits   map[K]int
+	inFlight map[K]int
+	rejected atomic.Int64
+}
+
+// KeyedConcurrencyStats is a point-in-time view of a KeyedConcurrency.
+type KeyedConcurrencyStats struct {
+	// Keys is the number of keys with work in flight.
+	Keys     int   `json:"keys"`
+	InFlight int   `json:"in_flight"`
+	Rejected int64 `json:"rejected"`
+}
+
+// NewKeyedConcurrency returns a limiter allowing limit concurrent
+// acquisitions per key.
+func NewKeyedConcurrency[K comparable](limit int) *KeyedConcurrency[K] {
+	return &KeyedConcurrency[K]{limit: limit, inFlight: make(map[K]int)}
+}
+
+// SetLimit overrides the limit for key. A negative n restores the default.
+func (c *KeyedConcurrency[K]) SetLimit(key K, n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n < 0 {
+		delete(c.limits, key)
+		return
+	}
+	if c.limits == nil {
+		c.limits = make(map[K]int)
+	}
+	c.limits[key] = n
+}
+
+// TryAcquire takes a slot for key without blocking. It reports false if key
+// is at its limit; otherwise release must be called when the work is done.
+func (c *KeyedConcurrency[K]) TryAcquire(key K) (release func(), ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	limit, ok := c.limits[key]
+	if !ok {
+		limit = c.limit
+	}
+	if c.inFlight[key] >= limit {
+		c.rejected.Add(1)
+		return nil, false
+	}
+	c.inFlight[key]++
+	var once sync.Once
+	return func() { once.Do(func() { c.release(key) }) }, true
+}
+
+func (c *KeyedConcurrency[K]) release(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n := c.inFlight[key] - 1; n > 0 {
+		c.inFlight[key] = n
+	} else {
+		delete(c.inFlight, key)
+	}
+}
+
+// InFlight returns the number of slots held for key.
+func (c *KeyedConcurrency[K]) InFlight(key K) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.inFlight[key]
+}
+
+// Stats returns the current load and the number of rejections.
+func (c *KeyedConcurrency[K]) Stats() KeyedConcurrencyStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := KeyedConcurrencyStats{Keys: len(c.inFlight), Rejected: c.rejected.Load()}
+	for _, n := range c.inFlight {
+		s.InFlight += n
+	}
+	return s
+}
+
+// Inspect reports Stats for DebugHandler.
+func (c *KeyedConcurrency[K]) Inspect() any {
+	return c.Stats()
+}
+
+// ConcurrencyMiddleware returns HTTP middleware that takes a slot from c
+// for the key extracted from each request's typed context, answering
+// requests over the key's limit with 429 Too Many Requests and a
+// Retry-After header. Like RequestWithContext, it panics if the request
+// context is not a C.
+func ConcurrencyMiddleware[C context.Context, K comparable](c *KeyedConcurrency[K], key func(ctx C) K) func(http.Handler) http.Handler {
+	retryAfter := c.RetryAfter
+	if retryAfter <= 0 {
+		retryAfter = time.Second
+	}
+	seconds := strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := (*RequestWithContext[C])(r).Context().(C)
+			release, ok := c.TryAcquire(key(ctx))
+			if !ok {
+				w.Header().Set("Retry-After", seconds)
+				http.Error(w, ErrConcurrencyLimit.Error(), http.StatusTooManyRequests)
+				return
+			}
+			defer release()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
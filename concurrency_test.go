@@ -0,0 +1,72 @@
+package generic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestKeyedConcurrency(t *testing.T) {
+	c := NewKeyedConcurrency[string](2)
+	c.SetLimit("vip", 3)
+	var releases []func()
+	for range 2 {
+		release, ok := c.TryAcquire("a")
+		if !ok {
+			t.Fatal("acquire under limit failed")
+		}
+		releases = append(releases, release)
+	}
+	if _, ok := c.TryAcquire("a"); ok {
+		t.Fatal("acquire over limit succeeded")
+	}
+	for range 3 {
+		if _, ok := c.TryAcquire("vip"); !ok {
+			t.Fatal("override limit not applied")
+		}
+	}
+	releases[0]()
+	releases[0]()
+	if n := c.InFlight("a"); n != 1 {
+		t.Fatalf("in flight = %d, want 1", n)
+	}
+	releases[1]()
+	if s := c.Stats(); s.Keys != 1 || s.InFlight != 3 || s.Rejected != 1 {
+		t.Fatalf("stats = %+v", s)
+	}
+}
+
+func TestConcurrencyMiddleware(t *testing.T) {
+	c := NewKeyedConcurrency[string](1)
+	entered := make(chan struct{})
+	unblock := make(chan struct{})
+	h := ConcurrencyMiddleware(c, tenantCtx.Tenant)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-unblock
+	}))
+	serve := func(tenant string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req = req.WithContext(tenantCtx{Context: req.Context(), tenant: tenant})
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	done := make(chan int)
+	go func() { done <- serve("acme").Code }()
+	<-entered
+
+	if rec := serve("acme"); rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("second acme request: %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	go func() { done <- serve("globex").Code }()
+	<-entered
+	close(unblock)
+	for range 2 {
+		if code := <-done; code != http.StatusOK {
+			t.Fatalf("code = %d", code)
+		}
+	}
+	if n := c.InFlight("acme"); n != 0 {
+		t.Fatalf("in flight after completion = %d", n)
+	}
+}
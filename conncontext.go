@@ -0,0 +1,50 @@
+package generic
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+type connKey[T any] struct{}
+
+// ConnContext returns a function for http.Server.ConnContext that attaches
+// fn's value for each new connection, typed by T. Handlers read it back
+// with ConnValue. Use ChainConnContext to attach several types.
+func ConnContext[T any](fn func(ctx context.Context, c net.Conn) T) func(context.Context, net.Conn) context.Context {
+	return func(ctx context.Context, c net.Conn) context.Context {
+		v := fn(ctx, c)
+		return context.WithValue(ctx, connKey[T]{}, func() T { return v })
+	}
+}
+
+// LazyConnContext is ConnContext that calls fn once, on the first
+// ConnValue for the connection. ConnContext runs before a TLS handshake, so
+// values derived from a *tls.Conn's ConnectionState, such as the client
+// certificate identity, need the lazy form.
+func LazyConnContext[T any](fn func(c net.Conn) T) func(context.Context, net.Conn) context.Context {
+	return func(ctx context.Context, c net.Conn) context.Context {
+		return context.WithValue(ctx, connKey[T]{}, sync.OnceValue(func() T { return fn(c) }))
+	}
+}
+
+// ChainConnContext combines several ConnContext functions into one.
+func ChainConnContext(fns ...func(context.Context, net.Conn) context.Context) func(context.Context, net.Conn) context.Context {
+	return func(ctx context.Context, c net.Conn) context.Context {
+		for _, fn := range fns {
+			ctx = fn(ctx, c)
+		}
+		return ctx
+	}
+}
+
+// ConnValue returns the connection's value of type T, or false if none was
+// attached.
+func ConnValue[T any](ctx context.Context) (T, bool) {
+	get, ok := ctx.Value(connKey[T]{}).(func() T)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return get(), true
+}
@@ -0,0 +1,96 @@
+package generic
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+type connInfo struct {
+	Remote string
+}
+
+type connTLS struct {
+	Version uint16
+}
+
+func TestConnContext(t *testing.T) {
+	var conns atomic.Int64
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info, ok := ConnValue[connInfo](r.Context())
+		if !ok || info.Remote != r.RemoteAddr {
+			http.Error(w, "missing conn info", http.StatusInternalServerError)
+			return
+		}
+		if _, ok := ConnValue[connTLS](r.Context()); ok {
+			http.Error(w, "unexpected TLS info", http.StatusInternalServerError)
+			return
+		}
+		io.WriteString(w, info.Remote)
+	}))
+	srv.Config.ConnContext = ConnContext(func(ctx context.Context, c net.Conn) connInfo {
+		conns.Add(1)
+		return connInfo{Remote: c.RemoteAddr().String()}
+	})
+	srv.Start()
+	defer srv.Close()
+
+	for range 3 {
+		resp, err := srv.Client().Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status %d: %s", resp.StatusCode, body)
+		}
+	}
+	if conns.Load() != 1 {
+		t.Fatalf("value should be computed once per connection, got %d", conns.Load())
+	}
+}
+
+func TestLazyConnContext_TLS(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		st, ok := ConnValue[connTLS](r.Context())
+		if !ok || st.Version == 0 {
+			http.Error(w, "handshake not complete", http.StatusInternalServerError)
+			return
+		}
+		if _, ok := ConnValue[connInfo](r.Context()); !ok {
+			http.Error(w, "chained value missing", http.StatusInternalServerError)
+		}
+	}))
+	srv.Config.ConnContext = ChainConnContext(
+		LazyConnContext(func(c net.Conn) connTLS {
+			return connTLS{Version: c.(*tls.Conn).ConnectionState().Version}
+		}),
+		ConnContext(func(ctx context.Context, c net.Conn) connInfo {
+			return connInfo{Remote: c.RemoteAddr().String()}
+		}),
+	)
+	srv.StartTLS()
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("status %d: %s", resp.StatusCode, body)
+	}
+}
+
+func TestConnValue_Missing(t *testing.T) {
+	if _, ok := ConnValue[connInfo](context.Background()); ok {
+		t.Fatal("expected no value")
+	}
+}
@@ -0,0 +1,125 @@
+package generic
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ConsumerStats describes one consumer goroutine started by
+// ShardedQueue.Consume or QueueGroup.ConsumeEach.
+type ConsumerStats struct {
+	// Name identifies the consumer: the shard index or the group key.
+	Name      string `json:"name"`
+	Processed int64  `json:"processed"`
+	// Errors counts items reported with RecordConsumerError.
+	Errors    int64   `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+	LastError string  `json:"last_error,omitempty"`
+	// LastActivity is when the consumer last finished an item.
+	LastActivity time.Time `json:"last_activity,omitzero"`
+	// ItemAge is how long the consumer has been handling its current item,
+	// or zero if it is waiting for one. A growing ItemAge marks a wedged
+	// consumer.
+	ItemAge time.Duration `json:"item_age"`
+}
+
+type consumerKey struct{}
+
+// RecordConsumerError counts err against the consumer whose handler
+// received ctx. Outside a consumer handler it does nothing.
+func RecordConsumerError(ctx context.Context, err error) {
+	c, ok := ctx.Value(consumerKey{}).(*consumerState)
+	if !ok || err == nil {
+		return
+	}
+	c.mu.Lock()
+	c.errors++
+	c.lastErr = err.Error()
+	c.mu.Unlock()
+}
+
+// consumerSet tracks the running consumers of one queue. The zero value is
+// ready to use.
+type consumerSet struct {
+	mu      sync.Mutex
+	running map[*consumerState]struct{}
+}
+
+type consumerState struct {
+	name  string
+	clock Clock
+
+	mu        sync.Mutex
+	processed int64
+	errors    int64
+	lastErr   string
+	last      time.Time
+	started   time.Time // zero while waiting for an item
+}
+
+// start registers a consumer; the caller must pass it to stop when done.
+func (s *consumerSet) start(name string, clock Clock) *consumerState {
+	c := &consumerState{name: name, clock: clock}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running == nil {
+		s.running = make(map[*consumerState]struct{})
+	}
+	s.running[c] = struct{}{}
+	return c
+}
+
+func (s *consumerSet) stop(c *consumerState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, c)
+}
+
+// handle runs fn for one item, recording its duration and outcome.
+func (c *consumerState) handle(ctx context.Context, fn func(ctx context.Context)) {
+	c.mu.Lock()
+	c.started = c.clock.Now()
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.processed++
+		c.last = c.clock.Now()
+		c.started = time.Time{}
+		c.mu.Unlock()
+	}()
+	fn(context.WithValue(ctx, consumerKey{}, c))
+}
+
+// stats returns a snapshot of every running consumer, ordered by name.
+func (s *consumerSet) stats() []ConsumerStats {
+	s.mu.Lock()
+	running := make([]*consumerState, 0, len(s.running))
+	for c := range s.running {
+		running = append(running, c)
+	}
+	s.mu.Unlock()
+	out := make([]ConsumerStats, 0, len(running))
+	for _, c := range running {
+		c.mu.Lock()
+		st := ConsumerStats{
+			Name:         c.name,
+			Processed:    c.processed,
+			Errors:       c.errors,
+			LastError:    c.lastErr,
+			LastActivity: c.last,
+		}
+		if !c.started.IsZero() {
+			st.ItemAge = c.clock.Now().Sub(c.started)
+		}
+		c.mu.Unlock()
+		if st.Processed > 0 {
+			st.ErrorRate = float64(st.Errors) / float64(st.Processed)
+		}
+		out = append(out, st)
+	}
+	slices.SortFunc(out, func(a, b ConsumerStats) int { return strings.Compare(a.Name, b.Name) })
+	return out
+}
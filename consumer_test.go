@@ -0,0 +1,101 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestShardedQueue_ConsumerStats(t *testing.T) {
+	q := NewShardedQueue(2, func(x int) uint64 { return uint64(x) })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	boom := errors.New("boom")
+	block := make(chan struct{})
+	go q.Consume(ctx, func(ctx context.Context, x int) {
+		if x < 0 {
+			<-block
+			return
+		}
+		if x%2 == 1 {
+			RecordConsumerError(ctx, boom)
+		}
+	})
+	for i := range 4 {
+		q.Put(ctx, i)
+	}
+	waitFor(t, func() bool {
+		var n int64
+		for _, s := range q.ConsumerStats() {
+			n += s.Processed
+		}
+		return n == 4
+	})
+	stats := q.ConsumerStats()
+	if len(stats) != 2 {
+		t.Fatalf("got %d consumers, want 2", len(stats))
+	}
+	var errs int64
+	for _, s := range stats {
+		errs += s.Errors
+		if s.Errors > 0 && (s.LastError != "boom" || s.ErrorRate <= 0) {
+			t.Errorf("consumer %s: %+v", s.Name, s)
+		}
+		if s.Processed > 0 && s.LastActivity.IsZero() {
+			t.Errorf("consumer %s has no activity", s.Name)
+		}
+	}
+	if errs != 2 {
+		t.Fatalf("errors = %d, want 2", errs)
+	}
+
+	q.Put(ctx, -1)
+	waitFor(t, func() bool {
+		for _, s := range q.ConsumerStats() {
+			if s.ItemAge > 0 {
+				return true
+			}
+		}
+		return false
+	})
+	close(block)
+	if st := q.Inspect().(ShardedQueueStats); st.Shards != 2 || len(st.Consumers) != 2 {
+		t.Fatalf("Inspect = %+v", st)
+	}
+}
+
+func TestQueueGroup_ConsumerStats(t *testing.T) {
+	var g QueueGroup[string, int]
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go g.ConsumeEach(ctx, func(context.Context, string, int) {})
+	g.Put(ctx, "a", 1)
+	g.Put(ctx, "b", 1)
+	g.Put(ctx, "b", 2)
+	waitFor(t, func() bool {
+		s := g.ConsumerStats()
+		return len(s) == 2 && s[0].Processed == 1 && s[1].Processed == 2
+	})
+	if s := g.ConsumerStats(); s[0].Name != "a" || s[1].Name != "b" {
+		t.Fatalf("names = %s, %s", s[0].Name, s[1].Name)
+	}
+	if st := g.Inspect().(QueueGroupStats); st.Queues != 2 {
+		t.Fatalf("Inspect = %+v", st)
+	}
+}
+
+func TestRecordConsumerError_OutsideConsumer(t *testing.T) {
+	RecordConsumerError(context.Background(), errors.New("ignored"))
+}
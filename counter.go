@@ -0,0 +1,123 @@
+package generic
+
+import (
+	"math/bits"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ShardedCounter is an int64 counter that spreads writes across cache-line
+// padded shards, trading a more expensive Load for uncontended Add. The zero
+// value is ready to use.
+type ShardedCounter struct {
+	shards atomic.Pointer[[]CacheLinePadded[atomic.Int64]]
+}
+
+func (c *ShardedCounter) load() []CacheLinePadded[atomic.Int64] {
+	if p := c.shards.Load(); p != nil {
+		return *p
+	}
+	n := 1 << bits.Len(uint(runtime.GOMAXPROCS(0)-1))
+	shards := make([]CacheLinePadded[atomic.Int64], n)
+	if c.shards.CompareAndSwap(nil, &shards) {
+		return shards
+	}
+	return *c.shards.Load()
+}
+
+// Add adds delta to the counter.
+func (c *ShardedCounter) Add(delta int64) {
+	shards := c.load()
+	shards[rand.Uint32()&uint32(len(shards)-1)].Value.Add(delta)
+}
+
+// Load returns the sum of all shards. Concurrent Adds may or may not be
+// included.
+func (c *ShardedCounter) Load() int64 {
+	var sum int64
+	shards := c.load()
+	for i := range shards {
+		sum += shards[i].Value.Load()
+	}
+	return sum
+}
+
+// Reset zeroes the counter and returns the value it held. Every Add is
+// counted exactly once across the returned value and later reads.
+func (c *ShardedCounter) Reset() int64 {
+	var sum int64
+	shards := c.load()
+	for i := range shards {
+		sum += shards[i].Value.Swap(0)
+	}
+	return sum
+}
+
+// CounterMap is a set of ShardedCounters created on first use of each key.
+// The zero value is ready to use.
+type CounterMap[K comparable] struct {
+	mu       sync.RWMutex // write-locked to add keys and by SwapAll
+	counters map[K]*ShardedCounter
+}
+
+// Add adds delta to the counter for key.
+func (m *CounterMap[K]) Add(key K, delta int64) {
+	m.mu.RLock()
+	c, ok := m.counters[key]
+	if ok {
+		// Adding under the read lock lets SwapAll wait out every Add to
+		// the counters it takes.
+		c.Add(delta)
+		m.mu.RUnlock()
+		return
+	}
+	m.mu.RUnlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok = m.counters[key]; !ok {
+		if m.counters == nil {
+			m.counters = make(map[K]*ShardedCounter)
+		}
+		c = new(ShardedCounter)
+		m.counters[key] = c
+	}
+	c.Add(delta)
+}
+
+// Load returns the value for key.
+func (m *CounterMap[K]) Load(key K) int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if c, ok := m.counters[key]; ok {
+		return c.Load()
+	}
+	return 0
+}
+
+// Snapshot returns the value of every counter.
+func (m *CounterMap[K]) Snapshot() map[K]int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return sumCounters(m.counters)
+}
+
+// SwapAll returns the value of every counter and starts over with no keys.
+// Every Add is counted in exactly one swap, unlike reading and then
+// resetting each key.
+func (m *CounterMap[K]) SwapAll() map[K]int64 {
+	m.mu.Lock()
+	counters := m.counters
+	m.counters = nil
+	m.mu.Unlock()
+	return sumCounters(counters)
+}
+
+func sumCounters[K comparable](counters map[K]*ShardedCounter) map[K]int64 {
+	out := make(map[K]int64, len(counters))
+	for k, c := range counters {
+		out[k] = c.Load()
+	}
+	return out
+}
@@ -0,0 +1,130 @@
+package generic
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestShardedCounter_AddLoad(t *testing.T) {
+	var c ShardedCounter
+	if got := c.Load(); got != 0 {
+		t.Fatalf("expected 0, got %d", got)
+	}
+	c.Add(5)
+	c.Add(-2)
+	if got := c.Load(); got != 3 {
+		t.Fatalf("expected 3, got %d", got)
+	}
+}
+
+func TestShardedCounter_Concurrent(t *testing.T) {
+	var c ShardedCounter
+	var wg sync.WaitGroup
+	const goroutines, perG = 16, 1000
+	for range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range perG {
+				c.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := c.Load(); got != goroutines*perG {
+		t.Fatalf("expected %d, got %d", goroutines*perG, got)
+	}
+}
+
+func TestShardedCounter_ResetLosesNothing(t *testing.T) {
+	var c ShardedCounter
+	var wg sync.WaitGroup
+	var drained int64
+	done := make(chan struct{})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 10000 {
+			c.Add(1)
+		}
+		close(done)
+	}()
+	for {
+		drained += c.Reset()
+		select {
+		case <-done:
+			wg.Wait()
+			drained += c.Reset()
+			if drained != 10000 {
+				t.Fatalf("expected 10000 drained, got %d", drained)
+			}
+			return
+		default:
+		}
+	}
+}
+
+func BenchmarkShardedCounter_Add(b *testing.B) {
+	var c ShardedCounter
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.Add(1)
+		}
+	})
+}
+
+func TestCounterMap(t *testing.T) {
+	var m CounterMap[string]
+	m.Add("a", 2)
+	m.Add("b", 1)
+	m.Add("a", 3)
+	if got := m.Load("a"); got != 5 {
+		t.Fatalf("expected a=5, got %d", got)
+	}
+	if s := m.Snapshot(); len(s) != 2 || s["b"] != 1 {
+		t.Fatalf("unexpected snapshot %v", s)
+	}
+	if s := m.SwapAll(); s["a"] != 5 || s["b"] != 1 {
+		t.Fatalf("unexpected swap %v", s)
+	}
+	if s := m.SwapAll(); len(s) != 0 {
+		t.Fatalf("expected empty map after swap, got %v", s)
+	}
+}
+
+func TestCounterMap_SwapAllLosesNothing(t *testing.T) {
+	var m CounterMap[int]
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 5000 {
+				m.Add((w+i)%3, 1)
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	var drained int64
+	for {
+		for _, n := range m.SwapAll() {
+			drained += n
+		}
+		select {
+		case <-done:
+			for _, n := range m.SwapAll() {
+				drained += n
+			}
+			if drained != 20000 {
+				t.Fatalf("expected 20000 drained, got %d", drained)
+			}
+			return
+		default:
+		}
+	}
+}
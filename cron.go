@@ -0,0 +1,272 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrCronSyntax = errors.New("cron syntax error")
+
+// Cron is a parsed cron schedule. It accepts the standard five fields
+// (minute, hour, day of month, month, day of week) or six with a leading
+// seconds field, each a list of values, ranges, * and /step, with month and
+// weekday names. When both day fields are restricted a day matching either
+// one fires, as in Vixie cron. The descriptors @yearly, @monthly, @weekly,
+// @daily and @hourly are also accepted, and a CRON_TZ= or TZ= prefix pins
+// the schedule to a time zone.
+type Cron struct {
+	expr string
+	loc  *time.Location // nil uses the location of the time passed to Next
+
+	second, minute, hour, dom, month, dow uint64
+	domStar, dowStar                      bool
+}
+
+type cronField struct {
+	min, max int
+	names    []string // indexed from min
+}
+
+var (
+	cronSeconds = cronField{min: 0, max: 59}
+	cronMinutes = cronField{min: 0, max: 59}
+	cronHours   = cronField{min: 0, max: 23}
+	cronDoms    = cronField{min: 1, max: 31}
+	cronMonths  = cronField{min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	// Weekday 7 is Sunday again, folded into bit 0 after parsing.
+	cronDows = cronField{min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a cron expression.
+func ParseCron(expr string) (*Cron, error) {
+	c := &Cron{expr: expr}
+	spec := strings.TrimSpace(expr)
+	for _, prefix := range []string{"CRON_TZ=", "TZ="} {
+		rest, ok := strings.CutPrefix(spec, prefix)
+		if !ok {
+			continue
+		}
+		name, rest, _ := strings.Cut(rest, " ")
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCronSyntax, err)
+		}
+		c.loc, spec = loc, strings.TrimSpace(rest)
+		break
+	}
+	if d, ok := cronDescriptors[strings.ToLower(spec)]; ok {
+		spec = d
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("%w: %q: expected 5 or 6 fields, got %d", ErrCronSyntax, expr, len(fields))
+	}
+	var err error
+	for i, f := range []struct {
+		dst  *uint64
+		spec cronField
+	}{
+		{&c.second, cronSeconds},
+		{&c.minute, cronMinutes},
+		{&c.hour, cronHours},
+		{&c.dom, cronDoms},
+		{&c.month, cronMonths},
+		{&c.dow, cronDows},
+	} {
+		if *f.dst, err = f.spec.parse(fields[i]); err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrCronSyntax, expr, err)
+		}
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow = c.dow&^(1<<7) | 1
+	}
+	c.domStar = fields[3] == "*" || fields[3] == "?"
+	c.dowStar = fields[5] == "*" || fields[5] == "?"
+	return c, nil
+}
+
+// MustParseCron is ParseCron that panics on error.
+func MustParseCron(expr string) *Cron {
+	c, err := ParseCron(expr)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func (f cronField) parse(s string) (uint64, error) {
+	var set uint64
+	for part := range strings.SplitSeq(s, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("bad step %q", stepStr)
+			}
+			step = n
+		}
+		lo, hi := f.min, f.max
+		if rng != "*" && rng != "?" {
+			loStr, hiStr, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(loStr); err != nil {
+				return 0, err
+			}
+			switch {
+			case isRange:
+				if hi, err = f.value(hiStr); err != nil {
+					return 0, err
+				}
+			case !hasStep:
+				hi = lo
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("bad range %q", rng)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("value %q out of range [%d, %d]", s, f.min, f.max)
+	}
+	return n, nil
+}
+
+func (c *Cron) String() string { return c.expr }
+
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<t.Day()) != 0
+	dow := c.dow&(1<<t.Weekday()) != 0
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// cronSearchYears bounds Next for schedules that can never fire, such as
+// February 30th.
+const cronSearchYears = 5
+
+// Next returns the first time strictly after t that matches the schedule,
+// in the schedule's time zone or else t's. It returns the zero time if
+// nothing matches within five years.
+func (c *Cron) Next(after time.Time) time.Time {
+	loc := c.loc
+	if loc == nil {
+		loc = after.Location()
+	}
+	t := after.In(loc).Truncate(time.Second).Add(time.Second)
+	limit := t.Year() + cronSearchYears
+
+search:
+	for t.Year() <= limit {
+		for c.month&(1<<t.Month()) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			if t.Month() == time.January {
+				continue search
+			}
+		}
+		for !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			if t.Day() == 1 {
+				continue search
+			}
+		}
+		// Each step restarts the search once the next larger field changes,
+		// which a DST change can do without passing through zero, such as
+		// from 23:00 to 01:00 the next day.
+		for c.hour&(1<<t.Hour()) == 0 {
+			day := t.Day()
+			t = t.Add(time.Hour - time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second)
+			if t.Day() != day {
+				continue search
+			}
+		}
+		for c.minute&(1<<t.Minute()) == 0 {
+			hour := t.Hour()
+			t = t.Truncate(time.Minute).Add(time.Minute)
+			if t.Hour() != hour || t.Minute() == 0 {
+				continue search
+			}
+		}
+		for c.second&(1<<t.Second()) == 0 {
+			minute := t.Minute()
+			t = t.Add(time.Second)
+			if t.Minute() != minute || t.Second() == 0 {
+				continue search
+			}
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// Times iterates over the schedule's firing times after t.
+func (c *Cron) Times(after time.Time) iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		for t := c.Next(after); !t.IsZero(); t = c.Next(t) {
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
+
+// RunCron calls fn at every time matched by c until ctx is done, then
+// returns ctx.Err(). Like RunEvery, runs never overlap and panics are
+// recovered as errors; a run that overruns skips the times it missed. Of
+// the RunEvery options, WithRunClock, WithOnError and WithLogger apply.
+func RunCron(ctx context.Context, c *Cron, fn func(ctx context.Context) error, opts ...RunEveryOption) error {
+	o := NewOptions(runEveryOptions{clock: SystemClock}, opts...)
+	for {
+		next := c.Next(o.clock.Now())
+		if next.IsZero() {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		select {
+		case <-o.clock.After(next.Sub(o.clock.Now())):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := recoverError(func() error { return fn(ctx) }); err != nil {
+			o.report(ctx, err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCron_Next(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC) // a Monday
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"30 * * * * *", time.Date(2024, 1, 15, 10, 30, 30, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2024, 1, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * FRI", time.Date(2024, 1, 19, 12, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2024, 1, 21, 12, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 8-10/2 * * mon-fri", time.Date(2024, 1, 16, 8, 0, 0, 0, time.UTC)},
+		{"0 0 20 * sun", time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 dec *", time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.expr, err)
+		}
+		if got := c.Next(base); !got.Equal(tt.want) {
+			t.Errorf("%s: Next = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCron_Never(t *testing.T) {
+	c := MustParseCron("0 0 30 2 *")
+	if got := c.Next(time.Now()); !got.IsZero() {
+		t.Fatalf("February 30th should never fire, got %v", got)
+	}
+}
+
+func TestCron_TimeZone(t *testing.T) {
+	c, err := ParseCron("CRON_TZ=America/New_York 0 9 * * *")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	got := c.Next(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	if got.Location().String() != "America/New_York" || got.Hour() != 9 {
+		t.Fatalf("expected 9:00 New York time, got %v", got)
+	}
+	if got.UTC().Hour() != 14 {
+		t.Fatalf("expected 14:00 UTC in winter, got %v", got.UTC())
+	}
+	// Across the March 10 DST change the UTC hour shifts but local stays 9.
+	got = c.Next(time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC))
+	if got.Hour() != 9 || got.UTC().Hour() != 13 {
+		t.Fatalf("expected 9:00 EDT, got %v", got)
+	}
+}
+
+func TestCron_DSTSkipsMidnight(t *testing.T) {
+	// São Paulo began DST on 2018-11-04 by skipping from 23:59:59 to 01:00,
+	// so stepping an hour from 23:00 lands on the next day at 01:00.
+	c, err := ParseCron("CRON_TZ=America/Sao_Paulo 0 5 * * 6")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	loc := c.loc
+	got := c.Next(time.Date(2018, 11, 3, 6, 0, 0, 0, loc))
+	if want := time.Date(2018, 11, 10, 5, 0, 0, 0, loc); !got.Equal(want) {
+		t.Fatalf("Next = %v, want %v", got, want)
+	}
+	// Daily midnight has no 00:00 that day; the next match is the day after.
+	c = MustParseCron("CRON_TZ=America/Sao_Paulo 30 0 * * *")
+	got = c.Next(time.Date(2018, 11, 3, 23, 0, 0, 0, loc))
+	if want := time.Date(2018, 11, 5, 0, 30, 0, 0, loc); !got.Equal(want) {
+		t.Fatalf("Next = %v, want %v", got, want)
+	}
+}
+
+func TestCron_Times(t *testing.T) {
+	c := MustParseCron("0 0 * * mon")
+	var got []time.Time
+	for ts := range c.Times(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		got = append(got, ts)
+		if len(got) == 3 {
+			break
+		}
+	}
+	for i, want := range []int{8, 15, 22} {
+		if got[i].Day() != want {
+			t.Fatalf("time %d = %v, want Jan %d", i, got[i], want)
+		}
+	}
+}
+
+func TestCron_ParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"TZ=Nowhere/Nope * * * * *",
+	} {
+		if _, err := ParseCron(expr); !errors.Is(err, ErrCronSyntax) {
+			t.Errorf("%q: expected syntax error, got %v", expr, err)
+		}
+	}
+}
+
+func TestRunCron(t *testing.T) {
+	clock := newTestClock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runs := make(chan time.Time, 4)
+	done := make(chan error, 1)
+	go func() {
+		done <- RunCron(ctx, MustParseCron("*/10 * * * * *"), func(context.Context) error {
+			runs <- clock.Now()
+			return nil
+		}, WithRunClock(clock))
+	}()
+
+	for range 2 {
+		for clock.Waiters() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		clock.Advance(10 * time.Second)
+		if ts := <-runs; ts.Second()%10 != 0 {
+			t.Fatalf("run at %v is off schedule", ts)
+		}
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package generic
+
+import "fmt"
+
+// DAG is a directed graph used to order work by its dependencies. It is not
+// safe for concurrent use.
+type DAG[N comparable] struct {
+	nodes []N // insertion order, for deterministic output
+	edges map[N][]N
+	in    map[N]int
+}
+
+func NewDAG[N comparable]() *DAG[N] {
+	return &DAG[N]{edges: make(map[N][]N), in: make(map[N]int)}
+}
+
+// AddNode adds n if it is not already present.
+func (g *DAG[N]) AddNode(n N) {
+	if _, ok := g.in[n]; ok {
+		return
+	}
+	g.in[n] = 0
+	g.nodes = append(g.nodes, n)
+}
+
+// AddEdge records that from must come before to, adding both nodes.
+func (g *DAG[N]) AddEdge(from, to N) {
+	g.AddNode(from)
+	g.AddNode(to)
+	g.edges[from] = append(g.edges[from], to)
+	g.in[to]++
+}
+
+// Levels groups the nodes so every edge points from an earlier group to a
+// later one. Nodes within a group are independent. It returns an error
+// wrapping ErrDependencyCycle if the graph has a cycle.
+func (g *DAG[N]) Levels() ([][]N, error) {
+	in := make(map[N]int, len(g.in))
+	var level []N
+	for _, n := range g.nodes {
+		in[n] = g.in[n]
+		if in[n] == 0 {
+			level = append(level, n)
+		}
+	}
+	var levels [][]N
+	seen := 0
+	for len(level) > 0 {
+		levels = append(levels, level)
+		seen += len(level)
+		var next []N
+		for _, n := range level {
+			for _, m := range g.edges[n] {
+				if in[m]--; in[m] == 0 {
+					next = append(next, m)
+				}
+			}
+		}
+		level = next
+	}
+	if seen != len(g.nodes) {
+		var stuck []N
+		for _, n := range g.nodes {
+			if in[n] > 0 {
+				stuck = append(stuck, n)
+			}
+		}
+		return nil, fmt.Errorf("%w among %v", ErrDependencyCycle, stuck)
+	}
+	return levels, nil
+}
+
+// Sort returns the nodes in a dependency-respecting order.
+func (g *DAG[N]) Sort() ([]N, error) {
+	levels, err := g.Levels()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]N, 0, len(g.nodes))
+	for _, level := range levels {
+		out = append(out, level...)
+	}
+	return out, nil
+}
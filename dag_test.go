@@ -0,0 +1,47 @@
+package generic
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDAG_Levels(t *testing.T) {
+	g := NewDAG[string]()
+	g.AddEdge("db", "api")
+	g.AddEdge("cache", "api")
+	g.AddEdge("api", "http")
+	g.AddNode("metrics")
+
+	levels, err := g.Levels()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(levels) != 3 {
+		t.Fatalf("expected 3 levels, got %v", levels)
+	}
+	if len(levels[0]) != 3 || levels[1][0] != "api" || levels[2][0] != "http" {
+		t.Errorf("unexpected levels %v", levels)
+	}
+
+	order, err := g.Sort()
+	if err != nil || len(order) != 5 {
+		t.Fatalf("unexpected sort %v (err %v)", order, err)
+	}
+	pos := map[string]int{}
+	for i, n := range order {
+		pos[n] = i
+	}
+	if pos["db"] > pos["api"] || pos["cache"] > pos["api"] || pos["api"] > pos["http"] {
+		t.Errorf("order violates dependencies: %v", order)
+	}
+}
+
+func TestDAG_Cycle(t *testing.T) {
+	g := NewDAG[int]()
+	g.AddEdge(1, 2)
+	g.AddEdge(2, 3)
+	g.AddEdge(3, 2)
+	if _, err := g.Levels(); !errors.Is(err, ErrDependencyCycle) {
+		t.Fatalf("expected ErrDependencyCycle, got %v", err)
+	}
+}
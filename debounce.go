@@ -0,0 +1,144 @@
+package generic
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DebouncedSaver coalesces frequent state updates and persists the latest
+// one at most once per Interval. Start Run in its own goroutine; when its
+// context is done it saves any pending state one last time.
+type DebouncedSaver[T any] struct {
+	// Interval is the minimum time between saves. Defaults to one second.
+	Interval time.Duration
+	// Clock is used for waiting. Defaults to SystemClock.
+	Clock Clock
+	// OnError receives save errors from Run. A failed save is retried at
+	// the next interval unless a newer update replaces it.
+	OnError func(error)
+	// Logger records save errors when OnError is nil. Defaults to
+	// DefaultLogger.
+	Logger Logger
+
+	save func(ctx context.Context, v T) error
+	wake chan struct{}
+
+	saveMu sync.Mutex // serializes save calls
+	mu     sync.Mutex
+	latest T
+	seq    uint64 // bumped by every Update
+	saved  uint64 // seq of the last successful save
+	last   time.Time
+}
+
+func NewDebouncedSaver[T any](save func(ctx context.Context, v T) error) *DebouncedSaver[T] {
+	return &DebouncedSaver[T]{save: save, wake: make(chan struct{}, 1)}
+}
+
+func (s *DebouncedSaver[T]) clock() Clock {
+	if s.Clock == nil {
+		return SystemClock
+	}
+	return s.Clock
+}
+
+func (s *DebouncedSaver[T]) interval() time.Duration {
+	if s.Interval > 0 {
+		return s.Interval
+	}
+	return time.Second
+}
+
+// Update records v as the state to save, replacing any unsaved value.
+func (s *DebouncedSaver[T]) Update(v T) {
+	s.mu.Lock()
+	s.latest = v
+	s.seq++
+	s.mu.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Track calls Update with every value observed on a until ctx is done. The
+// current value counts as an update, so it is saved once on start.
+func (s *DebouncedSaver[T]) Track(ctx context.Context, a Atomic[T]) {
+	for v := range a.Watch(ctx) {
+		s.Update(v)
+	}
+}
+
+// Pending reports whether there is an update not yet saved.
+func (s *DebouncedSaver[T]) Pending() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seq != s.saved
+}
+
+// Flush saves the latest update now if it has not been saved yet.
+func (s *DebouncedSaver[T]) Flush(ctx context.Context) error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	s.mu.Lock()
+	v, seq := s.latest, s.seq
+	pending := seq != s.saved
+	s.mu.Unlock()
+	if !pending {
+		return nil
+	}
+	err := recoverError(func() error { return s.save(ctx, v) })
+	s.mu.Lock()
+	s.last = s.clock().Now()
+	if err == nil {
+		s.saved = seq
+	}
+	s.mu.Unlock()
+	return err
+}
+
+func (s *DebouncedSaver[T]) report(ctx context.Context, err error) {
+	if s.OnError != nil {
+		s.OnError(err)
+		return
+	}
+	loggerOrDefault(s.Logger).Error(ctx, "generic: debounced save failed", slog.Any("error", err))
+}
+
+// Run saves pending updates, waiting at least Interval between saves, until
+// ctx is done. It then flushes once more with a context that is not
+// canceled and returns that flush's error, or ctx.Err() if it succeeded.
+func (s *DebouncedSaver[T]) Run(ctx context.Context) error {
+	clock := s.clock()
+	for {
+		if !s.Pending() {
+			select {
+			case <-s.wake:
+			case <-ctx.Done():
+				return s.final(ctx)
+			}
+		}
+		s.mu.Lock()
+		wait := s.interval() - clock.Now().Sub(s.last)
+		s.mu.Unlock()
+		if wait > 0 {
+			select {
+			case <-clock.After(wait):
+			case <-ctx.Done():
+				return s.final(ctx)
+			}
+		}
+		if err := s.Flush(ctx); err != nil && ctx.Err() == nil {
+			s.report(ctx, err)
+		}
+	}
+}
+
+func (s *DebouncedSaver[T]) final(ctx context.Context) error {
+	if err := s.Flush(context.WithoutCancel(ctx)); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,103 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type savedValues struct {
+	mu   sync.Mutex
+	vals []int
+	ch   chan int
+}
+
+func (s *savedValues) save(_ context.Context, v int) error {
+	s.mu.Lock()
+	s.vals = append(s.vals, v)
+	s.mu.Unlock()
+	s.ch <- v
+	return nil
+}
+
+func TestDebouncedSaver_Coalesces(t *testing.T) {
+	clock := newTestClock()
+	sv := &savedValues{ch: make(chan int, 10)}
+	s := NewDebouncedSaver(sv.save)
+	s.Clock = clock
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+
+	s.Update(1)
+	if v := <-sv.ch; v != 1 {
+		t.Fatalf("first save = %d, want 1", v)
+	}
+	s.Update(2)
+	s.Update(3)
+	advanceWhenParked(t, clock, time.Second)
+	if v := <-sv.ch; v != 3 {
+		t.Fatalf("second save = %d, want 3", v)
+	}
+
+	s.Update(4)
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run = %v", err)
+	}
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+	if len(sv.vals) != 3 || sv.vals[2] != 4 {
+		t.Fatalf("saved %v, want [1 3 4]", sv.vals)
+	}
+	if s.Pending() {
+		t.Fatal("pending after final flush")
+	}
+}
+
+func TestDebouncedSaver_RetriesFailedSave(t *testing.T) {
+	boom := errors.New("boom")
+	fail := true
+	var got []int
+	s := NewDebouncedSaver(func(_ context.Context, v int) error {
+		if fail {
+			return boom
+		}
+		got = append(got, v)
+		return nil
+	})
+	s.Update(1)
+	if err := s.Flush(context.Background()); !errors.Is(err, boom) {
+		t.Fatalf("Flush = %v, want boom", err)
+	}
+	if !s.Pending() {
+		t.Fatal("failed save should stay pending")
+	}
+	fail = false
+	if err := s.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Flush(context.Background()); err != nil || len(got) != 1 {
+		t.Fatalf("got %v, %v; want one save", got, err)
+	}
+}
+
+func TestDebouncedSaver_Track(t *testing.T) {
+	a := MakeAtomic("a")
+	s := NewDebouncedSaver(func(context.Context, string) error { return nil })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Track(ctx, a)
+	deadline := time.Now().Add(time.Second)
+	for !s.Pending() {
+		if time.Now().After(deadline) {
+			t.Fatal("Track never recorded the current value")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,106 @@
+package generic
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Inspector is implemented by primitives that can describe their internal
+// state for debugging. The returned value must be JSON-encodable.
+type Inspector interface {
+	Inspect() any
+}
+
+// InspectorFunc adapts an ordinary function to the Inspector interface.
+type InspectorFunc func() any
+
+func (f InspectorFunc) Inspect() any { return f() }
+
+// DebugHandler serves a JSON view of registered primitives. It is not mounted
+// anywhere by default; register sources and attach it to a mux:
+//
+//	h := generic.NewDebugHandler()
+//	h.Register("ingest", queue)
+//	mux.Handle("/debug/generic", h)
+//
+// Requests with ?diff=1 only report sources whose state changed since the
+// previous diff request, which keeps polling output small.
+type DebugHandler struct {
+	mu      sync.Mutex
+	sources map[string]Inspector
+	last    map[string][]byte
+}
+
+type debugReport struct {
+	Time    time.Time                  `json:"time"`
+	Sources map[string]json.RawMessage `json:"sources"`
+	Removed []string                   `json:"removed,omitempty"`
+}
+
+func NewDebugHandler() *DebugHandler {
+	return &DebugHandler{
+		sources: make(map[string]Inspector),
+		last:    make(map[string][]byte),
+	}
+}
+
+// Register adds or replaces the source reported under name.
+func (h *DebugHandler) Register(name string, src Inspector) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sources[name] = src
+}
+
+// Unregister removes the source reported under name.
+func (h *DebugHandler) Unregister(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.sources, name)
+}
+
+func (h *DebugHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	diff := r.URL.Query().Get("diff") == "1"
+
+	h.mu.Lock()
+	report := debugReport{
+		Time:    time.Now(),
+		Sources: make(map[string]json.RawMessage, len(h.sources)),
+	}
+	for name, src := range h.sources {
+		b, err := json.Marshal(src.Inspect())
+		if err != nil {
+			b, _ = json.Marshal(map[string]string{"error": err.Error()})
+		}
+		if diff {
+			if prev, ok := h.last[name]; ok && bytes.Equal(prev, b) {
+				continue
+			}
+			h.last[name] = b
+		}
+		report.Sources[name] = b
+	}
+	if diff {
+		for name := range h.last {
+			if _, ok := h.sources[name]; !ok {
+				delete(h.last, name)
+				report.Removed = append(report.Removed, name)
+			}
+		}
+		sort.Strings(report.Removed)
+	}
+	h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(report)
+}
@@ -0,0 +1,98 @@
+package generic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func getDebugReport(t *testing.T, h http.Handler, target string) debugReport {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected application/json, got %q", ct)
+	}
+	var report debugReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	return report
+}
+
+func TestDebugHandler_ReportsSources(t *testing.T) {
+	q := NewFiFo[int]()
+	q.Put(context.Background(), 1)
+	q.Put(context.Background(), 2)
+
+	h := NewDebugHandler()
+	h.Register("queue", q)
+	h.Register("custom", InspectorFunc(func() any { return map[string]int{"lag": 7} }))
+
+	report := getDebugReport(t, h, "/debug")
+
+	var stats FiFoStats
+	if err := json.Unmarshal(report.Sources["queue"], &stats); err != nil {
+		t.Fatalf("decode queue stats: %v", err)
+	}
+	if stats.Size != 2 || stats.Empty {
+		t.Errorf("unexpected queue stats: %+v", stats)
+	}
+
+	var custom map[string]int
+	if err := json.Unmarshal(report.Sources["custom"], &custom); err != nil {
+		t.Fatalf("decode custom stats: %v", err)
+	}
+	if custom["lag"] != 7 {
+		t.Errorf("expected lag 7, got %d", custom["lag"])
+	}
+}
+
+func TestDebugHandler_Diff(t *testing.T) {
+	q := NewFiFo[int]()
+	h := NewDebugHandler()
+	h.Register("queue", q)
+	h.Register("static", InspectorFunc(func() any { return "constant" }))
+
+	report := getDebugReport(t, h, "/debug?diff=1")
+	if len(report.Sources) != 2 {
+		t.Fatalf("first diff should report all sources, got %d", len(report.Sources))
+	}
+
+	report = getDebugReport(t, h, "/debug?diff=1")
+	if len(report.Sources) != 0 {
+		t.Errorf("expected no changes, got %v", report.Sources)
+	}
+
+	q.Put(context.Background(), 1)
+	report = getDebugReport(t, h, "/debug?diff=1")
+	if _, ok := report.Sources["queue"]; !ok || len(report.Sources) != 1 {
+		t.Errorf("expected only queue to change, got %v", report.Sources)
+	}
+
+	h.Unregister("static")
+	report = getDebugReport(t, h, "/debug?diff=1")
+	if len(report.Removed) != 1 || report.Removed[0] != "static" {
+		t.Errorf("expected static to be reported removed, got %v", report.Removed)
+	}
+
+	// Non-diff requests always report everything.
+	report = getDebugReport(t, h, "/debug")
+	if len(report.Sources) != 1 {
+		t.Errorf("expected 1 source, got %d", len(report.Sources))
+	}
+}
+
+func TestDebugHandler_MethodNotAllowed(t *testing.T) {
+	h := NewDebugHandler()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,239 @@
+package generic
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// DelayQueue holds each item until its ready time. Get returns the item
+// whose ready time is earliest once that time has passed, blocking until
+// then. Items with the same ready time come out in the order they were put.
+// Ready times taken from the Clock's Now keep its monotonic reading, so
+// wall-clock steps don't move them. The zero value is ready to use.
+type DelayQueue[T any] struct {
+	// Clock decides when items are ready. Defaults to SystemClock.
+	Clock Clock
+
+	mu     sync.Mutex
+	h      delayHeap[T]
+	seq    uint64
+	closed bool
+	notify atomicNotifier
+}
+
+type delayItem[T any] struct {
+	x       T
+	readyAt time.Time
+	seq     uint64
+}
+
+type delayHeap[T any] []delayItem[T]
+
+func (h delayHeap[T]) Len() int { return len(h) }
+
+func (h delayHeap[T]) Less(i, j int) bool {
+	if !h[i].readyAt.Equal(h[j].readyAt) {
+		return h[i].readyAt.Before(h[j].readyAt)
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h delayHeap[T]) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *delayHeap[T]) Push(x any) { *h = append(*h, x.(delayItem[T])) }
+
+func (h *delayHeap[T]) Pop() any {
+	old := *h
+	n := len(old)
+	it := old[n-1]
+	old[n-1] = delayItem[T]{}
+	*h = old[:n-1]
+	return it
+}
+
+func NewDelayQueue[T any]() *DelayQueue[T] {
+	return &DelayQueue[T]{}
+}
+
+func (q *DelayQueue[T]) clock() Clock {
+	if q.Clock == nil {
+		return SystemClock
+	}
+	return q.Clock
+}
+
+// Put adds x, to become ready at readyAt. It fails only if ctx is already
+// done or the queue is closed.
+func (q *DelayQueue[T]) Put(ctx context.Context, x T, readyAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return ErrClosed
+	}
+	q.push(x, readyAt)
+	q.mu.Unlock()
+	q.notify.notify()
+	return nil
+}
+
+// PutAfter adds x, to become ready d from now.
+func (q *DelayQueue[T]) PutAfter(ctx context.Context, x T, d time.Duration) error {
+	return q.Put(ctx, x, q.clock().Now().Add(d))
+}
+
+// push adds an item. The caller must hold q.mu.
+func (q *DelayQueue[T]) push(x T, readyAt time.Time) {
+	q.seq++
+	heap.Push(&q.h, delayItem[T]{x: x, readyAt: readyAt, seq: q.seq})
+}
+
+// popReady removes the head if it is ready, otherwise returning how long
+// until it is. The caller must hold q.mu.
+func (q *DelayQueue[T]) popReady(now time.Time) (x T, ok bool, wait time.Duration) {
+	if len(q.h) == 0 {
+		return x, false, -1
+	}
+	if d := q.h[0].readyAt.Sub(now); d > 0 {
+		return x, false, d
+	}
+	return heap.Pop(&q.h).(delayItem[T]).x, true, 0
+}
+
+// Get removes the earliest item, blocking until it is ready. On a closed
+// queue it still waits for the remaining items to become ready, then
+// returns ErrClosed.
+func (q *DelayQueue[T]) Get(ctx context.Context) (T, error) {
+	clock := q.clock()
+	for {
+		changed := q.notify.wait()
+		q.mu.Lock()
+		x, ok, wait := q.popReady(clock.Now())
+		closed := q.closed
+		q.mu.Unlock()
+		if ok {
+			return x, nil
+		}
+		if closed && wait < 0 {
+			return x, ErrClosed
+		}
+		var ready <-chan time.Time
+		if wait > 0 {
+			ready = clock.After(wait)
+		}
+		select {
+		case <-ready:
+		case <-changed:
+		case <-ctx.Done():
+			return x, ctx.Err()
+		}
+	}
+}
+
+// TryGet removes the earliest item if it is ready, without blocking.
+func (q *DelayQueue[T]) TryGet() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	x, ok, _ := q.popReady(q.clock().Now())
+	return x, ok
+}
+
+// Next returns the earliest ready time, if the queue is not empty.
+func (q *DelayQueue[T]) Next() (time.Time, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.h) == 0 {
+		return time.Time{}, false
+	}
+	return q.h[0].readyAt, true
+}
+
+// Size returns the number of items, ready or not.
+func (q *DelayQueue[T]) Size() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.h)
+}
+
+func (q *DelayQueue[T]) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+// Close stops the queue accepting items, with the same semantics as
+// FiFo.Close.
+func (q *DelayQueue[T]) Close() error {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.notify.notify()
+	return nil
+}
+
+// DelayQueueStats is the debug view of a DelayQueue returned by Inspect.
+type DelayQueueStats struct {
+	Size   int       `json:"size"`
+	Ready  int       `json:"ready"`
+	Next   time.Time `json:"next,omitzero"`
+	Closed bool      `json:"closed,omitempty"`
+}
+
+// Inspect reports the queue state for DebugHandler.
+func (q *DelayQueue[T]) Inspect() any {
+	now := q.clock().Now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	s := DelayQueueStats{Size: len(q.h), Closed: q.closed}
+	for _, it := range q.h {
+		if !it.readyAt.After(now) {
+			s.Ready++
+		}
+	}
+	if len(q.h) > 0 {
+		s.Next = q.h[0].readyAt
+	}
+	return s
+}
+
+// ExportState returns the items in ready order with their ReadyAt set.
+func (q *DelayQueue[T]) ExportState(ctx context.Context) (QueueState[T], error) {
+	if err := ctx.Err(); err != nil {
+		return QueueState[T]{}, err
+	}
+	q.mu.Lock()
+	items := make(delayHeap[T], len(q.h))
+	copy(items, q.h)
+	q.mu.Unlock()
+	s := QueueState[T]{Items: make([]QueueStateItem[T], 0, len(items))}
+	for len(items) > 0 {
+		it := heap.Pop(&items).(delayItem[T])
+		item := stateItem(it.x)
+		item.ReadyAt = it.readyAt
+		s.Items = append(s.Items, item)
+	}
+	return s, nil
+}
+
+// ImportState adds the items of s at their ReadyAt; items without one are
+// ready immediately.
+func (q *DelayQueue[T]) ImportState(ctx context.Context, s QueueState[T]) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return ErrClosed
+	}
+	for _, it := range s.Items {
+		q.push(it.Value, it.ReadyAt)
+	}
+	q.mu.Unlock()
+	q.notify.notify()
+	return nil
+}
+
+var _ StatefulQueue[int] = (*DelayQueue[int])(nil)
@@ -0,0 +1,104 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDelayQueue_Order(t *testing.T) {
+	clock := newTestClock()
+	q := &DelayQueue[string]{Clock: clock}
+	ctx := context.Background()
+	q.PutAfter(ctx, "late", 2*time.Second)
+	q.PutAfter(ctx, "early", time.Second)
+	q.PutAfter(ctx, "early2", time.Second)
+	q.Put(ctx, "now", time.Time{})
+
+	if x, ok := q.TryGet(); !ok || x != "now" {
+		t.Fatalf("TryGet = %q, %v", x, ok)
+	}
+	if _, ok := q.TryGet(); ok {
+		t.Fatal("TryGet returned an item before it was ready")
+	}
+	if next, _ := q.Next(); !next.Equal(clock.Now().Add(time.Second)) {
+		t.Fatalf("Next = %v", next)
+	}
+	clock.Advance(2 * time.Second)
+	var got []string
+	for range 3 {
+		x, _ := q.Get(ctx)
+		got = append(got, x)
+	}
+	if !slices.Equal(got, []string{"early", "early2", "late"}) {
+		t.Fatalf("order = %v", got)
+	}
+}
+
+func TestDelayQueue_GetWaitsForReady(t *testing.T) {
+	clock := newTestClock()
+	q := &DelayQueue[int]{Clock: clock}
+	ctx := context.Background()
+	got := make(chan int)
+	go func() {
+		x, _ := q.Get(ctx)
+		got <- x
+	}()
+	time.Sleep(10 * time.Millisecond)
+	q.PutAfter(ctx, 1, time.Minute)
+	advanceWhenParked(t, clock, 30*time.Second)
+	select {
+	case <-got:
+		t.Fatal("Get returned before the item was ready")
+	case <-time.After(10 * time.Millisecond):
+	}
+	// An earlier item put meanwhile wakes Get to wait for it instead.
+	q.PutAfter(ctx, 2, time.Second)
+	advanceWhenParked(t, clock, time.Second)
+	if x := <-got; x != 2 {
+		t.Fatalf("Get = %d, want 2", x)
+	}
+
+	short, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	if _, err := q.Get(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get before ready = %v", err)
+	}
+}
+
+func TestDelayQueue_CloseAndState(t *testing.T) {
+	clock := newTestClock()
+	q := &DelayQueue[int]{Clock: clock}
+	ctx := context.Background()
+	q.PutAfter(ctx, 2, 2*time.Second)
+	q.PutAfter(ctx, 1, time.Second)
+
+	s, _ := q.ExportState(ctx)
+	if got := s.Values(); !slices.Equal(got, []int{1, 2}) {
+		t.Fatalf("state = %v", got)
+	}
+	if !s.Items[1].ReadyAt.Equal(clock.Now().Add(2 * time.Second)) {
+		t.Fatalf("ReadyAt = %v", s.Items[1].ReadyAt)
+	}
+	restored := &DelayQueue[int]{Clock: clock}
+	restored.ImportState(ctx, s)
+	if st := restored.Inspect().(DelayQueueStats); st.Size != 2 || st.Ready != 0 {
+		t.Fatalf("Inspect = %+v", st)
+	}
+
+	q.Close()
+	if err := q.PutAfter(ctx, 3, 0); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Put after Close = %v", err)
+	}
+	clock.Advance(2 * time.Second)
+	for want := 1; want <= 2; want++ {
+		if x, err := q.Get(ctx); err != nil || x != want {
+			t.Fatalf("Get = %d, %v", x, err)
+		}
+	}
+	if _, err := q.Get(ctx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Get on drained queue = %v", err)
+	}
+}
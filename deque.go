@@ -0,0 +1,200 @@
+package generic
+
+import (
+	"context"
+	"sync"
+)
+
+// Deque is a double-ended queue. Pushes never block; the Wait variants of
+// the pops block until an item is available, ctx is done or the deque is
+// closed and drained. It satisfies Queue as a FIFO: Put pushes at the back
+// and Get pops from the front. The zero value is ready to use.
+type Deque[T any] struct {
+	mu     sync.Mutex
+	ring   ringBuffer[T]
+	closed bool
+	notify atomicNotifier
+}
+
+func NewDeque[T any]() *Deque[T] {
+	return &Deque[T]{}
+}
+
+// push adds x at either end, reporting false if the deque is closed.
+func (d *Deque[T]) push(x T, front bool) bool {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return false
+	}
+	if front {
+		d.ring.pushFront(x)
+	} else {
+		d.ring.push(x)
+	}
+	d.mu.Unlock()
+	d.notify.notify()
+	return true
+}
+
+// PushFront inserts x at the front, reporting false if the deque is closed.
+func (d *Deque[T]) PushFront(x T) bool {
+	return d.push(x, true)
+}
+
+// PushBack appends x at the back, reporting false if the deque is closed.
+func (d *Deque[T]) PushBack(x T) bool {
+	return d.push(x, false)
+}
+
+// pop removes an item from either end. The caller must hold d.mu.
+func (d *Deque[T]) pop(front bool) (T, bool) {
+	if d.ring.len() == 0 {
+		var zero T
+		return zero, false
+	}
+	if front {
+		return d.ring.pop(), true
+	}
+	return d.ring.popBack(), true
+}
+
+// PopFront removes the front item without blocking.
+func (d *Deque[T]) PopFront() (T, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.pop(true)
+}
+
+// PopBack removes the back item without blocking.
+func (d *Deque[T]) PopBack() (T, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.pop(false)
+}
+
+// wait pops from either end, blocking until an item is available.
+func (d *Deque[T]) wait(ctx context.Context, front bool) (T, error) {
+	for {
+		changed := d.notify.wait()
+		d.mu.Lock()
+		x, ok := d.pop(front)
+		closed := d.closed
+		d.mu.Unlock()
+		if ok {
+			return x, nil
+		}
+		if closed {
+			return x, ErrClosed
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return x, ctx.Err()
+		}
+	}
+}
+
+// PopFrontWait removes the front item, blocking until one is available. On
+// a closed deque it returns the remaining items, then ErrClosed.
+func (d *Deque[T]) PopFrontWait(ctx context.Context) (T, error) {
+	return d.wait(ctx, true)
+}
+
+// PopBackWait removes the back item, blocking like PopFrontWait.
+func (d *Deque[T]) PopBackWait(ctx context.Context) (T, error) {
+	return d.wait(ctx, false)
+}
+
+// PeekFront returns the front item without removing it.
+func (d *Deque[T]) PeekFront() (T, bool) {
+	return d.peek(0)
+}
+
+// PeekBack returns the back item without removing it.
+func (d *Deque[T]) PeekBack() (T, bool) {
+	return d.peek(-1)
+}
+
+// peek returns the i-th item from the front, or from the back if i is
+// negative.
+func (d *Deque[T]) peek(i int) (T, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n := d.ring.len()
+	if i < 0 {
+		i += n
+	}
+	if i < 0 || i >= n {
+		var zero T
+		return zero, false
+	}
+	return d.ring.at(i), true
+}
+
+// Snapshot returns the items from front to back.
+func (d *Deque[T]) Snapshot() []T {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.ring.len() == 0 {
+		return nil
+	}
+	return d.ring.appendTo(make([]T, 0, d.ring.len()))
+}
+
+// Put appends x at the back. It fails only if ctx is already done or the
+// deque is closed.
+func (d *Deque[T]) Put(ctx context.Context, x T) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !d.PushBack(x) {
+		return ErrClosed
+	}
+	return nil
+}
+
+// TryPut is PushBack.
+func (d *Deque[T]) TryPut(x T) bool {
+	return d.PushBack(x)
+}
+
+// Get is PopFrontWait.
+func (d *Deque[T]) Get(ctx context.Context) (T, error) {
+	return d.PopFrontWait(ctx)
+}
+
+// TryGet is PopFront.
+func (d *Deque[T]) TryGet() (T, bool) {
+	return d.PopFront()
+}
+
+func (d *Deque[T]) Size() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.ring.len()
+}
+
+func (d *Deque[T]) IsEmpty() bool {
+	return d.Size() == 0
+}
+
+// Close stops the deque accepting items, with the same semantics as
+// FiFo.Close.
+func (d *Deque[T]) Close() error {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+	d.notify.notify()
+	return nil
+}
+
+// Inspect reports the deque state for DebugHandler.
+func (d *Deque[T]) Inspect() any {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n := d.ring.len()
+	return FiFoStats{Size: n, Empty: n == 0, Closed: d.closed}
+}
+
+var _ Queue[int] = (*Deque[int])(nil)
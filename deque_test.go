@@ -0,0 +1,106 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDeque_BothEnds(t *testing.T) {
+	var d Deque[int]
+	d.PushBack(2)
+	d.PushBack(3)
+	d.PushFront(1)
+	d.PushFront(0)
+	if got := d.Snapshot(); !slices.Equal(got, []int{0, 1, 2, 3}) {
+		t.Fatalf("Snapshot = %v", got)
+	}
+	if x, _ := d.PeekFront(); x != 0 {
+		t.Fatalf("PeekFront = %d", x)
+	}
+	if x, _ := d.PeekBack(); x != 3 {
+		t.Fatalf("PeekBack = %d", x)
+	}
+	if x, _ := d.PopBack(); x != 3 {
+		t.Fatalf("PopBack = %d", x)
+	}
+	if x, _ := d.PopFront(); x != 0 {
+		t.Fatalf("PopFront = %d", x)
+	}
+	if d.Size() != 2 {
+		t.Fatalf("Size = %d", d.Size())
+	}
+	d.PopFront()
+	d.PopFront()
+	if _, ok := d.PopBack(); ok {
+		t.Fatal("PopBack on empty deque succeeded")
+	}
+	if _, ok := d.PeekFront(); ok {
+		t.Fatal("PeekFront on empty deque succeeded")
+	}
+}
+
+func TestDeque_Wraps(t *testing.T) {
+	d := NewDeque[int]()
+	var want []int
+	for i := range 100 {
+		if i%2 == 0 {
+			d.PushFront(i)
+			want = append([]int{i}, want...)
+		} else {
+			d.PushBack(i)
+			want = append(want, i)
+		}
+	}
+	if got := d.Snapshot(); !slices.Equal(got, want) {
+		t.Fatalf("Snapshot = %v, want %v", got, want)
+	}
+	for len(want) > 0 {
+		x, _ := d.PopBack()
+		if x != want[len(want)-1] {
+			t.Fatalf("PopBack = %d, want %d", x, want[len(want)-1])
+		}
+		want = want[:len(want)-1]
+	}
+}
+
+func TestDeque_WaitAndClose(t *testing.T) {
+	d := NewDeque[string]()
+	ctx := context.Background()
+	got := make(chan string)
+	go func() {
+		x, _ := d.PopBackWait(ctx)
+		got <- x
+	}()
+	time.Sleep(10 * time.Millisecond)
+	d.PushFront("x")
+	if x := <-got; x != "x" {
+		t.Fatalf("PopBackWait = %q", x)
+	}
+
+	short, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	if _, err := d.PopFrontWait(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("PopFrontWait on empty deque = %v", err)
+	}
+
+	d.Put(ctx, "y")
+	d.Close()
+	if d.PushFront("z") {
+		t.Fatal("PushFront after Close succeeded")
+	}
+	if err := d.Put(ctx, "z"); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Put after Close = %v", err)
+	}
+	if x, err := d.Get(ctx); err != nil || x != "y" {
+		t.Fatalf("Get = %q, %v", x, err)
+	}
+	if _, err := d.PopBackWait(ctx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("PopBackWait on drained deque = %v", err)
+	}
+	if s := d.Inspect().(FiFoStats); !s.Closed || !s.Empty {
+		t.Fatalf("Inspect = %+v", s)
+	}
+}
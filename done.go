@@ -0,0 +1,76 @@
+package generic
+
+import (
+	"context"
+	"io"
+	"iter"
+)
+
+// OnDone arranges for fn to run in its own goroutine once ctx is done, with
+// ctx itself and its cause. Unlike context.AfterFunc, fn receives ctx with
+// its static type, so typed contexts need no assertion. Calling stop
+// before ctx is done keeps fn from running; it reports whether it did so.
+func OnDone[C context.Context](ctx C, fn func(ctx C, cause error)) (stop func() bool) {
+	return context.AfterFunc(ctx, func() { fn(ctx, context.Cause(ctx)) })
+}
+
+// CloseOnDone closes c once ctx is done, which is how blocked reads and
+// writes on connections and listeners are aborted.
+func CloseOnDone(ctx context.Context, c io.Closer) (stop func() bool) {
+	return context.AfterFunc(ctx, func() { c.Close() })
+}
+
+// DoneErr yields the index and cause of each of ctxs as it finishes, in the
+// order they finish. Contexts that are never done block the sequence, so
+// stop iterating once enough have been seen.
+func DoneErr(ctxs ...context.Context) iter.Seq2[int, error] {
+	return func(yield func(int, error) bool) {
+		done := make(chan int, len(ctxs))
+		for i, ctx := range ctxs {
+			stop := context.AfterFunc(ctx, func() { done <- i })
+			defer stop()
+		}
+		for range ctxs {
+			i := <-done
+			if !yield(i, context.Cause(ctxs[i])) {
+				return
+			}
+		}
+	}
+}
+
+// Checkpoint returns ctx's error if it is done and nil otherwise, without
+// blocking. It is cheap enough for long CPU-bound loops to call every few
+// iterations so they stop promptly on cancellation.
+func Checkpoint(ctx context.Context) error {
+	done := ctx.Done()
+	if done == nil {
+		return nil
+	}
+	select {
+	case <-done:
+		return ctx.Err()
+	default:
+		return nil
+	}
+}
+
+// WithCancellationChecks yields the values of seq, checking ctx before
+// every every-th value and stopping early once it is done. An every of zero
+// or less checks before each value. Callers that need to tell a cancelled
+// loop from a finished one check ctx.Err afterwards.
+func WithCancellationChecks[T any](seq iter.Seq[T], ctx context.Context, every int) iter.Seq[T] {
+	every = max(every, 1)
+	return func(yield func(T) bool) {
+		n := 0
+		for x := range seq {
+			if n%every == 0 && Checkpoint(ctx) != nil {
+				return
+			}
+			n++
+			if !yield(x) {
+				return
+			}
+		}
+	}
+}
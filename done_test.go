@@ -0,0 +1,142 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+type doneTenantCtx struct {
+	context.Context
+	tenant string
+}
+
+func TestOnDone(t *testing.T) {
+	base, cancel := context.WithCancelCause(context.Background())
+	ctx := doneTenantCtx{base, "acme"}
+	boom := errors.New("boom")
+	got := make(chan string, 1)
+	OnDone(ctx, func(ctx doneTenantCtx, cause error) {
+		if !errors.Is(cause, boom) {
+			t.Errorf("cause = %v", cause)
+		}
+		got <- ctx.tenant
+	})
+	cancel(boom)
+	select {
+	case tenant := <-got:
+		if tenant != "acme" {
+			t.Fatalf("tenant = %q", tenant)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("fn never ran")
+	}
+}
+
+func TestOnDone_Stop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ran := make(chan struct{}, 1)
+	stop := OnDone(ctx, func(context.Context, error) { ran <- struct{}{} })
+	if !stop() {
+		t.Fatal("stop before done should report true")
+	}
+	cancel()
+	select {
+	case <-ran:
+		t.Fatal("fn ran after stop")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+type closeCounter chan struct{}
+
+func (c closeCounter) Close() error { close(c); return nil }
+
+func TestCloseOnDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := make(closeCounter)
+	CloseOnDone(ctx, c)
+	cancel()
+	select {
+	case <-c:
+	case <-time.After(time.Second):
+		t.Fatal("not closed")
+	}
+}
+
+func TestDoneErr(t *testing.T) {
+	a, cancelA := context.WithCancel(context.Background())
+	defer cancelA()
+	b, cancelB := context.WithTimeout(context.Background(), 0)
+	defer cancelB()
+	c := context.Background()
+	var order []int
+	for i, err := range DoneErr(a, b, c) {
+		order = append(order, i)
+		switch i {
+		case 1:
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("b err = %v", err)
+			}
+			cancelA()
+		case 0:
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("a err = %v", err)
+			}
+		}
+		if len(order) == 2 {
+			break
+		}
+	}
+	if len(order) != 2 || order[0] != 1 || order[1] != 0 {
+		t.Fatalf("order = %v, want [1 0]", order)
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	if err := Checkpoint(context.Background()); err != nil {
+		t.Fatalf("Checkpoint(Background) = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := Checkpoint(ctx); err != nil {
+		t.Fatalf("Checkpoint before cancel = %v", err)
+	}
+	cancel()
+	if err := Checkpoint(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Checkpoint after cancel = %v", err)
+	}
+}
+
+func TestWithCancellationChecks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var got []int
+	for x := range WithCancellationChecks(slices.Values(make([]int, 1000)), ctx, 10) {
+		got = append(got, x)
+		if len(got) == 25 {
+			cancel()
+		}
+	}
+	// Cancelled after 25 values; the next check comes before the 31st.
+	if len(got) != 30 {
+		t.Fatalf("yielded %d values after cancel at 25, want 30", len(got))
+	}
+
+	n := 0
+	for range WithCancellationChecks(slices.Values([]int{1, 2, 3}), context.Background(), 0) {
+		n++
+	}
+	if n != 3 {
+		t.Fatalf("yielded %d values, want 3", n)
+	}
+}
+
+func BenchmarkCheckpoint(b *testing.B) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for b.Loop() {
+		Checkpoint(ctx)
+	}
+}
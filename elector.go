@@ -0,0 +1,184 @@
+package generic
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// ElectionBackend decides leadership for a single election. LocalElection
+// is the in-process implementation; external backends wrap a lease in etcd,
+// a database row or similar.
+type ElectionBackend interface {
+	// Campaign blocks until id becomes leader or ctx is done. The returned
+	// channel is closed when the leadership is lost.
+	Campaign(ctx context.Context, id string) (lost <-chan struct{}, err error)
+	// Resign gives up leadership if id holds it.
+	Resign(ctx context.Context, id string) error
+}
+
+// Elector campaigns for leadership on behalf of one instance, so work such
+// as scheduled jobs runs on only one of several processes.
+type Elector struct {
+	// ID identifies this instance to the backend.
+	ID string
+	// OnElected is called when leadership is won. ctx is cancelled when it
+	// is lost, so leader-only work can be started under it.
+	OnElected func(ctx context.Context)
+	// OnDemoted is called when leadership ends for any reason.
+	OnDemoted func()
+
+	backend ElectionBackend
+	leader  atomic.Bool
+	resign  chan struct{}
+	once    sync.Once
+}
+
+func NewElector(backend ElectionBackend, id string) *Elector {
+	return &Elector{ID: id, backend: backend, resign: make(chan struct{})}
+}
+
+// IsLeader reports whether this instance currently holds leadership.
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Campaign seeks leadership until ctx is done or Resign is called, winning
+// it again whenever it is lost. It returns ctx.Err() or, after Resign, nil.
+// Leadership held when Campaign returns is given up.
+func (e *Elector) Campaign(ctx context.Context) error {
+	// The backend only sees a context, so Resign must cancel one to stop
+	// a follower's pending campaign.
+	campaignCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-e.resign:
+			cancel()
+		case <-campaignCtx.Done():
+		}
+	}()
+	for {
+		lost, err := e.backend.Campaign(campaignCtx, e.ID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if e.resigned() {
+				return nil
+			}
+			return err
+		}
+		if e.resigned() {
+			// Won just as Resign was called; don't start a term.
+			return e.backend.Resign(context.WithoutCancel(ctx), e.ID)
+		}
+		if done, err := e.lead(ctx, lost); done {
+			return err
+		}
+	}
+}
+
+// lead holds one term of leadership, reporting whether Campaign should
+// return.
+func (e *Elector) lead(ctx context.Context, lost <-chan struct{}) (bool, error) {
+	termCtx, cancel := context.WithCancel(ctx)
+	e.leader.Store(true)
+	if e.OnElected != nil {
+		e.OnElected(termCtx)
+	}
+	defer func() {
+		cancel()
+		e.leader.Store(false)
+		if e.OnDemoted != nil {
+			e.OnDemoted()
+		}
+	}()
+	select {
+	case <-lost:
+		return false, nil
+	case <-e.resign:
+		return true, e.backend.Resign(context.WithoutCancel(ctx), e.ID)
+	case <-ctx.Done():
+		e.backend.Resign(context.WithoutCancel(ctx), e.ID)
+		return true, ctx.Err()
+	}
+}
+
+func (e *Elector) resigned() bool {
+	select {
+	case <-e.resign:
+		return true
+	default:
+		return false
+	}
+}
+
+// Resign gives up leadership and ends Campaign. It does not wait for
+// Campaign to return.
+func (e *Elector) Resign() {
+	e.once.Do(func() { close(e.resign) })
+}
+
+// LocalElection is an in-process ElectionBackend for tests and single-node
+// deployments. The zero value is ready to use.
+type LocalElection struct {
+	mu     sync.Mutex
+	leader string
+	lost   chan struct{}
+	free   chan struct{} // closed when the current term ends
+}
+
+var _ ElectionBackend = (*LocalElection)(nil)
+
+func (l *LocalElection) Campaign(ctx context.Context, id string) (<-chan struct{}, error) {
+	for {
+		l.mu.Lock()
+		if l.leader == "" {
+			l.leader = id
+			l.lost = make(chan struct{})
+			l.free = make(chan struct{})
+			lost := l.lost
+			l.mu.Unlock()
+			return lost, nil
+		}
+		free := l.free
+		l.mu.Unlock()
+		select {
+		case <-free:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (l *LocalElection) Resign(ctx context.Context, id string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.leader == id {
+		l.endTerm()
+	}
+	return nil
+}
+
+// Depose ends the current leader's term, as a lost lease would.
+func (l *LocalElection) Depose() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.leader != "" {
+		l.endTerm()
+	}
+}
+
+// Leader returns the current leader's ID, or "" if there is none.
+func (l *LocalElection) Leader() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.leader
+}
+
+func (l *LocalElection) endTerm() {
+	close(l.lost)
+	close(l.free)
+	l.leader = ""
+}
@@ -0,0 +1,138 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func waitLeader(t *testing.T, l *LocalElection, want string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for l.Leader() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected leader %q, got %q", want, l.Leader())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestElector_Failover(t *testing.T) {
+	var election LocalElection
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan string, 10)
+	a := NewElector(&election, "a")
+	var termCtx context.Context
+	a.OnElected = func(ctx context.Context) {
+		termCtx = ctx
+		events <- "a elected"
+	}
+	a.OnDemoted = func() { events <- "a demoted" }
+	aDone := make(chan error)
+	go func() { aDone <- a.Campaign(ctx) }()
+	if ev := <-events; ev != "a elected" {
+		t.Fatalf("unexpected event %q", ev)
+	}
+	if !a.IsLeader() {
+		t.Fatal("a should be leader")
+	}
+
+	b := NewElector(&election, "b")
+	bDone := make(chan error)
+	go func() { bDone <- b.Campaign(ctx) }()
+	time.Sleep(10 * time.Millisecond)
+	if b.IsLeader() {
+		t.Fatal("b must not lead while a does")
+	}
+
+	a.Resign()
+	if ev := <-events; ev != "a demoted" {
+		t.Fatalf("unexpected event %q", ev)
+	}
+	if err := <-aDone; err != nil {
+		t.Fatalf("Campaign after Resign returned %v", err)
+	}
+	if termCtx.Err() == nil {
+		t.Fatal("term context should be cancelled on demotion")
+	}
+	waitLeader(t, &election, "b")
+
+	cancel()
+	if err := <-bDone; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+	waitLeader(t, &election, "")
+}
+
+func TestElector_ReelectedAfterDepose(t *testing.T) {
+	var election LocalElection
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	elected := make(chan struct{}, 10)
+	demoted := make(chan struct{}, 10)
+	e := NewElector(&election, "solo")
+	e.OnElected = func(context.Context) { elected <- struct{}{} }
+	e.OnDemoted = func() { demoted <- struct{}{} }
+	go e.Campaign(ctx)
+
+	<-elected
+	election.Depose()
+	<-demoted
+	select {
+	case <-elected:
+	case <-time.After(time.Second):
+		t.Fatal("expected to win the election again")
+	}
+}
+
+func TestLocalElection_CampaignCancelled(t *testing.T) {
+	var election LocalElection
+	if _, err := election.Campaign(context.Background(), "a"); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := election.Campaign(ctx, "b"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	election.Resign(context.Background(), "b") // not the leader: no effect
+	if election.Leader() != "a" {
+		t.Fatalf("expected a to remain leader, got %q", election.Leader())
+	}
+}
+
+func TestElector_FollowerResign(t *testing.T) {
+	var election LocalElection
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a := NewElector(&election, "a")
+	go a.Campaign(ctx)
+	waitLeader(t, &election, "a")
+
+	b := NewElector(&election, "b")
+	var elected bool
+	b.OnElected = func(context.Context) { elected = true }
+	bDone := make(chan error)
+	go func() { bDone <- b.Campaign(ctx) }()
+	time.Sleep(10 * time.Millisecond)
+	b.Resign()
+	select {
+	case err := <-bDone:
+		if err != nil {
+			t.Fatalf("follower Campaign after Resign = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Resign did not stop the follower's Campaign")
+	}
+
+	a.Resign()
+	waitLeader(t, &election, "")
+	if elected {
+		t.Fatal("resigned follower was elected")
+	}
+}
@@ -0,0 +1,215 @@
+package generic
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrUnknownKey is returned when a payload names a key the KeyProvider does
+// not have.
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+// KeyProvider supplies AES keys for EncryptedCodec. Keys are 16, 24 or 32
+// bytes and must never change once their ID is in use.
+type KeyProvider interface {
+	// CurrentKey returns the key new payloads are encrypted with.
+	CurrentKey() (id uint32, key []byte, err error)
+	// Key returns the key with the given ID, for decrypting.
+	Key(id uint32) ([]byte, error)
+}
+
+// KeyRing is an in-memory KeyProvider supporting rotation: Rotate adds a
+// key and makes it current while older keys keep decrypting existing
+// payloads until Retire removes them. The zero value has no keys.
+type KeyRing struct {
+	mu      sync.RWMutex
+	current uint32
+	keys    map[uint32][]byte
+}
+
+var _ KeyProvider = (*KeyRing)(nil)
+
+// Rotate adds key under id and makes it the current key.
+func (r *KeyRing) Rotate(id uint32, key []byte) error {
+	if _, err := aes.NewCipher(key); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if old, ok := r.keys[id]; ok && string(old) != string(key) {
+		return fmt.Errorf("key ring: key %d already holds a different key", id)
+	}
+	if r.keys == nil {
+		r.keys = make(map[uint32][]byte)
+	}
+	r.keys[id] = append([]byte(nil), key...)
+	r.current = id
+	return nil
+}
+
+// Retire removes a key that is no longer current. Payloads encrypted with
+// it can no longer be read.
+func (r *KeyRing) Retire(id uint32) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id == r.current {
+		return fmt.Errorf("key ring: cannot retire current key %d", id)
+	}
+	delete(r.keys, id)
+	return nil
+}
+
+func (r *KeyRing) CurrentKey() (uint32, []byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	key, ok := r.keys[r.current]
+	if !ok {
+		return 0, nil, fmt.Errorf("%w: key ring is empty", ErrUnknownKey)
+	}
+	return r.current, bytes.Clone(key), nil
+}
+
+func (r *KeyRing) Key(id uint32) ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	key, ok := r.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownKey, id)
+	}
+	return bytes.Clone(key), nil
+}
+
+// encryptedVersion is the first byte of every EncryptedCodec payload.
+const encryptedVersion byte = 1
+
+// encryptedHeaderSize covers the version byte and the key ID, which are
+// authenticated along with the ciphertext.
+const encryptedHeaderSize = 1 + 4
+
+// EncryptedCodec wraps a Codec with AES-GCM. Each payload records the ID
+// of the key that sealed it, so keys can be rotated without re-encrypting
+// stored data. Ciphers are cached by key ID: the cache is emptied when the
+// current key changes and loses a key the KeyProvider no longer has, so
+// retired keys don't linger in memory.
+type EncryptedCodec[T any] struct {
+	codec Codec[T]
+	keys  KeyProvider
+
+	mu      sync.RWMutex
+	current uint32
+	aeads   map[uint32]cachedAEAD
+}
+
+type cachedAEAD struct {
+	key  []byte
+	aead cipher.AEAD
+}
+
+var _ Codec[int] = (*EncryptedCodec[int])(nil)
+
+func NewEncryptedCodec[T any](codec Codec[T], keys KeyProvider) *EncryptedCodec[T] {
+	return &EncryptedCodec[T]{codec: codec, keys: keys}
+}
+
+// aead returns the cipher for key id, building it unless the cached one
+// was made from the same key.
+func (c *EncryptedCodec[T]) aead(id uint32, key []byte) (cipher.AEAD, error) {
+	c.mu.RLock()
+	e, ok := c.aeads[id]
+	c.mu.RUnlock()
+	if ok && bytes.Equal(e.key, key) {
+		return e.aead, nil
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	a, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.aeads == nil {
+		c.aeads = make(map[uint32]cachedAEAD)
+	}
+	c.aeads[id] = cachedAEAD{key: bytes.Clone(key), aead: a}
+	return a, nil
+}
+
+// rotated empties the cache when id is not the current key it last saw.
+func (c *EncryptedCodec[T]) rotated(id uint32) {
+	c.mu.RLock()
+	same := c.current == id
+	c.mu.RUnlock()
+	if same {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current != id {
+		c.current = id
+		clear(c.aeads)
+	}
+}
+
+// forget drops the cipher for a key the KeyProvider no longer has.
+func (c *EncryptedCodec[T]) forget(id uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.aeads, id)
+}
+
+func (c *EncryptedCodec[T]) Marshal(x T) ([]byte, error) {
+	data, err := c.codec.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+	id, key, err := c.keys.CurrentKey()
+	if err != nil {
+		return nil, err
+	}
+	c.rotated(id)
+	a, err := c.aead(id, key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, encryptedHeaderSize+a.NonceSize(), encryptedHeaderSize+a.NonceSize()+len(data)+a.Overhead())
+	out[0] = encryptedVersion
+	binary.BigEndian.PutUint32(out[1:], id)
+	nonce := out[encryptedHeaderSize:]
+	rand.Read(nonce)
+	return a.Seal(out, nonce, data, out[:encryptedHeaderSize]), nil
+}
+
+func (c *EncryptedCodec[T]) Unmarshal(b []byte) (T, error) {
+	var zero T
+	if len(b) < encryptedHeaderSize || b[0] != encryptedVersion {
+		return zero, errors.New("encrypted codec: malformed payload")
+	}
+	id := binary.BigEndian.Uint32(b[1:])
+	key, err := c.keys.Key(id)
+	if err != nil {
+		c.forget(id)
+		return zero, err
+	}
+	a, err := c.aead(id, key)
+	if err != nil {
+		return zero, err
+	}
+	if len(b) < encryptedHeaderSize+a.NonceSize() {
+		return zero, errors.New("encrypted codec: malformed payload")
+	}
+	nonce := b[encryptedHeaderSize : encryptedHeaderSize+a.NonceSize()]
+	data, err := a.Open(nil, nonce, b[encryptedHeaderSize+a.NonceSize():], b[:encryptedHeaderSize])
+	if err != nil {
+		return zero, fmt.Errorf("encrypted codec: %w", err)
+	}
+	return c.codec.Unmarshal(data)
+}
@@ -0,0 +1,126 @@
+package generic
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func testKey(b byte) []byte { return bytes.Repeat([]byte{b}, 32) }
+
+func TestEncryptedCodec_RoundTrip(t *testing.T) {
+	var ring KeyRing
+	if err := ring.Rotate(1, testKey(1)); err != nil {
+		t.Fatal(err)
+	}
+	codec := NewEncryptedCodec[ioRecord](JSONCodec[ioRecord]{}, &ring)
+	x := ioRecord{7, "secret"}
+	b, err := codec.Marshal(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(b, []byte("secret")) {
+		t.Fatal("payload is not encrypted")
+	}
+	b2, _ := codec.Marshal(x)
+	if bytes.Equal(b, b2) {
+		t.Fatal("nonces must differ between payloads")
+	}
+	if got, err := codec.Unmarshal(b); err != nil || got != x {
+		t.Fatalf("expected %+v, got %+v, %v", x, got, err)
+	}
+}
+
+func TestEncryptedCodec_Rotation(t *testing.T) {
+	var ring KeyRing
+	ring.Rotate(1, testKey(1))
+	codec := NewEncryptedCodec[string](JSONCodec[string]{}, &ring)
+	old, _ := codec.Marshal("old")
+
+	if err := ring.Rotate(2, testKey(2)); err != nil {
+		t.Fatal(err)
+	}
+	fresh, _ := codec.Marshal("new")
+	for _, b := range [][]byte{old, fresh} {
+		if _, err := codec.Unmarshal(b); err != nil {
+			t.Fatalf("decrypt after rotation: %v", err)
+		}
+	}
+
+	if err := ring.Retire(2); err == nil {
+		t.Fatal("retiring the current key should fail")
+	}
+	if err := ring.Retire(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := codec.Unmarshal(old); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey after retiring, got %v", err)
+	}
+	if err := ring.Rotate(2, testKey(3)); err == nil {
+		t.Fatal("reusing a key ID for a different key should fail")
+	}
+}
+
+func TestEncryptedCodec_CacheFollowsKeys(t *testing.T) {
+	var ring KeyRing
+	ring.Rotate(1, testKey(1))
+	codec := NewEncryptedCodec[string](JSONCodec[string]{}, &ring)
+	old, _ := codec.Marshal("old")
+	ring.Rotate(2, testKey(2))
+	codec.Marshal("new")
+	if _, ok := codec.aeads[1]; ok || len(codec.aeads) != 1 {
+		t.Fatalf("rotation kept %d cached ciphers", len(codec.aeads))
+	}
+	codec.Unmarshal(old)
+	ring.Retire(1)
+	codec.Unmarshal(old)
+	if _, ok := codec.aeads[1]; ok {
+		t.Fatal("retired key still cached")
+	}
+}
+
+func TestKeyRing_KeyCopies(t *testing.T) {
+	var ring KeyRing
+	ring.Rotate(1, testKey(1))
+	key, _ := ring.Key(1)
+	key[0] ^= 0xff
+	_, current, _ := ring.CurrentKey()
+	current[1] ^= 0xff
+	if got, _ := ring.Key(1); !bytes.Equal(got, testKey(1)) {
+		t.Fatal("caller modified a stored key")
+	}
+}
+
+func TestEncryptedCodec_Tampering(t *testing.T) {
+	var ring KeyRing
+	ring.Rotate(1, testKey(1))
+	ring.Rotate(2, testKey(2))
+	codec := NewEncryptedCodec[string](JSONCodec[string]{}, &ring)
+	b, _ := codec.Marshal("payload")
+
+	flipped := bytes.Clone(b)
+	flipped[len(flipped)-1] ^= 1
+	if _, err := codec.Unmarshal(flipped); err == nil {
+		t.Fatal("expected authentication failure for modified ciphertext")
+	}
+
+	// Pointing the header at another key must fail too.
+	relabeled := bytes.Clone(b)
+	relabeled[4] = 1
+	if _, err := codec.Unmarshal(relabeled); err == nil {
+		t.Fatal("expected authentication failure for modified key ID")
+	}
+	if _, err := codec.Unmarshal([]byte{1, 0}); err == nil {
+		t.Fatal("expected error for short payload")
+	}
+}
+
+func TestKeyRing_Empty(t *testing.T) {
+	var ring KeyRing
+	if _, err := NewEncryptedCodec[int](JSONCodec[int]{}, &ring).Marshal(1); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey, got %v", err)
+	}
+	if err := ring.Rotate(1, []byte("short")); err == nil {
+		t.Fatal("expected invalid key size error")
+	}
+}
@@ -0,0 +1,281 @@
+package generic
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type expiringEntry[V any] struct {
+	value   V
+	expires time.Time // zero means never
+}
+
+func (e expiringEntry[V]) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// ExpiringMap is a map whose entries can carry a time to live. Expired
+// entries are invisible to reads and removed lazily when touched, or in bulk
+// by RunJanitor. Its method set mirrors sync.Map with Set added for TTLs.
+// The zero value is ready to use.
+type ExpiringMap[K comparable, V any] struct {
+	// Clock supplies the current time. Defaults to SystemClock.
+	Clock Clock
+	// OnEvict, if set, is called for every entry removed because it
+	// expired. It is not called for Delete or overwrites.
+	OnEvict func(key K, value V)
+	// TombstoneTTL, if positive, makes Delete leave a tombstone recording
+	// when a live key was deleted, kept for this long or until the key is
+	// set again. Tombstones are invisible to Load and Range; read them
+	// with LoadTombstone and RangeTombstones to propagate deletes or
+	// detect conflicting writes. Expiry does not leave a tombstone.
+	TombstoneTTL time.Duration
+
+	mu         sync.Mutex
+	entries    map[K]expiringEntry[V]
+	tombstones map[K]time.Time // deletion time
+}
+
+func (m *ExpiringMap[K, V]) now() time.Time {
+	if m.Clock == nil {
+		return time.Now()
+	}
+	return m.Clock.Now()
+}
+
+// lookup returns the live entry for key, removing it if it has expired. The
+// caller must hold m.mu and call evicted with the result after unlocking.
+func (m *ExpiringMap[K, V]) lookup(key K, now time.Time) (expiringEntry[V], bool, *expiringEntry[V]) {
+	e, ok := m.entries[key]
+	if ok && e.expired(now) {
+		delete(m.entries, key)
+		return expiringEntry[V]{}, false, &e
+	}
+	return e, ok, nil
+}
+
+func (m *ExpiringMap[K, V]) evicted(key K, e *expiringEntry[V]) {
+	if e != nil && m.OnEvict != nil {
+		m.OnEvict(key, e.value)
+	}
+}
+
+// Set stores value for key, expiring after ttl. A ttl of zero or less means
+// the entry never expires.
+func (m *ExpiringMap[K, V]) Set(key K, value V, ttl time.Duration) {
+	e := expiringEntry[V]{value: value}
+	if ttl > 0 {
+		e.expires = m.now().Add(ttl)
+	}
+	m.set(key, e)
+}
+
+func (m *ExpiringMap[K, V]) set(key K, e expiringEntry[V]) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.entries == nil {
+		m.entries = make(map[K]expiringEntry[V])
+	}
+	m.entries[key] = e
+	delete(m.tombstones, key)
+}
+
+// SetUntil stores value for key, expiring at the wall-clock time t. The wall
+// clock is consulted once to convert t to a TTL; expiry is then measured on
+// the map's Clock.
+func (m *ExpiringMap[K, V]) SetUntil(key K, value V, t time.Time) {
+	now := m.now()
+	// Adding to now keeps now's monotonic reading; a deadline already
+	// passed expires immediately.
+	m.set(key, expiringEntry[V]{value: value, expires: now.Add(max(t.Sub(now), 0))})
+}
+
+// Store stores value for key without expiry.
+func (m *ExpiringMap[K, V]) Store(key K, value V) {
+	m.Set(key, value, 0)
+}
+
+// Load returns the value for key if present and not expired.
+func (m *ExpiringMap[K, V]) Load(key K) (value V, ok bool) {
+	m.mu.Lock()
+	e, ok, ev := m.lookup(key, m.now())
+	m.mu.Unlock()
+	m.evicted(key, ev)
+	return e.value, ok
+}
+
+// TTL returns how long key has left to live. It reports zero and true for
+// entries without expiry.
+func (m *ExpiringMap[K, V]) TTL(key K) (time.Duration, bool) {
+	now := m.now()
+	m.mu.Lock()
+	e, ok, ev := m.lookup(key, now)
+	m.mu.Unlock()
+	m.evicted(key, ev)
+	if !ok || e.expires.IsZero() {
+		return 0, ok
+	}
+	return e.expires.Sub(now), true
+}
+
+// LoadOrSet returns the existing live value for key, or stores value with
+// ttl. loaded reports whether the value was already present.
+func (m *ExpiringMap[K, V]) LoadOrSet(key K, value V, ttl time.Duration) (actual V, loaded bool) {
+	now := m.now()
+	m.mu.Lock()
+	e, ok, ev := m.lookup(key, now)
+	if !ok {
+		e = expiringEntry[V]{value: value}
+		if ttl > 0 {
+			e.expires = now.Add(ttl)
+		}
+		if m.entries == nil {
+			m.entries = make(map[K]expiringEntry[V])
+		}
+		m.entries[key] = e
+		delete(m.tombstones, key)
+	}
+	m.mu.Unlock()
+	m.evicted(key, ev)
+	return e.value, ok
+}
+
+// LoadOrStore is LoadOrSet without expiry.
+func (m *ExpiringMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	return m.LoadOrSet(key, value, 0)
+}
+
+// LoadAndDelete removes key, returning its value if it was live.
+func (m *ExpiringMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	now := m.now()
+	m.mu.Lock()
+	e, ok, ev := m.lookup(key, now)
+	delete(m.entries, key)
+	if ok && m.TombstoneTTL > 0 {
+		if m.tombstones == nil {
+			m.tombstones = make(map[K]time.Time)
+		}
+		m.tombstones[key] = now
+	}
+	m.mu.Unlock()
+	m.evicted(key, ev)
+	return e.value, ok
+}
+
+// Delete removes key.
+func (m *ExpiringMap[K, V]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+// LoadTombstone reports when key was deleted, if its tombstone is still
+// kept.
+func (m *ExpiringMap[K, V]) LoadTombstone(key K) (deletedAt time.Time, ok bool) {
+	now := m.now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	at, ok := m.tombstones[key]
+	if ok && !now.Before(at.Add(m.TombstoneTTL)) {
+		delete(m.tombstones, key)
+		return time.Time{}, false
+	}
+	return at, ok
+}
+
+// RangeTombstones calls f for each kept tombstone until f returns false.
+// Like Range, it works on a copy.
+func (m *ExpiringMap[K, V]) RangeTombstones(f func(key K, deletedAt time.Time) bool) {
+	type kt struct {
+		k  K
+		at time.Time
+	}
+	now := m.now()
+	m.mu.Lock()
+	kept := make([]kt, 0, len(m.tombstones))
+	for k, at := range m.tombstones {
+		if now.Before(at.Add(m.TombstoneTTL)) {
+			kept = append(kept, kt{k, at})
+		}
+	}
+	m.mu.Unlock()
+	for _, t := range kept {
+		if !f(t.k, t.at) {
+			return
+		}
+	}
+}
+
+// Range calls f for each live entry until f returns false. It works on a
+// copy, so f may modify the map.
+func (m *ExpiringMap[K, V]) Range(f func(key K, value V) bool) {
+	type kv struct {
+		k K
+		v V
+	}
+	now := m.now()
+	m.mu.Lock()
+	live := make([]kv, 0, len(m.entries))
+	for k, e := range m.entries {
+		if !e.expired(now) {
+			live = append(live, kv{k, e.value})
+		}
+	}
+	m.mu.Unlock()
+	for _, e := range live {
+		if !f(e.k, e.v) {
+			return
+		}
+	}
+}
+
+// Len returns the number of entries, including expired ones not yet removed.
+func (m *ExpiringMap[K, V]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.entries)
+}
+
+// Sweep removes every expired entry and returns how many were removed.
+// Expired tombstones are dropped too but not counted.
+func (m *ExpiringMap[K, V]) Sweep() int {
+	type kv struct {
+		k K
+		e expiringEntry[V]
+	}
+	now := m.now()
+	var removed []kv
+	m.mu.Lock()
+	for k, e := range m.entries {
+		if e.expired(now) {
+			delete(m.entries, k)
+			removed = append(removed, kv{k, e})
+		}
+	}
+	for k, at := range m.tombstones {
+		if !now.Before(at.Add(m.TombstoneTTL)) {
+			delete(m.tombstones, k)
+		}
+	}
+	m.mu.Unlock()
+	for _, r := range removed {
+		m.evicted(r.k, &r.e)
+	}
+	return len(removed)
+}
+
+// RunJanitor calls Sweep every interval until ctx is done. Run it in its own
+// goroutine when expired entries must be released without being read.
+func (m *ExpiringMap[K, V]) RunJanitor(ctx context.Context, interval time.Duration) error {
+	clock := m.Clock
+	if clock == nil {
+		clock = SystemClock
+	}
+	for {
+		select {
+		case <-clock.After(interval):
+			m.Sweep()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
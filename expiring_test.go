@@ -0,0 +1,191 @@
+package generic
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestExpiringMap_ZeroValue(t *testing.T) {
+	var m ExpiringMap[string, int]
+	if _, ok := m.Load("missing"); ok {
+		t.Fatal("expected missing key")
+	}
+	m.Store("a", 1)
+	if v, ok := m.Load("a"); !ok || v != 1 {
+		t.Fatalf("expected 1, got %d (ok %v)", v, ok)
+	}
+	if ttl, ok := m.TTL("a"); !ok || ttl != 0 {
+		t.Fatalf("expected no expiry, got %v (ok %v)", ttl, ok)
+	}
+}
+
+func TestExpiringMap_LazyExpiry(t *testing.T) {
+	clock := newTestClock()
+	var evicted []string
+	m := &ExpiringMap[string, int]{
+		Clock: clock,
+		OnEvict: func(k string, v int) {
+			if v != 1 {
+				t.Errorf("expected evicted value 1, got %d", v)
+			}
+			evicted = append(evicted, k)
+		},
+	}
+
+	m.Set("short", 1, time.Second)
+	m.Set("long", 2, time.Minute)
+	m.Store("forever", 3)
+
+	if ttl, _ := m.TTL("short"); ttl != time.Second {
+		t.Fatalf("expected 1s TTL, got %v", ttl)
+	}
+
+	clock.Advance(time.Second)
+	if _, ok := m.Load("short"); ok {
+		t.Fatal("expected short to have expired")
+	}
+	if len(evicted) != 1 || evicted[0] != "short" {
+		t.Fatalf("expected short to be evicted, got %v", evicted)
+	}
+	if v, ok := m.Load("long"); !ok || v != 2 {
+		t.Fatalf("expected long to be live, got %d (ok %v)", v, ok)
+	}
+	if m.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", m.Len())
+	}
+
+	count := 0
+	m.Range(func(string, int) bool { count++; return true })
+	if count != 2 {
+		t.Fatalf("expected Range over 2 live entries, got %d", count)
+	}
+}
+
+func TestExpiringMap_SetUntil(t *testing.T) {
+	clock := newTestClock()
+	m := &ExpiringMap[string, int]{Clock: clock}
+	m.SetUntil("a", 1, clock.Now().Add(time.Minute))
+	m.SetUntil("past", 2, clock.Now().Add(-time.Minute))
+	if ttl, _ := m.TTL("a"); ttl != time.Minute {
+		t.Fatalf("expected 1m TTL, got %v", ttl)
+	}
+	if _, ok := m.Load("past"); ok {
+		t.Fatal("entry with a past deadline should already be expired")
+	}
+}
+
+func TestExpiringMap_LoadOrSet(t *testing.T) {
+	clock := newTestClock()
+	m := &ExpiringMap[string, string]{Clock: clock}
+
+	v, loaded := m.LoadOrSet("nonce", "first", time.Second)
+	if loaded || v != "first" {
+		t.Fatalf("expected to store first, got %q (loaded %v)", v, loaded)
+	}
+	v, loaded = m.LoadOrSet("nonce", "second", time.Second)
+	if !loaded || v != "first" {
+		t.Fatalf("expected to load first, got %q (loaded %v)", v, loaded)
+	}
+
+	clock.Advance(2 * time.Second)
+	v, loaded = m.LoadOrSet("nonce", "third", time.Second)
+	if loaded || v != "third" {
+		t.Fatalf("expected expired entry to be replaced, got %q (loaded %v)", v, loaded)
+	}
+
+	if v, ok := m.LoadAndDelete("nonce"); !ok || v != "third" {
+		t.Fatalf("expected to delete third, got %q (ok %v)", v, ok)
+	}
+	if _, ok := m.Load("nonce"); ok {
+		t.Fatal("expected nonce to be deleted")
+	}
+}
+
+func TestExpiringMap_Janitor(t *testing.T) {
+	clock := newTestClock()
+	evicted := make(chan string, 10)
+	m := &ExpiringMap[string, int]{
+		Clock:   clock,
+		OnEvict: func(k string, _ int) { evicted <- k },
+	}
+	m.Set("a", 1, time.Second)
+	m.Set("b", 2, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- m.RunJanitor(ctx, time.Minute) }()
+
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Minute)
+
+	select {
+	case k := <-evicted:
+		if k != "a" {
+			t.Fatalf("expected a to be swept, got %q", k)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("janitor did not sweep")
+	}
+	if m.Len() != 1 {
+		t.Errorf("expected 1 entry after sweep, got %d", m.Len())
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestExpiringMap_Tombstones(t *testing.T) {
+	clock := newTestClock()
+	m := &ExpiringMap[string, int]{Clock: clock, TombstoneTTL: time.Minute}
+	m.Store("a", 1)
+	m.Set("b", 2, time.Second)
+	deletedAt := clock.Now()
+	m.Delete("a")
+
+	if _, ok := m.Load("a"); ok {
+		t.Fatal("deleted key should not load")
+	}
+	if at, ok := m.LoadTombstone("a"); !ok || !at.Equal(deletedAt) {
+		t.Fatalf("expected tombstone at %v, got %v %v", deletedAt, at, ok)
+	}
+	m.Delete("missing")
+	if _, ok := m.LoadTombstone("missing"); ok {
+		t.Fatal("deleting an absent key should not leave a tombstone")
+	}
+	clock.Advance(time.Second)
+	m.Delete("b")
+	if _, ok := m.LoadTombstone("b"); ok {
+		t.Fatal("expiry should not leave a tombstone")
+	}
+
+	var keys []string
+	m.RangeTombstones(func(k string, _ time.Time) bool {
+		keys = append(keys, k)
+		return true
+	})
+	if len(keys) != 1 || keys[0] != "a" {
+		t.Fatalf("unexpected tombstones %v", keys)
+	}
+
+	m.Store("a", 3)
+	if _, ok := m.LoadTombstone("a"); ok {
+		t.Fatal("setting a key should clear its tombstone")
+	}
+	m.Delete("a")
+	clock.Advance(time.Minute)
+	if _, ok := m.LoadTombstone("a"); ok {
+		t.Fatal("tombstone should expire after TombstoneTTL")
+	}
+	m.Store("c", 1)
+	m.LoadAndDelete("c")
+	clock.Advance(time.Minute)
+	m.Sweep()
+	if len(m.tombstones) != 0 {
+		t.Fatalf("Sweep should drop expired tombstones, %d left", len(m.tombstones))
+	}
+}
@@ -0,0 +1,231 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ErrInjectedFault is the error injected by a Fault without its own Err.
+var ErrInjectedFault = errors.New("injected fault")
+
+// FaultConfig sets per-call fault probabilities, each between 0 and 1.
+type FaultConfig struct {
+	// DelayRate is the probability of sleeping for Delay before the call.
+	DelayRate float64
+	Delay     time.Duration
+	// ErrorRate is the probability of failing the call with Err, or
+	// ErrInjectedFault if Err is nil.
+	ErrorRate float64
+	Err       error
+	// DropRate is the probability of silently losing the call's effect:
+	// a Put that reports success without enqueueing, a pooled value that
+	// is not returned, an HTTP response lost after the request was sent.
+	DropRate float64
+}
+
+// FaultStats counts the faults a Fault has injected.
+type FaultStats struct {
+	Delays int64 `json:"delays"`
+	Errors int64 `json:"errors"`
+	Drops  int64 `json:"drops"`
+}
+
+// Fault injects latency, errors and drops into the primitives wrapped with
+// FaultyQueue, FaultyPool, FaultyFunc and FaultyTransport, for resilience
+// testing. Its configuration can be changed while in use. The zero value
+// and a nil *Fault inject nothing.
+type Fault struct {
+	cfg    atomic.Pointer[FaultConfig]
+	delays atomic.Int64
+	errors atomic.Int64
+	drops  atomic.Int64
+}
+
+// Set replaces the fault configuration.
+func (f *Fault) Set(cfg FaultConfig) {
+	f.cfg.Store(&cfg)
+}
+
+// Disable stops injecting faults.
+func (f *Fault) Disable() {
+	f.cfg.Store(nil)
+}
+
+// Stats returns how many faults have been injected.
+func (f *Fault) Stats() FaultStats {
+	if f == nil {
+		return FaultStats{}
+	}
+	return FaultStats{Delays: f.delays.Load(), Errors: f.errors.Load(), Drops: f.drops.Load()}
+}
+
+// Inspect reports Stats for DebugHandler.
+func (f *Fault) Inspect() any {
+	return f.Stats()
+}
+
+// inject applies a delay and returns an injected error, if the dice say
+// so. A delay cut short by ctx returns ctx.Err().
+func (f *Fault) inject(ctx context.Context) error {
+	if f == nil {
+		return nil
+	}
+	cfg := f.cfg.Load()
+	if cfg == nil {
+		return nil
+	}
+	if cfg.Delay > 0 && hit(cfg.DelayRate) {
+		f.delays.Add(1)
+		t := time.NewTimer(cfg.Delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+	if hit(cfg.ErrorRate) {
+		f.errors.Add(1)
+		if cfg.Err != nil {
+			return cfg.Err
+		}
+		return ErrInjectedFault
+	}
+	return nil
+}
+
+// drop reports whether to lose the current call's effect.
+func (f *Fault) drop() bool {
+	if f == nil {
+		return false
+	}
+	cfg := f.cfg.Load()
+	if cfg == nil || !hit(cfg.DropRate) {
+		return false
+	}
+	f.drops.Add(1)
+	return true
+}
+
+func hit(rate float64) bool {
+	return rate > 0 && (rate >= 1 || rand.Float64() < rate)
+}
+
+type faultyQueue[T any] struct {
+	Queue[T]
+	f *Fault
+}
+
+// FaultyQueue wraps q so every call first passes through f. Try methods
+// report failure for injected errors.
+func FaultyQueue[T any](q Queue[T], f *Fault) Queue[T] {
+	return faultyQueue[T]{Queue: q, f: f}
+}
+
+func (q faultyQueue[T]) Put(ctx context.Context, x T) error {
+	if err := q.f.inject(ctx); err != nil {
+		return err
+	}
+	if q.f.drop() {
+		return nil
+	}
+	return q.Queue.Put(ctx, x)
+}
+
+func (q faultyQueue[T]) TryPut(x T) bool {
+	if q.f.inject(context.Background()) != nil {
+		return false
+	}
+	return q.f.drop() || q.Queue.TryPut(x)
+}
+
+func (q faultyQueue[T]) Get(ctx context.Context) (T, error) {
+	if err := q.f.inject(ctx); err != nil {
+		var zero T
+		return zero, err
+	}
+	return q.Queue.Get(ctx)
+}
+
+func (q faultyQueue[T]) TryGet() (T, bool) {
+	if q.f.inject(context.Background()) != nil {
+		var zero T
+		return zero, false
+	}
+	return q.Queue.TryGet()
+}
+
+type faultyPool[T any] struct {
+	Pool[T]
+	f *Fault
+}
+
+// FaultyPool wraps p so Get and Put pass through f. An injected error makes
+// Get return the zero value, as an empty pool would; a drop makes Put
+// discard the value.
+func FaultyPool[T any](p Pool[T], f *Fault) Pool[T] {
+	return faultyPool[T]{Pool: p, f: f}
+}
+
+func (p faultyPool[T]) Get() T {
+	if p.f.inject(context.Background()) != nil {
+		var zero T
+		return zero
+	}
+	return p.Pool.Get()
+}
+
+func (p faultyPool[T]) Put(x T) {
+	if p.f.inject(context.Background()) != nil || p.f.drop() {
+		return
+	}
+	p.Pool.Put(x)
+}
+
+// FaultyFunc wraps a context-aware function, such as a WeakCache loader, so
+// each call passes through f. A drop discards the result and reports
+// ErrInjectedFault after the call has run.
+func FaultyFunc[K, V any](f *Fault, fn func(ctx context.Context, key K) (V, error)) func(ctx context.Context, key K) (V, error) {
+	return func(ctx context.Context, key K) (V, error) {
+		var zero V
+		if err := f.inject(ctx); err != nil {
+			return zero, err
+		}
+		v, err := fn(ctx, key)
+		if err == nil && f.drop() {
+			return zero, ErrInjectedFault
+		}
+		return v, err
+	}
+}
+
+// FaultyTransport is an http.RoundTripper that injects faults before
+// requests reach Base, or http.DefaultTransport if Base is nil. A drop sends
+// the request and then loses the response.
+type FaultyTransport struct {
+	Base  http.RoundTripper
+	Fault *Fault
+}
+
+func (t *FaultyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if err := t.Fault.inject(req.Context()); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	resp, err := base.RoundTrip(req)
+	if err == nil && t.Fault.drop() {
+		resp.Body.Close()
+		return nil, ErrInjectedFault
+	}
+	return resp, err
+}
@@ -0,0 +1,180 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFault_Queue(t *testing.T) {
+	var f Fault
+	inner := NewFiFo[int]()
+	q := FaultyQueue[int](inner, &f)
+	ctx := context.Background()
+
+	if err := q.Put(ctx, 1); err != nil || inner.Size() != 1 {
+		t.Fatalf("zero Fault should pass calls through, got %v", err)
+	}
+
+	f.Set(FaultConfig{ErrorRate: 1})
+	if err := q.Put(ctx, 2); !errors.Is(err, ErrInjectedFault) {
+		t.Fatalf("expected injected error, got %v", err)
+	}
+	if _, ok := q.TryGet(); ok {
+		t.Fatal("TryGet should fail under injected errors")
+	}
+
+	f.Set(FaultConfig{DropRate: 1})
+	if err := q.Put(ctx, 3); err != nil {
+		t.Fatalf("dropped Put should report success, got %v", err)
+	}
+	if !q.TryPut(4) {
+		t.Fatal("dropped TryPut should report success")
+	}
+	if inner.Size() != 1 {
+		t.Fatalf("dropped items must not be enqueued, size %d", inner.Size())
+	}
+
+	f.Disable()
+	if x, err := q.Get(ctx); err != nil || x != 1 {
+		t.Fatalf("expected 1, got %d, %v", x, err)
+	}
+	if s := f.Stats(); s.Errors != 2 || s.Drops != 2 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
+
+func TestFault_Delay(t *testing.T) {
+	var f Fault
+	f.Set(FaultConfig{DelayRate: 1, Delay: time.Hour})
+	q := FaultyQueue[int](NewFiFo[int](), &f)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.Put(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("delay should respect ctx, got %v", err)
+	}
+
+	custom := errors.New("disk full")
+	f.Set(FaultConfig{DelayRate: 1, Delay: time.Millisecond, ErrorRate: 1, Err: custom})
+	start := time.Now()
+	if err := q.Put(context.Background(), 1); err != custom {
+		t.Fatalf("expected custom error, got %v", err)
+	}
+	if time.Since(start) < time.Millisecond {
+		t.Fatal("expected delay before the error")
+	}
+}
+
+func TestFault_Pool(t *testing.T) {
+	var f Fault
+	inner := &stubPool{}
+	p := FaultyPool[int](inner, &f)
+
+	f.Set(FaultConfig{DropRate: 1})
+	p.Put(1)
+	if inner.puts != 0 {
+		t.Fatal("dropped Put reached the pool")
+	}
+	f.Set(FaultConfig{ErrorRate: 1})
+	if x := p.Get(); x != 0 || inner.gets != 0 {
+		t.Fatalf("injected error should return zero without touching the pool, got %d", x)
+	}
+	f.Disable()
+	if x := p.Get(); x != 42 {
+		t.Fatalf("expected pool value, got %d", x)
+	}
+}
+
+// stubPool is a minimal Pool for fault tests.
+type stubPool struct{ gets, puts int }
+
+func (p *stubPool) Get() int  { p.gets++; return 42 }
+func (p *stubPool) Put(x int) { p.puts++ }
+
+func TestFault_Func(t *testing.T) {
+	var f Fault
+	var calls atomic.Int32
+	load := FaultyFunc(&f, func(ctx context.Context, key string) (*int, error) {
+		calls.Add(1)
+		v := len(key)
+		return &v, nil
+	})
+	cache := NewWeakCache[string, int](load)
+
+	f.Set(FaultConfig{DropRate: 1})
+	if _, err := cache.Get(context.Background(), "abc"); !errors.Is(err, ErrInjectedFault) {
+		t.Fatalf("expected dropped load to fail, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatal("dropped call should still run the function")
+	}
+	f.Disable()
+	if v, err := cache.Get(context.Background(), "abc"); err != nil || *v != 3 {
+		t.Fatalf("expected 3, got %v, %v", v, err)
+	}
+}
+
+func TestFault_Transport(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	var f Fault
+	client := &http.Client{Transport: &FaultyTransport{Fault: &f}}
+
+	f.Set(FaultConfig{ErrorRate: 1})
+	if _, err := client.Get(srv.URL); !errors.Is(err, ErrInjectedFault) {
+		t.Fatalf("expected injected error, got %v", err)
+	}
+	if hits.Load() != 0 {
+		t.Fatal("errored request should not reach the server")
+	}
+
+	f.Set(FaultConfig{DropRate: 1})
+	if _, err := client.Get(srv.URL); !errors.Is(err, ErrInjectedFault) {
+		t.Fatalf("expected lost response, got %v", err)
+	}
+	if hits.Load() != 1 {
+		t.Fatal("dropped request should reach the server")
+	}
+
+	f.Disable()
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+}
+
+func TestFault_Nil(t *testing.T) {
+	ctx := context.Background()
+	q := FaultyQueue[int](NewFiFo[int](), nil)
+	if err := q.Put(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if x, ok := q.TryGet(); !ok || x != 1 {
+		t.Fatalf("TryGet = %d, %v", x, ok)
+	}
+	fn := FaultyFunc(nil, func(_ context.Context, k string) (string, error) { return k, nil })
+	if v, err := fn(ctx, "k"); err != nil || v != "k" {
+		t.Fatalf("FaultyFunc = %q, %v", v, err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+	resp, err := (&http.Client{Transport: &FaultyTransport{}}).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	var f *Fault
+	if f.Stats() != (FaultStats{}) {
+		t.Fatal("nil Fault reported faults")
+	}
+}
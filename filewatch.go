@@ -0,0 +1,181 @@
+package generic
+
+import (
+	"cmp"
+	"context"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// FileOp is the kind of change reported in a FileEvent.
+type FileOp int
+
+const (
+	FileCreated FileOp = iota + 1
+	FileWritten
+	FileRemoved
+)
+
+func (op FileOp) String() string {
+	switch op {
+	case FileCreated:
+		return "create"
+	case FileWritten:
+		return "write"
+	case FileRemoved:
+		return "remove"
+	}
+	return "unknown"
+}
+
+// FileEvent describes a settled change to a watched file.
+type FileEvent struct {
+	Path    string
+	Op      FileOp
+	Size    int64
+	ModTime time.Time
+}
+
+// FileWatcher reports changes to files by polling their metadata, which
+// works on every platform and filesystem without extra dependencies.
+// Watched directories are scanned one level deep.
+type FileWatcher struct {
+	// Interval between polls. Defaults to one second.
+	Interval time.Duration
+	// Debounce is how long a file must stay unchanged before its event is
+	// reported, coalescing bursts of writes into one event. Defaults to
+	// Interval.
+	Debounce time.Duration
+	// Clock drives polling. Defaults to SystemClock.
+	Clock Clock
+}
+
+// WatchFiles watches paths with the default FileWatcher settings.
+func WatchFiles(ctx context.Context, paths ...string) iter.Seq[FileEvent] {
+	return (&FileWatcher{}).Watch(ctx, paths...)
+}
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+type pendingFileEvent struct {
+	ev      FileEvent
+	changed time.Time
+}
+
+// Watch returns the changes to paths as a sequence that ends when ctx is
+// done. Changes present before Watch is called are not reported. Events
+// settled in the same poll are yielded in path order.
+func (w *FileWatcher) Watch(ctx context.Context, paths ...string) iter.Seq[FileEvent] {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	debounce := w.Debounce
+	if debounce <= 0 {
+		debounce = interval
+	}
+	clock := w.Clock
+	if clock == nil {
+		clock = SystemClock
+	}
+	return func(yield func(FileEvent) bool) {
+		known := scanFiles(paths)
+		pending := make(map[string]pendingFileEvent)
+		for {
+			select {
+			case <-clock.After(interval):
+			case <-ctx.Done():
+				return
+			}
+			now := clock.Now()
+			current := scanFiles(paths)
+			for path, st := range current {
+				old, existed := known[path]
+				switch {
+				case !existed:
+					mergeFileEvent(pending, path, FileCreated, st, now)
+				case old != st:
+					mergeFileEvent(pending, path, FileWritten, st, now)
+				}
+			}
+			for path := range known {
+				if _, ok := current[path]; !ok {
+					mergeFileEvent(pending, path, FileRemoved, fileState{}, now)
+				}
+			}
+			known = current
+
+			var ready []FileEvent
+			for path, p := range pending {
+				if now.Sub(p.changed) >= debounce {
+					ready = append(ready, p.ev)
+					delete(pending, path)
+				}
+			}
+			slices.SortFunc(ready, func(a, b FileEvent) int {
+				return cmp.Compare(a.Path, b.Path)
+			})
+			for _, ev := range ready {
+				if !yield(ev) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// mergeFileEvent coalesces a new change into any unreported one for path.
+func mergeFileEvent(pending map[string]pendingFileEvent, path string, op FileOp, st fileState, now time.Time) {
+	if p, ok := pending[path]; ok {
+		switch {
+		case p.ev.Op == FileCreated && op == FileRemoved:
+			// Came and went between reports.
+			delete(pending, path)
+			return
+		case p.ev.Op == FileCreated && op == FileWritten:
+			op = FileCreated
+		case p.ev.Op == FileRemoved && op == FileCreated:
+			op = FileWritten
+		}
+	}
+	pending[path] = pendingFileEvent{
+		ev:      FileEvent{Path: path, Op: op, Size: st.size, ModTime: st.modTime},
+		changed: now,
+	}
+}
+
+func scanFiles(paths []string) map[string]fileState {
+	files := make(map[string]fileState)
+	add := func(path string, info os.FileInfo) {
+		files[path] = fileState{size: info.Size(), modTime: info.ModTime()}
+	}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			add(path, info)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			if info, err := e.Info(); err == nil {
+				add(filepath.Join(path, e.Name()), info)
+			}
+		}
+	}
+	return files
+}
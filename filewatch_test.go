@@ -0,0 +1,152 @@
+package generic
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fileWatchHarness runs a FileWatcher on a test clock and collects events.
+type fileWatchHarness struct {
+	t      *testing.T
+	clock  *testClock
+	events chan FileEvent
+}
+
+func startFileWatch(t *testing.T, debounce time.Duration, paths ...string) *fileWatchHarness {
+	t.Helper()
+	h := &fileWatchHarness{t: t, clock: newTestClock(), events: make(chan FileEvent, 16)}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	w := &FileWatcher{Interval: time.Second, Debounce: debounce, Clock: h.clock}
+	seq := w.Watch(ctx, paths...)
+	go func() {
+		for ev := range seq {
+			h.events <- ev
+		}
+	}()
+	// Wait for the initial scan so later changes are seen as changes.
+	for h.clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	return h
+}
+
+// poll advances the clock by one interval once the watcher is waiting.
+func (h *fileWatchHarness) poll() {
+	h.t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for h.clock.Waiters() == 0 {
+		if time.Now().After(deadline) {
+			h.t.Fatal("watcher is not polling")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	h.clock.Advance(time.Second)
+	// Wait for the watcher to finish this poll and park again.
+	for h.clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (h *fileWatchHarness) expect(path string, op FileOp) {
+	h.t.Helper()
+	select {
+	case ev := <-h.events:
+		if ev.Path != path || ev.Op != op {
+			h.t.Fatalf("expected %s %s, got %s %s", op, path, ev.Op, ev.Path)
+		}
+	case <-time.After(time.Second):
+		h.t.Fatalf("timed out waiting for %s %s", op, path)
+	}
+}
+
+func (h *fileWatchHarness) expectNone() {
+	h.t.Helper()
+	select {
+	case ev := <-h.events:
+		h.t.Fatalf("unexpected event %s %s", ev.Op, ev.Path)
+	default:
+	}
+}
+
+func touch(t *testing.T, path, content string, mod time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileWatcher_CreateWriteRemove(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.conf")
+	touch(t, existing, "v1", time.Unix(100, 0))
+
+	h := startFileWatch(t, time.Second, dir)
+	h.poll()
+	h.expectNone()
+
+	created := filepath.Join(dir, "new.conf")
+	touch(t, created, "hello", time.Unix(200, 0))
+	h.poll() // detected
+	h.poll() // settled
+	h.expect(created, FileCreated)
+
+	touch(t, existing, "v2", time.Unix(300, 0))
+	h.poll()
+	h.poll()
+	h.expect(existing, FileWritten)
+
+	os.Remove(created)
+	h.poll()
+	h.poll()
+	h.expect(created, FileRemoved)
+}
+
+func TestFileWatcher_Debounce(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.conf")
+	touch(t, path, "v1", time.Unix(100, 0))
+
+	h := startFileWatch(t, 3*time.Second, path)
+	for i := range 3 {
+		touch(t, path, "v"+string(rune('2'+i)), time.Unix(int64(200+i), 0))
+		h.poll()
+	}
+	h.expectNone()
+
+	// Two quiet polls are not enough; the third settles the write.
+	h.poll()
+	h.poll()
+	h.expectNone()
+	h.poll()
+	h.expect(path, FileWritten)
+	h.expectNone()
+}
+
+func TestFileWatcher_CreateThenRemoveIsDropped(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tmp")
+
+	h := startFileWatch(t, 2*time.Second, dir)
+	touch(t, path, "x", time.Unix(100, 0))
+	h.poll()
+	os.Remove(path)
+	h.poll()
+	h.poll()
+	h.poll()
+	h.expectNone()
+}
+
+func TestFileOp_String(t *testing.T) {
+	for op, want := range map[FileOp]string{FileCreated: "create", FileWritten: "write", FileRemoved: "remove", 0: "unknown"} {
+		if got := op.String(); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+}
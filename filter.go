@@ -0,0 +1,419 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+var ErrFilterSyntax = errors.New("filter syntax error")
+
+// Filter is a predicate over T compiled from an expression such as
+//
+//	priority >= 2 && (region == "eu" || !internal)
+//
+// Operands are struct fields, named by Go name or json tag and joined with
+// dots for nested structs, compared against number, string or bool
+// literals with == != < <= > >=. A bool field may stand alone. Terms
+// combine with &&, || and !, and group with parentheses. Field lookups are
+// resolved once at compile time, so Match only walks cached field indexes.
+type Filter[T any] struct {
+	expr  string
+	match func(reflect.Value) bool
+}
+
+// CompileFilter parses expr for T, which must be a struct or a pointer to
+// one. Unknown fields and literals that don't fit their field's type are
+// reported here rather than at Match time.
+func CompileFilter[T any](expr string) (*Filter[T], error) {
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("generic: Filter needs a struct type, got %v", typ)
+	}
+	p := &filterParser{typ: typ, toks: lexFilter(expr)}
+	match, err := p.parseOr()
+	if err == nil && p.peek().kind != tokEOF {
+		err = p.errorf("unexpected %q", p.peek().text)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Filter[T]{expr: expr, match: match}, nil
+}
+
+// MustCompileFilter is CompileFilter that panics on error.
+func MustCompileFilter[T any](expr string) *Filter[T] {
+	f, err := CompileFilter[T](expr)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// Match reports whether x satisfies the filter. A nil pointer never
+// matches.
+func (f *Filter[T]) Match(x T) bool {
+	v := reflect.ValueOf(&x).Elem()
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	return f.match(v)
+}
+
+func (f *Filter[T]) String() string { return f.expr }
+
+type filterTokKind int
+
+const (
+	tokEOF filterTokKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOp
+	tokErr
+)
+
+type filterTok struct {
+	kind filterTokKind
+	text string
+	pos  int
+}
+
+func lexFilter(s string) []filterTok {
+	var toks []filterTok
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(s) && s[j] != s[i] {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				return append(toks, filterTok{tokErr, "unterminated string", i})
+			}
+			text := s[i+1 : j]
+			if c == '"' {
+				uq, err := strconv.Unquote(s[i : j+1])
+				if err != nil {
+					return append(toks, filterTok{tokErr, "bad string", i})
+				}
+				text = uq
+			}
+			toks = append(toks, filterTok{tokString, text, i})
+			i = j + 1
+		case c == '-' || c == '.' || unicode.IsDigit(c):
+			j := i + 1
+			for j < len(s) && (s[j] == '.' || s[j] == 'e' || s[j] == 'E' || unicode.IsDigit(rune(s[j])) ||
+				(s[j] == '-' || s[j] == '+') && (s[j-1] == 'e' || s[j-1] == 'E')) {
+				j++
+			}
+			toks = append(toks, filterTok{tokNumber, s[i:j], i})
+			i = j
+		case c == '_' || unicode.IsLetter(c):
+			j := i + 1
+			for j < len(s) && (s[j] == '_' || s[j] == '.' || unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j]))) {
+				j++
+			}
+			toks = append(toks, filterTok{tokIdent, s[i:j], i})
+			i = j
+		default:
+			op := ""
+			for _, o := range []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")"} {
+				if strings.HasPrefix(s[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return append(toks, filterTok{tokErr, fmt.Sprintf("unexpected %q", c), i})
+			}
+			toks = append(toks, filterTok{tokOp, op, i})
+			i += len(op)
+		}
+	}
+	return append(toks, filterTok{tokEOF, "end of expression", len(s)})
+}
+
+type filterParser struct {
+	typ  reflect.Type
+	toks []filterTok
+	pos  int
+}
+
+func (p *filterParser) peek() filterTok { return p.toks[p.pos] }
+
+func (p *filterParser) next() filterTok {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF && t.kind != tokErr {
+		p.pos++
+	}
+	return t
+}
+
+func (p *filterParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w at %d: %s", ErrFilterSyntax, p.peek().pos, fmt.Sprintf(format, args...))
+}
+
+func (p *filterParser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokOp && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) parseOr() (func(reflect.Value) bool, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.accept("||") {
+		var right func(reflect.Value) bool
+		if right, err = p.parseAnd(); err == nil {
+			l := left
+			left = func(v reflect.Value) bool { return l(v) || right(v) }
+		}
+	}
+	return left, err
+}
+
+func (p *filterParser) parseAnd() (func(reflect.Value) bool, error) {
+	left, err := p.parseUnary()
+	for err == nil && p.accept("&&") {
+		var right func(reflect.Value) bool
+		if right, err = p.parseUnary(); err == nil {
+			l := left
+			left = func(v reflect.Value) bool { return l(v) && right(v) }
+		}
+	}
+	return left, err
+}
+
+func (p *filterParser) parseUnary() (func(reflect.Value) bool, error) {
+	if p.accept("!") {
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(v reflect.Value) bool { return !inner(v) }, nil
+	}
+	if p.accept("(") {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, p.errorf("expected )")
+		}
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (func(reflect.Value) bool, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokErr:
+		return nil, p.errorf("%s", t.text)
+	case tokIdent:
+	default:
+		return nil, p.errorf("expected field, got %q", t.text)
+	}
+	p.next()
+	index, ftyp, err := filterField(p.typ, t.text)
+	if err != nil {
+		return nil, p.errorf("%v", err)
+	}
+	get := func(v reflect.Value) (reflect.Value, bool) {
+		for _, i := range index {
+			if v.Kind() == reflect.Pointer {
+				if v.IsNil() {
+					return v, false
+				}
+				v = v.Elem()
+			}
+			v = v.Field(i)
+		}
+		return v, true
+	}
+
+	op := p.peek()
+	test, isCmp := filterOps[op.text]
+	if op.kind != tokOp || !isCmp {
+		if ftyp.Kind() != reflect.Bool {
+			return nil, p.errorf("field %s is not a bool and needs a comparison", t.text)
+		}
+		return func(v reflect.Value) bool {
+			f, ok := get(v)
+			return ok && f.Bool()
+		}, nil
+	}
+	p.next()
+	lit := p.next()
+	if lit.kind == tokErr {
+		return nil, p.errorf("%s", lit.text)
+	}
+	cmp, err := filterCompare(ftyp, lit)
+	if err != nil {
+		return nil, p.errorf("field %s: %v", t.text, err)
+	}
+	return func(v reflect.Value) bool {
+		f, ok := get(v)
+		return ok && test(cmp(f))
+	}, nil
+}
+
+var filterOps = map[string]func(int) bool{
+	"==": func(c int) bool { return c == 0 },
+	"!=": func(c int) bool { return c != 0 },
+	"<":  func(c int) bool { return c < 0 },
+	"<=": func(c int) bool { return c <= 0 },
+	">":  func(c int) bool { return c > 0 },
+	">=": func(c int) bool { return c >= 0 },
+}
+
+// filterField resolves a dotted field path to its index chain.
+func filterField(typ reflect.Type, path string) ([]int, reflect.Type, error) {
+	var index []int
+	for name := range strings.SplitSeq(path, ".") {
+		if typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct {
+			return nil, nil, fmt.Errorf("%s: %v has no fields", path, typ)
+		}
+		f, ok := lookupFilterField(typ, name)
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown field %s", path)
+		}
+		index = append(index, f.Index...)
+		typ = f.Type
+	}
+	return index, typ, nil
+}
+
+func lookupFilterField(typ reflect.Type, name string) (reflect.StructField, bool) {
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if f.Name == name || tag == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// filterCompare returns a three-way comparison of a field of type typ
+// against lit.
+func filterCompare(typ reflect.Type, lit filterTok) (func(reflect.Value) int, error) {
+	switch typ.Kind() {
+	case reflect.String:
+		if lit.kind != tokString {
+			return nil, fmt.Errorf("expected string, got %q", lit.text)
+		}
+		return func(v reflect.Value) int { return strings.Compare(v.String(), lit.text) }, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(lit.text)
+		if lit.kind != tokIdent || err != nil {
+			return nil, fmt.Errorf("expected true or false, got %q", lit.text)
+		}
+		return func(v reflect.Value) int {
+			if v.Bool() == b {
+				return 0
+			}
+			return 1
+		}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		if lit.kind != tokNumber {
+			return nil, fmt.Errorf("expected number, got %q", lit.text)
+		}
+		n, err := strconv.ParseFloat(lit.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad number %q", lit.text)
+		}
+		return func(v reflect.Value) int {
+			var f float64
+			switch {
+			case v.CanInt():
+				f = float64(v.Int())
+			case v.CanUint():
+				f = float64(v.Uint())
+			default:
+				f = v.Float()
+			}
+			switch {
+			case f < n:
+				return -1
+			case f > n:
+				return 1
+			}
+			return 0
+		}, nil
+	}
+	return nil, fmt.Errorf("cannot compare %v", typ)
+}
+
+// FilterQueue wraps a Queue so consumers only see items matching its
+// current Filter. Items that don't match are discarded as they are read.
+// The filter can be replaced at any time with SetFilter.
+type FilterQueue[T any] struct {
+	Queue[T]
+	filter atomic.Pointer[Filter[T]]
+}
+
+// NewFilterQueue returns q filtered by f. A nil f passes everything.
+func NewFilterQueue[T any](q Queue[T], f *Filter[T]) *FilterQueue[T] {
+	fq := &FilterQueue[T]{Queue: q}
+	fq.filter.Store(f)
+	return fq
+}
+
+// SetFilter replaces the filter applied to subsequent reads.
+func (q *FilterQueue[T]) SetFilter(f *Filter[T]) {
+	q.filter.Store(f)
+}
+
+func (q *FilterQueue[T]) matches(x T) bool {
+	f := q.filter.Load()
+	return f == nil || f.Match(x)
+}
+
+// Get returns the next matching item.
+func (q *FilterQueue[T]) Get(ctx context.Context) (T, error) {
+	for {
+		x, err := q.Queue.Get(ctx)
+		if err != nil || q.matches(x) {
+			return x, err
+		}
+	}
+}
+
+// TryGet returns the next matching item without blocking, discarding
+// non-matching items until it finds one or the queue is empty.
+func (q *FilterQueue[T]) TryGet() (T, bool) {
+	for {
+		x, ok := q.Queue.TryGet()
+		if !ok || q.matches(x) {
+			return x, ok
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type filterEvent struct {
+	Kind     string `json:"kind"`
+	Priority int    `json:"priority"`
+	Score    float64
+	Internal bool
+	Meta     *filterMeta `json:"meta"`
+}
+
+type filterMeta struct {
+	Region string `json:"region"`
+}
+
+func TestFilter_Match(t *testing.T) {
+	ev := filterEvent{Kind: "order", Priority: 3, Score: 0.5, Meta: &filterMeta{Region: "eu"}}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`kind == "order"`, true},
+		{`Kind != 'order'`, false},
+		{`priority >= 3 && Score < 1`, true},
+		{`priority > 3 || Internal`, false},
+		{`!Internal && (priority == 1 || meta.region == "eu")`, true},
+		{`Score <= -1.5e2`, false},
+		{`Internal == false`, true},
+		{`kind < "p"`, true},
+	}
+	for _, tt := range tests {
+		f, err := CompileFilter[filterEvent](tt.expr)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.expr, err)
+		}
+		if got := f.Match(ev); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.expr, got, tt.want)
+		}
+		if got := MustCompileFilter[*filterEvent](tt.expr).Match(&ev); got != tt.want {
+			t.Errorf("%s on pointer = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestFilter_NilPointers(t *testing.T) {
+	f := MustCompileFilter[*filterEvent](`meta.region == "eu"`)
+	if f.Match(nil) || f.Match(&filterEvent{}) {
+		t.Fatal("nil values should not match")
+	}
+}
+
+func TestFilter_CompileErrors(t *testing.T) {
+	for _, expr := range []string{
+		``,
+		`missing == 1`,
+		`priority == "x"`,
+		`kind == 1`,
+		`kind`,
+		`priority == 1 &&`,
+		`(priority == 1`,
+		`kind == "open`,
+		`priority # 1`,
+		`Internal == maybe`,
+	} {
+		if _, err := CompileFilter[filterEvent](expr); !errors.Is(err, ErrFilterSyntax) {
+			t.Errorf("%q: expected syntax error, got %v", expr, err)
+		}
+	}
+	if _, err := CompileFilter[int]("x == 1"); err == nil {
+		t.Error("non-struct type should be rejected")
+	}
+}
+
+func TestFilterQueue(t *testing.T) {
+	inner := NewFiFo[filterEvent]()
+	q := NewFilterQueue[filterEvent](inner, MustCompileFilter[filterEvent](`priority > 1`))
+	ctx := context.Background()
+	for i := range 5 {
+		q.Put(ctx, filterEvent{Priority: i})
+	}
+	x, err := q.Get(ctx)
+	if err != nil || x.Priority != 2 {
+		t.Fatalf("expected priority 2, got %v %v", x, err)
+	}
+	q.SetFilter(MustCompileFilter[filterEvent](`priority == 4`))
+	if x, ok := q.TryGet(); !ok || x.Priority != 4 {
+		t.Fatalf("expected priority 4 after SetFilter, got %v %v", x, ok)
+	}
+	q.SetFilter(nil)
+	q.Put(ctx, filterEvent{Priority: 0})
+	if _, ok := q.TryGet(); !ok {
+		t.Fatal("nil filter should pass everything")
+	}
+	if _, ok := q.TryGet(); ok {
+		t.Fatal("queue should be empty")
+	}
+}
+
+func BenchmarkFilter_Match(b *testing.B) {
+	f := MustCompileFilter[filterEvent](`priority >= 2 && (meta.region == "eu" || !Internal)`)
+	ev := filterEvent{Priority: 3, Meta: &filterMeta{Region: "us"}}
+	for b.Loop() {
+		f.Match(ev)
+	}
+}
@@ -0,0 +1,68 @@
+package generic
+
+import "context"
+
+// Bind1 fixes the first argument of fn.
+func Bind1[A, B, R any](fn func(A, B) R, a A) func(B) R {
+	return func(b B) R { return fn(a, b) }
+}
+
+// Bind2 fixes the second argument of fn.
+func Bind2[A, B, R any](fn func(A, B) R, b B) func(A) R {
+	return func(a A) R { return fn(a, b) }
+}
+
+// Compose returns g after f.
+func Compose[A, B, C any](f func(A) B, g func(B) C) func(A) C {
+	return func(a A) C { return g(f(a)) }
+}
+
+// ComposeContext chains two fallible, context-aware steps, stopping at the
+// first error.
+func ComposeContext[A, B, C any](f func(context.Context, A) (B, error), g func(context.Context, B) (C, error)) func(context.Context, A) (C, error) {
+	return func(ctx context.Context, a A) (C, error) {
+		b, err := f(ctx, a)
+		if err != nil {
+			var zero C
+			return zero, err
+		}
+		return g(ctx, b)
+	}
+}
+
+// IgnoreContext adapts fn to the consumer signature used by
+// ShardedQueue.Consume and ConsumeEnvelopes.
+func IgnoreContext[T any](fn func(T)) func(context.Context, T) {
+	return func(_ context.Context, x T) { fn(x) }
+}
+
+// NoError adapts fn to a signature that returns an error, always nil.
+func NoError[T any](fn func(context.Context, T)) func(context.Context, T) error {
+	return func(ctx context.Context, x T) error {
+		fn(ctx, x)
+		return nil
+	}
+}
+
+// OnError adapts a fallible handler to the consumer signature, passing
+// failures to handle along with the item that caused them.
+func OnError[T any](fn func(context.Context, T) error, handle func(ctx context.Context, x T, err error)) func(context.Context, T) {
+	return func(ctx context.Context, x T) {
+		if err := fn(ctx, x); err != nil {
+			handle(ctx, x, err)
+		}
+	}
+}
+
+// BusHandlerFunc adapts a typed command handler to a BusHandler, for use
+// with middleware written against the untyped signature. A command of the
+// wrong type fails with ErrHandlerType.
+func BusHandlerFunc[TCmd, TResp any](fn func(ctx context.Context, cmd TCmd) (TResp, error)) BusHandler {
+	return func(ctx context.Context, cmd any) (any, error) {
+		c, ok := cmd.(TCmd)
+		if !ok {
+			return nil, ErrHandlerType
+		}
+		return fn(ctx, c)
+	}
+}
@@ -0,0 +1,72 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestBindCompose(t *testing.T) {
+	sub := func(a, b int) int { return a - b }
+	if got := Bind1(sub, 10)(3); got != 7 {
+		t.Fatalf("Bind1 = %d, want 7", got)
+	}
+	if got := Bind2(sub, 10)(3); got != -7 {
+		t.Fatalf("Bind2 = %d, want -7", got)
+	}
+	f := Compose(Bind2(sub, 1), strconv.Itoa)
+	if got := f(5); got != "4" {
+		t.Fatalf("Compose = %q, want 4", got)
+	}
+}
+
+func TestComposeContext(t *testing.T) {
+	parse := func(_ context.Context, s string) (int, error) { return strconv.Atoi(s) }
+	double := func(_ context.Context, n int) (int, error) { return 2 * n, nil }
+	f := ComposeContext(parse, double)
+	if got, err := f(context.Background(), "21"); err != nil || got != 42 {
+		t.Fatalf("got %d, %v", got, err)
+	}
+	if _, err := f(context.Background(), "x"); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
+
+func TestConsumerAdapters(t *testing.T) {
+	ctx := context.Background()
+	var seen []int
+	IgnoreContext(func(x int) { seen = append(seen, x) })(ctx, 1)
+	if err := NoError(IgnoreContext(func(x int) { seen = append(seen, x) }))(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	boom := errors.New("boom")
+	var failed []int
+	h := OnError(func(_ context.Context, x int) error {
+		if x%2 == 1 {
+			return boom
+		}
+		seen = append(seen, x)
+		return nil
+	}, func(_ context.Context, x int, err error) {
+		if !errors.Is(err, boom) {
+			t.Errorf("err = %v", err)
+		}
+		failed = append(failed, x)
+	})
+	h(ctx, 3)
+	h(ctx, 4)
+	if len(seen) != 3 || seen[2] != 4 || len(failed) != 1 || failed[0] != 3 {
+		t.Fatalf("seen %v, failed %v", seen, failed)
+	}
+}
+
+func TestBusHandlerFunc(t *testing.T) {
+	h := BusHandlerFunc(func(_ context.Context, n int) (string, error) { return strconv.Itoa(n), nil })
+	if got, err := h(context.Background(), 7); err != nil || got != "7" {
+		t.Fatalf("got %v, %v", got, err)
+	}
+	if _, err := h(context.Background(), "7"); !errors.Is(err, ErrHandlerType) {
+		t.Fatalf("err = %v, want ErrHandlerType", err)
+	}
+}
@@ -0,0 +1,91 @@
+package generictest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+// The assertions below report through t.Errorf, so a test carries on and
+// can report several failures, and return whether they passed for callers
+// that want to stop early. msg, if given, is formatted with fmt.Sprint and
+// prefixed to the failure.
+
+// Equal checks that got == want.
+func Equal[T comparable](t testing.TB, got, want T, msg ...any) bool {
+	t.Helper()
+	if got == want {
+		return true
+	}
+	t.Errorf("%sgot %#v, want %#v", prefix(msg), got, want)
+	return false
+}
+
+// DeepEqual checks that got and want are equal by reflect.DeepEqual, for
+// slices, maps and structs holding them.
+func DeepEqual[T any](t testing.TB, got, want T, msg ...any) bool {
+	t.Helper()
+	if reflect.DeepEqual(got, want) {
+		return true
+	}
+	t.Errorf("%sgot %#v, want %#v", prefix(msg), got, want)
+	return false
+}
+
+// ErrorIs checks that errors.Is(err, target). A nil target checks that err
+// is nil.
+func ErrorIs(t testing.TB, err, target error, msg ...any) bool {
+	t.Helper()
+	if errors.Is(err, target) {
+		return true
+	}
+	t.Errorf("%sgot error %v, want %v", prefix(msg), err, target)
+	return false
+}
+
+// Contains checks that s holds v.
+func Contains[T comparable](t testing.TB, s []T, v T, msg ...any) bool {
+	t.Helper()
+	if slices.Contains(s, v) {
+		return true
+	}
+	t.Errorf("%s%#v does not contain %#v", prefix(msg), s, v)
+	return false
+}
+
+// Eventually polls cond until it reports true, failing the test if ctx is
+// done first. cond runs on the calling goroutine; state driven by a
+// FakeClock must be moved along by another goroutine or inside cond.
+func Eventually(t testing.TB, ctx context.Context, cond func() bool, msg ...any) {
+	t.Helper()
+	tick := time.NewTicker(time.Millisecond)
+	defer tick.Stop()
+	for !cond() {
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			t.Fatalf("generictest: %scondition not met: %v", prefix(msg), context.Cause(ctx))
+			return
+		}
+	}
+}
+
+// Eventually polls cond until it reports true, failing the test after
+// timeout of real time.
+func (h *Harness) Eventually(timeout time.Duration, cond func() bool, msg ...any) {
+	h.T.Helper()
+	ctx, cancel := context.WithTimeout(h.Ctx, timeout)
+	defer cancel()
+	Eventually(h.T, ctx, cond, msg...)
+}
+
+func prefix(msg []any) string {
+	if len(msg) == 0 {
+		return ""
+	}
+	return fmt.Sprint(msg...) + ": "
+}
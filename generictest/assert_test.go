@@ -0,0 +1,95 @@
+package generictest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recorder is a testing.TB that records failures instead of reporting them.
+type recorder struct {
+	testing.TB
+	failures []string
+	fatal    bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+	r.fatal = true
+}
+
+func TestAssertions_Pass(t *testing.T) {
+	r := &recorder{TB: t}
+	ok := Equal(r, 3, 3) &&
+		DeepEqual(r, map[string][]int{"a": {1}}, map[string][]int{"a": {1}}) &&
+		ErrorIs(r, fmt.Errorf("wrapped: %w", io.EOF), io.EOF) &&
+		ErrorIs(r, nil, nil) &&
+		Contains(r, []string{"a", "b"}, "b")
+	if !ok || len(r.failures) != 0 {
+		t.Fatalf("unexpected failures %q", r.failures)
+	}
+}
+
+func TestAssertions_Fail(t *testing.T) {
+	r := &recorder{TB: t}
+	if Equal(r, "got", "want", "field ", 1) {
+		t.Fatal("Equal passed on different values")
+	}
+	if DeepEqual(r, []int{1}, []int{2}) {
+		t.Fatal("DeepEqual passed on different slices")
+	}
+	if ErrorIs(r, errors.New("other"), io.EOF) {
+		t.Fatal("ErrorIs passed on an unrelated error")
+	}
+	if Contains(r, []int{1, 2}, 3) {
+		t.Fatal("Contains passed on a missing value")
+	}
+	if len(r.failures) != 4 || r.fatal {
+		t.Fatalf("got failures %q, fatal %v", r.failures, r.fatal)
+	}
+	if want := `field 1: got "got", want "want"`; r.failures[0] != want {
+		t.Fatalf("got message %q, want %q", r.failures[0], want)
+	}
+}
+
+func TestEventually(t *testing.T) {
+	h := New(t)
+	var n atomic.Int32
+	h.Go(func(ctx context.Context) error {
+		for range 3 {
+			n.Add(1)
+		}
+		return nil
+	})
+	h.Eventually(time.Second, func() bool { return n.Load() == 3 })
+
+	r := &recorder{TB: t}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	Eventually(r, ctx, func() bool { return false }, "never")
+	if !r.fatal || len(r.failures) != 1 || !strings.Contains(r.failures[0], "never: condition not met") {
+		t.Fatalf("got failures %q, fatal %v", r.failures, r.fatal)
+	}
+}
+
+func TestEventually_FakeClock(t *testing.T) {
+	h := New(t)
+	m, _ := NewExpiringMap[string, int](h)
+	m.Set("k", 1, time.Second)
+	h.Eventually(time.Second, func() bool {
+		h.Advance(100 * time.Millisecond)
+		_, ok := m.Load("k")
+		return !ok
+	})
+}
@@ -0,0 +1,52 @@
+package generictest
+
+import "sync"
+
+// Capture records values passed to a hook so tests can assert on them.
+type Capture[T any] struct {
+	mu     sync.Mutex
+	values []T
+	signal chan struct{}
+}
+
+// Record appends v. It is safe to use directly as a callback.
+func (c *Capture[T]) Record(v T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values = append(c.values, v)
+	if c.signal != nil {
+		close(c.signal)
+		c.signal = nil
+	}
+}
+
+// All returns a copy of the recorded values.
+func (c *Capture[T]) All() []T {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]T(nil), c.values...)
+}
+
+// Len returns the number of recorded values.
+func (c *Capture[T]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.values)
+}
+
+// Reset discards the recorded values.
+func (c *Capture[T]) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values = nil
+}
+
+// changed returns a channel closed on the next Record.
+func (c *Capture[T]) changed() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.signal == nil {
+		c.signal = make(chan struct{})
+	}
+	return c.signal
+}
@@ -0,0 +1,56 @@
+package generictest
+
+import (
+	"testing"
+	"time"
+
+	generic "github.com/agentflare-ai/go-generic"
+)
+
+var _ generic.Clock = (*FakeClock)(nil)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Unix(100, 0)
+	c := NewFakeClock(start)
+	if !c.Now().Equal(start) {
+		t.Fatalf("expected %v, got %v", start, c.Now())
+	}
+
+	late := c.After(2 * time.Second)
+	early := c.After(time.Second)
+	if c.Waiters() != 2 {
+		t.Fatalf("expected 2 waiters, got %d", c.Waiters())
+	}
+	if got := c.Afters.All(); len(got) != 2 || got[0] != 2*time.Second || got[1] != time.Second {
+		t.Fatalf("unexpected recorded durations %v", got)
+	}
+
+	c.Advance(time.Second)
+	select {
+	case <-early:
+	default:
+		t.Fatal("expected early timer to fire")
+	}
+	select {
+	case <-late:
+		t.Fatal("late timer fired too soon")
+	default:
+	}
+
+	c.Advance(time.Second)
+	if got := <-late; !got.Equal(start.Add(2 * time.Second)) {
+		t.Fatalf("unexpected fire time %v", got)
+	}
+
+	select {
+	case <-c.After(0):
+	default:
+		t.Fatal("expected non-positive After to fire immediately")
+	}
+}
+
+func TestFakeClock_DefaultStart(t *testing.T) {
+	if NewFakeClock(time.Time{}).Now().IsZero() {
+		t.Fatal("expected a non-zero default start")
+	}
+}
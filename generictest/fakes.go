@@ -0,0 +1,172 @@
+package generictest
+
+import (
+	"context"
+	"sync"
+
+	generic "github.com/agentflare-ai/go-generic"
+)
+
+// FakeQueue is a generic.Queue backed by a real FiFo that records every item
+// going in and out. Setting PutErr or GetErr makes the matching blocking
+// call fail without touching the queue. The zero value is ready to use.
+type FakeQueue[T any] struct {
+	// Puts records items accepted by Put and TryPut.
+	Puts Capture[T]
+	// Gets records items returned by Get and TryGet.
+	Gets Capture[T]
+
+	PutErr error
+	GetErr error
+
+	once sync.Once
+	q    *generic.FiFo[T]
+}
+
+var _ generic.Queue[int] = (*FakeQueue[int])(nil)
+
+func (f *FakeQueue[T]) fifo() *generic.FiFo[T] {
+	f.once.Do(func() { f.q = generic.NewFiFo[T]() })
+	return f.q
+}
+
+func (f *FakeQueue[T]) Put(ctx context.Context, x T) error {
+	if f.PutErr != nil {
+		return f.PutErr
+	}
+	if err := f.fifo().Put(ctx, x); err != nil {
+		return err
+	}
+	f.Puts.Record(x)
+	return nil
+}
+
+func (f *FakeQueue[T]) TryPut(x T) bool {
+	if !f.fifo().TryPut(x) {
+		return false
+	}
+	f.Puts.Record(x)
+	return true
+}
+
+func (f *FakeQueue[T]) Get(ctx context.Context) (T, error) {
+	if f.GetErr != nil {
+		var zero T
+		return zero, f.GetErr
+	}
+	x, err := f.fifo().Get(ctx)
+	if err == nil {
+		f.Gets.Record(x)
+	}
+	return x, err
+}
+
+func (f *FakeQueue[T]) TryGet() (T, bool) {
+	x, ok := f.fifo().TryGet()
+	if ok {
+		f.Gets.Record(x)
+	}
+	return x, ok
+}
+
+func (f *FakeQueue[T]) IsEmpty() bool { return f.fifo().IsEmpty() }
+func (f *FakeQueue[T]) Size() int     { return f.fifo().Size() }
+
+// FakeLimiter is a generic.Limiter that admits everything until Deny is
+// called. While denying, Wait blocks until Permit or ctx is done. The zero
+// value is ready to use.
+type FakeLimiter struct {
+	// Allows records the results returned by Allow.
+	Allows Capture[bool]
+	// Waits records the results returned by Wait.
+	Waits Capture[error]
+
+	mu      sync.Mutex
+	deny    bool
+	permits chan struct{} // closed by Permit; nil while permitting
+}
+
+var _ generic.Limiter = (*FakeLimiter)(nil)
+
+// Deny makes the limiter reject Allow and block Wait.
+func (l *FakeLimiter) Deny() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.deny {
+		l.deny = true
+		l.permits = make(chan struct{})
+	}
+}
+
+// Permit makes the limiter admit everything again, releasing blocked Waits.
+func (l *FakeLimiter) Permit() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.deny {
+		l.deny = false
+		close(l.permits)
+		l.permits = nil
+	}
+}
+
+func (l *FakeLimiter) Allow() bool {
+	l.mu.Lock()
+	ok := !l.deny
+	l.mu.Unlock()
+	l.Allows.Record(ok)
+	return ok
+}
+
+func (l *FakeLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	permits := l.permits
+	l.mu.Unlock()
+	var err error
+	if permits != nil {
+		select {
+		case <-permits:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	l.Waits.Record(err)
+	return err
+}
+
+// ScriptedPool is a generic.Pool whose Get returns Script in order, then
+// values from New (or the zero value) once the script runs out. Put values
+// are recorded but never handed out again, so tests see exactly the scripted
+// sequence.
+type ScriptedPool[T any] struct {
+	Script []T
+	New    func() T
+
+	// Gets records values returned by Get.
+	Gets Capture[T]
+	// Puts records values passed to Put.
+	Puts Capture[T]
+
+	mu   sync.Mutex
+	next int
+}
+
+var _ generic.Pool[int] = (*ScriptedPool[int])(nil)
+
+func (p *ScriptedPool[T]) Get() T {
+	p.mu.Lock()
+	var x T
+	switch {
+	case p.next < len(p.Script):
+		x = p.Script[p.next]
+		p.next++
+	case p.New != nil:
+		x = p.New()
+	}
+	p.mu.Unlock()
+	p.Gets.Record(x)
+	return x
+}
+
+func (p *ScriptedPool[T]) Put(x T) {
+	p.Puts.Record(x)
+}
@@ -0,0 +1,100 @@
+package generictest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFakeQueue(t *testing.T) {
+	var q FakeQueue[string]
+	ctx := context.Background()
+
+	q.Put(ctx, "a")
+	q.TryPut("b")
+	if q.Size() != 2 || q.IsEmpty() {
+		t.Fatalf("expected 2 queued items, got %d", q.Size())
+	}
+	if x, err := q.Get(ctx); err != nil || x != "a" {
+		t.Fatalf("expected a, got %q, %v", x, err)
+	}
+	if x, ok := q.TryGet(); !ok || x != "b" {
+		t.Fatalf("expected b, got %q, %v", x, ok)
+	}
+	if got := q.Puts.All(); !slices.Equal(got, []string{"a", "b"}) {
+		t.Fatalf("unexpected puts %v", got)
+	}
+	if got := q.Gets.All(); !slices.Equal(got, []string{"a", "b"}) {
+		t.Fatalf("unexpected gets %v", got)
+	}
+
+	boom := errors.New("boom")
+	q.PutErr, q.GetErr = boom, boom
+	if err := q.Put(ctx, "c"); !errors.Is(err, boom) {
+		t.Fatalf("expected injected put error, got %v", err)
+	}
+	if _, err := q.Get(ctx); !errors.Is(err, boom) {
+		t.Fatalf("expected injected get error, got %v", err)
+	}
+	if q.Puts.Len() != 2 || q.Gets.Len() != 2 {
+		t.Fatal("failed calls should not be recorded")
+	}
+}
+
+func TestFakeLimiter(t *testing.T) {
+	var l FakeLimiter
+	if !l.Allow() {
+		t.Fatal("zero limiter should allow")
+	}
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("unexpected wait error %v", err)
+	}
+
+	l.Deny()
+	if l.Allow() {
+		t.Fatal("denying limiter should reject")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	done := make(chan error)
+	go func() { done <- l.Wait(context.Background()) }()
+	l.Permit()
+	if err := <-done; err != nil {
+		t.Fatalf("expected Permit to release Wait, got %v", err)
+	}
+
+	if got := l.Allows.All(); !slices.Equal(got, []bool{true, false}) {
+		t.Fatalf("unexpected allow results %v", got)
+	}
+	if got := l.Waits.All(); len(got) != 3 || got[0] != nil || got[1] == nil || got[2] != nil {
+		t.Fatalf("unexpected wait results %v", got)
+	}
+}
+
+func TestScriptedPool(t *testing.T) {
+	p := ScriptedPool[int]{Script: []int{1, 2}}
+	got := []int{p.Get(), p.Get(), p.Get()}
+	if !slices.Equal(got, []int{1, 2, 0}) {
+		t.Fatalf("unexpected gets %v", got)
+	}
+	p.New = func() int { return 7 }
+	if x := p.Get(); x != 7 {
+		t.Fatalf("expected New value 7, got %d", x)
+	}
+	p.Put(1)
+	if x := p.Get(); x != 7 {
+		t.Fatalf("Put values must not be reused, got %d", x)
+	}
+	if got := p.Puts.All(); !slices.Equal(got, []int{1}) {
+		t.Fatalf("unexpected puts %v", got)
+	}
+	if p.Gets.Len() != 5 {
+		t.Fatalf("expected 5 recorded gets, got %d", p.Gets.Len())
+	}
+}
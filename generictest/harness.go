@@ -0,0 +1,159 @@
+// Package generictest provides fakes and a test harness for code built on
+// package generic.
+package generictest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	generic "github.com/agentflare-ai/go-generic"
+)
+
+// Harness bundles a FakeClock, a context cancelled when the test ends and
+// constructors for the package's queues, timers and pools wired to both,
+// with Captures recording what they do.
+type Harness struct {
+	T     testing.TB
+	Clock *FakeClock
+	// Ctx is cancelled during test cleanup, before goroutines started with
+	// Go are waited for.
+	Ctx context.Context
+
+	wg sync.WaitGroup
+}
+
+// New returns a harness for t.
+func New(t testing.TB) *Harness {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &Harness{T: t, Clock: NewFakeClock(time.Time{}), Ctx: ctx}
+	t.Cleanup(func() {
+		cancel()
+		h.wg.Wait()
+	})
+	return h
+}
+
+// Go runs fn in a goroutine with the harness context, reporting a non-nil
+// error other than context cancellation as a test failure.
+func (h *Harness) Go(fn func(ctx context.Context) error) {
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		if err := fn(h.Ctx); err != nil && h.Ctx.Err() == nil {
+			h.T.Errorf("generictest: background function failed: %v", err)
+		}
+	}()
+}
+
+// Advance moves the fake clock forward.
+func (h *Harness) Advance(d time.Duration) {
+	h.Clock.Advance(d)
+}
+
+// Eviction is an entry removed from an ExpiringMap.
+type Eviction[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// NewExpiringMap returns an ExpiringMap on the harness clock whose evictions
+// are recorded in the returned Capture.
+func NewExpiringMap[K comparable, V any](h *Harness) (*generic.ExpiringMap[K, V], *Capture[Eviction[K, V]]) {
+	evicted := &Capture[Eviction[K, V]]{}
+	m := &generic.ExpiringMap[K, V]{
+		Clock:   h.Clock,
+		OnEvict: func(k K, v V) { evicted.Record(Eviction[K, V]{Key: k, Value: v}) },
+	}
+	return m, evicted
+}
+
+// NewQueueGroup returns a QueueGroup on the harness clock.
+func NewQueueGroup[K comparable, T any](h *Harness, idle time.Duration) *generic.QueueGroup[K, T] {
+	return &generic.QueueGroup[K, T]{Clock: h.Clock, IdleTimeout: idle}
+}
+
+// NewQueue returns a FakeQueue over a FiFo built with opts, recording the
+// items going in and out in its Puts and Gets.
+func NewQueue[T any](h *Harness, opts ...generic.FiFoOption) *FakeQueue[T] {
+	f := &FakeQueue[T]{}
+	f.once.Do(func() { f.q = generic.NewFiFo[T](opts...) })
+	return f
+}
+
+// NewTimedQueue returns a TimedQueue stamping items with the harness clock.
+func NewTimedQueue[T any](h *Harness, opts ...generic.FiFoOption) *generic.TimedQueue[T] {
+	q := generic.NewTimedQueue[T](opts...)
+	q.Clock = h.Clock
+	return q
+}
+
+// NewDelayQueue returns a DelayQueue whose items become ready as the
+// harness clock advances.
+func NewDelayQueue[T any](h *Harness) *generic.DelayQueue[T] {
+	q := generic.NewDelayQueue[T]()
+	q.Clock = h.Clock
+	return q
+}
+
+// NewTimerWheel returns a TimerWheel on the harness clock, already running
+// until the test ends, whose fired values are recorded in the returned
+// Capture.
+func NewTimerWheel[T any](h *Harness, tick time.Duration) (*generic.TimerWheel[T], *Capture[T]) {
+	fired := &Capture[T]{}
+	w := generic.NewTimerWheel(tick, h.Clock, fired.Record)
+	h.Go(w.Run)
+	return w, fired
+}
+
+// NewTokenBucket returns a TokenBucket refilling on the harness clock.
+func NewTokenBucket(h *Harness, limit float64, burst int) *generic.TokenBucket {
+	return generic.NewTokenBucket(limit, burst, h.Clock)
+}
+
+// NewResourcePool returns a ResourcePool timing holds on the harness clock,
+// whose newly created resources are recorded in the returned Capture.
+func NewResourcePool[T any](h *Harness, max int, newFn func(ctx context.Context) (T, error)) (*generic.ResourcePool[T], *Capture[T]) {
+	created := &Capture[T]{}
+	p := generic.NewResourcePool(max, func(ctx context.Context) (T, error) {
+		x, err := newFn(ctx)
+		if err == nil {
+			created.Record(x)
+		}
+		return x, err
+	})
+	p.Clock = h.Clock
+	return p, created
+}
+
+// Drain removes every item currently in q without blocking.
+func Drain[T any](q generic.Queue[T]) []T {
+	var items []T
+	for {
+		x, ok := q.TryGet()
+		if !ok {
+			return items
+		}
+		items = append(items, x)
+	}
+}
+
+// WaitFor blocks until c has recorded at least n values or the timeout
+// expires, failing the test on timeout.
+func WaitFor[T any](h *Harness, c *Capture[T], n int, timeout time.Duration) []T {
+	h.T.Helper()
+	deadline := time.After(timeout)
+	for {
+		changed := c.changed()
+		if c.Len() >= n {
+			return c.All()
+		}
+		select {
+		case <-changed:
+		case <-deadline:
+			h.T.Fatalf("generictest: timed out waiting for %d captured values, have %d", n, c.Len())
+			return nil
+		}
+	}
+}
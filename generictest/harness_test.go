@@ -0,0 +1,132 @@
+package generictest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	generic "github.com/agentflare-ai/go-generic"
+)
+
+func TestHarness_ExpiringMap(t *testing.T) {
+	h := New(t)
+	m, evicted := NewExpiringMap[string, int](h)
+
+	m.Set("token", 1, time.Minute)
+	h.Advance(time.Minute)
+	if _, ok := m.Load("token"); ok {
+		t.Fatal("expected token to expire on the fake clock")
+	}
+	got := evicted.All()
+	if len(got) != 1 || got[0].Key != "token" || got[0].Value != 1 {
+		t.Fatalf("unexpected evictions %+v", got)
+	}
+}
+
+func TestHarness_JanitorOnFakeClock(t *testing.T) {
+	h := New(t)
+	m, evicted := NewExpiringMap[string, int](h)
+	m.Set("a", 1, time.Second)
+
+	h.Go(func(ctx context.Context) error { return m.RunJanitor(ctx, time.Second) })
+	h.Clock.BlockUntil(1)
+	h.Advance(time.Second)
+
+	WaitFor(h, evicted, 1, time.Second)
+}
+
+func TestHarness_QueueGroupAndDrain(t *testing.T) {
+	h := New(t)
+	g := NewQueueGroup[string, int](h, time.Minute)
+	g.Put(h.Ctx, "k", 1)
+	g.Get(h.Ctx, "k")
+
+	h.Advance(time.Minute)
+	if n := g.Collect(); n != 1 {
+		t.Fatalf("expected idle queue to be collected, got %d", n)
+	}
+
+	q := NewQueue[int](h, generic.WithBound(2, generic.OverflowDropNewest))
+	for i := range 3 {
+		q.Put(h.Ctx, i)
+	}
+	if items := Drain(q); len(items) != 2 || items[1] != 1 {
+		t.Fatalf("unexpected drained items %v", items)
+	}
+	if q.Puts.Len() != 3 || q.Gets.Len() != 2 {
+		t.Fatalf("captured %d puts and %d gets", q.Puts.Len(), q.Gets.Len())
+	}
+}
+
+func TestHarness_Timers(t *testing.T) {
+	h := New(t)
+	dq := NewDelayQueue[string](h)
+	dq.PutAfter(h.Ctx, "later", time.Minute)
+	if _, ok := dq.TryGet(); ok {
+		t.Fatal("delayed item ready early")
+	}
+	h.Advance(time.Minute)
+	if x, ok := dq.TryGet(); !ok || x != "later" {
+		t.Fatalf("TryGet = %q, %v", x, ok)
+	}
+
+	w, fired := NewTimerWheel[int](h, time.Second)
+	w.Schedule(time.Second, 7)
+	h.Clock.BlockUntil(1)
+	h.Advance(time.Second)
+	if got := WaitFor(h, fired, 1, time.Second); got[0] != 7 {
+		t.Fatalf("fired %v", got)
+	}
+
+	tq := NewTimedQueue[int](h)
+	tq.Put(h.Ctx, 1)
+	h.Advance(time.Second)
+	if age := tq.AgeOfHead(); age != time.Second {
+		t.Fatalf("AgeOfHead = %v", age)
+	}
+
+	b := NewTokenBucket(h, 1, 1)
+	if !b.Allow() || b.Allow() {
+		t.Fatal("expected a burst of one")
+	}
+	h.Advance(time.Second)
+	if !b.Allow() {
+		t.Fatal("bucket did not refill on the fake clock")
+	}
+}
+
+func TestHarness_ResourcePool(t *testing.T) {
+	h := New(t)
+	n := 0
+	p, created := NewResourcePool(h, 1, func(context.Context) (int, error) {
+		n++
+		return n, nil
+	})
+	l, err := p.Acquire(h.Ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Advance(time.Second)
+	l.Release()
+	if got := created.All(); len(got) != 1 || got[0] != 1 {
+		t.Fatalf("created %v", got)
+	}
+	if s := p.Stats(); s.AvgHold != time.Second {
+		t.Fatalf("AvgHold = %v on the fake clock", s.AvgHold)
+	}
+}
+
+func TestHarness_ContextCancelledAtCleanup(t *testing.T) {
+	var ctx context.Context
+	t.Run("inner", func(t *testing.T) {
+		h := New(t)
+		ctx = h.Ctx
+		h.Go(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	})
+	if ctx.Err() == nil {
+		t.Fatal("expected harness context to be cancelled after the test")
+	}
+}
@@ -0,0 +1,130 @@
+package generic
+
+import (
+	"cmp"
+	"hash/maphash"
+	"slices"
+	"sync"
+)
+
+type ringPoint[N comparable] struct {
+	hash uint64
+	node N
+}
+
+type virtualNode[N comparable] struct {
+	node N
+	i    int
+}
+
+// HashRing is a consistent hashing ring mapping keys of type K to nodes of
+// type N. Each node is placed on the ring at several virtual points so load
+// stays even and adding or removing a node only moves the keys adjacent to
+// its points. It is safe for concurrent use.
+//
+// Hashes are seeded per ring, so two rings in different processes do not
+// agree on placement.
+type HashRing[K, N comparable] struct {
+	mu       sync.RWMutex
+	seed     maphash.Seed
+	replicas int
+	points   []ringPoint[N]
+	nodes    map[N]struct{}
+}
+
+// NewHashRing returns an empty ring placing each node at virtualNodes points.
+func NewHashRing[K, N comparable](virtualNodes int) *HashRing[K, N] {
+	if virtualNodes < 1 {
+		virtualNodes = 1
+	}
+	return &HashRing[K, N]{
+		seed:     maphash.MakeSeed(),
+		replicas: virtualNodes,
+		nodes:    make(map[N]struct{}),
+	}
+}
+
+// AddNode places nodes on the ring. Nodes already present are ignored.
+func (r *HashRing[K, N]) AddNode(nodes ...N) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, n := range nodes {
+		if _, ok := r.nodes[n]; ok {
+			continue
+		}
+		r.nodes[n] = struct{}{}
+		for i := range r.replicas {
+			h := maphash.Comparable(r.seed, virtualNode[N]{node: n, i: i})
+			r.points = append(r.points, ringPoint[N]{hash: h, node: n})
+		}
+	}
+	slices.SortFunc(r.points, func(a, b ringPoint[N]) int {
+		return cmp.Compare(a.hash, b.hash)
+	})
+}
+
+// RemoveNode takes nodes off the ring.
+func (r *HashRing[K, N]) RemoveNode(nodes ...N) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, n := range nodes {
+		delete(r.nodes, n)
+	}
+	r.points = slices.DeleteFunc(r.points, func(p ringPoint[N]) bool {
+		_, ok := r.nodes[p.node]
+		return !ok
+	})
+}
+
+// Nodes returns the nodes on the ring in no particular order.
+func (r *HashRing[K, N]) Nodes() []N {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	nodes := make([]N, 0, len(r.nodes))
+	for n := range r.nodes {
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
+
+// search returns the index of the first point at or after key's hash.
+func (r *HashRing[K, N]) search(key K) int {
+	h := maphash.Comparable(r.seed, key)
+	i, _ := slices.BinarySearchFunc(r.points, h, func(p ringPoint[N], h uint64) int {
+		return cmp.Compare(p.hash, h)
+	})
+	if i == len(r.points) {
+		i = 0
+	}
+	return i
+}
+
+// Locate returns the node owning key, or false if the ring is empty.
+func (r *HashRing[K, N]) Locate(key K) (N, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.points) == 0 {
+		var zero N
+		return zero, false
+	}
+	return r.points[r.search(key)].node, true
+}
+
+// LocateN returns up to n distinct nodes for key in ring order, starting with
+// the owner. It is meant for choosing replicas.
+func (r *HashRing[K, N]) LocateN(key K, n int) []N {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	n = min(n, len(r.nodes))
+	if n <= 0 {
+		return nil
+	}
+	out := make([]N, 0, n)
+	for i, start := 0, r.search(key); len(out) < n; i++ {
+		node := r.points[(start+i)%len(r.points)].node
+		if !slices.Contains(out, node) {
+			out = append(out, node)
+		}
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+package generic
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHashRing_Empty(t *testing.T) {
+	r := NewHashRing[string, string](10)
+	if _, ok := r.Locate("key"); ok {
+		t.Error("expected empty ring to locate nothing")
+	}
+	if got := r.LocateN("key", 3); got != nil {
+		t.Errorf("expected nil, got %v", got)
+	}
+}
+
+func TestHashRing_LocateIsStable(t *testing.T) {
+	r := NewHashRing[string, string](50)
+	r.AddNode("a", "b", "c")
+	for i := range 100 {
+		key := fmt.Sprint("key-", i)
+		first, ok := r.Locate(key)
+		if !ok {
+			t.Fatal("expected a node")
+		}
+		if again, _ := r.Locate(key); again != first {
+			t.Fatalf("key %q located to %q then %q", key, first, again)
+		}
+	}
+}
+
+func TestHashRing_Distribution(t *testing.T) {
+	r := NewHashRing[int, string](100)
+	r.AddNode("a", "b", "c", "d")
+	counts := map[string]int{}
+	const keys = 10000
+	for k := range keys {
+		n, _ := r.Locate(k)
+		counts[n]++
+	}
+	for node, c := range counts {
+		if c < keys/4/2 || c > keys/4*2 {
+			t.Errorf("node %s owns %d keys; distribution too uneven: %v", node, c, counts)
+		}
+	}
+}
+
+func TestHashRing_RemoveOnlyMovesRemovedKeys(t *testing.T) {
+	r := NewHashRing[int, string](100)
+	r.AddNode("a", "b", "c")
+	before := map[int]string{}
+	for k := range 1000 {
+		before[k], _ = r.Locate(k)
+	}
+
+	r.RemoveNode("b")
+	if len(r.Nodes()) != 2 {
+		t.Fatalf("expected 2 nodes, got %v", r.Nodes())
+	}
+	for k, owner := range before {
+		now, _ := r.Locate(k)
+		if now == "b" {
+			t.Fatalf("key %d still maps to removed node", k)
+		}
+		if owner != "b" && now != owner {
+			t.Fatalf("key %d moved from %s to %s although its node stayed", k, owner, now)
+		}
+	}
+}
+
+func TestHashRing_LocateN(t *testing.T) {
+	r := NewHashRing[string, int](20)
+	r.AddNode(1, 2, 3)
+	r.AddNode(2) // duplicate is ignored
+
+	replicas := r.LocateN("key", 5)
+	if len(replicas) != 3 {
+		t.Fatalf("expected 3 distinct replicas, got %v", replicas)
+	}
+	owner, _ := r.Locate("key")
+	if replicas[0] != owner {
+		t.Errorf("expected first replica to be owner %d, got %d", owner, replicas[0])
+	}
+	seen := map[int]bool{}
+	for _, n := range replicas {
+		if seen[n] {
+			t.Errorf("duplicate replica %d in %v", n, replicas)
+		}
+		seen[n] = true
+	}
+}
+
+func BenchmarkHashRing_Locate(b *testing.B) {
+	r := NewHashRing[int, string](100)
+	r.AddNode("a", "b", "c", "d", "e")
+	for i := 0; i < b.N; i++ {
+		r.Locate(i)
+	}
+}
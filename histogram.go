@@ -0,0 +1,122 @@
+package generic
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// histogramBuckets covers latencies from under a microsecond to about 12
+// days in powers of two; the last bucket holds everything longer.
+const histogramBuckets = 42
+
+// LatencyHistogram records durations in exponential buckets without locks.
+// Quantiles are estimated to within a factor of two, enough to alert on.
+// The zero value is ready to use.
+type LatencyHistogram struct {
+	buckets [histogramBuckets]atomic.Int64
+	count   atomic.Int64
+	sum     atomic.Int64
+	max     atomic.Int64
+}
+
+// LatencySnapshot summarizes a LatencyHistogram.
+type LatencySnapshot struct {
+	Count int64         `json:"count"`
+	Mean  time.Duration `json:"mean"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P99   time.Duration `json:"p99"`
+	Max   time.Duration `json:"max"`
+}
+
+// histogramBucket returns the bucket for d: bucket 0 holds everything under
+// a microsecond and bucket i durations in [2^(i-1), 2^i) microseconds.
+func histogramBucket(d time.Duration) int {
+	us := uint64(max(d, 0) / time.Microsecond)
+	return min(bits.Len64(us), histogramBuckets-1)
+}
+
+// Observe records one duration.
+func (h *LatencyHistogram) Observe(d time.Duration) {
+	h.buckets[histogramBucket(d)].Add(1)
+	h.count.Add(1)
+	h.sum.Add(int64(d))
+	for {
+		old := h.max.Load()
+		if int64(d) <= old || h.max.CompareAndSwap(old, int64(d)) {
+			return
+		}
+	}
+}
+
+// Quantile estimates the q-quantile, 0 < q <= 1, as the upper bound of the
+// bucket holding it, capped at the largest duration seen.
+func (h *LatencyHistogram) Quantile(q float64) time.Duration {
+	var counts [histogramBuckets]int64
+	for i := range h.buckets {
+		counts[i] = h.buckets[i].Load()
+	}
+	return histogramQuantile(&counts, h.count.Load(), time.Duration(h.max.Load()), q)
+}
+
+func histogramQuantile(counts *[histogramBuckets]int64, total int64, maxSeen time.Duration, q float64) time.Duration {
+	if total == 0 {
+		return 0
+	}
+	rank := int64(q * float64(total))
+	var seen int64
+	for i, n := range counts {
+		seen += n
+		if (seen > rank || seen >= total) && i < histogramBuckets-1 {
+			return min(time.Microsecond<<i, maxSeen)
+		}
+	}
+	return maxSeen
+}
+
+// Snapshot returns the count, mean, common quantiles and maximum.
+func (h *LatencyHistogram) Snapshot() LatencySnapshot {
+	s := LatencySnapshot{
+		Count: h.count.Load(),
+		P50:   h.Quantile(0.5),
+		P90:   h.Quantile(0.9),
+		P99:   h.Quantile(0.99),
+		Max:   time.Duration(h.max.Load()),
+	}
+	if s.Count > 0 {
+		s.Mean = time.Duration(h.sum.Load() / s.Count)
+	}
+	return s
+}
+
+// SwapAll returns a snapshot and empties the histogram in one pass, so a
+// scraper reading deltas never loses observations that race with it: each
+// is counted in exactly one swap.
+func (h *LatencyHistogram) SwapAll() LatencySnapshot {
+	var counts [histogramBuckets]int64
+	var total int64
+	for i := range h.buckets {
+		counts[i] = h.buckets[i].Swap(0)
+		total += counts[i]
+	}
+	h.count.Add(-total)
+	sum := h.sum.Swap(0)
+	maxSeen := time.Duration(h.max.Swap(0))
+	s := LatencySnapshot{
+		Count: total,
+		P50:   histogramQuantile(&counts, total, maxSeen, 0.5),
+		P90:   histogramQuantile(&counts, total, maxSeen, 0.9),
+		P99:   histogramQuantile(&counts, total, maxSeen, 0.99),
+		Max:   maxSeen,
+	}
+	if total > 0 {
+		s.Mean = time.Duration(sum / total)
+	}
+	return s
+}
+
+// Inspect reports Snapshot for DebugHandler.
+func (h *LatencyHistogram) Inspect() any {
+	return h.Snapshot()
+}
@@ -0,0 +1,92 @@
+package generic
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogram_Quantiles(t *testing.T) {
+	var h LatencyHistogram
+	if h.Quantile(0.5) != 0 || h.Snapshot().Mean != 0 {
+		t.Fatal("empty histogram should report zeros")
+	}
+	for range 90 {
+		h.Observe(100 * time.Microsecond)
+	}
+	for range 9 {
+		h.Observe(10 * time.Millisecond)
+	}
+	h.Observe(time.Second)
+
+	s := h.Snapshot()
+	if s.Count != 100 || s.Max != time.Second {
+		t.Fatalf("unexpected snapshot %+v", s)
+	}
+	within := func(got, want time.Duration) bool { return got >= want && got <= 2*want }
+	if !within(s.P50, 100*time.Microsecond) {
+		t.Errorf("p50 = %v, want about 100µs", s.P50)
+	}
+	if !within(s.P90, 10*time.Millisecond) || !within(s.P99, time.Second) {
+		t.Errorf("p90 = %v, p99 = %v", s.P90, s.P99)
+	}
+	if want := (90*100*time.Microsecond + 9*10*time.Millisecond + time.Second) / 100; s.Mean != want {
+		t.Errorf("mean = %v, want %v", s.Mean, want)
+	}
+}
+
+func TestLatencyHistogram_Extremes(t *testing.T) {
+	var h LatencyHistogram
+	h.Observe(-time.Second)
+	h.Observe(0)
+	h.Observe(100 * 24 * time.Hour)
+	if got := h.Quantile(1); got != 100*24*time.Hour {
+		t.Fatalf("max quantile should be capped at the max, got %v", got)
+	}
+	if got := h.Quantile(0.1); got != time.Microsecond {
+		t.Fatalf("sub-microsecond samples should land in the first bucket, got %v", got)
+	}
+}
+
+func TestLatencyHistogram_Concurrent(t *testing.T) {
+	var h LatencyHistogram
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 1000 {
+				h.Observe(time.Duration(i*1000+j) * time.Microsecond)
+			}
+		}()
+	}
+	wg.Wait()
+	if s := h.Snapshot(); s.Count != 8000 || s.Max != 7999*time.Microsecond {
+		t.Fatalf("unexpected snapshot %+v", s)
+	}
+}
+
+func BenchmarkLatencyHistogram_Observe(b *testing.B) {
+	var h LatencyHistogram
+	for b.Loop() {
+		h.Observe(150 * time.Microsecond)
+	}
+}
+
+func TestLatencyHistogram_SwapAll(t *testing.T) {
+	var h LatencyHistogram
+	for range 10 {
+		h.Observe(time.Millisecond)
+	}
+	s := h.SwapAll()
+	if s.Count != 10 || s.Max != time.Millisecond || s.Mean != time.Millisecond || s.P99 != time.Millisecond {
+		t.Fatalf("unexpected swap %+v", s)
+	}
+	if s := h.Snapshot(); s.Count != 0 || s.Max != 0 {
+		t.Fatalf("expected empty histogram after swap, got %+v", s)
+	}
+	h.Observe(time.Second)
+	if s := h.SwapAll(); s.Count != 1 || s.Max != time.Second {
+		t.Fatalf("unexpected second swap %+v", s)
+	}
+}
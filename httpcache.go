@@ -0,0 +1,335 @@
+package generic
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ResponseCache is HTTP middleware that caches successful GET and HEAD
+// responses in an ExpiringMap. Responses are fresh for TTL, or the
+// response's own max-age, and may then be served stale for
+// StaleWhileRevalidate while a single background request refreshes them.
+// Responses that Vary on request headers are cached per variant, and
+// Cache-Control no-store or private keeps a response out of the cache, as
+// does Set-Cookie. Responses to requests with Authorization are cached
+// only if marked public or s-maxage, or if Authorization is a KeyHeader. A
+// request with Cache-Control no-cache skips the lookup and no-store skips
+// the cache entirely. The zero value caches nothing until TTL is set.
+type ResponseCache struct {
+	TTL                  time.Duration
+	StaleWhileRevalidate time.Duration
+	// Key returns the cache key for a request. Defaults to the method,
+	// path and query.
+	Key func(r *http.Request) string
+	// KeyHeaders are request headers always added to the key, such as
+	// Authorization for per-user responses.
+	KeyHeaders []string
+	// MaxBodySize bounds the responses kept. Defaults to 1MiB.
+	MaxBodySize int64
+	// Clock supplies the current time. Defaults to SystemClock.
+	Clock Clock
+
+	entries      ExpiringMap[string, *cachedResponse]
+	revalidating sync.Map // key → struct{}
+	hits         atomic.Int64
+	stale        atomic.Int64
+	misses       atomic.Int64
+}
+
+type cachedResponse struct {
+	vary []string // set on the marker stored under the base key
+
+	status int
+	header http.Header
+	body   []byte
+	stored time.Time
+	fresh  time.Duration
+}
+
+// ResponseCacheStats is the debug view of a ResponseCache.
+type ResponseCacheStats struct {
+	Entries int   `json:"entries"`
+	Hits    int64 `json:"hits"`
+	Stale   int64 `json:"stale"`
+	Misses  int64 `json:"misses"`
+}
+
+func (c *ResponseCache) now() time.Time {
+	if c.Clock == nil {
+		return SystemClock.Now()
+	}
+	return c.Clock.Now()
+}
+
+func (c *ResponseCache) baseKey(r *http.Request) string {
+	var b strings.Builder
+	if c.Key != nil {
+		b.WriteString(c.Key(r))
+	} else {
+		b.WriteString(r.Method)
+		b.WriteByte(' ')
+		b.WriteString(r.URL.RequestURI())
+	}
+	for _, h := range c.KeyHeaders {
+		b.WriteByte(0)
+		b.WriteString(strings.Join(r.Header.Values(h), ","))
+	}
+	return b.String()
+}
+
+func variantKey(base string, vary []string, r *http.Request) string {
+	var b strings.Builder
+	b.WriteString(base)
+	for _, h := range vary {
+		b.WriteString("\x00vary\x00")
+		b.WriteString(strings.Join(r.Header.Values(h), ","))
+	}
+	return b.String()
+}
+
+// lookup returns the cached response for r and its key.
+func (c *ResponseCache) lookup(base string, r *http.Request) (*cachedResponse, string, bool) {
+	e, ok := c.entries.Load(base)
+	if !ok || e.vary == nil {
+		return e, base, ok
+	}
+	key := variantKey(base, e.vary, r)
+	e, ok = c.entries.Load(key)
+	return e, key, ok
+}
+
+func cacheDirectives(h http.Header) map[string]string {
+	d := make(map[string]string)
+	for _, v := range h.Values("Cache-Control") {
+		for part := range strings.SplitSeq(v, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			d[strings.ToLower(name)] = strings.Trim(value, `"`)
+		}
+	}
+	return d
+}
+
+// Middleware wraps next with the cache. Configure the cache before calling
+// it.
+func (c *ResponseCache) Middleware(next http.Handler) http.Handler {
+	c.entries.Clock = c.Clock
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqCC := cacheDirectives(r.Header)
+		_, noStore := reqCC["no-store"]
+		if c.TTL <= 0 || (r.Method != http.MethodGet && r.Method != http.MethodHead) || noStore {
+			next.ServeHTTP(w, r)
+			return
+		}
+		base := c.baseKey(r)
+		if _, noCache := reqCC["no-cache"]; !noCache {
+			if e, key, ok := c.lookup(base, r); ok {
+				age := c.now().Sub(e.stored)
+				if age >= e.fresh {
+					c.stale.Add(1)
+					c.revalidate(next, r, base, key)
+					c.serve(w, r, e, age, "STALE")
+				} else {
+					c.hits.Add(1)
+					c.serve(w, r, e, age, "HIT")
+				}
+				return
+			}
+		}
+		c.misses.Add(1)
+		cw := &cacheWriter{ResponseWriter: w, limit: c.maxBodySize()}
+		next.ServeHTTP(cw, r)
+		if cw.finish() {
+			c.store(base, r, cw.status, cw.snapshot, cw.buf.Bytes())
+		}
+	})
+}
+
+func (c *ResponseCache) maxBodySize() int64 {
+	if c.MaxBodySize <= 0 {
+		return 1 << 20
+	}
+	return c.MaxBodySize
+}
+
+func (c *ResponseCache) serve(w http.ResponseWriter, r *http.Request, e *cachedResponse, age time.Duration, state string) {
+	h := w.Header()
+	for k, v := range e.header {
+		h[k] = append([]string(nil), v...)
+	}
+	h.Set("Age", strconv.Itoa(int(age/time.Second)))
+	h.Set("X-Cache", state)
+	w.WriteHeader(e.status)
+	if r.Method != http.MethodHead {
+		w.Write(e.body)
+	}
+}
+
+// store caches a response if it is cacheable.
+func (c *ResponseCache) store(base string, r *http.Request, status int, header http.Header, body []byte) {
+	if status < 200 || status >= 300 || status == http.StatusPartialContent || header == nil {
+		return
+	}
+	cc := cacheDirectives(header)
+	if _, ok := cc["no-store"]; ok {
+		return
+	}
+	if _, ok := cc["private"]; ok {
+		return
+	}
+	// A response setting a cookie belongs to one client.
+	if header.Get("Set-Cookie") != "" {
+		return
+	}
+	// RFC 9111 §3.5: responses to authenticated requests are shared only
+	// when marked public or s-maxage, unless the credentials are part of
+	// the key.
+	if r.Header.Get("Authorization") != "" && !c.keyedBy("Authorization") {
+		_, public := cc["public"]
+		_, sMaxAge := cc["s-maxage"]
+		if !public && !sMaxAge {
+			return
+		}
+	}
+	fresh := c.TTL
+	if v, ok := cc["max-age"]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return
+		}
+		fresh = time.Duration(n) * time.Second
+	}
+	var vary []string
+	for _, v := range header.Values("Vary") {
+		for h := range strings.SplitSeq(v, ",") {
+			if h = strings.TrimSpace(h); h == "*" {
+				return
+			} else if h != "" {
+				vary = append(vary, textproto.CanonicalMIMEHeaderKey(h))
+			}
+		}
+	}
+	ttl := fresh + c.StaleWhileRevalidate
+	key := base
+	if vary != nil {
+		c.entries.Set(base, &cachedResponse{vary: vary}, ttl)
+		key = variantKey(base, vary, r)
+	}
+	c.entries.Set(key, &cachedResponse{
+		status: status,
+		header: header,
+		body:   bytes.Clone(body),
+		stored: c.now(),
+		fresh:  fresh,
+	}, ttl)
+}
+
+// keyedBy reports whether h is one of the KeyHeaders.
+func (c *ResponseCache) keyedBy(h string) bool {
+	for _, k := range c.KeyHeaders {
+		if strings.EqualFold(k, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// revalidate refreshes key in the background unless a refresh is already
+// running.
+func (c *ResponseCache) revalidate(next http.Handler, r *http.Request, base, key string) {
+	if _, running := c.revalidating.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+	r = r.Clone(context.WithoutCancel(r.Context()))
+	go func() {
+		defer c.revalidating.Delete(key)
+		bw := &cacheWriter{ResponseWriter: discardResponseWriter{header: make(http.Header)}, limit: c.maxBodySize()}
+		if recoverError(func() error { next.ServeHTTP(bw, r); return nil }) != nil {
+			return
+		}
+		if bw.finish() {
+			c.store(base, r, bw.status, bw.snapshot, bw.buf.Bytes())
+		}
+	}()
+}
+
+// Purge removes every cached response.
+func (c *ResponseCache) Purge() {
+	c.entries.Range(func(key string, _ *cachedResponse) bool {
+		c.entries.Delete(key)
+		return true
+	})
+}
+
+// Stats returns hit, stale and miss counts.
+func (c *ResponseCache) Stats() ResponseCacheStats {
+	return ResponseCacheStats{
+		Entries: c.entries.Len(),
+		Hits:    c.hits.Load(),
+		Stale:   c.stale.Load(),
+		Misses:  c.misses.Load(),
+	}
+}
+
+// Inspect reports Stats for DebugHandler.
+func (c *ResponseCache) Inspect() any {
+	return c.Stats()
+}
+
+// cacheWriter passes a response through while keeping a copy of it, up to
+// limit bytes.
+type cacheWriter struct {
+	http.ResponseWriter
+	limit    int64
+	status   int
+	snapshot http.Header
+	buf      bytes.Buffer
+	over     bool
+}
+
+func (w *cacheWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+		w.snapshot = w.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *cacheWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.over {
+		if int64(w.buf.Len()+len(p)) > w.limit {
+			w.over = true
+			w.buf = bytes.Buffer{}
+		} else {
+			w.buf.Write(p)
+		}
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// finish records an implicit 200 for handlers that wrote nothing and
+// reports whether the whole body was kept.
+func (w *cacheWriter) finish() bool {
+	if w.status == 0 {
+		w.status = http.StatusOK
+		w.snapshot = w.Header().Clone()
+	}
+	return !w.over
+}
+
+func (w *cacheWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+type discardResponseWriter struct{ header http.Header }
+
+func (w discardResponseWriter) Header() http.Header         { return w.header }
+func (w discardResponseWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w discardResponseWriter) WriteHeader(int)             {}
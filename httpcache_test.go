@@ -0,0 +1,214 @@
+package generic
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func cacheTestHandler(calls *atomic.Int64, header http.Header) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		if r.URL.Query().Get("fail") != "" {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, "%s %d %s", r.URL.Path, n, r.Header.Get("Accept-Language"))
+	})
+}
+
+func cacheGet(h http.Handler, target string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestResponseCache_HitAndExpiry(t *testing.T) {
+	clock := newTestClock()
+	var calls atomic.Int64
+	c := &ResponseCache{TTL: time.Minute, Clock: clock}
+	h := c.Middleware(cacheTestHandler(&calls, nil))
+
+	first := cacheGet(h, "/a?x=1")
+	second := cacheGet(h, "/a?x=1")
+	if calls.Load() != 1 || second.Body.String() != first.Body.String() {
+		t.Fatalf("second request should hit the cache, calls %d", calls.Load())
+	}
+	if second.Header().Get("X-Cache") != "HIT" {
+		t.Fatalf("expected X-Cache HIT, got %q", second.Header().Get("X-Cache"))
+	}
+	cacheGet(h, "/a?x=2")
+	if calls.Load() != 2 {
+		t.Fatal("a different query should miss")
+	}
+
+	clock.Advance(time.Minute)
+	cacheGet(h, "/a?x=1")
+	if calls.Load() != 3 {
+		t.Fatal("expired entries should miss without stale-while-revalidate")
+	}
+	if s := c.Stats(); s.Hits != 1 || s.Misses != 3 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
+
+func TestResponseCache_StaleWhileRevalidate(t *testing.T) {
+	clock := newTestClock()
+	var calls atomic.Int64
+	c := &ResponseCache{TTL: time.Minute, StaleWhileRevalidate: time.Minute, Clock: clock}
+	h := c.Middleware(cacheTestHandler(&calls, nil))
+
+	cacheGet(h, "/a")
+	clock.Advance(90 * time.Second)
+	stale := cacheGet(h, "/a")
+	if stale.Header().Get("X-Cache") != "STALE" || stale.Body.String() != "/a 1 " {
+		t.Fatalf("expected stale body, got %q %q", stale.Header().Get("X-Cache"), stale.Body.String())
+	}
+	if stale.Header().Get("Age") != "90" {
+		t.Fatalf("expected Age 90, got %q", stale.Header().Get("Age"))
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		rec := cacheGet(h, "/a")
+		if rec.Body.String() == "/a 2 " && rec.Header().Get("X-Cache") == "HIT" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("background revalidation never landed, got %q", rec.Body.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected exactly one revalidation, calls %d", calls.Load())
+	}
+}
+
+func TestResponseCache_Bypass(t *testing.T) {
+	var calls atomic.Int64
+	c := &ResponseCache{TTL: time.Minute}
+	h := c.Middleware(cacheTestHandler(&calls, nil))
+
+	cacheGet(h, "/a")
+	cacheGet(h, "/a", "Cache-Control", "no-cache")
+	if calls.Load() != 2 {
+		t.Fatal("no-cache should skip the lookup")
+	}
+	if rec := cacheGet(h, "/a"); rec.Body.String() != "/a 2 " {
+		t.Fatalf("no-cache response should refresh the entry, got %q", rec.Body.String())
+	}
+	cacheGet(h, "/b", "Cache-Control", "no-store")
+	cacheGet(h, "/b")
+	if calls.Load() != 4 {
+		t.Fatal("no-store should not be cached")
+	}
+	cacheGet(h, "/c?fail=1")
+	cacheGet(h, "/c?fail=1")
+	if calls.Load() != 6 {
+		t.Fatal("errors should not be cached")
+	}
+	req := httptest.NewRequest(http.MethodPost, "/a", nil)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if calls.Load() != 7 {
+		t.Fatal("POST should bypass the cache")
+	}
+}
+
+func TestResponseCache_ResponseDirectives(t *testing.T) {
+	clock := newTestClock()
+	var calls atomic.Int64
+	c := &ResponseCache{TTL: time.Hour, Clock: clock}
+	h := c.Middleware(cacheTestHandler(&calls, http.Header{"Cache-Control": {"public, max-age=10"}}))
+	cacheGet(h, "/a")
+	clock.Advance(5 * time.Second)
+	cacheGet(h, "/a")
+	clock.Advance(5 * time.Second)
+	cacheGet(h, "/a")
+	if calls.Load() != 2 {
+		t.Fatalf("max-age should override TTL, calls %d", calls.Load())
+	}
+
+	calls.Store(0)
+	h = c.Middleware(cacheTestHandler(&calls, http.Header{"Cache-Control": {"private"}}))
+	cacheGet(h, "/p")
+	cacheGet(h, "/p")
+	if calls.Load() != 2 {
+		t.Fatal("private responses should not be cached")
+	}
+}
+
+func TestResponseCache_Vary(t *testing.T) {
+	var calls atomic.Int64
+	c := &ResponseCache{TTL: time.Minute}
+	h := c.Middleware(cacheTestHandler(&calls, http.Header{"Vary": {"accept-language"}}))
+
+	en := cacheGet(h, "/a", "Accept-Language", "en")
+	fr := cacheGet(h, "/a", "Accept-Language", "fr")
+	if en.Body.String() == fr.Body.String() || calls.Load() != 2 {
+		t.Fatal("variants should be cached separately")
+	}
+	if rec := cacheGet(h, "/a", "Accept-Language", "en"); rec.Body.String() != en.Body.String() || calls.Load() != 2 {
+		t.Fatalf("en variant should hit, got %q", rec.Body.String())
+	}
+
+	c2 := &ResponseCache{TTL: time.Minute}
+	h = c2.Middleware(cacheTestHandler(&calls, http.Header{"Vary": {"*"}}))
+	cacheGet(h, "/a")
+	cacheGet(h, "/a")
+	if c2.Stats().Entries != 0 {
+		t.Fatal("Vary: * should not be cached")
+	}
+}
+
+func TestResponseCache_KeyHeadersAndSize(t *testing.T) {
+	var calls atomic.Int64
+	c := &ResponseCache{TTL: time.Minute, KeyHeaders: []string{"Authorization"}, MaxBodySize: 8}
+	h := c.Middleware(cacheTestHandler(&calls, nil))
+	cacheGet(h, "/a", "Authorization", "alice")
+	cacheGet(h, "/a", "Authorization", "bob")
+	cacheGet(h, "/a", "Authorization", "alice")
+	if calls.Load() != 2 {
+		t.Fatalf("key headers should split the cache, calls %d", calls.Load())
+	}
+	cacheGet(h, "/long-path")
+	cacheGet(h, "/long-path")
+	if calls.Load() != 4 {
+		t.Fatal("bodies over MaxBodySize should not be cached")
+	}
+}
+
+func TestResponseCache_PrivateResponses(t *testing.T) {
+	var calls atomic.Int64
+	c := &ResponseCache{TTL: time.Minute}
+	h := c.Middleware(cacheTestHandler(&calls, http.Header{"Set-Cookie": {"session=alice"}}))
+	cacheGet(h, "/me")
+	if rec := cacheGet(h, "/me"); calls.Load() != 2 || rec.Header().Get("X-Cache") != "" {
+		t.Fatal("responses with Set-Cookie should not be cached")
+	}
+
+	calls.Store(0)
+	h = c.Middleware(cacheTestHandler(&calls, nil))
+	alice := cacheGet(h, "/profile", "Authorization", "alice")
+	bob := cacheGet(h, "/profile", "Authorization", "bob")
+	if calls.Load() != 2 || bob.Body.String() == alice.Body.String() {
+		t.Fatal("responses to authorized requests should not be shared")
+	}
+
+	calls.Store(0)
+	for _, cc := range []string{"public", "s-maxage=60"} {
+		h = c.Middleware(cacheTestHandler(&calls, http.Header{"Cache-Control": {cc}}))
+		cacheGet(h, "/"+cc, "Authorization", "alice")
+		if rec := cacheGet(h, "/"+cc, "Authorization", "bob"); rec.Header().Get("X-Cache") != "HIT" {
+			t.Fatalf("authorized response marked %s should be cached", cc)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+// queueFrameType is the content type of length-prefixed Codec frames, the
+// same framing QueueWriter and QueueReader use.
+const queueFrameType = "application/octet-stream"
+
+// QueueHandler exposes a Queue over HTTP. GET streams items as frames until
+// the client goes away, or until ?max=N items have been sent; POST reads
+// frames from the body and Puts each one. Streaming works over HTTP/1.1
+// chunked encoding and HTTP/2 alike.
+//
+// An item whose frame cannot be written is put back, possibly out of order,
+// if the queue has room; otherwise it is logged and counted in Lost. A
+// write can succeed after the client has gone, so items in flight when a
+// client disconnects may be lost too.
+type QueueHandler[T any] struct {
+	// Logger records items that could not be put back. Defaults to
+	// DefaultLogger.
+	Logger Logger
+
+	q     Queue[T]
+	codec Codec[T]
+	lost  atomic.Int64
+}
+
+func NewQueueHandler[T any](q Queue[T], codec Codec[T]) *QueueHandler[T] {
+	return &QueueHandler[T]{q: q, codec: codec}
+}
+
+func (h *QueueHandler[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.stream(w, r)
+	case http.MethodPost:
+		h.receive(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *QueueHandler[T]) stream(w http.ResponseWriter, r *http.Request) {
+	limit := -1
+	if s := r.URL.Query().Get("max"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid max", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	ctx := r.Context()
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", queueFrameType)
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+	for sent := 0; limit < 0 || sent < limit; sent++ {
+		x, err := h.q.Get(ctx)
+		if err != nil {
+			return
+		}
+		data, err := h.codec.Marshal(x)
+		if err == nil {
+			err = writeFrame(w, data)
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			if !h.q.TryPut(x) {
+				h.lost.Add(1)
+				loggerOrDefault(h.Logger).Error(ctx, "generic: queue handler lost an unsent item", slog.Any("error", err))
+			}
+			return
+		}
+	}
+}
+
+// Lost returns how many unsent items could not be put back.
+func (h *QueueHandler[T]) Lost() int64 {
+	return h.lost.Load()
+}
+
+func (h *QueueHandler[T]) receive(w http.ResponseWriter, r *http.Request) {
+	qw := NewQueueWriter(r.Context(), h.q, h.codec)
+	_, err := io.Copy(qw, r.Body)
+	if err == nil {
+		err = qw.Close()
+	}
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case r.Context().Err() != nil:
+		// The client is gone; nobody will read a response.
+	case errors.Is(err, ErrFrameTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// QueueClient talks to a QueueHandler at URL. A nil Client uses
+// http.DefaultClient.
+type QueueClient[T any] struct {
+	URL    string
+	Client *http.Client
+	Codec  Codec[T]
+}
+
+func (c *QueueClient[T]) client() *http.Client {
+	if c.Client != nil {
+		return c.Client
+	}
+	return http.DefaultClient
+}
+
+// Stream returns the items served by the handler, in order. The sequence
+// ends when ctx is done or the server ends the stream; a failure is yielded
+// once as the final error, with io.EOF never reported.
+func (c *QueueClient[T]) Stream(ctx context.Context) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		req, err := NewRequestWithContext(ctx, http.MethodGet, c.URL, nil)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		resp, err := c.client().Do((*http.Request)(req))
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			yield(zero, queueStatusError(resp))
+			return
+		}
+		for {
+			data, err := readFrame(resp.Body)
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					err = ctx.Err()
+				}
+				yield(zero, err)
+				return
+			}
+			x, err := c.Codec.Unmarshal(data)
+			if !yield(x, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Send streams items to the handler in a single request body, returning
+// once the server has put them all.
+func (c *QueueClient[T]) Send(ctx context.Context, items iter.Seq[T]) error {
+	pr, pw := io.Pipe()
+	go func() {
+		for x := range items {
+			data, err := c.Codec.Marshal(x)
+			if err == nil {
+				err = writeFrame(pw, data)
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		pw.Close()
+	}()
+	defer pr.Close()
+	req, err := NewRequestWithContext(ctx, http.MethodPost, c.URL, pr)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", queueFrameType)
+	resp, err := c.client().Do((*http.Request)(req))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return queueStatusError(resp)
+	}
+	return nil
+}
+
+func queueStatusError(resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("queue: %s: %s", resp.Status, msg)
+}
@@ -0,0 +1,178 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueueHandler_SendAndStream(t *testing.T) {
+	for _, h2 := range []bool{false, true} {
+		name := "http1"
+		if h2 {
+			name = "http2"
+		}
+		t.Run(name, func(t *testing.T) {
+			q := NewFiFo[ioRecord]()
+			srv := httptest.NewUnstartedServer(NewQueueHandler[ioRecord](q, JSONCodec[ioRecord]{}))
+			srv.EnableHTTP2 = h2
+			srv.StartTLS()
+			defer srv.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			c := &QueueClient[ioRecord]{URL: srv.URL, Client: srv.Client(), Codec: JSONCodec[ioRecord]{}}
+			want := []ioRecord{{1, "a"}, {2, "b"}, {3, "c"}}
+			if err := c.Send(ctx, slices.Values(want)); err != nil {
+				t.Fatalf("send: %v", err)
+			}
+			if q.Size() != 3 {
+				t.Fatalf("expected 3 queued items, got %d", q.Size())
+			}
+
+			var got []ioRecord
+			for x, err := range c.Stream(ctx) {
+				if err != nil {
+					t.Fatalf("stream: %v", err)
+				}
+				got = append(got, x)
+				if len(got) == len(want) {
+					break
+				}
+			}
+			if !slices.Equal(got, want) {
+				t.Fatalf("expected %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestQueueHandler_StreamMax(t *testing.T) {
+	q := NewFiFo[int]()
+	for i := range 5 {
+		q.Put(context.Background(), i)
+	}
+	srv := httptest.NewServer(NewQueueHandler[int](q, JSONCodec[int]{}))
+	defer srv.Close()
+
+	c := &QueueClient[int]{URL: srv.URL + "?max=2", Codec: JSONCodec[int]{}}
+	var got []int
+	for x, err := range c.Stream(context.Background()) {
+		if err != nil {
+			t.Fatalf("stream: %v", err)
+		}
+		got = append(got, x)
+	}
+	if !slices.Equal(got, []int{0, 1}) {
+		t.Fatalf("expected [0 1], got %v", got)
+	}
+	if q.Size() != 3 {
+		t.Fatalf("expected 3 items left, got %d", q.Size())
+	}
+}
+
+func TestQueueHandler_Errors(t *testing.T) {
+	srv := httptest.NewServer(NewQueueHandler[int](NewFiFo[int](), JSONCodec[int]{}))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL, queueFrameType, strings.NewReader("\x00\x00\x00\x03bad"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for bad frame, got %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, srv.URL, nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", resp.StatusCode)
+	}
+
+	c := &QueueClient[int]{URL: srv.URL + "?max=-1", Codec: JSONCodec[int]{}}
+	for _, err := range c.Stream(context.Background()) {
+		if err == nil || !strings.Contains(err.Error(), "400") {
+			t.Fatalf("expected 400 error, got %v", err)
+		}
+	}
+}
+
+// failingWriter fails every frame write, running onWrite first.
+type failingWriter struct {
+	header  http.Header
+	onWrite func()
+}
+
+func (w *failingWriter) Header() http.Header { return w.header }
+func (w *failingWriter) WriteHeader(int)     {}
+func (w *failingWriter) FlushError() error   { return nil }
+
+func (w *failingWriter) Write([]byte) (int, error) {
+	w.onWrite()
+	return 0, errors.New("client gone")
+}
+
+func TestQueueHandler_PutBack(t *testing.T) {
+	ctx := context.Background()
+	q := NewBoundedFiFo[int](1, OverflowBlock)
+	h := NewQueueHandler[int](q, JSONCodec[int]{})
+	h.Logger = nopLogger{}
+	serve := func(onWrite func()) {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			h.ServeHTTP(&failingWriter{header: make(http.Header), onWrite: onWrite}, httptest.NewRequest(http.MethodGet, "/", nil))
+		}()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("handler blocked putting an item back")
+		}
+	}
+
+	q.Put(ctx, 1)
+	serve(func() {})
+	if x, ok := q.TryGet(); !ok || x != 1 || h.Lost() != 0 {
+		t.Fatalf("put back %d, %v; lost %d", x, ok, h.Lost())
+	}
+
+	// The queue refills while the write fails, leaving no room.
+	q.Put(ctx, 1)
+	serve(func() { q.TryPut(2) })
+	if x, _ := q.TryGet(); x != 2 || h.Lost() != 1 {
+		t.Fatalf("queue holds %d; lost %d", x, h.Lost())
+	}
+}
+
+func TestQueueClient_StreamCancel(t *testing.T) {
+	q := NewFiFo[int]()
+	srv := httptest.NewServer(NewQueueHandler[int](q, JSONCodec[int]{}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	c := &QueueClient[int]{URL: srv.URL, Codec: JSONCodec[int]{}}
+	for _, err := range c.Stream(ctx) {
+		if err != context.DeadlineExceeded {
+			t.Fatalf("expected deadline exceeded, got %v", err)
+		}
+	}
+
+	// The server's Get is abandoned, so a new item stays queued.
+	time.Sleep(20 * time.Millisecond)
+	q.Put(context.Background(), 1)
+	time.Sleep(10 * time.Millisecond)
+	if q.Size() != 1 {
+		t.Fatalf("expected item to stay queued, got size %d", q.Size())
+	}
+}
@@ -0,0 +1,145 @@
+package generic
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HandlerTimeouts bounds how long HTTP handlers may run, like
+// http.TimeoutHandler, but keeps the request's typed context and counts
+// timeouts per handler so the slowest routes can be found. A timed-out
+// request is answered with 503 Service Unavailable and its context is
+// cancelled; the handler's response is buffered, and whatever it writes
+// after the deadline is discarded, with Write returning
+// http.ErrHandlerTimeout.
+type HandlerTimeouts[C context.Context] struct {
+	// Message is the body of the 503 response. Defaults to
+	// "handler timeout".
+	Message string
+
+	timeout     time.Duration
+	withTimeout func(ctx C, d time.Duration) (C, context.CancelFunc)
+	timedOut    CounterMap[string]
+}
+
+// NewHandlerTimeouts returns HandlerTimeouts allowing each request timeout.
+// withTimeout derives the handler's C from the request's, as
+// context.WithTimeout does for a plain context; it may be nil if C is
+// context.Context itself.
+func NewHandlerTimeouts[C context.Context](timeout time.Duration, withTimeout func(ctx C, d time.Duration) (C, context.CancelFunc)) *HandlerTimeouts[C] {
+	if withTimeout == nil {
+		withTimeout = func(ctx C, d time.Duration) (C, context.CancelFunc) {
+			tctx, cancel := context.WithTimeout(ctx, d)
+			return any(tctx).(C), cancel
+		}
+	}
+	return &HandlerTimeouts[C]{timeout: timeout, withTimeout: withTimeout}
+}
+
+// Timeouts returns how many requests each handler has timed out, keyed by
+// the name given to Middleware.
+func (h *HandlerTimeouts[C]) Timeouts() map[string]int64 {
+	return h.timedOut.Snapshot()
+}
+
+// Inspect reports Timeouts for DebugHandler.
+func (h *HandlerTimeouts[C]) Inspect() any {
+	return h.Timeouts()
+}
+
+// Middleware returns HTTP middleware applying the timeout to the handler
+// it wraps, counting its timeouts under name. Like RequestWithContext, it
+// panics if the request context is not a C. A panic in the handler is
+// re-raised on the serving goroutine with Rethrow, keeping its stack.
+func (h *HandlerTimeouts[C]) Middleware(name string) func(http.Handler) http.Handler {
+	msg := h.Message
+	if msg == "" {
+		msg = "handler timeout"
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			parent := (*RequestWithContext[C])(r).Context().(C)
+			ctx, cancel := h.withTimeout(parent, h.timeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+
+			tw := &timeoutWriter{header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan error, 1)
+			go func() {
+				_, err := CatchPanic(func() (struct{}, error) {
+					next.ServeHTTP(tw, r)
+					return struct{}{}, nil
+				})
+				if err != nil {
+					panicked <- err
+					return
+				}
+				close(done)
+			}()
+
+			select {
+			case err := <-panicked:
+				Rethrow(err)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				maps.Copy(w.Header(), tw.header)
+				if tw.code == 0 {
+					tw.code = http.StatusOK
+				}
+				w.WriteHeader(tw.code)
+				w.Write(tw.buf.Bytes())
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
+				if ctx.Err() == context.DeadlineExceeded {
+					h.timedOut.Add(name, 1)
+					http.Error(w, msg, http.StatusServiceUnavailable)
+					return
+				}
+				// The client went away; there is no one to answer.
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers a handler's response until it finishes in time.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	code     int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	return tw.buf.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.code != 0 {
+		return
+	}
+	tw.code = code
+}
@@ -0,0 +1,73 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandlerTimeouts(t *testing.T) {
+	h := NewHandlerTimeouts(20*time.Millisecond, func(ctx tenantCtx, d time.Duration) (tenantCtx, context.CancelFunc) {
+		tctx, cancel := context.WithTimeout(ctx.Context, d)
+		return tenantCtx{Context: tctx, tenant: ctx.tenant}, cancel
+	})
+	late := make(chan error, 1)
+	var handlerCtx tenantCtx
+	slow := h.Middleware("slow")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerCtx = (*RequestWithContext[tenantCtx])(r).Context().(tenantCtx)
+		w.Write([]byte("partial"))
+		<-r.Context().Done()
+		time.Sleep(5 * time.Millisecond)
+		_, err := w.Write([]byte("late"))
+		late <- err
+	}))
+	fast := h.Middleware("fast")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Tenant", r.Context().(tenantCtx).Tenant())
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("ok"))
+	}))
+	serve := func(handler http.Handler) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req = req.WithContext(tenantCtx{Context: req.Context(), tenant: "acme"})
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(fast)
+	if rec.Code != http.StatusCreated || rec.Body.String() != "ok" || rec.Header().Get("X-Tenant") != "acme" {
+		t.Fatalf("fast handler: %d %q %v", rec.Code, rec.Body, rec.Header())
+	}
+
+	rec = serve(slow)
+	if body, _ := io.ReadAll(rec.Body); rec.Code != http.StatusServiceUnavailable || string(body) != "handler timeout\n" {
+		t.Fatalf("slow handler: %d %q", rec.Code, body)
+	}
+	if err := <-late; !errors.Is(err, http.ErrHandlerTimeout) {
+		t.Fatalf("write after timeout = %v", err)
+	}
+	if handlerCtx.Tenant() != "acme" || handlerCtx.Err() == nil {
+		t.Fatalf("handler context: tenant %q, err %v", handlerCtx.Tenant(), handlerCtx.Err())
+	}
+	if got := h.Timeouts(); len(got) != 1 || got["slow"] != 1 {
+		t.Fatalf("Timeouts = %v", got)
+	}
+}
+
+func TestHandlerTimeouts_Panic(t *testing.T) {
+	h := NewHandlerTimeouts[context.Context](time.Second, nil)
+	handler := h.Middleware("boom")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	_, err := CatchPanic(func() (struct{}, error) {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		return struct{}{}, nil
+	})
+	if v, ok := PanicValue[string](err); !ok || v != "boom" {
+		t.Fatalf("recovered %v", err)
+	}
+}
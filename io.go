@@ -0,0 +1,68 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+var ErrReadLimit = errors.New("read limit exceeded")
+
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// NewContextReader returns a reader that fails with ctx.Err() once ctx is
+// done. Cancellation is checked between reads; if r has a SetReadDeadline
+// method (net.Conn, *os.File pipes) a blocked Read is also interrupted.
+// Interrupting a read leaves r's deadline in the past.
+func NewContextReader(ctx context.Context, r io.Reader) io.Reader {
+	return &contextReader{ctx: ctx, r: r}
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	d, ok := r.r.(readDeadliner)
+	if !ok || r.ctx.Done() == nil {
+		return r.r.Read(p)
+	}
+	stop := context.AfterFunc(r.ctx, func() { d.SetReadDeadline(time.Unix(1, 0)) })
+	n, err := r.r.Read(p)
+	if !stop() && errors.Is(err, os.ErrDeadlineExceeded) {
+		err = r.ctx.Err()
+	}
+	return n, err
+}
+
+// CopyContext is io.Copy that stops promptly when ctx is done, returning
+// ctx.Err() along with the bytes written so far.
+func CopyContext(ctx context.Context, dst io.Writer, src io.Reader) (int64, error) {
+	return io.Copy(dst, NewContextReader(ctx, src))
+}
+
+// ReadAllContext reads r until EOF like io.ReadAll, but stops when ctx is
+// done and fails with ErrReadLimit if r holds more than max bytes. A max of
+// zero or less means no limit.
+func ReadAllContext(ctx context.Context, r io.Reader, max int64) ([]byte, error) {
+	cr := NewContextReader(ctx, r)
+	if max <= 0 {
+		return io.ReadAll(cr)
+	}
+	b, err := io.ReadAll(io.LimitReader(cr, max+1))
+	if err != nil {
+		return b, err
+	}
+	if int64(len(b)) > max {
+		return b[:max], ErrReadLimit
+	}
+	return b, nil
+}
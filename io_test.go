@@ -0,0 +1,93 @@
+package generic
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// slowReader yields one byte per read and cancels after a number of reads.
+type slowReader struct {
+	reads  int
+	cancel func()
+	after  int
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	r.reads++
+	if r.reads == r.after {
+		r.cancel()
+	}
+	p[0] = 'x'
+	return 1, nil
+}
+
+func TestCopyContext(t *testing.T) {
+	var dst bytes.Buffer
+	n, err := CopyContext(context.Background(), &dst, strings.NewReader("hello"))
+	if err != nil || n != 5 || dst.String() != "hello" {
+		t.Fatalf("unexpected copy result n=%d err=%v dst=%q", n, err, dst.String())
+	}
+}
+
+func TestCopyContext_StopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	src := &slowReader{cancel: cancel, after: 10}
+	n, err := CopyContext(ctx, io.Discard, src)
+	if err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if n != 10 {
+		t.Errorf("expected 10 bytes copied before cancel, got %d", n)
+	}
+}
+
+func TestReadAllContext(t *testing.T) {
+	ctx := context.Background()
+
+	b, err := ReadAllContext(ctx, strings.NewReader("abc"), 0)
+	if err != nil || string(b) != "abc" {
+		t.Fatalf("unlimited: got %q, %v", b, err)
+	}
+
+	b, err = ReadAllContext(ctx, strings.NewReader("abc"), 3)
+	if err != nil || string(b) != "abc" {
+		t.Fatalf("exact limit: got %q, %v", b, err)
+	}
+
+	b, err = ReadAllContext(ctx, strings.NewReader("abcd"), 3)
+	if err != ErrReadLimit {
+		t.Fatalf("expected ErrReadLimit, got %v", err)
+	}
+	if string(b) != "abc" {
+		t.Errorf("expected truncated data 'abc', got %q", b)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := ReadAllContext(cancelled, strings.NewReader("abc"), 0); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestContextReader_InterruptsBlockedRead(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := ReadAllContext(ctx, client, 0)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("blocked read was not interrupted promptly: %v", elapsed)
+	}
+}
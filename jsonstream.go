@@ -0,0 +1,82 @@
+package generic
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DecodeJSONStream decodes the body of r one element at a time, calling fn
+// for each, and returns the number of elements handled. It stops at the
+// first error from decoding or fn, or when the request context is done.
+// See DecodeJSONReader for the accepted formats.
+func DecodeJSONStream[T any](r *http.Request, fn func(T) error) (int, error) {
+	return DecodeJSONReader(r.Context(), r.Body, fn)
+}
+
+// DecodeJSONReader decodes either a top-level JSON array or a stream of
+// newline-delimited JSON values from rd, calling fn for each element. Only
+// one element is held in memory at a time, so arbitrarily large bodies can
+// be ingested; bound the size of a single element with http.MaxBytesReader
+// or io.LimitReader if the input is untrusted.
+func DecodeJSONReader[T any](ctx context.Context, rd io.Reader, fn func(T) error) (int, error) {
+	br := bufio.NewReader(rd)
+	array, err := startsWithArray(br)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	dec := json.NewDecoder(br)
+	if array {
+		if _, err := dec.Token(); err != nil {
+			return 0, err
+		}
+	}
+	n := 0
+	for !array || dec.More() {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+		var x T
+		if err := dec.Decode(&x); err != nil {
+			if !array && errors.Is(err, io.EOF) {
+				return n, nil
+			}
+			return n, fmt.Errorf("generic: decoding element %d: %w", n, err)
+		}
+		if err := fn(x); err != nil {
+			return n, err
+		}
+		n++
+	}
+	// Only arrays leave the loop without returning.
+	if _, err := dec.Token(); err != nil {
+		return n, fmt.Errorf("generic: unterminated JSON array: %w", err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return n, errors.New("generic: unexpected data after JSON array")
+	}
+	return n, nil
+}
+
+// startsWithArray skips leading whitespace and reports whether the next
+// byte opens an array.
+func startsWithArray(br *bufio.Reader) (bool, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return false, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b == '[', br.UnreadByte()
+	}
+}
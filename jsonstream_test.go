@@ -0,0 +1,138 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodeJSONReader_Formats(t *testing.T) {
+	for name, input := range map[string]string{
+		"array":  ` [{"ID":1,"Name":"a"}, {"ID":2,"Name":"b"},{"ID":3,"Name":"c"}] `,
+		"ndjson": "{\"ID\":1,\"Name\":\"a\"}\n{\"ID\":2,\"Name\":\"b\"}\n\n{\"ID\":3,\"Name\":\"c\"}\n",
+	} {
+		var got []ioRecord
+		n, err := DecodeJSONReader(context.Background(), strings.NewReader(input), func(r ioRecord) error {
+			got = append(got, r)
+			return nil
+		})
+		if err != nil || n != 3 {
+			t.Fatalf("%s: decoded %d, %v", name, n, err)
+		}
+		for i, r := range got {
+			if r.ID != i+1 {
+				t.Fatalf("%s: element %d = %+v", name, i, r)
+			}
+		}
+	}
+}
+
+func TestDecodeJSONReader_Empty(t *testing.T) {
+	for _, input := range []string{"", "  \n", "[]", " [ ] "} {
+		n, err := DecodeJSONReader(context.Background(), strings.NewReader(input), func(int) error {
+			t.Fatal("fn should not be called")
+			return nil
+		})
+		if err != nil || n != 0 {
+			t.Fatalf("%q: got %d, %v", input, n, err)
+		}
+	}
+}
+
+func TestDecodeJSONReader_Errors(t *testing.T) {
+	for _, input := range []string{
+		`[1, 2, "x"]`,
+		`[1, 2`,
+		`[1, 2] 3`,
+		"1\n2\n{",
+	} {
+		if _, err := DecodeJSONReader(context.Background(), strings.NewReader(input), func(int) error { return nil }); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+
+	stop := errors.New("stop")
+	n, err := DecodeJSONReader(context.Background(), strings.NewReader(`[1,2,3]`), func(x int) error {
+		if x == 2 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || n != 1 {
+		t.Fatalf("expected fn error after 1 element, got %d, %v", n, err)
+	}
+}
+
+func TestDecodeJSONReader_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	n, err := DecodeJSONReader(ctx, strings.NewReader(`[1,2,3,4]`), func(x int) error {
+		if x == 2 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || n != 2 {
+		t.Fatalf("expected cancellation after 2 elements, got %d, %v", n, err)
+	}
+}
+
+// endlessArray yields "[0,1,2,..." without ever ending.
+type endlessArray struct {
+	n   int
+	buf []byte
+}
+
+func (r *endlessArray) Read(p []byte) (int, error) {
+	if len(r.buf) == 0 {
+		if r.n == 0 {
+			r.buf = append(r.buf, '[')
+		} else {
+			r.buf = append(r.buf, ',')
+		}
+		r.buf = fmt.Appendf(r.buf, "%d", r.n)
+		r.n++
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func TestDecodeJSONReader_Streams(t *testing.T) {
+	stop := errors.New("enough")
+	n, err := DecodeJSONReader(context.Background(), &endlessArray{}, func(x int) error {
+		if x == 100_000 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || n != 100_000 {
+		t.Fatalf("expected to stream 100000 elements, got %d, %v", n, err)
+	}
+}
+
+func TestDecodeJSONStream(t *testing.T) {
+	var got []ioRecord
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, err := DecodeJSONStream(r, func(rec ioRecord) error {
+			got = append(got, rec)
+			return nil
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, n)
+	})
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`[{"ID":7}]`))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	body, _ := io.ReadAll(rec.Body)
+	if string(body) != "1" || len(got) != 1 || got[0].ID != 7 {
+		t.Fatalf("unexpected result %q %+v", body, got)
+	}
+}
@@ -0,0 +1,149 @@
+package generic
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// KeyedQueue is a deduplicating work queue in the style of the Kubernetes
+// workqueue: putting an item whose key is already queued replaces the
+// queued item instead of adding another, so a key is processed once
+// however often it is put before a Get reaches it. The key keeps its
+// original place in line and Get returns the latest item put for it. Once
+// a Get has taken a key, putting it again queues it anew.
+type KeyedQueue[K comparable, T any] struct {
+	key       func(T) K
+	mu        sync.Mutex
+	order     ringBuffer[K]
+	items     map[K]T
+	closed    bool
+	coalesced atomic.Int64
+	notify    atomicNotifier
+}
+
+// NewKeyedQueue returns a KeyedQueue deduplicating items by key(x).
+func NewKeyedQueue[K comparable, T any](key func(T) K) *KeyedQueue[K, T] {
+	return &KeyedQueue[K, T]{key: key, items: make(map[K]T)}
+}
+
+// TryPut queues x, or replaces the item queued under its key. It reports
+// false only if the queue is closed.
+func (q *KeyedQueue[K, T]) TryPut(x T) bool {
+	k := q.key(x)
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return false
+	}
+	if _, ok := q.items[k]; ok {
+		q.coalesced.Add(1)
+	} else {
+		q.order.push(k)
+	}
+	q.items[k] = x
+	q.mu.Unlock()
+	q.notify.notify()
+	return true
+}
+
+// Put is TryPut for the Queue interface; it never blocks and returns
+// ErrClosed once the queue is closed.
+func (q *KeyedQueue[K, T]) Put(ctx context.Context, x T) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !q.TryPut(x) {
+		return ErrClosed
+	}
+	return nil
+}
+
+// pop removes the oldest key and its latest item. The caller must hold
+// q.mu.
+func (q *KeyedQueue[K, T]) pop() (T, bool) {
+	if q.order.len() == 0 {
+		var zero T
+		return zero, false
+	}
+	k := q.order.pop()
+	x := q.items[k]
+	delete(q.items, k)
+	return x, true
+}
+
+// TryGet removes the item for the oldest key without blocking.
+func (q *KeyedQueue[K, T]) TryGet() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pop()
+}
+
+// Get removes the item for the oldest key, blocking until one is
+// available. On a closed queue it returns the remaining items, then
+// ErrClosed.
+func (q *KeyedQueue[K, T]) Get(ctx context.Context) (T, error) {
+	for {
+		changed := q.notify.wait()
+		q.mu.Lock()
+		x, ok := q.pop()
+		closed := q.closed
+		q.mu.Unlock()
+		if ok {
+			return x, nil
+		}
+		if closed {
+			return x, ErrClosed
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return x, ctx.Err()
+		}
+	}
+}
+
+// Queued reports whether an item with key k is waiting in the queue.
+func (q *KeyedQueue[K, T]) Queued(k K) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	_, ok := q.items[k]
+	return ok
+}
+
+// Coalesced returns how many puts replaced an already queued item.
+func (q *KeyedQueue[K, T]) Coalesced() int64 {
+	return q.coalesced.Load()
+}
+
+// Size returns the number of queued keys.
+func (q *KeyedQueue[K, T]) Size() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.order.len()
+}
+
+func (q *KeyedQueue[K, T]) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+// Close stops the queue accepting items. Gets drain the remaining items
+// and then return ErrClosed instead of blocking.
+func (q *KeyedQueue[K, T]) Close() error {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.notify.notify()
+	return nil
+}
+
+// Inspect reports the queue state for DebugHandler, counting coalesced
+// puts as Compacted.
+func (q *KeyedQueue[K, T]) Inspect() any {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := q.order.len()
+	return FiFoStats{Size: n, Empty: n == 0, Closed: q.closed, Compacted: q.coalesced.Load()}
+}
+
+var _ Queue[int] = (*KeyedQueue[int, int])(nil)
@@ -0,0 +1,70 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+type keyedJob struct {
+	agent string
+	rev   int
+}
+
+func TestKeyedQueue_Coalesces(t *testing.T) {
+	ctx := context.Background()
+	q := NewKeyedQueue(func(j keyedJob) string { return j.agent })
+	q.Put(ctx, keyedJob{"a", 1})
+	q.Put(ctx, keyedJob{"b", 1})
+	q.Put(ctx, keyedJob{"a", 2})
+	q.Put(ctx, keyedJob{"a", 3})
+	if q.Size() != 2 || q.Coalesced() != 2 || !q.Queued("a") {
+		t.Fatalf("size %d, coalesced %d", q.Size(), q.Coalesced())
+	}
+
+	var got []keyedJob
+	for range 2 {
+		j, _ := q.Get(ctx)
+		got = append(got, j)
+	}
+	if !slices.Equal(got, []keyedJob{{"a", 3}, {"b", 1}}) {
+		t.Fatalf("got %v", got)
+	}
+	// A key taken by Get is queued anew.
+	q.Put(ctx, keyedJob{"a", 4})
+	if j, ok := q.TryGet(); !ok || j.rev != 4 {
+		t.Fatalf("TryGet = %v, %v", j, ok)
+	}
+	if st := q.Inspect().(FiFoStats); !st.Empty || st.Compacted != 2 {
+		t.Fatalf("Inspect = %+v", st)
+	}
+}
+
+func TestKeyedQueue_BlockingAndClose(t *testing.T) {
+	ctx := context.Background()
+	q := NewKeyedQueue(func(x int) int { return x % 10 })
+	got := make(chan int)
+	go func() {
+		x, _ := q.Get(ctx)
+		got <- x
+	}()
+	time.Sleep(10 * time.Millisecond)
+	q.Put(ctx, 7)
+	if x := <-got; x != 7 {
+		t.Fatalf("Get = %d", x)
+	}
+
+	q.Put(ctx, 1)
+	q.Close()
+	if err := q.Put(ctx, 11); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Put after Close = %v", err)
+	}
+	if x, err := q.Get(ctx); err != nil || x != 1 {
+		t.Fatalf("Get = %d, %v", x, err)
+	}
+	if _, err := q.Get(ctx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Get on drained queue = %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+var ErrDependencyCycle = errors.New("dependency cycle")
+
+// Component is a unit started and stopped by a Lifecycle.
+type Component struct {
+	Name string
+	// DependsOn names components that must start before this one and stop
+	// after it.
+	DependsOn []string
+	Start     func(ctx context.Context) error
+	Stop      func(ctx context.Context) error
+	// Timeout bounds Start and Stop individually. Zero means no limit
+	// beyond the context passed to the Lifecycle.
+	Timeout time.Duration
+}
+
+// Lifecycle starts components in dependency order and stops them in reverse.
+// Components whose dependencies are satisfied start in parallel.
+type Lifecycle struct {
+	mu         sync.Mutex
+	components map[string]*Component
+	started    []string // in completion order, for Stop
+}
+
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{components: make(map[string]*Component)}
+}
+
+// Add registers components. Names must be unique.
+func (l *Lifecycle) Add(cs ...Component) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, c := range cs {
+		if _, ok := l.components[c.Name]; ok {
+			return fmt.Errorf("lifecycle: duplicate component %q", c.Name)
+		}
+		l.components[c.Name] = &c
+	}
+	return nil
+}
+
+// Order returns the components grouped into levels; every component's
+// dependencies are in earlier levels. It reports unknown dependencies and
+// cycles.
+func (l *Lifecycle) Order() ([][]string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order()
+}
+
+func (l *Lifecycle) order() ([][]string, error) {
+	g := NewDAG[string]()
+	for name, c := range l.components {
+		g.AddNode(name)
+		for _, dep := range c.DependsOn {
+			if _, ok := l.components[dep]; !ok {
+				return nil, fmt.Errorf("lifecycle: %q depends on unknown component %q", name, dep)
+			}
+			g.AddEdge(dep, name)
+		}
+	}
+	levels, err := g.Levels()
+	if err != nil {
+		return nil, fmt.Errorf("lifecycle: %w", err)
+	}
+	for _, level := range levels {
+		slices.Sort(level)
+	}
+	return levels, nil
+}
+
+func withComponentTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// Start starts every component level by level. If any component fails, the
+// components already started are stopped in reverse order and the start
+// error is returned.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	levels, err := l.order()
+	if err != nil {
+		return err
+	}
+	for _, level := range levels {
+		errs := make([]error, len(level))
+		var wg sync.WaitGroup
+		for i, name := range level {
+			c := l.components[name]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if c.Start == nil {
+					return
+				}
+				cctx, cancel := withComponentTimeout(ctx, c.Timeout)
+				defer cancel()
+				if err := c.Start(cctx); err != nil {
+					errs[i] = fmt.Errorf("lifecycle: start %q: %w", name, err)
+				}
+			}()
+		}
+		wg.Wait()
+		var failed []error
+		for i, name := range level {
+			if errs[i] != nil {
+				failed = append(failed, errs[i])
+			} else {
+				l.started = append(l.started, name)
+			}
+		}
+		if len(failed) > 0 {
+			stopErr := l.stop(context.WithoutCancel(ctx))
+			return errors.Join(append(failed, stopErr)...)
+		}
+	}
+	return nil
+}
+
+// Stop stops started components in reverse start order, continuing past
+// failures and returning them joined.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stop(ctx)
+}
+
+func (l *Lifecycle) stop(ctx context.Context) error {
+	var errs []error
+	for i := len(l.started) - 1; i >= 0; i-- {
+		name := l.started[i]
+		c := l.components[name]
+		if c.Stop == nil {
+			continue
+		}
+		cctx, cancel := withComponentTimeout(ctx, c.Timeout)
+		if err := c.Stop(cctx); err != nil {
+			errs = append(errs, fmt.Errorf("lifecycle: stop %q: %w", name, err))
+		}
+		cancel()
+	}
+	l.started = nil
+	return errors.Join(errs...)
+}
@@ -0,0 +1,159 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type lifecycleRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *lifecycleRecorder) record(e string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func (r *lifecycleRecorder) component(name string, deps ...string) Component {
+	return Component{
+		Name:      name,
+		DependsOn: deps,
+		Start:     func(context.Context) error { r.record("start " + name); return nil },
+		Stop:      func(context.Context) error { r.record("stop " + name); return nil },
+	}
+}
+
+func (r *lifecycleRecorder) index(e string) int {
+	for i, got := range r.events {
+		if got == e {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestLifecycle_StartStopOrder(t *testing.T) {
+	rec := &lifecycleRecorder{}
+	l := NewLifecycle()
+	if err := l.Add(
+		rec.component("http", "api"),
+		rec.component("api", "db", "cache"),
+		rec.component("db"),
+		rec.component("cache"),
+	); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+
+	levels, err := l.Order()
+	if err != nil {
+		t.Fatalf("order failed: %v", err)
+	}
+	if len(levels) != 3 || strings.Join(levels[0], ",") != "cache,db" {
+		t.Fatalf("unexpected levels %v", levels)
+	}
+
+	ctx := context.Background()
+	if err := l.Start(ctx); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	if rec.index("start db") > rec.index("start api") || rec.index("start api") > rec.index("start http") {
+		t.Fatalf("components started out of order: %v", rec.events)
+	}
+
+	if err := l.Stop(ctx); err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+	if rec.index("stop http") > rec.index("stop api") || rec.index("stop api") > rec.index("stop db") {
+		t.Fatalf("components stopped out of order: %v", rec.events)
+	}
+}
+
+func TestLifecycle_IndependentComponentsStartInParallel(t *testing.T) {
+	l := NewLifecycle()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	start := func(context.Context) error {
+		wg.Done()
+		wg.Wait() // deadlocks unless both start concurrently
+		return nil
+	}
+	l.Add(Component{Name: "a", Start: start}, Component{Name: "b", Start: start})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- l.Start(ctx) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("start failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("independent components were not started in parallel")
+	}
+}
+
+func TestLifecycle_FailureRollsBack(t *testing.T) {
+	rec := &lifecycleRecorder{}
+	l := NewLifecycle()
+	boom := errors.New("boom")
+	l.Add(
+		rec.component("db"),
+		Component{
+			Name:      "api",
+			DependsOn: []string{"db"},
+			Start:     func(context.Context) error { return boom },
+		},
+		rec.component("http", "api"),
+	)
+
+	err := l.Start(context.Background())
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected boom, got %v", err)
+	}
+	if rec.index("stop db") < 0 {
+		t.Errorf("expected db to be stopped after failure: %v", rec.events)
+	}
+	if rec.index("start http") >= 0 {
+		t.Errorf("http should not start after its dependency failed: %v", rec.events)
+	}
+}
+
+func TestLifecycle_Timeout(t *testing.T) {
+	l := NewLifecycle()
+	l.Add(Component{
+		Name:    "slow",
+		Timeout: 10 * time.Millisecond,
+		Start: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+	if err := l.Start(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestLifecycle_InvalidGraph(t *testing.T) {
+	l := NewLifecycle()
+	l.Add(Component{Name: "a", DependsOn: []string{"missing"}})
+	if _, err := l.Order(); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("expected unknown dependency error, got %v", err)
+	}
+
+	l = NewLifecycle()
+	l.Add(Component{Name: "a", DependsOn: []string{"b"}}, Component{Name: "b", DependsOn: []string{"a"}})
+	if err := l.Start(context.Background()); !errors.Is(err, ErrDependencyCycle) {
+		t.Errorf("expected ErrDependencyCycle, got %v", err)
+	}
+
+	if err := l.Add(Component{Name: "a"}); err == nil {
+		t.Error("expected duplicate name error")
+	}
+}
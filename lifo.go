@@ -0,0 +1,131 @@
+package generic
+
+import (
+	"context"
+	"sync"
+)
+
+// LiFo is a stack satisfying Queue: Get returns the most recently put
+// item. Put never blocks; Get blocks until an item is available, ctx is
+// done or the stack is closed and drained. The zero value is ready to use.
+type LiFo[T any] struct {
+	mu     sync.Mutex
+	items  []T
+	closed bool
+	notify atomicNotifier
+}
+
+func NewLiFo[T any]() *LiFo[T] {
+	return &LiFo[T]{}
+}
+
+// Put pushes x. It fails only if ctx is already done or the stack is
+// closed.
+func (s *LiFo[T]) Put(ctx context.Context, x T) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !s.TryPut(x) {
+		return ErrClosed
+	}
+	return nil
+}
+
+// TryPut pushes x, reporting false if the stack is closed.
+func (s *LiFo[T]) TryPut(x T) bool {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return false
+	}
+	s.items = append(s.items, x)
+	s.mu.Unlock()
+	s.notify.notify()
+	return true
+}
+
+// pop removes the top item. The caller must hold s.mu.
+func (s *LiFo[T]) pop() (T, bool) {
+	var zero T
+	n := len(s.items)
+	if n == 0 {
+		return zero, false
+	}
+	x := s.items[n-1]
+	s.items[n-1] = zero
+	s.items = s.items[:n-1]
+	if cap(s.items) > minRingCap && n-1 < cap(s.items)/4 {
+		s.items = append([]T(nil), s.items...)
+	}
+	return x, true
+}
+
+// Get pops the top item, blocking until one is available. On a closed
+// stack it returns the remaining items, then ErrClosed.
+func (s *LiFo[T]) Get(ctx context.Context) (T, error) {
+	for {
+		changed := s.notify.wait()
+		s.mu.Lock()
+		x, ok := s.pop()
+		closed := s.closed
+		s.mu.Unlock()
+		if ok {
+			return x, nil
+		}
+		if closed {
+			return x, ErrClosed
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return x, ctx.Err()
+		}
+	}
+}
+
+// TryGet pops the top item without blocking.
+func (s *LiFo[T]) TryGet() (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pop()
+}
+
+// Peek returns the top item without removing it.
+func (s *LiFo[T]) Peek() (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	return s.items[len(s.items)-1], true
+}
+
+func (s *LiFo[T]) Size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items)
+}
+
+func (s *LiFo[T]) IsEmpty() bool {
+	return s.Size() == 0
+}
+
+// Close stops the stack accepting items, with the same semantics as
+// FiFo.Close.
+func (s *LiFo[T]) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.notify.notify()
+	return nil
+}
+
+// Inspect reports the stack state for DebugHandler.
+func (s *LiFo[T]) Inspect() any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return FiFoStats{Size: len(s.items), Empty: len(s.items) == 0, Closed: s.closed}
+}
+
+var _ Queue[int] = (*LiFo[int])(nil)
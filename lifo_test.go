@@ -0,0 +1,80 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLiFo_Order(t *testing.T) {
+	var s LiFo[int]
+	ctx := context.Background()
+	for i := range 3 {
+		s.Put(ctx, i)
+	}
+	if top, _ := s.Peek(); top != 2 {
+		t.Fatalf("Peek = %d", top)
+	}
+	var got []int
+	for !s.IsEmpty() {
+		x, _ := s.Get(ctx)
+		got = append(got, x)
+	}
+	if !slices.Equal(got, []int{2, 1, 0}) {
+		t.Fatalf("order = %v", got)
+	}
+	if _, ok := s.TryGet(); ok {
+		t.Fatal("TryGet on empty stack succeeded")
+	}
+}
+
+func TestLiFo_BlockingGetAndClose(t *testing.T) {
+	s := NewLiFo[string]()
+	ctx := context.Background()
+	got := make(chan string)
+	go func() {
+		x, _ := s.Get(ctx)
+		got <- x
+	}()
+	time.Sleep(10 * time.Millisecond)
+	s.Put(ctx, "x")
+	if x := <-got; x != "x" {
+		t.Fatalf("Get = %q", x)
+	}
+
+	short, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	if _, err := s.Get(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get on empty stack = %v", err)
+	}
+
+	s.Put(ctx, "y")
+	s.Close()
+	if err := s.Put(ctx, "z"); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Put after Close = %v", err)
+	}
+	if x, err := s.Get(ctx); err != nil || x != "y" {
+		t.Fatalf("Get = %q, %v", x, err)
+	}
+	if _, err := s.Get(ctx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Get on drained stack = %v", err)
+	}
+}
+
+func TestLiFo_Shrinks(t *testing.T) {
+	var s LiFo[int]
+	for i := range 1024 {
+		s.TryPut(i)
+	}
+	for range 1020 {
+		s.TryGet()
+	}
+	if c := cap(s.items); c >= 1024 {
+		t.Fatalf("capacity %d after draining", c)
+	}
+	if x, _ := s.TryGet(); x != 3 {
+		t.Fatalf("TryGet = %d, want 3", x)
+	}
+}
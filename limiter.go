@@ -0,0 +1,155 @@
+package generic
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter admits or delays work. *rate.Limiter from golang.org/x/time/rate
+// and *TokenBucket satisfy it.
+type Limiter interface {
+	Allow() bool
+	Wait(ctx context.Context) error
+}
+
+// TokenBucket is a Limiter admitting limit events per second on average,
+// with bursts of up to burst events. It starts full.
+type TokenBucket struct {
+	limit float64
+	burst float64
+	clock Clock
+
+	mu     sync.Mutex
+	tokens float64 // negative while Waits hold reservations
+	last   time.Time
+}
+
+var _ Limiter = (*TokenBucket)(nil)
+
+// NewTokenBucket returns a TokenBucket refilling at limit tokens per second
+// and holding at most burst, which is at least one. A limit of zero or less
+// admits the first burst events and nothing after. A nil clock means
+// SystemClock.
+func NewTokenBucket(limit float64, burst int, clock Clock) *TokenBucket {
+	if clock == nil {
+		clock = SystemClock
+	}
+	burst = max(burst, 1)
+	return &TokenBucket{limit: limit, burst: float64(burst), clock: clock, tokens: float64(burst), last: clock.Now()}
+}
+
+// refillLocked adds the tokens earned since the last call.
+func (b *TokenBucket) refillLocked() {
+	now := b.clock.Now()
+	if b.limit > 0 {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.limit)
+	}
+	b.last = now
+}
+
+// Allow takes a token if one is available now.
+func (b *TokenBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refillLocked()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Wait takes a token, waiting until one is earned or ctx is done. A Wait
+// cut short by ctx gives its token back.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.refillLocked()
+	if b.tokens >= 1 {
+		b.tokens--
+		b.mu.Unlock()
+		return nil
+	}
+	if b.limit <= 0 {
+		b.mu.Unlock()
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	b.tokens--
+	d := time.Duration(-b.tokens / b.limit * float64(time.Second))
+	b.mu.Unlock()
+	select {
+	case <-b.clock.After(d):
+		return nil
+	case <-ctx.Done():
+		b.refund()
+		return ctx.Err()
+	}
+}
+
+// refund returns a token taken for work that didn't happen.
+func (b *TokenBucket) refund() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refillLocked()
+	b.tokens = min(b.burst, b.tokens+1)
+}
+
+// refunder is a Limiter that can give back a token it handed out.
+type refunder interface {
+	refund()
+}
+
+type rateLimitedQueue[T any] struct {
+	Queue[T]
+	l Limiter
+}
+
+// RateLimited wraps q so Get waits for a token from a TokenBucket admitting
+// limit items per second in bursts of up to burst, and TryGet fails without
+// one, throttling consumers without limiter code in each worker.
+func RateLimited[T any](q Queue[T], limit float64, burst int) Queue[T] {
+	return RateLimitedBy(q, NewTokenBucket(limit, burst, nil))
+}
+
+// RateLimitedBy is RateLimited with any Limiter, such as a *rate.Limiter.
+// With a *TokenBucket a Get or TryGet that takes no item gives its token
+// back; other limiters keep it, though TryGet doesn't ask on an empty
+// queue.
+func RateLimitedBy[T any](q Queue[T], l Limiter) Queue[T] {
+	return rateLimitedQueue[T]{Queue: q, l: l}
+}
+
+func (q rateLimitedQueue[T]) Get(ctx context.Context) (T, error) {
+	if err := q.l.Wait(ctx); err != nil {
+		var zero T
+		return zero, err
+	}
+	x, err := q.Queue.Get(ctx)
+	if err != nil {
+		q.refund()
+	}
+	return x, err
+}
+
+func (q rateLimitedQueue[T]) TryGet() (T, bool) {
+	if q.Queue.IsEmpty() || !q.l.Allow() {
+		var zero T
+		return zero, false
+	}
+	// Another consumer may have taken the item since IsEmpty.
+	x, ok := q.Queue.TryGet()
+	if !ok {
+		q.refund()
+	}
+	return x, ok
+}
+
+func (q rateLimitedQueue[T]) refund() {
+	if r, ok := q.l.(refunder); ok {
+		r.refund()
+	}
+}
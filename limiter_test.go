@@ -0,0 +1,124 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// countingLimiter admits n calls, then denies Allow and blocks Wait until
+// ctx is done.
+type countingLimiter struct{ n int }
+
+func (l *countingLimiter) Allow() bool {
+	if l.n == 0 {
+		return false
+	}
+	l.n--
+	return true
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	if l.Allow() {
+		return nil
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestRateLimitedBy(t *testing.T) {
+	ctx := context.Background()
+	fifo := NewFiFo[int]()
+	l := &countingLimiter{n: 2}
+	q := RateLimitedBy[int](fifo, l)
+
+	if _, ok := q.TryGet(); ok || l.n != 2 {
+		t.Fatalf("TryGet on an empty queue spent a token, %d left", l.n)
+	}
+	for i := range 4 {
+		q.Put(ctx, i)
+	}
+	if x, ok := q.TryGet(); !ok || x != 0 {
+		t.Fatalf("TryGet = %d, %v", x, ok)
+	}
+	if x, err := q.Get(ctx); err != nil || x != 1 {
+		t.Fatalf("Get = %d, %v", x, err)
+	}
+	if _, ok := q.TryGet(); ok {
+		t.Fatal("TryGet succeeded without a token")
+	}
+	short, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	if _, err := q.Get(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("throttled Get = %v", err)
+	}
+	if q.Size() != 2 {
+		t.Fatalf("Size = %d, want 2", q.Size())
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	clock := newTestClock()
+	b := NewTokenBucket(10, 2, clock)
+	if !b.Allow() || !b.Allow() || b.Allow() {
+		t.Fatal("expected a burst of 2")
+	}
+	clock.Advance(50 * time.Millisecond)
+	if b.Allow() {
+		t.Fatal("admitted before a token was earned")
+	}
+	clock.Advance(50 * time.Millisecond)
+	if !b.Allow() {
+		t.Fatal("denied after 100ms at 10/s")
+	}
+
+	// Wait reserves the next token and sleeps until it is earned.
+	done := make(chan error, 1)
+	go func() { done <- b.Wait(context.Background()) }()
+	waitFor(t, func() bool { return clock.Waiters() == 1 })
+	clock.Advance(100 * time.Millisecond)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// A cancelled Wait gives its reservation back.
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { done <- b.Wait(ctx) }()
+	waitFor(t, func() bool { return clock.Waiters() == 1 })
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait = %v", err)
+	}
+	clock.Advance(100 * time.Millisecond)
+	if !b.Allow() {
+		t.Fatal("cancelled Wait kept its token")
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	ctx := context.Background()
+	fifo := NewFiFo[int]()
+	q := RateLimited[int](fifo, 1, 1)
+	q.Put(ctx, 1)
+	if x, err := q.Get(ctx); err != nil || x != 1 {
+		t.Fatalf("Get = %d, %v", x, err)
+	}
+	if _, ok := q.TryGet(); ok {
+		t.Fatal("TryGet succeeded without a token")
+	}
+
+	// A Get that takes nothing refunds its token for the next one.
+	fifo = NewFiFo[int]()
+	b := NewTokenBucket(0, 1, nil)
+	q = RateLimitedBy[int](fifo, b)
+	short, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	if _, err := q.Get(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get on an empty queue = %v", err)
+	}
+	q.Put(ctx, 2)
+	if x, ok := q.TryGet(); !ok || x != 2 {
+		t.Fatalf("TryGet = %d, %v", x, ok)
+	}
+}
@@ -0,0 +1,89 @@
+package generic
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Logger is how background loops in this package report problems nobody
+// is waiting on. Attributes stored in the context with WithLogAttrs are
+// attached to every record.
+type Logger interface {
+	Debug(ctx context.Context, msg string, attrs ...slog.Attr)
+	Info(ctx context.Context, msg string, attrs ...slog.Attr)
+	Warn(ctx context.Context, msg string, attrs ...slog.Attr)
+	Error(ctx context.Context, msg string, attrs ...slog.Attr)
+}
+
+// DefaultLogger is used when a component has no Logger. It writes to
+// slog.Default at the time of each call.
+var DefaultLogger Logger = SlogLogger(nil)
+
+// NopLogger discards everything.
+var NopLogger Logger = nopLogger{}
+
+// SlogLogger adapts l to Logger. A nil l means slog.Default.
+func SlogLogger(l *slog.Logger) Logger {
+	return slogLogger{l}
+}
+
+type slogLogger struct{ l *slog.Logger }
+
+func (s slogLogger) log(ctx context.Context, level slog.Level, msg string, attrs []slog.Attr) {
+	l := s.l
+	if l == nil {
+		l = slog.Default()
+	}
+	if !l.Enabled(ctx, level) {
+		return
+	}
+	if extra := LogAttrs(ctx); len(extra) > 0 {
+		attrs = append(extra[:len(extra):len(extra)], attrs...)
+	}
+	l.LogAttrs(ctx, level, msg, attrs...)
+}
+
+func (s slogLogger) Debug(ctx context.Context, msg string, attrs ...slog.Attr) {
+	s.log(ctx, slog.LevelDebug, msg, attrs)
+}
+
+func (s slogLogger) Info(ctx context.Context, msg string, attrs ...slog.Attr) {
+	s.log(ctx, slog.LevelInfo, msg, attrs)
+}
+
+func (s slogLogger) Warn(ctx context.Context, msg string, attrs ...slog.Attr) {
+	s.log(ctx, slog.LevelWarn, msg, attrs)
+}
+
+func (s slogLogger) Error(ctx context.Context, msg string, attrs ...slog.Attr) {
+	s.log(ctx, slog.LevelError, msg, attrs)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(context.Context, string, ...slog.Attr) {}
+func (nopLogger) Info(context.Context, string, ...slog.Attr)  {}
+func (nopLogger) Warn(context.Context, string, ...slog.Attr)  {}
+func (nopLogger) Error(context.Context, string, ...slog.Attr) {}
+
+type logAttrsKey struct{}
+
+// WithLogAttrs returns a context whose log records carry attrs in addition
+// to any attached by outer contexts.
+func WithLogAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	prev := LogAttrs(ctx)
+	return context.WithValue(ctx, logAttrsKey{}, append(prev[:len(prev):len(prev)], attrs...))
+}
+
+// LogAttrs returns the attributes attached to ctx with WithLogAttrs.
+func LogAttrs(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(logAttrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+func loggerOrDefault(l Logger) Logger {
+	if l == nil {
+		return DefaultLogger
+	}
+	return l
+}
@@ -0,0 +1,87 @@
+package generic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newBufferLogger(level slog.Level) (Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	h := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	return SlogLogger(slog.New(h)), &buf
+}
+
+func TestSlogLogger_ContextAttrs(t *testing.T) {
+	l, buf := newBufferLogger(slog.LevelInfo)
+	ctx := WithLogAttrs(context.Background(), slog.String("tenant", "acme"))
+	ctx = WithLogAttrs(ctx, slog.Int("request", 7))
+	l.Info(ctx, "hello", slog.String("k", "v"))
+	l.Debug(ctx, "hidden")
+
+	got := strings.TrimSpace(buf.String())
+	want := `level=INFO msg=hello tenant=acme request=7 k=v`
+	if got != want {
+		t.Fatalf("got %q\nwant %q", got, want)
+	}
+	if len(LogAttrs(context.Background())) != 0 {
+		t.Fatal("background context should carry no attrs")
+	}
+}
+
+func TestSlogLogger_Default(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer slog.SetDefault(prev)
+
+	DefaultLogger.Warn(context.Background(), "uses slog.Default")
+	NopLogger.Error(context.Background(), "dropped")
+	if !strings.Contains(buf.String(), "uses slog.Default") || strings.Contains(buf.String(), "dropped") {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+}
+
+func TestRunEvery_LogsErrors(t *testing.T) {
+	l, buf := newBufferLogger(slog.LevelInfo)
+	clock := newTestClock()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- RunEvery(ctx, time.Second, func(context.Context) error {
+			cancel()
+			return errors.New("boom")
+		}, WithImmediate(), WithRunClock(clock), WithLogger(l))
+	}()
+	<-done
+	if !strings.Contains(buf.String(), "error=boom") {
+		t.Fatalf("expected the error to be logged, got %q", buf.String())
+	}
+}
+
+func TestWatchdog_LogsAlerts(t *testing.T) {
+	l, buf := newBufferLogger(slog.LevelInfo)
+	clock := newTestClock()
+	w := &Watchdog{Clock: clock, Logger: l}
+	hb := w.Heartbeat("worker", time.Second)
+	clock.Advance(2 * time.Second)
+	w.Check()
+	hb.Beat()
+	w.Check()
+	out := buf.String()
+	if !strings.Contains(out, "level=WARN") || !strings.Contains(out, "name=worker") || !strings.Contains(out, "level=INFO") {
+		t.Fatalf("expected warn and recovery records, got %q", out)
+	}
+}
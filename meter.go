@@ -0,0 +1,180 @@
+package generic
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// meterTick is how often Meter folds new events into its moving averages.
+const meterTick = 5 * time.Second
+
+// EWMA is an exponentially weighted moving average of an event rate. Events
+// are recorded with Update and folded into the average on every Tick, which
+// the caller is expected to invoke once per tick interval.
+type EWMA struct {
+	alpha     float64
+	tick      time.Duration
+	uncounted ShardedCounter
+	rate      atomic.Uint64 // math.Float64bits of events per second
+	init      atomic.Bool
+	mu        sync.Mutex
+}
+
+// NewEWMA returns an EWMA that averages over window, assuming Tick is called
+// every tick.
+func NewEWMA(window, tick time.Duration) *EWMA {
+	return &EWMA{
+		alpha: 1 - math.Exp(-float64(tick)/float64(window)),
+		tick:  tick,
+	}
+}
+
+// Update records n events.
+func (e *EWMA) Update(n int64) {
+	e.uncounted.Add(n)
+}
+
+// Tick folds the events recorded since the last tick into the average.
+func (e *EWMA) Tick() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	instant := float64(e.uncounted.Reset()) / e.tick.Seconds()
+	if !e.init.Load() {
+		e.rate.Store(math.Float64bits(instant))
+		e.init.Store(true)
+		return
+	}
+	rate := math.Float64frombits(e.rate.Load())
+	rate += e.alpha * (instant - rate)
+	e.rate.Store(math.Float64bits(rate))
+}
+
+// Rate returns the average rate in events per second.
+func (e *EWMA) Rate() float64 {
+	return math.Float64frombits(e.rate.Load())
+}
+
+// MeterSnapshot is a point-in-time view of a Meter. Rates are per second.
+type MeterSnapshot struct {
+	Count    int64   `json:"count"`
+	Rate1    float64 `json:"rate1"`
+	Rate5    float64 `json:"rate5"`
+	Rate15   float64 `json:"rate15"`
+	RateMean float64 `json:"rate_mean"`
+}
+
+// Meter counts events and tracks their 1, 5 and 15 minute moving average
+// rates. Mark is safe for heavy concurrent use; averages are advanced lazily
+// when the meter is marked or read, so no background goroutine is needed.
+type Meter struct {
+	count    ShardedCounter
+	counted  int64 // count already fed to the averages; guarded by tickMu
+	rate1    *EWMA
+	rate5    *EWMA
+	rate15   *EWMA
+	start    time.Time
+	lastTick atomic.Int64 // nanoseconds since start, on the monotonic clock
+	swapped  atomic.Int64 // nanoseconds since start of the last SwapAll
+	tickMu   sync.Mutex
+	now      func() time.Time
+}
+
+func NewMeter() *Meter {
+	return newMeter(time.Now)
+}
+
+func newMeter(now func() time.Time) *Meter {
+	start := now()
+	m := &Meter{
+		rate1:  NewEWMA(time.Minute, meterTick),
+		rate5:  NewEWMA(5*time.Minute, meterTick),
+		rate15: NewEWMA(15*time.Minute, meterTick),
+		start:  start,
+		now:    now,
+	}
+	return m
+}
+
+// Mark records n events.
+func (m *Meter) Mark(n int64) {
+	m.tickIfNeeded()
+	m.count.Add(n)
+}
+
+// Count returns the number of events recorded since creation or the last
+// SwapAll.
+func (m *Meter) Count() int64 {
+	return m.count.Load()
+}
+
+func (m *Meter) tickIfNeeded() {
+	now := int64(m.now().Sub(m.start))
+	if now-m.lastTick.Load() < int64(meterTick) {
+		return
+	}
+	m.tickMu.Lock()
+	defer m.tickMu.Unlock()
+	last := m.lastTick.Load()
+	ticks := (now - last) / int64(meterTick)
+	if ticks <= 0 {
+		return
+	}
+	m.lastTick.Store(last + ticks*int64(meterTick))
+	total := m.count.Load()
+	delta := total - m.counted
+	m.counted = total
+	for _, e := range [...]*EWMA{m.rate1, m.rate5, m.rate15} {
+		e.Update(delta)
+		for range ticks {
+			e.Tick()
+		}
+	}
+}
+
+// Snapshot returns the current count and rates.
+func (m *Meter) Snapshot() MeterSnapshot {
+	m.tickIfNeeded()
+	s := MeterSnapshot{
+		Count:  m.count.Load(),
+		Rate1:  m.rate1.Rate(),
+		Rate5:  m.rate5.Rate(),
+		Rate15: m.rate15.Rate(),
+	}
+	if elapsed := m.now().Sub(m.start.Add(time.Duration(m.swapped.Load()))).Seconds(); elapsed > 0 {
+		s.RateMean = float64(s.Count) / elapsed
+	}
+	return s
+}
+
+// SwapAll returns a snapshot and zeroes the count in one step, so every
+// Mark is counted in exactly one swap. Count and RateMean in the snapshot
+// cover the time since the previous SwapAll; the moving averages are not
+// reset.
+func (m *Meter) SwapAll() MeterSnapshot {
+	m.tickIfNeeded()
+	m.tickMu.Lock()
+	defer m.tickMu.Unlock()
+	now := int64(m.now().Sub(m.start))
+	from := m.swapped.Swap(now)
+	n := m.count.Reset()
+	// Marks since the last tick are still owed to the averages; counted
+	// goes negative by that amount.
+	m.counted -= n
+	s := MeterSnapshot{
+		Count:  n,
+		Rate1:  m.rate1.Rate(),
+		Rate5:  m.rate5.Rate(),
+		Rate15: m.rate15.Rate(),
+	}
+	if elapsed := time.Duration(now - from).Seconds(); elapsed > 0 {
+		s.RateMean = float64(n) / elapsed
+	}
+	return s
+}
+
+// Inspect reports the meter snapshot for DebugHandler.
+func (m *Meter) Inspect() any {
+	return m.Snapshot()
+}
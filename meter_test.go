@@ -0,0 +1,110 @@
+package generic
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEWMA(t *testing.T) {
+	e := NewEWMA(time.Minute, 5*time.Second)
+	e.Update(300)
+	e.Tick()
+	if got := e.Rate(); got != 60 {
+		t.Fatalf("expected first tick to set rate to 60/s, got %v", got)
+	}
+
+	// With no further events the rate decays by exp(-5/60) per tick.
+	e.Tick()
+	want := 60 * math.Exp(-5.0/60)
+	if got := e.Rate(); math.Abs(got-want) > 1e-9 {
+		t.Fatalf("expected %v after decay, got %v", want, got)
+	}
+
+	for range 12 * 15 {
+		e.Tick()
+	}
+	if got := e.Rate(); got > 0.01 {
+		t.Fatalf("expected rate to decay to ~0, got %v", got)
+	}
+}
+
+type fakeNow struct{ t time.Time }
+
+func (f *fakeNow) now() time.Time { return f.t }
+
+func TestMeter(t *testing.T) {
+	clock := &fakeNow{t: time.Unix(1000, 0)}
+	m := newMeter(clock.now)
+
+	m.Mark(10)
+	m.Mark(5)
+	if got := m.Count(); got != 15 {
+		t.Fatalf("expected count 15, got %d", got)
+	}
+	if s := m.Snapshot(); s.Rate1 != 0 {
+		t.Fatalf("expected no rate before first tick, got %v", s.Rate1)
+	}
+
+	clock.t = clock.t.Add(meterTick)
+	s := m.Snapshot()
+	if s.Count != 15 {
+		t.Errorf("expected count 15, got %d", s.Count)
+	}
+	if s.Rate1 != 3 || s.Rate5 != 3 || s.Rate15 != 3 {
+		t.Errorf("expected all rates 3/s, got %+v", s)
+	}
+	if s.RateMean != 3 {
+		t.Errorf("expected mean 3/s, got %v", s.RateMean)
+	}
+
+	// A long idle period decays the short window faster than the long one.
+	clock.t = clock.t.Add(5 * time.Minute)
+	s = m.Snapshot()
+	if !(s.Rate1 < s.Rate5 && s.Rate5 < s.Rate15) {
+		t.Errorf("expected rate1 < rate5 < rate15 after idle, got %+v", s)
+	}
+}
+
+func TestMeter_Inspect(t *testing.T) {
+	m := NewMeter()
+	m.Mark(1)
+	s, ok := m.Inspect().(MeterSnapshot)
+	if !ok || s.Count != 1 {
+		t.Fatalf("unexpected inspect result: %#v", m.Inspect())
+	}
+}
+
+func BenchmarkMeter_Mark(b *testing.B) {
+	m := NewMeter()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			m.Mark(1)
+		}
+	})
+}
+
+func TestMeter_SwapAll(t *testing.T) {
+	clock := &fakeNow{t: time.Unix(1000, 0)}
+	m := newMeter(clock.now)
+
+	m.Mark(10)
+	clock.t = clock.t.Add(meterTick)
+	s := m.SwapAll()
+	if s.Count != 10 || s.RateMean != 2 || s.Rate1 != 2 {
+		t.Fatalf("unexpected first swap %+v", s)
+	}
+	m.Mark(5)
+	if got := m.Count(); got != 5 {
+		t.Fatalf("expected count 5 after swap, got %d", got)
+	}
+	clock.t = clock.t.Add(meterTick)
+	s = m.SwapAll()
+	if s.Count != 5 || s.RateMean != 1 {
+		t.Fatalf("unexpected second swap %+v", s)
+	}
+	// The averages saw both batches exactly once.
+	if s.Rate15 <= 1 || s.Rate15 >= 2 {
+		t.Fatalf("expected rate15 between 1 and 2, got %v", s.Rate15)
+	}
+}
@@ -0,0 +1,94 @@
+package generic
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownVersion is returned when decoding data whose schema version is
+// newer than the Migrator's, or that has no upgrade path.
+var ErrUnknownVersion = errors.New("unknown schema version")
+
+// Migrator is a Codec that tags encoded items with a schema version and
+// upgrades older payloads when decoding. Upgrades operate on encoded bytes,
+// one version at a time, so old struct definitions only need to live as
+// long as their upgrade function. Use it wherever a Codec persists items,
+// such as OverflowBuffer, Saga records or queue bridges.
+type Migrator[T any] struct {
+	codec    Codec[T]
+	version  int
+	upgrades map[int]func([]byte) ([]byte, error)
+}
+
+var _ Codec[int] = (*Migrator[int])(nil)
+
+// NewMigrator returns a Migrator writing version with codec. Versions start
+// at zero.
+func NewMigrator[T any](codec Codec[T], version int) *Migrator[T] {
+	if version < 0 {
+		panic(fmt.Errorf("generic: negative schema version %d", version))
+	}
+	return &Migrator[T]{codec: codec, version: version, upgrades: make(map[int]func([]byte) ([]byte, error))}
+}
+
+// Register adds the upgrade from version from to from+1. It panics if from
+// is not below the current version or already has an upgrade.
+func (m *Migrator[T]) Register(from int, upgrade func([]byte) ([]byte, error)) {
+	if from < 0 || from >= m.version {
+		panic(fmt.Errorf("generic: upgrade from version %d outside [0, %d)", from, m.version))
+	}
+	if _, ok := m.upgrades[from]; ok {
+		panic(fmt.Errorf("generic: upgrade from version %d already registered", from))
+	}
+	m.upgrades[from] = upgrade
+}
+
+func (m *Migrator[T]) Marshal(x T) ([]byte, error) {
+	data, err := m.codec.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+	out := binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64+len(data)), uint64(m.version))
+	return append(out, data...), nil
+}
+
+func (m *Migrator[T]) Unmarshal(b []byte) (T, error) {
+	var zero T
+	v, n := binary.Uvarint(b)
+	if n <= 0 {
+		return zero, fmt.Errorf("%w: missing version header", ErrUnknownVersion)
+	}
+	if v > uint64(m.version) {
+		return zero, fmt.Errorf("%w: %d is newer than %d", ErrUnknownVersion, v, m.version)
+	}
+	data := b[n:]
+	for from := int(v); from < m.version; from++ {
+		upgrade, ok := m.upgrades[from]
+		if !ok {
+			return zero, fmt.Errorf("%w: no upgrade from %d", ErrUnknownVersion, from)
+		}
+		var err error
+		if data, err = upgrade(data); err != nil {
+			return zero, fmt.Errorf("upgrade from version %d: %w", from, err)
+		}
+	}
+	return m.codec.Unmarshal(data)
+}
+
+// MigrateJSON adapts a typed upgrade between two JSON-encoded versions for
+// Register.
+func MigrateJSON[Old, New any](fn func(Old) (New, error)) func([]byte) ([]byte, error) {
+	return func(b []byte) ([]byte, error) {
+		var old Old
+		if err := json.Unmarshal(b, &old); err != nil {
+			return nil, err
+		}
+		x, err := fn(old)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(x)
+	}
+}
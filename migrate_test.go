@@ -0,0 +1,83 @@
+package generic
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type orderV0 struct {
+	Name string
+}
+
+type orderV1 struct {
+	First, Last string
+}
+
+type orderV2 struct {
+	First, Last string
+	Priority    int
+}
+
+func orderMigrator() *Migrator[orderV2] {
+	m := NewMigrator[orderV2](JSONCodec[orderV2]{}, 2)
+	m.Register(0, MigrateJSON(func(o orderV0) (orderV1, error) {
+		first, last, _ := strings.Cut(o.Name, " ")
+		return orderV1{First: first, Last: last}, nil
+	}))
+	m.Register(1, MigrateJSON(func(o orderV1) (orderV2, error) {
+		return orderV2{First: o.First, Last: o.Last, Priority: 1}, nil
+	}))
+	return m
+}
+
+func TestMigrator_Upgrades(t *testing.T) {
+	// Data written by an older binary at version 0.
+	old, err := NewMigrator[orderV0](JSONCodec[orderV0]{}, 0).Marshal(orderV0{Name: "Ada Lovelace"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := orderMigrator().Unmarshal(old)
+	if err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if want := (orderV2{"Ada", "Lovelace", 1}); got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+
+	// Current data round trips untouched.
+	m := orderMigrator()
+	b, _ := m.Marshal(orderV2{"Alan", "Turing", 5})
+	if got, err := m.Unmarshal(b); err != nil || got.Priority != 5 {
+		t.Fatalf("round trip failed: %+v, %v", got, err)
+	}
+}
+
+func TestMigrator_Errors(t *testing.T) {
+	m := orderMigrator()
+	newer, _ := NewMigrator[orderV2](JSONCodec[orderV2]{}, 3).Marshal(orderV2{})
+	if _, err := m.Unmarshal(newer); !errors.Is(err, ErrUnknownVersion) {
+		t.Fatalf("expected ErrUnknownVersion for newer data, got %v", err)
+	}
+	if _, err := m.Unmarshal(nil); !errors.Is(err, ErrUnknownVersion) {
+		t.Fatalf("expected ErrUnknownVersion for missing header, got %v", err)
+	}
+
+	gap := NewMigrator[orderV2](JSONCodec[orderV2]{}, 2)
+	gap.Register(1, func(b []byte) ([]byte, error) { return b, nil })
+	old, _ := NewMigrator[orderV0](JSONCodec[orderV0]{}, 0).Marshal(orderV0{})
+	if _, err := gap.Unmarshal(old); !errors.Is(err, ErrUnknownVersion) {
+		t.Fatalf("expected missing upgrade error, got %v", err)
+	}
+
+	for _, from := range []int{2, 1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("expected Register(%d) to panic", from)
+				}
+			}()
+			gap.Register(from, nil)
+		}()
+	}
+}
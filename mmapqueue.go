@@ -0,0 +1,273 @@
+//go:build unix
+
+package generic
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// Shared file layout: a header page holding the geometry and the two
+// sequence counters on separate cache lines, followed by the slots. Each
+// slot is a 4-byte big-endian length and the encoded item.
+const (
+	mmapMagic      = "GGSPSC01"
+	mmapHeadOffset = 64
+	mmapTailOffset = 128
+	mmapHeaderSize = 4096
+	mmapLenSize    = 4
+)
+
+// MmapQueue is an experimental single-producer, single-consumer queue over
+// a memory-mapped file, letting two cooperating processes on one host
+// exchange items with microsecond latency. The ring has a fixed number of
+// fixed-size slots; items are encoded with a Codec and must fit a slot.
+// Sequence numbers only ever grow, so each side owns the counter it writes
+// and reads the other's with atomic loads.
+//
+// At most one process or goroutine may put and one may get at a time.
+// Blocked Put and Get poll, spinning briefly before sleeping, since there
+// is no cross-process wakeup.
+type MmapQueue[T any] struct {
+	f        *os.File
+	mem      []byte
+	slots    uint64
+	slotSize uint64
+	head     *atomic.Uint64 // next sequence to read
+	tail     *atomic.Uint64 // next sequence to write
+	codec    Codec[T]
+
+	// mu is held for reading while the mapping is in use, so Close can't
+	// unmap it under an operation.
+	mu     sync.RWMutex
+	closed bool
+}
+
+// OpenMmapQueue opens the queue file at path, creating it with the given
+// geometry if it doesn't exist. An existing file must have been created
+// with the same geometry.
+func OpenMmapQueue[T any](path string, slots, slotSize int, codec Codec[T]) (*MmapQueue[T], error) {
+	if slots <= 0 || slotSize <= mmapLenSize {
+		return nil, fmt.Errorf("generic: invalid MmapQueue geometry %d×%d", slots, slotSize)
+	}
+	size := int64(mmapHeaderSize) + int64(slots)*int64(slotSize)
+	if err := createMmapFile(path, size, slots, slotSize); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	st, err := f.Stat()
+	if err == nil && st.Size() != size {
+		err = fmt.Errorf("generic: %s is %d bytes, expected %d", path, st.Size(), size)
+	}
+	var mem []byte
+	if err == nil {
+		mem, err = syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if string(mem[:8]) != mmapMagic ||
+		binary.LittleEndian.Uint32(mem[8:]) != uint32(slots) ||
+		binary.LittleEndian.Uint32(mem[12:]) != uint32(slotSize) {
+		syscall.Munmap(mem)
+		f.Close()
+		return nil, fmt.Errorf("generic: %s has a different MmapQueue geometry", path)
+	}
+	return &MmapQueue[T]{
+		f:        f,
+		mem:      mem,
+		slots:    uint64(slots),
+		slotSize: uint64(slotSize),
+		head:     (*atomic.Uint64)(unsafe.Pointer(&mem[mmapHeadOffset])),
+		tail:     (*atomic.Uint64)(unsafe.Pointer(&mem[mmapTailOffset])),
+		codec:    codec,
+	}, nil
+}
+
+// createMmapFile initializes a queue file under a temporary name and links
+// it into place, so a concurrent opener sees either no file or a complete
+// header.
+func createMmapFile(path string, size int64, slots, slotSize int) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	tmp := path + ".tmp" + strconv.Itoa(os.Getpid())
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	defer f.Close()
+	var hdr [16]byte
+	copy(hdr[:], mmapMagic)
+	binary.LittleEndian.PutUint32(hdr[8:], uint32(slots))
+	binary.LittleEndian.PutUint32(hdr[12:], uint32(slotSize))
+	if err := f.Truncate(size); err != nil {
+		return err
+	}
+	if _, err := f.WriteAt(hdr[:], 0); err != nil {
+		return err
+	}
+	if err := os.Link(tmp, path); err != nil && !errors.Is(err, os.ErrExist) {
+		return err
+	}
+	return nil
+}
+
+func (q *MmapQueue[T]) slot(seq uint64) []byte {
+	off := mmapHeaderSize + (seq%q.slots)*q.slotSize
+	return q.mem[off : off+q.slotSize]
+}
+
+// write encodes x into the next slot if there is room. It returns false
+// with a nil error when the ring is full.
+func (q *MmapQueue[T]) write(x T) (bool, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return false, ErrClosed
+	}
+	tail := q.tail.Load()
+	if tail-q.head.Load() >= q.slots {
+		return false, nil
+	}
+	data, err := q.codec.Marshal(x)
+	if err != nil {
+		return false, err
+	}
+	if uint64(len(data)) > q.slotSize-mmapLenSize {
+		return false, fmt.Errorf("%w: %d bytes exceeds the %d-byte slot", ErrFrameTooLarge, len(data), q.slotSize-mmapLenSize)
+	}
+	s := q.slot(tail)
+	binary.BigEndian.PutUint32(s, uint32(len(data)))
+	copy(s[mmapLenSize:], data)
+	q.tail.Store(tail + 1)
+	return true, nil
+}
+
+// read decodes the next item if there is one.
+func (q *MmapQueue[T]) read() (T, bool, error) {
+	var zero T
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return zero, false, ErrClosed
+	}
+	head := q.head.Load()
+	if head == q.tail.Load() {
+		return zero, false, nil
+	}
+	s := q.slot(head)
+	n := binary.BigEndian.Uint32(s)
+	if uint64(n) > q.slotSize-mmapLenSize {
+		return zero, false, fmt.Errorf("generic: corrupt MmapQueue slot %d", head%q.slots)
+	}
+	x, err := q.codec.Unmarshal(s[mmapLenSize : mmapLenSize+n])
+	// The slot is consumed even if it can't be decoded, so one bad item
+	// doesn't wedge the queue.
+	q.head.Store(head + 1)
+	return x, err == nil, err
+}
+
+// mmapPoll retries op until it reports done, spinning first and then sleeping
+// with a growing pause up to a millisecond.
+func mmapPoll(ctx context.Context, op func() (bool, error)) error {
+	pause := time.Microsecond
+	for i := 0; ; i++ {
+		if done, err := op(); done || err != nil {
+			return err
+		}
+		if i < defaultWaitSpins {
+			runtime.Gosched()
+			continue
+		}
+		select {
+		case <-time.After(pause):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		pause = min(pause*2, time.Millisecond)
+	}
+}
+
+// Put waits for room and writes x. It returns ErrClosed once the queue is
+// closed.
+func (q *MmapQueue[T]) Put(ctx context.Context, x T) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return mmapPoll(ctx, func() (bool, error) { return q.write(x) })
+}
+
+// TryPut writes x if there is room, reporting false if the ring is full or
+// x can't be encoded into a slot.
+func (q *MmapQueue[T]) TryPut(x T) bool {
+	ok, err := q.write(x)
+	return ok && err == nil
+}
+
+// Get waits for and returns the next item. An item that fails to decode is
+// dropped and its error returned. It returns ErrClosed once the queue is
+// closed.
+func (q *MmapQueue[T]) Get(ctx context.Context) (T, error) {
+	var x T
+	err := mmapPoll(ctx, func() (bool, error) {
+		var ok bool
+		var err error
+		x, ok, err = q.read()
+		return ok, err
+	})
+	return x, err
+}
+
+// TryGet returns the next item if there is one.
+func (q *MmapQueue[T]) TryGet() (T, bool) {
+	x, ok, _ := q.read()
+	return x, ok
+}
+
+// Size returns the number of items in the ring, or zero once the queue is
+// closed.
+func (q *MmapQueue[T]) Size() int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return 0
+	}
+	return int(q.tail.Load() - q.head.Load())
+}
+
+func (q *MmapQueue[T]) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+// Close unmaps the queue, waiting for operations in progress. Items left
+// in the ring stay in the file for the next opener. Closing again does
+// nothing.
+func (q *MmapQueue[T]) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	err := syscall.Munmap(q.mem)
+	if cerr := q.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package generic
+
+import "errors"
+
+// OpenMmapQueue is only supported on Unix systems.
+func OpenMmapQueue[T any](path string, slots, slotSize int, codec Codec[T]) (*MmapQueue[T], error) {
+	return nil, errors.ErrUnsupported
+}
+
+// MmapQueue is only supported on Unix systems.
+type MmapQueue[T any] struct{ Queue[T] }
+
+func (q *MmapQueue[T]) Close() error { return errors.ErrUnsupported }
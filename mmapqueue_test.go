@@ -0,0 +1,203 @@
+//go:build unix
+
+package generic
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMmapQueue_SharedMapping(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "q")
+	prod, err := OpenMmapQueue(path, 4, 64, JSONCodec[ioRecord]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer prod.Close()
+	cons, err := OpenMmapQueue(path, 4, 64, JSONCodec[ioRecord]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cons.Close()
+
+	ctx := context.Background()
+	for i := range 4 {
+		if err := prod.Put(ctx, ioRecord{ID: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if prod.TryPut(ioRecord{ID: 4}) {
+		t.Fatal("ring of 4 should be full")
+	}
+	if cons.Size() != 4 {
+		t.Fatalf("consumer should see 4 items, got %d", cons.Size())
+	}
+	for i := range 4 {
+		x, err := cons.Get(ctx)
+		if err != nil || x.ID != i {
+			t.Fatalf("get %d: %+v %v", i, x, err)
+		}
+	}
+	if _, ok := cons.TryGet(); ok || !prod.IsEmpty() {
+		t.Fatal("queue should be empty")
+	}
+
+	if err := prod.Put(ctx, ioRecord{Name: strings.Repeat("x", 100)}); !errors.Is(err, ErrFrameTooLarge) {
+		t.Fatalf("oversized item should fail, got %v", err)
+	}
+	cctx, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	if _, err := cons.Get(cctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline on empty queue, got %v", err)
+	}
+}
+
+func TestMmapQueue_Geometry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "q")
+	q, err := OpenMmapQueue(path, 8, 32, JSONCodec[int]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.TryPut(7)
+	q.Close()
+
+	if _, err := OpenMmapQueue(path, 16, 32, JSONCodec[int]{}); err == nil {
+		t.Fatal("mismatched geometry should be rejected")
+	}
+	q, err = OpenMmapQueue(path, 8, 32, JSONCodec[int]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	if x, ok := q.TryGet(); !ok || x != 7 {
+		t.Fatalf("items should survive reopening, got %v %v", x, ok)
+	}
+	if _, err := OpenMmapQueue(path, 0, 32, JSONCodec[int]{}); err == nil {
+		t.Fatal("invalid geometry should be rejected")
+	}
+}
+
+func TestMmapQueue_Wraparound(t *testing.T) {
+	q, err := OpenMmapQueue(filepath.Join(t.TempDir(), "q"), 3, 16, JSONCodec[int]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	ctx := context.Background()
+	done := make(chan error, 1)
+	go func() {
+		for i := range 1000 {
+			if err := q.Put(ctx, i); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	for i := range 1000 {
+		x, err := q.Get(ctx)
+		if err != nil || x != i {
+			t.Fatalf("get %d: %v %v", i, x, err)
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMmapQueue_Close(t *testing.T) {
+	q, err := OpenMmapQueue(filepath.Join(t.TempDir(), "q"), 4, 16, JSONCodec[int]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	q.Put(ctx, 1)
+	got := make(chan error, 1)
+	go func() {
+		q.Get(ctx)
+		_, err := q.Get(ctx) // polls the empty ring until Close
+		got <- err
+	}()
+	time.Sleep(5 * time.Millisecond)
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-got; !errors.Is(err, ErrClosed) {
+		t.Fatalf("blocked Get = %v", err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatalf("second Close = %v", err)
+	}
+	if err := q.Put(ctx, 2); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Put after Close = %v", err)
+	}
+	if q.TryPut(2) || q.Size() != 0 || !q.IsEmpty() {
+		t.Fatal("closed queue still in use")
+	}
+	if _, ok := q.TryGet(); ok {
+		t.Fatal("TryGet succeeded after Close")
+	}
+}
+
+const mmapHelperEnv = "GENERIC_MMAP_QUEUE_PRODUCER"
+
+func TestMmapQueue_ProducerProcess(t *testing.T) {
+	path := os.Getenv(mmapHelperEnv)
+	if path == "" {
+		t.Skip("helper for TestMmapQueue_CrossProcess")
+	}
+	q, err := OpenMmapQueue(path, 16, 64, JSONCodec[int]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	for i := range 500 {
+		if err := q.Put(context.Background(), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMmapQueue_CrossProcess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "q")
+	q, err := OpenMmapQueue(path, 16, 64, JSONCodec[int]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestMmapQueue_ProducerProcess$")
+	cmd.Env = append(os.Environ(), mmapHelperEnv+"="+path)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for i := range 500 {
+		x, err := q.Get(ctx)
+		if err != nil || x != i {
+			t.Fatalf("get %d from producer process: %v %v", i, x, err)
+		}
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("producer process: %v", err)
+	}
+}
+
+func BenchmarkMmapQueue_PutGet(b *testing.B) {
+	q, err := OpenMmapQueue(filepath.Join(b.TempDir(), "q"), 1024, 64, JSONCodec[int]{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer q.Close()
+	for b.Loop() {
+		q.TryPut(1)
+		q.TryGet()
+	}
+}
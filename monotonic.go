@@ -0,0 +1,87 @@
+package generic
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// monoEpoch anchors MonotonicDeadline. time.Since on a time.Now value reads
+// only the monotonic clock, so wall-clock steps never move a deadline.
+var monoEpoch = time.Now()
+
+func monoNow() time.Duration { return time.Since(monoEpoch) }
+
+// MonotonicDeadline is a point in time on the process's monotonic clock.
+// Unlike a time.Time that has lost its monotonic reading (through Round(0),
+// encoding, or Unix arithmetic), it cannot be shifted by NTP steps or manual
+// clock changes. It is only meaningful inside the process that created it;
+// convert with Wall and DeadlineAt at process boundaries. The zero value is
+// no deadline.
+//
+// TTL-based types in this package measure time the same way: they only
+// compare readings from their Clock, which for SystemClock carry a
+// monotonic component.
+type MonotonicDeadline struct {
+	at  time.Duration // since monoEpoch
+	set bool
+}
+
+// DeadlineAfter returns the deadline d from now.
+func DeadlineAfter(d time.Duration) MonotonicDeadline {
+	return MonotonicDeadline{at: monoNow() + d, set: true}
+}
+
+// DeadlineAt converts a wall-clock time to a deadline. The wall clock is
+// consulted once, now; later steps do not affect the result.
+func DeadlineAt(t time.Time) MonotonicDeadline {
+	return DeadlineAfter(time.Until(t))
+}
+
+// DeadlineFromContext returns ctx's deadline, if it has one.
+func DeadlineFromContext(ctx context.Context) (MonotonicDeadline, bool) {
+	t, ok := ctx.Deadline()
+	if !ok {
+		return MonotonicDeadline{}, false
+	}
+	return DeadlineAt(t), true
+}
+
+// IsZero reports whether d is no deadline.
+func (d MonotonicDeadline) IsZero() bool { return !d.set }
+
+// Remaining returns the time left until d, which is negative once d has
+// passed. A zero deadline never arrives and reports the maximum duration.
+func (d MonotonicDeadline) Remaining() time.Duration {
+	if !d.set {
+		return math.MaxInt64
+	}
+	return d.at - monoNow()
+}
+
+// Expired reports whether d has passed. A zero deadline never expires.
+func (d MonotonicDeadline) Expired() bool {
+	return d.set && monoNow() >= d.at
+}
+
+// Before reports whether d comes before other. A zero deadline sorts after
+// every real one.
+func (d MonotonicDeadline) Before(other MonotonicDeadline) bool {
+	switch {
+	case !d.set:
+		return false
+	case !other.set:
+		return true
+	}
+	return d.at < other.at
+}
+
+// Wall converts d to a wall-clock time, reading the wall clock now. The
+// result also carries a monotonic reading. A zero deadline returns the zero
+// time.
+func (d MonotonicDeadline) Wall() time.Time {
+	if !d.set {
+		return time.Time{}
+	}
+	return time.Now().Add(d.Remaining())
+}
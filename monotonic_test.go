@@ -0,0 +1,56 @@
+package generic
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMonotonicDeadline(t *testing.T) {
+	var zero MonotonicDeadline
+	if !zero.IsZero() || zero.Expired() || zero.Remaining() != math.MaxInt64 || !zero.Wall().IsZero() {
+		t.Fatal("zero deadline should never expire")
+	}
+
+	d := DeadlineAfter(time.Hour)
+	if d.IsZero() || d.Expired() {
+		t.Fatal("future deadline should not be expired")
+	}
+	if rem := d.Remaining(); rem <= 59*time.Minute || rem > time.Hour {
+		t.Fatalf("unexpected remaining %v", rem)
+	}
+	if diff := time.Until(d.Wall()) - time.Hour; diff > time.Second || diff < -time.Second {
+		t.Fatalf("wall conversion off by %v", diff)
+	}
+
+	past := DeadlineAfter(-time.Millisecond)
+	if !past.Expired() || past.Remaining() >= 0 {
+		t.Fatal("past deadline should be expired")
+	}
+
+	if !past.Before(d) || d.Before(past) || !d.Before(zero) || zero.Before(d) {
+		t.Fatal("unexpected ordering")
+	}
+}
+
+func TestMonotonicDeadline_IgnoresStrippedWallTime(t *testing.T) {
+	// A wall time without a monotonic reading is converted once.
+	wall := time.Now().Add(time.Minute).Round(0)
+	d := DeadlineAt(wall)
+	if rem := d.Remaining(); rem <= 59*time.Second || rem > time.Minute {
+		t.Fatalf("unexpected remaining %v", rem)
+	}
+}
+
+func TestDeadlineFromContext(t *testing.T) {
+	if _, ok := DeadlineFromContext(context.Background()); ok {
+		t.Fatal("background context has no deadline")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	d, ok := DeadlineFromContext(ctx)
+	if !ok || d.Expired() || d.Remaining() > time.Minute {
+		t.Fatalf("unexpected deadline %v, %v", d.Remaining(), ok)
+	}
+}
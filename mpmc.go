@@ -0,0 +1,204 @@
+package generic
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// MPMCQueue is a bounded, lock-free multi-producer multi-consumer Queue
+// after Dmitry Vyukov's array-based design: each slot carries a sequence
+// number that producers and consumers claim with a single CAS on their own
+// cache-line-padded cursor, so TryPut and TryGet never take a lock or
+// allocate. Use it where the channel-token FiFo becomes the bottleneck.
+//
+// Put blocks while the queue is full and Get while it is empty; a blocked
+// caller parks until the other side makes progress. Ordering is FIFO per
+// producer. Close has the usual semantics, except that Puts racing with it
+// may still succeed.
+type MPMCQueue[T any] struct {
+	cells  []mpmcCell[T]
+	mask   uint64
+	enq    CacheLinePadded[atomic.Uint64]
+	deq    CacheLinePadded[atomic.Uint64]
+	closed atomic.Bool
+
+	notEmpty atomicNotifier
+	notFull  atomicNotifier
+}
+
+type mpmcCell[T any] struct {
+	seq   atomic.Uint64
+	value T
+}
+
+// NewMPMCQueue returns a queue holding at most capacity items, rounded up
+// to a power of two.
+func NewMPMCQueue[T any](capacity int) *MPMCQueue[T] {
+	n := 2
+	for n < capacity {
+		n <<= 1
+	}
+	q := &MPMCQueue[T]{cells: make([]mpmcCell[T], n), mask: uint64(n - 1)}
+	for i := range q.cells {
+		q.cells[i].seq.Store(uint64(i))
+	}
+	return q
+}
+
+// enqueue claims the next free slot for x, reporting false if the queue is
+// full.
+func (q *MPMCQueue[T]) enqueue(x T) bool {
+	pos := q.enq.Value.Load()
+	for {
+		cell := &q.cells[pos&q.mask]
+		switch dif := int64(cell.seq.Load() - pos); {
+		case dif == 0:
+			if q.enq.Value.CompareAndSwap(pos, pos+1) {
+				cell.value = x
+				cell.seq.Store(pos + 1)
+				return true
+			}
+			pos = q.enq.Value.Load()
+		case dif < 0:
+			return false
+		default:
+			pos = q.enq.Value.Load()
+		}
+	}
+}
+
+// dequeue takes the oldest filled slot, reporting false if the queue is
+// empty.
+func (q *MPMCQueue[T]) dequeue() (T, bool) {
+	pos := q.deq.Value.Load()
+	for {
+		cell := &q.cells[pos&q.mask]
+		switch dif := int64(cell.seq.Load() - (pos + 1)); {
+		case dif == 0:
+			if q.deq.Value.CompareAndSwap(pos, pos+1) {
+				x := cell.value
+				var zero T
+				cell.value = zero
+				cell.seq.Store(pos + q.mask + 1)
+				return x, true
+			}
+			pos = q.deq.Value.Load()
+		case dif < 0:
+			var zero T
+			return zero, false
+		default:
+			pos = q.deq.Value.Load()
+		}
+	}
+}
+
+// TryPut adds x without blocking, reporting false if the queue is full or
+// closed.
+func (q *MPMCQueue[T]) TryPut(x T) bool {
+	if q.closed.Load() || !q.enqueue(x) {
+		return false
+	}
+	q.notEmpty.notify()
+	return true
+}
+
+// Put adds x, waiting for room while the queue is full. It returns
+// ErrClosed once the queue is closed.
+func (q *MPMCQueue[T]) Put(ctx context.Context, x T) error {
+	// The notifier is only armed once the queue is found full, so the fast
+	// path doesn't allocate.
+	var changed <-chan struct{}
+	for {
+		if q.closed.Load() {
+			return ErrClosed
+		}
+		if q.enqueue(x) {
+			q.notEmpty.notify()
+			return nil
+		}
+		if changed == nil {
+			changed = q.notFull.wait()
+			continue
+		}
+		select {
+		case <-changed:
+			changed = nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// TryGet removes the oldest item without blocking.
+func (q *MPMCQueue[T]) TryGet() (T, bool) {
+	x, ok := q.dequeue()
+	if ok {
+		q.notFull.notify()
+	}
+	return x, ok
+}
+
+// Get removes the oldest item, waiting while the queue is empty. On a
+// closed queue it returns the remaining items, then ErrClosed.
+func (q *MPMCQueue[T]) Get(ctx context.Context) (T, error) {
+	var changed <-chan struct{}
+	for {
+		closed := q.closed.Load()
+		if x, ok := q.dequeue(); ok {
+			q.notFull.notify()
+			return x, nil
+		}
+		if closed {
+			var zero T
+			return zero, ErrClosed
+		}
+		if changed == nil {
+			changed = q.notEmpty.wait()
+			continue
+		}
+		select {
+		case <-changed:
+			changed = nil
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// Size returns the number of queued items. Under concurrent use it is an
+// estimate.
+func (q *MPMCQueue[T]) Size() int {
+	deq := q.deq.Value.Load()
+	enq := q.enq.Value.Load()
+	if enq < deq {
+		return 0
+	}
+	return int(min(enq-deq, q.mask+1))
+}
+
+func (q *MPMCQueue[T]) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+// Cap returns the queue's capacity.
+func (q *MPMCQueue[T]) Cap() int {
+	return len(q.cells)
+}
+
+// Close stops the queue accepting items. Gets drain the remaining items
+// and then return ErrClosed instead of blocking.
+func (q *MPMCQueue[T]) Close() error {
+	q.closed.Store(true)
+	q.notEmpty.notify()
+	q.notFull.notify()
+	return nil
+}
+
+// Inspect reports the queue state for DebugHandler.
+func (q *MPMCQueue[T]) Inspect() any {
+	n := q.Size()
+	return FiFoStats{Size: n, Empty: n == 0, Capacity: len(q.cells), Closed: q.closed.Load()}
+}
+
+var _ Queue[int] = (*MPMCQueue[int])(nil)
@@ -0,0 +1,175 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMPMCQueue_Basic(t *testing.T) {
+	ctx := context.Background()
+	q := NewMPMCQueue[int](3)
+	if q.Cap() != 4 {
+		t.Fatalf("Cap = %d, want 4", q.Cap())
+	}
+	for i := range 4 {
+		if !q.TryPut(i) {
+			t.Fatalf("TryPut(%d) failed", i)
+		}
+	}
+	if q.TryPut(4) {
+		t.Fatal("TryPut on a full queue succeeded")
+	}
+	if q.Size() != 4 {
+		t.Fatalf("Size = %d", q.Size())
+	}
+	for want := range 4 {
+		if x, err := q.Get(ctx); err != nil || x != want {
+			t.Fatalf("Get = %d, %v, want %d", x, err, want)
+		}
+	}
+	if _, ok := q.TryGet(); ok || !q.IsEmpty() {
+		t.Fatal("queue not empty after draining")
+	}
+}
+
+func TestMPMCQueue_BlockingAndClose(t *testing.T) {
+	ctx := context.Background()
+	q := NewMPMCQueue[int](2)
+	q.Put(ctx, 1)
+	q.Put(ctx, 2)
+	done := make(chan error, 1)
+	go func() { done <- q.Put(ctx, 3) }()
+	time.Sleep(10 * time.Millisecond)
+	if x, _ := q.Get(ctx); x != 1 {
+		t.Fatalf("Get = %d", x)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("blocked Put = %v", err)
+	}
+
+	short, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	if err := q.Put(short, 4); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Put on full queue = %v", err)
+	}
+
+	got := make(chan error, 1)
+	empty := NewMPMCQueue[int](2)
+	go func() {
+		_, err := empty.Get(ctx)
+		got <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	empty.Close()
+	if err := <-got; !errors.Is(err, ErrClosed) {
+		t.Fatalf("Get woken by Close = %v", err)
+	}
+
+	q.Close()
+	if err := q.Put(ctx, 5); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Put after Close = %v", err)
+	}
+	for _, want := range []int{2, 3} {
+		if x, err := q.Get(ctx); err != nil || x != want {
+			t.Fatalf("Get = %d, %v, want %d", x, err, want)
+		}
+	}
+	if _, err := q.Get(ctx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Get on drained queue = %v", err)
+	}
+}
+
+func TestMPMCQueue_Concurrent(t *testing.T) {
+	const producers, perProducer = 4, 5000
+	ctx := context.Background()
+	q := NewMPMCQueue[int](64)
+	var wg sync.WaitGroup
+	for p := range producers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perProducer {
+				q.Put(ctx, p*perProducer+i)
+			}
+		}()
+	}
+	results := make(chan []int, producers)
+	for range producers {
+		go func() {
+			var got []int
+			for {
+				x, err := q.Get(ctx)
+				if err != nil {
+					results <- got
+					return
+				}
+				got = append(got, x)
+			}
+		}()
+	}
+	wg.Wait()
+	q.Close()
+
+	seen := make([]bool, producers*perProducer)
+	for range producers {
+		got := <-results
+		// Items of one producer reach a consumer in order.
+		last := make([]int, producers)
+		for i := range last {
+			last[i] = -1
+		}
+		for _, x := range got {
+			if seen[x] {
+				t.Fatalf("item %d delivered twice", x)
+			}
+			seen[x] = true
+			p := x / perProducer
+			if x <= last[p] {
+				t.Fatalf("producer %d items out of order: %d after %d", p, x, last[p])
+			}
+			last[p] = x
+		}
+	}
+	for x, ok := range seen {
+		if !ok {
+			t.Fatalf("item %d lost", x)
+		}
+	}
+}
+
+func TestMPMCQueue_NoAllocs(t *testing.T) {
+	ctx := context.Background()
+	q := NewMPMCQueue[int](64)
+	allocs := testing.AllocsPerRun(1000, func() {
+		q.Put(ctx, 1)
+		q.Get(ctx)
+	})
+	if allocs != 0 {
+		t.Fatalf("Put/Get allocated %v times", allocs)
+	}
+}
+
+func BenchmarkMPMCQueue_Parallel(b *testing.B) {
+	ctx := context.Background()
+	q := NewMPMCQueue[int](1024)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			q.Put(ctx, 1)
+			q.Get(ctx)
+		}
+	})
+}
+
+func BenchmarkFiFo_Parallel(b *testing.B) {
+	ctx := context.Background()
+	q := NewFiFo[int]()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			q.Put(ctx, 1)
+			q.Get(ctx)
+		}
+	})
+}
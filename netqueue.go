@@ -0,0 +1,567 @@
+package generic
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Bridge protocol: each request and response is one frame (see
+// QueueWriter). A request is an op byte followed by an optional encoded
+// item; a response is a status byte followed by an item, a size or an error
+// message. A connection carries one request at a time.
+//
+// From version 1 a connection opens with a hello request carrying the
+// client's supported version range, codec identifier and compression; the
+// server answers with the highest common version or a handshake error.
+// Version 0 connections, from clients predating the handshake, start
+// straight with a request.
+const (
+	netOpPut byte = iota + 1
+	netOpTryPut
+	netOpGet
+	netOpTryGet
+	netOpSize
+	netOpHello
+)
+
+const (
+	netStatusOK byte = iota
+	netStatusNone
+	netStatusErr
+	// netStatusClosed reports ErrClosed from the served queue to version 1
+	// clients; version 0 clients get it as a netStatusErr message.
+	netStatusClosed
+)
+
+// netProtocolVersion is the newest bridge protocol version.
+const netProtocolVersion = 1
+
+var (
+	ErrProtocolVersion     = errors.New("no common bridge protocol version")
+	ErrCodecMismatch       = errors.New("bridge codec mismatch")
+	ErrCompressionMismatch = errors.New("bridge compression mismatch")
+)
+
+// Handshake failure reasons, sent after netStatusErr in a hello response.
+var netHandshakeReasons = []error{ErrProtocolVersion, ErrCodecMismatch, ErrCompressionMismatch}
+
+// HandshakeError is returned by RemoteQueue calls when the server rejects
+// the connection handshake. It wraps ErrProtocolVersion, ErrCodecMismatch or
+// ErrCompressionMismatch. Such calls are not retried.
+type HandshakeError struct {
+	Reason error
+	// Detail is the server's description of the mismatch.
+	Detail string
+}
+
+func (e *HandshakeError) Error() string {
+	return fmt.Sprintf("%v: %s", e.Reason, e.Detail)
+}
+
+func (e *HandshakeError) Unwrap() error { return e.Reason }
+
+// NetQueueOption configures ListenQueue and DialQueue.
+type NetQueueOption = Option[netQueueOptions]
+
+type netQueueOptions struct {
+	minVersion, maxVersion int
+	codec                  string
+	compression            string
+}
+
+// WithProtocolVersions sets the range of bridge protocol versions spoken.
+// Servers default to 0 through the newest version, so clients predating
+// the handshake can still connect during a rolling upgrade; a minVersion of
+// 1 turns them away. Clients always handshake and default to the newest
+// version only.
+func WithProtocolVersions(minVersion, maxVersion int) NetQueueOption {
+	return func(o *netQueueOptions) { o.minVersion, o.maxVersion = minVersion, maxVersion }
+}
+
+// WithCodecID names the codec's wire format, such as "json/v2". When both
+// ends name one, the handshake fails with ErrCodecMismatch unless they are
+// equal.
+func WithCodecID(id string) NetQueueOption {
+	return func(o *netQueueOptions) { o.codec = id }
+}
+
+// WithCompression names the compression the codec applies, such as "gzip".
+// When both ends name one, the handshake fails with ErrCompressionMismatch
+// unless they are equal.
+func WithCompression(name string) NetQueueOption {
+	return func(o *netQueueOptions) { o.compression = name }
+}
+
+// negotiate picks the version for a client hello, or returns the index of
+// the handshake reason and a description.
+func (o netQueueOptions) negotiate(hello []byte) (version int, reason int, detail string) {
+	lo, n1 := binary.Uvarint(hello)
+	hi, n2 := binary.Uvarint(hello[max(n1, 0):])
+	if n1 <= 0 || n2 <= 0 {
+		return 0, 0, "malformed hello"
+	}
+	rest := hello[n1+n2:]
+	codec, rest := netString(rest)
+	compression, _ := netString(rest)
+	version = min(int(hi), o.maxVersion)
+	switch {
+	case version < int(lo) || version < max(o.minVersion, 1):
+		return 0, 0, fmt.Sprintf("client speaks %d-%d, server %d-%d", lo, hi, o.minVersion, o.maxVersion)
+	case codec != "" && o.codec != "" && codec != o.codec:
+		return 0, 1, fmt.Sprintf("client codec %q, server %q", codec, o.codec)
+	case compression != "" && o.compression != "" && compression != o.compression:
+		return 0, 2, fmt.Sprintf("client compression %q, server %q", compression, o.compression)
+	}
+	return version, -1, ""
+}
+
+func appendNetString(b []byte, s string) []byte {
+	return append(binary.AppendUvarint(b, uint64(len(s))), s...)
+}
+
+func netString(b []byte) (string, []byte) {
+	n, k := binary.Uvarint(b)
+	if k <= 0 || uint64(len(b)-k) < n {
+		return "", nil
+	}
+	return string(b[k : k+int(n)]), b[k+int(n):]
+}
+
+// ListenQueue serves q to DialQueue clients connecting on lis until ctx is
+// done, then closes lis and every connection and returns ctx.Err(). A Put
+// blocks the client until q accepts the item, so a bounded q pushes
+// backpressure onto remote producers. A Put or Get on a closed q fails on
+// the client with ErrClosed. A dequeued item whose response cannot be
+// written is put back on q, possibly out of order; since a write can
+// succeed after the client has gone, items in flight when a client
+// disconnects may still be lost.
+//
+// Connections are version-negotiated; see WithProtocolVersions, WithCodecID
+// and WithCompression.
+func ListenQueue[T any](ctx context.Context, lis net.Listener, q Queue[T], codec Codec[T], opts ...NetQueueOption) error {
+	o := NewOptions(netQueueOptions{maxVersion: netProtocolVersion}, opts...)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := CloseOnDone(ctx, lis)
+	defer stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serveQueueConn(ctx, conn, q, codec, o)
+		}()
+	}
+}
+
+func serveQueueConn[T any](ctx context.Context, conn net.Conn, q Queue[T], codec Codec[T], o netQueueOptions) {
+	defer conn.Close()
+	// connCtx ends when the server stops or the client hangs up, which
+	// aborts a blocked Put or Get.
+	connCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := CloseOnDone(connCtx, conn)
+	defer stop()
+
+	reqs := make(chan []byte)
+	go func() {
+		defer cancel()
+		for {
+			frame, err := readFrame(conn)
+			if err != nil {
+				return
+			}
+			select {
+			case reqs <- frame:
+			case <-connCtx.Done():
+				return
+			}
+		}
+	}()
+
+	var resp []byte
+	first := true
+	version := 0
+	for {
+		var req []byte
+		select {
+		case req = <-reqs:
+		case <-connCtx.Done():
+			return
+		}
+		if len(req) == 0 {
+			return
+		}
+		resp = resp[:0]
+		if first {
+			first = false
+			if req[0] == netOpHello {
+				v, reason, detail := o.negotiate(req[1:])
+				if reason >= 0 {
+					writeFrame(conn, append([]byte{netStatusErr, byte(reason)}, detail...))
+					return
+				}
+				if writeFrame(conn, binary.AppendUvarint([]byte{netStatusOK}, uint64(v))) != nil {
+					return
+				}
+				version = v
+				continue
+			}
+			if o.minVersion > 0 {
+				writeFrame(conn, append([]byte{netStatusErr}, "generic: bridge protocol version 0 is not supported"...))
+				return
+			}
+		}
+		var taken T
+		var took bool
+		switch req[0] {
+		case netOpPut, netOpTryPut:
+			x, err := codec.Unmarshal(req[1:])
+			switch {
+			case err != nil:
+			case req[0] == netOpPut:
+				err = q.Put(connCtx, x)
+			case !q.TryPut(x):
+				resp = append(resp, netStatusNone)
+			}
+			if err != nil {
+				if connCtx.Err() != nil {
+					return
+				}
+				resp = appendNetErr(resp, err, version)
+			} else if len(resp) == 0 {
+				resp = append(resp, netStatusOK)
+			}
+		case netOpGet, netOpTryGet:
+			if req[0] == netOpGet {
+				x, err := q.Get(connCtx)
+				if err != nil {
+					if connCtx.Err() != nil {
+						return
+					}
+					if writeFrame(conn, appendNetErr(resp, err, version)) != nil {
+						return
+					}
+					continue
+				}
+				taken, took = x, true
+			} else {
+				taken, took = q.TryGet()
+			}
+			if !took {
+				resp = append(resp, netStatusNone)
+				break
+			}
+			data, err := codec.Marshal(taken)
+			if err != nil {
+				resp = append(append(resp, netStatusErr), err.Error()...)
+				break
+			}
+			resp = append(append(resp, netStatusOK), data...)
+		case netOpSize:
+			resp = binary.AppendUvarint(append(resp, netStatusOK), uint64(q.Size()))
+		default:
+			return
+		}
+		if err := writeFrame(conn, resp); err != nil {
+			if took {
+				q.Put(ctx, taken)
+			}
+			return
+		}
+	}
+}
+
+// appendNetErr appends the error response for err, using netStatusClosed
+// for ErrClosed if the client speaks version 1.
+func appendNetErr(resp []byte, err error, version int) []byte {
+	if errors.Is(err, ErrClosed) && version >= 1 {
+		return append(resp, netStatusClosed)
+	}
+	return append(append(resp, netStatusErr), err.Error()...)
+}
+
+// RemoteQueue is a Queue served by ListenQueue in another process. It keeps
+// a pool of connections, dialing on demand; a blocked Get holds its own
+// connection so it never delays other calls.
+type RemoteQueue[T any] struct {
+	ctx     context.Context
+	network string
+	addr    string
+	codec   Codec[T]
+	hello   []byte
+
+	mu   sync.Mutex
+	idle []net.Conn
+}
+
+var _ Queue[int] = (*RemoteQueue[int])(nil)
+
+// DialQueue returns a client for the queue served at addr, which is a TCP
+// host:port or "unix:" followed by a socket path. Connections are made
+// lazily; Put and Get redial with backoff until their context is done, so
+// the client rides out server restarts. The client stops working and closes
+// its connections once ctx is done.
+//
+// A Put cut off after the server may have received it is not retried,
+// since the server may already hold the item.
+//
+// Every new connection starts with a handshake; a server that rejects it
+// makes calls fail with a *HandshakeError.
+func DialQueue[T any](ctx context.Context, addr string, codec Codec[T], opts ...NetQueueOption) *RemoteQueue[T] {
+	network := "tcp"
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		network, addr = "unix", path
+	}
+	o := NewOptions(netQueueOptions{minVersion: netProtocolVersion, maxVersion: netProtocolVersion}, opts...)
+	hello := binary.AppendUvarint([]byte{netOpHello}, uint64(max(o.minVersion, 1)))
+	hello = binary.AppendUvarint(hello, uint64(max(o.maxVersion, 1)))
+	hello = appendNetString(appendNetString(hello, o.codec), o.compression)
+	r := &RemoteQueue[T]{ctx: ctx, network: network, addr: addr, codec: codec, hello: hello}
+	context.AfterFunc(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for _, c := range r.idle {
+			c.Close()
+		}
+		r.idle = nil
+	})
+	return r
+}
+
+func (r *RemoteQueue[T]) Put(ctx context.Context, x T) error {
+	data, err := r.codec.Marshal(x)
+	if err != nil {
+		return err
+	}
+	_, _, err = r.call(ctx, netOpPut, data, true)
+	return err
+}
+
+func (r *RemoteQueue[T]) TryPut(x T) bool {
+	data, err := r.codec.Marshal(x)
+	if err != nil {
+		return false
+	}
+	status, _, err := r.call(r.ctx, netOpTryPut, data, false)
+	return err == nil && status == netStatusOK
+}
+
+func (r *RemoteQueue[T]) Get(ctx context.Context) (T, error) {
+	_, body, err := r.call(ctx, netOpGet, nil, true)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return r.codec.Unmarshal(body)
+}
+
+func (r *RemoteQueue[T]) TryGet() (T, bool) {
+	var zero T
+	status, body, err := r.call(r.ctx, netOpTryGet, nil, false)
+	if err != nil || status != netStatusOK {
+		return zero, false
+	}
+	x, err := r.codec.Unmarshal(body)
+	if err != nil {
+		return zero, false
+	}
+	return x, true
+}
+
+// Size returns the remote queue's size, or 0 if the server is unreachable.
+func (r *RemoteQueue[T]) Size() int {
+	_, body, err := r.call(r.ctx, netOpSize, nil, false)
+	if err != nil {
+		return 0
+	}
+	n, _ := binary.Uvarint(body)
+	return int(n)
+}
+
+func (r *RemoteQueue[T]) IsEmpty() bool {
+	return r.Size() == 0
+}
+
+// call sends one request and returns the response status and body. With
+// retry set, dial failures and stale connections are retried with backoff
+// until ctx is done.
+func (r *RemoteQueue[T]) call(ctx context.Context, op byte, payload []byte, retry bool) (byte, []byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(r.ctx, cancel)
+	defer stop()
+
+	req := append([]byte{op}, payload...)
+	backoff := 10 * time.Millisecond
+	for {
+		status, body, sent, err := r.roundTrip(ctx, req)
+		if err == nil {
+			switch status {
+			case netStatusErr:
+				return status, nil, fmt.Errorf("remote queue: %s", body)
+			case netStatusClosed:
+				return status, nil, ErrClosed
+			}
+			return status, body, nil
+		}
+		if ctx.Err() != nil {
+			return 0, nil, ctx.Err()
+		}
+		// A sent Put may have been applied; everything else is safe to
+		// repeat. A rejected handshake won't get better.
+		var he *HandshakeError
+		if !retry || (sent && (op == netOpPut || op == netOpTryPut)) || errors.As(err, &he) {
+			return 0, nil, err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return 0, nil, ctx.Err()
+		}
+		backoff = min(2*backoff, time.Second)
+	}
+}
+
+// roundTrip runs req on one connection. sent reports whether the request
+// was fully written.
+func (r *RemoteQueue[T]) roundTrip(ctx context.Context, req []byte) (status byte, body []byte, sent bool, err error) {
+	conn, reused, err := r.conn(ctx)
+	if err != nil {
+		return 0, nil, false, err
+	}
+	// Closing the connection is the only way to abort a blocked request;
+	// the server notices and gives up too.
+	stop := CloseOnDone(ctx, conn)
+	defer stop()
+	if err := writeFrame(conn, req); err != nil {
+		conn.Close()
+		return 0, nil, false, err
+	}
+	resp, err := readFrame(conn)
+	if err == nil && len(resp) == 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		conn.Close()
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		// A pooled connection closed or reset before any reply was
+		// dropped by the server while idle, so the request never arrived.
+		dropped := reused && (errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNRESET))
+		return 0, nil, !dropped, err
+	}
+	// The whole response arrived, so return it even if ctx was cancelled
+	// meanwhile: the server has already applied the request, and dropping
+	// a Get response would lose the item.
+	if stop() {
+		r.release(conn)
+	} else {
+		conn.Close()
+	}
+	return resp[0], resp[1:], true, nil
+}
+
+// conn returns an idle connection, or dials a new one. reused reports
+// whether the connection came from the pool.
+func (r *RemoteQueue[T]) conn(ctx context.Context) (c net.Conn, reused bool, err error) {
+	r.mu.Lock()
+	if n := len(r.idle); n > 0 {
+		c = r.idle[n-1]
+		r.idle = r.idle[:n-1]
+		r.mu.Unlock()
+		return c, true, nil
+	}
+	r.mu.Unlock()
+	var d net.Dialer
+	c, err = d.DialContext(ctx, r.network, r.addr)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := r.handshake(ctx, c); err != nil {
+		c.Close()
+		return nil, false, err
+	}
+	return c, false, nil
+}
+
+// handshake sends the hello request on a new connection and checks the
+// server's answer.
+func (r *RemoteQueue[T]) handshake(ctx context.Context, c net.Conn) error {
+	stop := CloseOnDone(ctx, c)
+	defer stop()
+	if err := writeFrame(c, r.hello); err != nil {
+		return err
+	}
+	resp, err := readFrame(c)
+	if err == nil && len(resp) == 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	if resp[0] == netStatusOK {
+		return nil
+	}
+	if len(resp) < 2 || int(resp[1]) >= len(netHandshakeReasons) {
+		return fmt.Errorf("remote queue: handshake failed: %s", resp[1:])
+	}
+	return &HandshakeError{Reason: netHandshakeReasons[resp[1]], Detail: string(resp[2:])}
+}
+
+func (r *RemoteQueue[T]) release(c net.Conn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ctx.Err() != nil {
+		c.Close()
+		return
+	}
+	r.idle = append(r.idle, c)
+}
+
+func writeFrame(w io.Writer, data []byte) error {
+	frame := make([]byte, frameHeaderSize, frameHeaderSize+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(data)))
+	_, err := w.Write(append(frame, data...))
+	return err
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	var hdr [frameHeaderSize]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(hdr[:])
+	if n > maxFrameSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrFrameTooLarge, n)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return data, nil
+}
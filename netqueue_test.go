@@ -0,0 +1,251 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func serveTestQueue(t *testing.T, lis net.Listener, q Queue[string]) (stop func()) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- ListenQueue[string](ctx, lis, q, JSONCodec[string]{}) }()
+	return func() {
+		cancel()
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Errorf("ListenQueue returned %v", err)
+		}
+	}
+}
+
+func TestNetQueue_RoundTrip(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := NewFiFo[string]()
+	defer serveTestQueue(t, lis, q)()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	rq := DialQueue[string](ctx, lis.Addr().String(), JSONCodec[string]{})
+
+	if err := rq.Put(ctx, "a"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if !rq.TryPut("b") {
+		t.Fatal("TryPut failed")
+	}
+	if n := rq.Size(); n != 2 || rq.IsEmpty() {
+		t.Fatalf("expected size 2, got %d", n)
+	}
+	if x, err := rq.Get(ctx); err != nil || x != "a" {
+		t.Fatalf("expected a, got %q, %v", x, err)
+	}
+	if x, ok := rq.TryGet(); !ok || x != "b" {
+		t.Fatalf("expected b, got %q, %v", x, ok)
+	}
+	if _, ok := rq.TryGet(); ok {
+		t.Fatal("expected empty queue")
+	}
+
+	// A blocked Get does not hold up other calls.
+	got := make(chan string)
+	go func() {
+		x, _ := rq.Get(ctx)
+		got <- x
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if err := rq.Put(ctx, "c"); err != nil {
+		t.Fatalf("put while Get blocked: %v", err)
+	}
+	if x := <-got; x != "c" {
+		t.Fatalf("expected c, got %q", x)
+	}
+}
+
+func TestNetQueue_CancelGetKeepsItems(t *testing.T) {
+	lis, err := net.Listen("unix", filepath.Join(t.TempDir(), "q.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := NewFiFo[string]()
+	defer serveTestQueue(t, lis, q)()
+
+	rq := DialQueue[string](context.Background(), "unix:"+lis.Addr().String(), JSONCodec[string]{})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := rq.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	// The abandoned server-side Get must not swallow the next item.
+	time.Sleep(10 * time.Millisecond)
+	q.Put(context.Background(), "kept")
+	time.Sleep(10 * time.Millisecond)
+	if x, ok := q.TryGet(); !ok || x != "kept" {
+		t.Fatalf("expected item to stay on the queue, got %q, %v", x, ok)
+	}
+}
+
+func TestNetQueue_Reconnect(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	q := NewFiFo[string]()
+	stop := serveTestQueue(t, lis, q)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	rq := DialQueue[string](ctx, addr, JSONCodec[string]{})
+	if err := rq.Put(ctx, "before"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	stop()
+
+	// Restart the server after the client has started retrying.
+	restarted := make(chan func())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Error(err)
+			restarted <- func() {}
+			return
+		}
+		restarted <- serveTestQueue(t, lis, q)
+	}()
+	if err := rq.Put(ctx, "after"); err != nil {
+		t.Fatalf("put after restart: %v", err)
+	}
+	defer (<-restarted)()
+	if n := q.Size(); n != 2 {
+		t.Fatalf("expected 2 items, got %d", n)
+	}
+}
+
+func TestNetQueue_RemoteError(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ListenQueue[int](ctx, lis, NewFiFo[int](), JSONCodec[int]{})
+
+	// The server decodes ints, so a string payload fails remotely.
+	rq := DialQueue[string](ctx, lis.Addr().String(), JSONCodec[string]{})
+	if err := rq.Put(ctx, "nope"); err == nil {
+		t.Fatal("expected remote decode error")
+	}
+}
+
+func TestNetQueue_Handshake(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go ListenQueue[string](ctx, lis, NewFiFo[string](), JSONCodec[string]{},
+		WithCodecID("json"), WithCompression("gzip"))
+	addr := lis.Addr().String()
+
+	ok := DialQueue[string](ctx, addr, JSONCodec[string]{}, WithCodecID("json"))
+	if err := ok.Put(ctx, "a"); err != nil {
+		t.Fatalf("Put with matching codec = %v", err)
+	}
+
+	cases := []struct {
+		opts []NetQueueOption
+		want error
+	}{
+		{[]NetQueueOption{WithCodecID("gob")}, ErrCodecMismatch},
+		{[]NetQueueOption{WithCompression("zstd")}, ErrCompressionMismatch},
+		{[]NetQueueOption{WithProtocolVersions(2, 3)}, ErrProtocolVersion},
+	}
+	for _, c := range cases {
+		rq := DialQueue[string](ctx, addr, JSONCodec[string]{}, c.opts...)
+		err := rq.Put(ctx, "b")
+		var he *HandshakeError
+		if !errors.Is(err, c.want) || !errors.As(err, &he) {
+			t.Fatalf("Put = %v, want %v", err, c.want)
+		}
+		if ctx.Err() != nil {
+			t.Fatal("handshake failure was retried until the deadline")
+		}
+	}
+}
+
+func TestNetQueue_LegacyClient(t *testing.T) {
+	for _, minVersion := range []int{0, 1} {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		go ListenQueue[string](ctx, lis, NewFiFo[string](), JSONCodec[string]{},
+			WithProtocolVersions(minVersion, 1))
+
+		// A version 0 client sends requests without a hello.
+		conn, err := net.Dial("tcp", lis.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		if err := writeFrame(conn, []byte{netOpSize}); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := readFrame(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := netStatusOK
+		if minVersion > 0 {
+			want = netStatusErr
+		}
+		if resp[0] != want {
+			t.Fatalf("min version %d: legacy Size status = %d, want %d", minVersion, resp[0], want)
+		}
+	}
+}
+
+func TestNetQueue_ClosedQueue(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := NewFiFo[string]()
+	defer serveTestQueue(t, lis, q)()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	rq := DialQueue[string](ctx, lis.Addr().String(), JSONCodec[string]{})
+
+	// A Get blocked on the server is answered when the queue closes.
+	got := make(chan error, 1)
+	go func() {
+		_, err := rq.Get(ctx)
+		got <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	q.Close()
+	if err := <-got; !errors.Is(err, ErrClosed) {
+		t.Fatalf("blocked Get = %v", err)
+	}
+	if err := rq.Put(ctx, "a"); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Put on closed queue = %v", err)
+	}
+	if _, err := rq.Get(ctx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Get on closed queue = %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("closed queue was retried until the deadline")
+	}
+}
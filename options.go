@@ -0,0 +1,38 @@
+package generic
+
+// Option configures a T, usually an unexported options struct behind a
+// constructor. Package constructors use named aliases of it, such as
+// FiFoOption.
+type Option[T any] func(*T)
+
+// Apply applies opts to dst in order, skipping nil options.
+func Apply[T any](dst *T, opts ...Option[T]) {
+	for _, opt := range opts {
+		if opt != nil {
+			opt(dst)
+		}
+	}
+}
+
+// NewOptions returns defaults with opts applied.
+func NewOptions[T any](defaults T, opts ...Option[T]) T {
+	Apply(&defaults, opts...)
+	return defaults
+}
+
+// ValidOptions is NewOptions followed by the result's Validate method, if
+// *T has one.
+func ValidOptions[T any](defaults T, opts ...Option[T]) (T, error) {
+	o := NewOptions(defaults, opts...)
+	if v, ok := any(&o).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return o, err
+		}
+	}
+	return o, nil
+}
+
+// Combine bundles several options into one, for presets.
+func Combine[T any](opts ...Option[T]) Option[T] {
+	return func(o *T) { Apply(o, opts...) }
+}
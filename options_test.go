@@ -0,0 +1,62 @@
+package generic
+
+import (
+	"errors"
+	"testing"
+)
+
+type testOptions struct {
+	name    string
+	retries int
+}
+
+func (o *testOptions) Validate() error {
+	if o.retries < 0 {
+		return errors.New("negative retries")
+	}
+	return nil
+}
+
+func withName(name string) Option[testOptions] {
+	return func(o *testOptions) { o.name = name }
+}
+
+func withRetries(n int) Option[testOptions] {
+	return func(o *testOptions) { o.retries = n }
+}
+
+func TestOptions(t *testing.T) {
+	defaults := testOptions{name: "default", retries: 3}
+	o := NewOptions(defaults, withRetries(5), nil)
+	if o.name != "default" || o.retries != 5 {
+		t.Fatalf("unexpected options %+v", o)
+	}
+	if defaults.retries != 3 {
+		t.Fatal("defaults must not be modified")
+	}
+
+	preset := Combine(withName("fast"), withRetries(0))
+	o = NewOptions(defaults, preset, withRetries(1))
+	if o.name != "fast" || o.retries != 1 {
+		t.Fatalf("later options should override presets, got %+v", o)
+	}
+
+	if _, err := ValidOptions(defaults, withRetries(-1)); err == nil {
+		t.Fatal("expected validation error")
+	}
+	if o, err := ValidOptions(defaults, withName("x")); err != nil || o.name != "x" {
+		t.Fatalf("unexpected %+v %v", o, err)
+	}
+	// Types without Validate always pass.
+	if _, err := ValidOptions(fifoOptions{}, WithInitialCapacity(4)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOptions_PackageAliases(t *testing.T) {
+	var opt FiFoOption = Combine(WithInitialCapacity(8), WithWakeupOrder(WakeupFIFO))
+	q := NewFiFo[int](opt)
+	if q.waiters == nil || q.waiters.order != WakeupFIFO {
+		t.Fatal("combined FiFo options should apply")
+	}
+}
@@ -0,0 +1,147 @@
+package generic
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// OutboxRecord is a committed item waiting in an outbox table. ID must
+// increase with commit order.
+type OutboxRecord[T any] struct {
+	ID   uint64
+	Item T
+}
+
+// OutboxStore is the durable side of an Outbox, usually a table written in
+// the same database transaction as the business data.
+type OutboxStore[T any] interface {
+	// Fetch returns up to limit committed, unacknowledged records in ID
+	// order.
+	Fetch(ctx context.Context, limit int) ([]OutboxRecord[T], error)
+	// Ack marks records as delivered so Fetch no longer returns them.
+	Ack(ctx context.Context, ids []uint64) error
+}
+
+// Outbox relays items committed to an OutboxStore into a Queue. Each
+// committed record is put on the queue once per process: records whose Ack
+// failed are remembered and not put again. A crash between Put and Ack
+// redelivers those records after restart.
+type Outbox[T any] struct {
+	// Interval is how often Relay polls the store when not notified.
+	// Defaults to one second.
+	Interval time.Duration
+	// Batch is the maximum number of records fetched per pass. Defaults
+	// to 100.
+	Batch int
+	// Clock is used for polling. Defaults to SystemClock.
+	Clock Clock
+	// OnError receives store and queue errors from Relay.
+	OnError func(error)
+	// Logger records Relay errors when OnError is nil. Defaults to
+	// DefaultLogger.
+	Logger Logger
+
+	store OutboxStore[T]
+	q     Queue[T]
+	wake  chan struct{}
+
+	mu      sync.Mutex
+	unacked []uint64 // put on q but not yet acknowledged
+}
+
+func NewOutbox[T any](store OutboxStore[T], q Queue[T]) *Outbox[T] {
+	return &Outbox[T]{store: store, q: q, wake: make(chan struct{}, 1)}
+}
+
+// Stage runs tx, which should write the business data and the outbox
+// records in one database transaction, and wakes the relay if it succeeds.
+func (o *Outbox[T]) Stage(ctx context.Context, tx func(ctx context.Context) error) error {
+	if err := tx(ctx); err != nil {
+		return err
+	}
+	o.Notify()
+	return nil
+}
+
+// Notify wakes Relay to fetch right away instead of at the next poll.
+func (o *Outbox[T]) Notify() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (o *Outbox[T]) batch() int {
+	if o.Batch > 0 {
+		return o.Batch
+	}
+	return 100
+}
+
+// Flush relays one batch of records, returning how many were put on the
+// queue.
+func (o *Outbox[T]) Flush(ctx context.Context) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.unacked) > 0 {
+		if err := o.store.Ack(ctx, o.unacked); err != nil {
+			return 0, err
+		}
+		o.unacked = nil
+	}
+	recs, err := o.store.Fetch(ctx, o.batch())
+	if err != nil {
+		return 0, err
+	}
+	ids := make([]uint64, 0, len(recs))
+	var putErr error
+	for _, rec := range recs {
+		if putErr = o.q.Put(ctx, rec.Item); putErr != nil {
+			break
+		}
+		ids = append(ids, rec.ID)
+	}
+	if len(ids) > 0 {
+		if err := o.store.Ack(ctx, ids); err != nil {
+			o.unacked = slices.Clip(ids)
+			return len(ids), err
+		}
+	}
+	return len(ids), putErr
+}
+
+// Relay flushes the outbox whenever notified or every Interval until ctx is
+// done, then returns ctx.Err(). Full batches are followed immediately by
+// another pass.
+func (o *Outbox[T]) Relay(ctx context.Context) error {
+	clock := o.Clock
+	if clock == nil {
+		clock = SystemClock
+	}
+	interval := o.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	for {
+		n, err := o.Flush(ctx)
+		if err != nil && ctx.Err() == nil {
+			if o.OnError != nil {
+				o.OnError(err)
+			} else {
+				loggerOrDefault(o.Logger).Error(ctx, "generic: outbox relay failed", slog.Any("error", err))
+			}
+		}
+		if err == nil && n == o.batch() {
+			continue
+		}
+		select {
+		case <-o.wake:
+		case <-clock.After(interval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memOutbox is an in-memory OutboxStore with switchable failures.
+type memOutbox struct {
+	mu      sync.Mutex
+	nextID  uint64
+	rows    []OutboxRecord[string]
+	failAck bool
+	acks    int
+}
+
+func (s *memOutbox) insert(items ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, x := range items {
+		s.nextID++
+		s.rows = append(s.rows, OutboxRecord[string]{ID: s.nextID, Item: x})
+	}
+}
+
+func (s *memOutbox) Fetch(ctx context.Context, limit int) ([]OutboxRecord[string], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.rows[:min(limit, len(s.rows))]), nil
+}
+
+func (s *memOutbox) Ack(ctx context.Context, ids []uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.acks++
+	if s.failAck {
+		return errors.New("ack failed")
+	}
+	s.rows = slices.DeleteFunc(s.rows, func(r OutboxRecord[string]) bool { return slices.Contains(ids, r.ID) })
+	return nil
+}
+
+func drainStrings(q *FiFo[string]) []string {
+	var out []string
+	for {
+		x, ok := q.TryGet()
+		if !ok {
+			return out
+		}
+		out = append(out, x)
+	}
+}
+
+func TestOutbox_Flush(t *testing.T) {
+	store := &memOutbox{}
+	q := NewFiFo[string]()
+	ob := NewOutbox[string](store, q)
+	ob.Batch = 2
+	ctx := context.Background()
+
+	store.insert("a", "b", "c")
+	if n, err := ob.Flush(ctx); err != nil || n != 2 {
+		t.Fatalf("expected 2 relayed, got %d, %v", n, err)
+	}
+	if n, err := ob.Flush(ctx); err != nil || n != 1 {
+		t.Fatalf("expected 1 relayed, got %d, %v", n, err)
+	}
+	if got := drainStrings(q); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Fatalf("unexpected queue contents %v", got)
+	}
+}
+
+func TestOutbox_AckFailureDoesNotDuplicate(t *testing.T) {
+	store := &memOutbox{failAck: true}
+	q := NewFiFo[string]()
+	ob := NewOutbox[string](store, q)
+	ctx := context.Background()
+
+	store.insert("a")
+	if _, err := ob.Flush(ctx); err == nil {
+		t.Fatal("expected ack error")
+	}
+	if _, err := ob.Flush(ctx); err == nil {
+		t.Fatal("expected ack retry to fail")
+	}
+	store.mu.Lock()
+	store.failAck = false
+	store.mu.Unlock()
+	if n, err := ob.Flush(ctx); err != nil || n != 0 {
+		t.Fatalf("expected retried ack and nothing new, got %d, %v", n, err)
+	}
+	if got := drainStrings(q); !slices.Equal(got, []string{"a"}) {
+		t.Fatalf("expected exactly one delivery, got %v", got)
+	}
+	if len(store.rows) != 0 {
+		t.Fatalf("expected record to be acknowledged, got %v", store.rows)
+	}
+}
+
+func TestOutbox_RelayStage(t *testing.T) {
+	store := &memOutbox{}
+	q := NewFiFo[string]()
+	ob := NewOutbox[string](store, q)
+	ob.Interval = time.Hour // only notifications should wake the relay
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- ob.Relay(ctx) }()
+
+	if err := ob.Stage(ctx, func(context.Context) error {
+		store.insert("order-1")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	getCtx, stop := context.WithTimeout(ctx, time.Second)
+	defer stop()
+	if x, err := q.Get(getCtx); err != nil || x != "order-1" {
+		t.Fatalf("expected order-1, got %q, %v", x, err)
+	}
+
+	txErr := errors.New("rollback")
+	if err := ob.Stage(ctx, func(context.Context) error { return txErr }); err != txErr {
+		t.Fatalf("expected tx error, got %v", err)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package generic
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+var ErrBufferClosed = errors.New("buffer is closed")
+
+// OverflowBuffer is a FIFO buffer that keeps up to a fixed number of items in
+// memory and spills the rest to a temporary file, reading them back in order
+// as the memory part drains. It lets a producer keep going for a long time
+// while its consumer is stalled without holding everything on the heap.
+//
+// The spill file is created on first overflow, truncated whenever it is fully
+// drained, and removed by Close.
+type OverflowBuffer[T any] struct {
+	mu       sync.Mutex
+	mem      *FiFo[T]
+	memLen   int
+	memLimit int
+	codec    Codec[T]
+	dir      string
+	file     *os.File
+	readOff  int64
+	writeOff int64
+	spilled  int
+	closed   bool
+	notify   atomicNotifier
+}
+
+// NewOverflowBuffer returns a buffer holding up to memLimit items in memory.
+// Overflow is encoded with codec into a temporary file in dir, or the default
+// temporary directory if dir is empty.
+func NewOverflowBuffer[T any](memLimit int, codec Codec[T], dir string) *OverflowBuffer[T] {
+	if memLimit < 1 {
+		memLimit = 1
+	}
+	return &OverflowBuffer[T]{
+		mem:      NewFiFo[T](),
+		memLimit: memLimit,
+		codec:    codec,
+		dir:      dir,
+	}
+}
+
+// Put appends x, spilling it to disk if the memory part is full or earlier
+// items are already on disk.
+func (b *OverflowBuffer[T]) Put(ctx context.Context, x T) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrBufferClosed
+	}
+	if b.spilled == 0 && b.memLen < b.memLimit {
+		b.mem.TryPut(x) // never fails: all access is under b.mu
+		b.memLen++
+	} else if err := b.spill(x); err != nil {
+		return err
+	}
+	b.notify.notify()
+	return nil
+}
+
+func (b *OverflowBuffer[T]) spill(x T) error {
+	data, err := b.codec.Marshal(x)
+	if err != nil {
+		return fmt.Errorf("overflow buffer: encode: %w", err)
+	}
+	if b.file == nil {
+		f, err := os.CreateTemp(b.dir, "overflow-*")
+		if err != nil {
+			return fmt.Errorf("overflow buffer: %w", err)
+		}
+		b.file = f
+	}
+	frame := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(data)))
+	copy(frame[4:], data)
+	if _, err := b.file.WriteAt(frame, b.writeOff); err != nil {
+		return fmt.Errorf("overflow buffer: %w", err)
+	}
+	b.writeOff += int64(len(frame))
+	b.spilled++
+	return nil
+}
+
+// refill moves spilled items back into memory until it is full.
+func (b *OverflowBuffer[T]) refill() error {
+	var hdr [4]byte
+	for b.spilled > 0 && b.memLen < b.memLimit {
+		if _, err := b.file.ReadAt(hdr[:], b.readOff); err != nil {
+			return fmt.Errorf("overflow buffer: %w", err)
+		}
+		data := make([]byte, binary.BigEndian.Uint32(hdr[:]))
+		if _, err := b.file.ReadAt(data, b.readOff+4); err != nil {
+			return fmt.Errorf("overflow buffer: %w", err)
+		}
+		x, err := b.codec.Unmarshal(data)
+		if err != nil {
+			return fmt.Errorf("overflow buffer: decode: %w", err)
+		}
+		b.readOff += int64(4 + len(data))
+		b.spilled--
+		b.mem.TryPut(x)
+		b.memLen++
+	}
+	if b.spilled == 0 && b.writeOff > 0 {
+		b.readOff, b.writeOff = 0, 0
+		if err := b.file.Truncate(0); err != nil {
+			return fmt.Errorf("overflow buffer: %w", err)
+		}
+	}
+	return nil
+}
+
+func (b *OverflowBuffer[T]) pop() (T, bool, error) {
+	if b.memLen == 0 {
+		if err := b.refill(); err != nil {
+			var zero T
+			return zero, false, err
+		}
+	}
+	x, ok := b.mem.TryGet()
+	if ok {
+		b.memLen--
+	}
+	return x, ok, nil
+}
+
+// TryGet removes the next item without blocking.
+func (b *OverflowBuffer[T]) TryGet() (T, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.pop()
+}
+
+// Get removes the next item, blocking until one is available, ctx is done or
+// the buffer is closed and drained.
+func (b *OverflowBuffer[T]) Get(ctx context.Context) (T, error) {
+	var zero T
+	for {
+		changed := b.notify.wait()
+		b.mu.Lock()
+		x, ok, err := b.pop()
+		closed := b.closed
+		b.mu.Unlock()
+		if err != nil || ok {
+			return x, err
+		}
+		if closed {
+			return zero, ErrBufferClosed
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// Size returns the number of buffered items, in memory and on disk.
+func (b *OverflowBuffer[T]) Size() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.memLen + b.spilled
+}
+
+// Spilled returns the number of items currently on disk.
+func (b *OverflowBuffer[T]) Spilled() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.spilled
+}
+
+// Close stops accepting items, discards anything still spilled and removes
+// the spill file. Items already in memory can still be drained with Get.
+func (b *OverflowBuffer[T]) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	b.spilled = 0
+	b.readOff, b.writeOff = 0, 0
+	b.notify.notify()
+	if b.file == nil {
+		return nil
+	}
+	name := b.file.Name()
+	err := b.file.Close()
+	if rmErr := os.Remove(name); err == nil {
+		err = rmErr
+	}
+	b.file = nil
+	return err
+}
@@ -0,0 +1,110 @@
+package generic
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestOverflowBuffer_SpillsAndPreservesOrder(t *testing.T) {
+	dir := t.TempDir()
+	b := NewOverflowBuffer[int](3, JSONCodec[int]{}, dir)
+	defer b.Close()
+	ctx := context.Background()
+
+	for i := range 10 {
+		if err := b.Put(ctx, i); err != nil {
+			t.Fatalf("put %d failed: %v", i, err)
+		}
+	}
+	if got := b.Size(); got != 10 {
+		t.Fatalf("expected size 10, got %d", got)
+	}
+	if got := b.Spilled(); got != 7 {
+		t.Fatalf("expected 7 spilled items, got %d", got)
+	}
+
+	// Interleave puts with gets; new items must queue behind spilled ones.
+	for i := range 5 {
+		x, err := b.Get(ctx)
+		if err != nil || x != i {
+			t.Fatalf("expected %d, got %d (err %v)", i, x, err)
+		}
+	}
+	b.Put(ctx, 10)
+	for i := 5; i <= 10; i++ {
+		x, err := b.Get(ctx)
+		if err != nil || x != i {
+			t.Fatalf("expected %d, got %d (err %v)", i, x, err)
+		}
+	}
+	if got := b.Size(); got != 0 {
+		t.Fatalf("expected empty buffer, got size %d", got)
+	}
+
+	// The spill file is truncated once drained.
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("expected one spill file, got %d", len(entries))
+	}
+	info, _ := entries[0].Info()
+	if info.Size() != 0 {
+		t.Errorf("expected drained spill file to be truncated, size %d", info.Size())
+	}
+}
+
+func TestOverflowBuffer_GetBlocksUntilPut(t *testing.T) {
+	b := NewOverflowBuffer[string](1, JSONCodec[string]{}, t.TempDir())
+	defer b.Close()
+
+	got := make(chan string, 1)
+	go func() {
+		x, _ := b.Get(context.Background())
+		got <- x
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	b.Put(context.Background(), "hello")
+	select {
+	case x := <-got:
+		if x != "hello" {
+			t.Fatalf("expected hello, got %q", x)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Get did not wake up after Put")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := b.Get(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestOverflowBuffer_CloseRemovesFile(t *testing.T) {
+	dir := t.TempDir()
+	b := NewOverflowBuffer[int](1, GobCodec[int]{}, dir)
+	ctx := context.Background()
+	b.Put(ctx, 1)
+	b.Put(ctx, 2)
+
+	if err := b.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("expected spill file to be removed, found %d entries", len(entries))
+	}
+	if err := b.Put(ctx, 3); err != ErrBufferClosed {
+		t.Errorf("expected ErrBufferClosed, got %v", err)
+	}
+
+	// In-memory items drain, then Get reports closure instead of blocking.
+	if x, err := b.Get(ctx); err != nil || x != 1 {
+		t.Fatalf("expected 1, got %d (err %v)", x, err)
+	}
+	if _, err := b.Get(ctx); err != ErrBufferClosed {
+		t.Errorf("expected ErrBufferClosed, got %v", err)
+	}
+}
@@ -0,0 +1,14 @@
+package generic
+
+// cacheLineSize is a conservative cache line size. 64 bytes covers x86-64 and
+// most arm64 cores; Apple silicon uses 128 but prefetches in pairs of 64.
+const cacheLineSize = 64
+
+// CacheLinePadded holds a value followed by a full cache line of padding, so
+// consecutive CacheLinePadded values in an array or struct never share a
+// cache line. Use it for independently written hot fields, such as per-shard
+// counters, to avoid false sharing.
+type CacheLinePadded[T any] struct {
+	Value T
+	_     [cacheLineSize]byte
+}
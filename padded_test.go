@@ -0,0 +1,46 @@
+package generic
+
+import (
+	"sync/atomic"
+	"testing"
+	"unsafe"
+)
+
+func TestCacheLinePadded_Separation(t *testing.T) {
+	var arr [8]CacheLinePadded[atomic.Int64]
+	a := uintptr(unsafe.Pointer(&arr[0].Value))
+	b := uintptr(unsafe.Pointer(&arr[1].Value))
+	if b-a < cacheLineSize {
+		t.Fatalf("padded values are only %d bytes apart", b-a)
+	}
+
+	var s CacheLinePadded[string]
+	s.Value = "hot"
+	if s.Value != "hot" {
+		t.Fatal("unexpected value")
+	}
+}
+
+// Each goroutine owns one of eight adjacent counters, so the only contention
+// is false sharing; compare ns/op of the padded and unpadded variants.
+func BenchmarkCounters_Unpadded(b *testing.B) {
+	var counters [8]atomic.Int64
+	var next atomic.Int32
+	b.RunParallel(func(pb *testing.PB) {
+		c := &counters[next.Add(1)&7]
+		for pb.Next() {
+			c.Add(1)
+		}
+	})
+}
+
+func BenchmarkCounters_Padded(b *testing.B) {
+	var counters [8]CacheLinePadded[atomic.Int64]
+	var next atomic.Int32
+	b.RunParallel(func(pb *testing.PB) {
+		c := &counters[next.Add(1)&7].Value
+		for pb.Next() {
+			c.Add(1)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package generic
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is a recovered panic converted into an error.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it was an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// CatchPanic calls fn, converting a panic into a *PanicError holding the
+// panic value and the stack where it happened. A *PanicError re-raised by
+// Rethrow is returned as is, keeping its original stack.
+func CatchPanic[T any](fn func() (T, error)) (v T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			var zero T
+			v, err = zero, panicError(r)
+		}
+	}()
+	return fn()
+}
+
+func panicError(r any) *PanicError {
+	if pe, ok := r.(*PanicError); ok {
+		return pe
+	}
+	return &PanicError{Value: r, Stack: debug.Stack()}
+}
+
+// Rethrow panics with err unless it is nil. If err wraps a *PanicError,
+// that is re-raised instead, so a panic carried across goroutines or
+// recovery layers keeps its value and original stack.
+func Rethrow(err error) {
+	if err == nil {
+		return
+	}
+	var pe *PanicError
+	if errors.As(err, &pe) {
+		panic(pe)
+	}
+	panic(err)
+}
+
+// PanicValue returns the value of the panic recorded in err's chain if it
+// is a T.
+func PanicValue[T any](err error) (T, bool) {
+	var pe *PanicError
+	if errors.As(err, &pe) {
+		v, ok := pe.Value.(T)
+		return v, ok
+	}
+	var zero T
+	return zero, false
+}
+
+// recoverError calls fn, converting a panic into a *PanicError.
+func recoverError(fn func() error) error {
+	_, err := CatchPanic(func() (struct{}, error) { return struct{}{}, fn() })
+	return err
+}
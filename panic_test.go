@@ -0,0 +1,76 @@
+package generic
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRecoverError(t *testing.T) {
+	if err := recoverError(func() error { return nil }); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	boom := errors.New("boom")
+	if err := recoverError(func() error { return boom }); err != boom {
+		t.Fatalf("expected boom, got %v", err)
+	}
+
+	err := recoverError(func() error { panic("oops") })
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected *PanicError, got %T", err)
+	}
+	if pe.Value != "oops" || err.Error() != "panic: oops" {
+		t.Errorf("unexpected panic error %v", pe)
+	}
+	if !strings.Contains(string(pe.Stack), "TestRecoverError") {
+		t.Errorf("expected stack to include the test function")
+	}
+
+	err = recoverError(func() error { panic(boom) })
+	if !errors.Is(err, boom) {
+		t.Errorf("expected panic error to unwrap to boom, got %v", err)
+	}
+}
+
+func TestCatchPanic(t *testing.T) {
+	v, err := CatchPanic(func() (int, error) { return 7, nil })
+	if v != 7 || err != nil {
+		t.Fatalf("CatchPanic = %d, %v", v, err)
+	}
+
+	type payload struct{ code int }
+	v, err = CatchPanic(func() (int, error) { panic(payload{42}) })
+	if p, ok := PanicValue[payload](err); v != 0 || !ok || p.code != 42 {
+		t.Fatalf("CatchPanic = %d, %v", v, err)
+	}
+	if _, ok := PanicValue[string](err); ok {
+		t.Error("PanicValue matched the wrong type")
+	}
+
+	// Rethrowing across a goroutine keeps the original panic.
+	errs := make(chan error)
+	go func() {
+		_, err := CatchPanic(func() (int, error) { panic("in worker") })
+		errs <- err
+	}()
+	first := <-errs
+	_, err = CatchPanic(func() (int, error) {
+		Rethrow(fmt.Errorf("job failed: %w", first))
+		return 0, nil
+	})
+	var pe *PanicError
+	if !errors.As(err, &pe) || error(pe) != first {
+		t.Fatalf("rethrown panic = %v", err)
+	}
+	if !strings.Contains(string(pe.Stack), "TestCatchPanic.func") {
+		t.Error("stack of the original panic lost")
+	}
+
+	_, err = CatchPanic(func() (int, error) { Rethrow(nil); return 1, nil })
+	if err != nil {
+		t.Fatalf("Rethrow(nil) = %v", err)
+	}
+}
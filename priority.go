@@ -0,0 +1,160 @@
+package generic
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// PriorityQueue is a Queue that hands out the least item first, as ordered
+// by less. Items that compare equal come out in the order they were put.
+// Put never blocks; Get blocks until an item is available, ctx is done or
+// the queue is closed and drained.
+type PriorityQueue[T any] struct {
+	mu     sync.Mutex
+	h      priorityHeap[T]
+	seq    uint64
+	closed bool
+	notify atomicNotifier
+}
+
+type priorityItem[T any] struct {
+	x   T
+	seq uint64
+}
+
+type priorityHeap[T any] struct {
+	items []priorityItem[T]
+	less  func(a, b T) bool
+}
+
+func (h *priorityHeap[T]) Len() int { return len(h.items) }
+
+func (h *priorityHeap[T]) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	if h.less(a.x, b.x) {
+		return true
+	}
+	if h.less(b.x, a.x) {
+		return false
+	}
+	return a.seq < b.seq
+}
+
+func (h *priorityHeap[T]) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *priorityHeap[T]) Push(x any) { h.items = append(h.items, x.(priorityItem[T])) }
+
+func (h *priorityHeap[T]) Pop() any {
+	n := len(h.items)
+	it := h.items[n-1]
+	h.items[n-1] = priorityItem[T]{}
+	h.items = h.items[:n-1]
+	return it
+}
+
+// NewPriorityQueue returns an empty queue ordered by less.
+func NewPriorityQueue[T any](less func(a, b T) bool) *PriorityQueue[T] {
+	return &PriorityQueue[T]{h: priorityHeap[T]{less: less}}
+}
+
+// Put adds x. It fails only if ctx is already done or the queue is closed.
+func (q *PriorityQueue[T]) Put(ctx context.Context, x T) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !q.TryPut(x) {
+		return ErrClosed
+	}
+	return nil
+}
+
+// TryPut adds x, reporting false if the queue is closed.
+func (q *PriorityQueue[T]) TryPut(x T) bool {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return false
+	}
+	q.seq++
+	heap.Push(&q.h, priorityItem[T]{x: x, seq: q.seq})
+	q.mu.Unlock()
+	q.notify.notify()
+	return true
+}
+
+// Get removes the least item, blocking until one is available. On a closed
+// queue it returns the remaining items, then ErrClosed.
+func (q *PriorityQueue[T]) Get(ctx context.Context) (T, error) {
+	for {
+		changed := q.notify.wait()
+		q.mu.Lock()
+		if q.h.Len() > 0 {
+			x := heap.Pop(&q.h).(priorityItem[T]).x
+			q.mu.Unlock()
+			return x, nil
+		}
+		closed := q.closed
+		q.mu.Unlock()
+		var zero T
+		if closed {
+			return zero, ErrClosed
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// TryGet removes the least item without blocking.
+func (q *PriorityQueue[T]) TryGet() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.h.Len() == 0 {
+		var zero T
+		return zero, false
+	}
+	return heap.Pop(&q.h).(priorityItem[T]).x, true
+}
+
+// Peek returns the least item without removing it.
+func (q *PriorityQueue[T]) Peek() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.h.Len() == 0 {
+		var zero T
+		return zero, false
+	}
+	return q.h.items[0].x, true
+}
+
+func (q *PriorityQueue[T]) Size() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.h.Len()
+}
+
+func (q *PriorityQueue[T]) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+// Close stops the queue accepting items, with the same semantics as
+// FiFo.Close.
+func (q *PriorityQueue[T]) Close() error {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.notify.notify()
+	return nil
+}
+
+// Inspect reports the queue state for DebugHandler.
+func (q *PriorityQueue[T]) Inspect() any {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return FiFoStats{Size: q.h.Len(), Empty: q.h.Len() == 0, Closed: q.closed}
+}
+
+var _ Queue[int] = (*PriorityQueue[int])(nil)
@@ -0,0 +1,113 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+type prioritized struct {
+	prio int
+	name string
+}
+
+func byPrio(a, b prioritized) bool { return a.prio > b.prio }
+
+func TestPriorityQueue_Order(t *testing.T) {
+	q := NewPriorityQueue(byPrio)
+	ctx := context.Background()
+	for _, x := range []prioritized{{1, "a"}, {5, "b"}, {1, "c"}, {9, "d"}, {5, "e"}} {
+		q.Put(ctx, x)
+	}
+	if top, _ := q.Peek(); top.name != "d" {
+		t.Fatalf("Peek = %v", top)
+	}
+	var got []string
+	for !q.IsEmpty() {
+		x, _ := q.Get(ctx)
+		got = append(got, x.name)
+	}
+	if want := []string{"d", "b", "e", "a", "c"}; !slices.Equal(got, want) {
+		t.Fatalf("order = %v, want %v", got, want)
+	}
+	if _, ok := q.TryGet(); ok {
+		t.Fatal("TryGet on empty queue succeeded")
+	}
+}
+
+func TestPriorityQueue_BlockingGet(t *testing.T) {
+	q := NewPriorityQueue(func(a, b int) bool { return a < b })
+	ctx := context.Background()
+	got := make(chan int)
+	go func() {
+		x, _ := q.Get(ctx)
+		got <- x
+	}()
+	time.Sleep(10 * time.Millisecond)
+	q.Put(ctx, 3)
+	if x := <-got; x != 3 {
+		t.Fatalf("Get = %d", x)
+	}
+
+	short, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	if _, err := q.Get(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get on empty queue = %v", err)
+	}
+}
+
+func TestPriorityQueue_Close(t *testing.T) {
+	q := NewPriorityQueue(func(a, b int) bool { return a < b })
+	ctx := context.Background()
+	q.Put(ctx, 2)
+	q.Put(ctx, 1)
+	blocked := NewPriorityQueue(func(a, b int) bool { return a < b })
+	errc := make(chan error)
+	go func() {
+		_, err := blocked.Get(ctx)
+		errc <- err
+	}()
+	q.Close()
+	blocked.Close()
+	if err := <-errc; !errors.Is(err, ErrClosed) {
+		t.Fatalf("blocked Get = %v", err)
+	}
+	if err := q.Put(ctx, 0); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Put after Close = %v", err)
+	}
+	for want := 1; want <= 2; want++ {
+		if x, err := q.Get(ctx); err != nil || x != want {
+			t.Fatalf("Get = %d, %v", x, err)
+		}
+	}
+	if _, err := q.Get(ctx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Get on drained queue = %v", err)
+	}
+}
+
+func TestPriorityQueue_Concurrent(t *testing.T) {
+	q := NewPriorityQueue(func(a, b int) bool { return a < b })
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for p := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 250 {
+				q.Put(ctx, p*250+i)
+			}
+		}()
+	}
+	seen := make([]bool, 1000)
+	for range 1000 {
+		x, err := q.Get(ctx)
+		if err != nil || seen[x] {
+			t.Fatalf("Get = %d, %v", x, err)
+		}
+		seen[x] = true
+	}
+	wg.Wait()
+}
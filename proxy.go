@@ -0,0 +1,95 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+)
+
+var ErrNoUpstream = errors.New("no upstream")
+
+// ProxyWithContext is a reverse proxy whose hooks see requests as
+// *RequestWithContext[C], so routing can depend on a typed per-request
+// context such as a tenant. Director chooses the upstream for each request;
+// without one, Balancer does. Every balancer pick is reported back through
+// its done func, with 5xx responses and transport errors counted as
+// failures, so latency- and load-aware balancers steer away from sick
+// upstreams.
+type ProxyWithContext[C context.Context] struct {
+	Director func(r *RequestWithContext[C]) (*url.URL, error)
+	Balancer Balancer[*url.URL]
+	// Rewrite, if set, edits the outgoing request after its URL and
+	// X-Forwarded headers are set.
+	Rewrite        func(out, in *RequestWithContext[C])
+	ModifyResponse func(resp *http.Response, r *RequestWithContext[C]) error
+	// ErrorHandler reports routing and upstream errors. Defaults to 502 Bad
+	// Gateway.
+	ErrorHandler  func(w http.ResponseWriter, r *RequestWithContext[C], err error)
+	Transport     http.RoundTripper
+	FlushInterval time.Duration
+}
+
+func (p *ProxyWithContext[C]) route(r *RequestWithContext[C]) (*url.URL, func(error), error) {
+	switch {
+	case p.Director != nil:
+		target, err := p.Director(r)
+		return target, noopDone, err
+	case p.Balancer != nil:
+		target, done := p.Balancer.Pick(r.Context())
+		return target, done, nil
+	}
+	return nil, noopDone, ErrNoUpstream
+}
+
+func (p *ProxyWithContext[C]) error(w http.ResponseWriter, r *RequestWithContext[C], err error) {
+	if p.ErrorHandler != nil {
+		p.ErrorHandler(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusBadGateway)
+}
+
+func (p *ProxyWithContext[C]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	in := (*RequestWithContext[C])(r)
+	target, done, err := p.route(in)
+	if err == nil && target == nil {
+		err = ErrNoUpstream
+	}
+	if err != nil {
+		done(err)
+		p.error(w, in, err)
+		return
+	}
+
+	var failure error
+	rp := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+			if p.Rewrite != nil {
+				p.Rewrite((*RequestWithContext[C])(pr.Out), in)
+			}
+		},
+		Transport:     p.Transport,
+		FlushInterval: p.FlushInterval,
+		ModifyResponse: func(resp *http.Response) error {
+			if resp.StatusCode >= 500 {
+				failure = fmt.Errorf("upstream %s: %s", target.Host, resp.Status)
+			}
+			if p.ModifyResponse != nil {
+				return p.ModifyResponse(resp, in)
+			}
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, _ *http.Request, err error) {
+			failure = err
+			p.error(w, in, err)
+		},
+	}
+	rp.ServeHTTP(w, r)
+	done(failure)
+}
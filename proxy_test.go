@@ -0,0 +1,127 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type tenantContext interface {
+	context.Context
+	Tenant() string
+}
+
+type tenantCtx struct {
+	context.Context
+	tenant string
+}
+
+func (c tenantCtx) Tenant() string { return c.tenant }
+
+func proxyUpstream(t *testing.T, name string, status int) *url.URL {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Upstream", name)
+		w.Header().Set("X-Seen-Tenant", r.Header.Get("X-Tenant"))
+		w.WriteHeader(status)
+		io.WriteString(w, name+" "+r.URL.Path)
+	}))
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+	return u
+}
+
+func proxyRequest(p http.Handler, tenant string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req = req.WithContext(tenantCtx{Context: req.Context(), tenant: tenant})
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestProxyWithContext_Director(t *testing.T) {
+	upstreams := map[string]*url.URL{
+		"acme":   proxyUpstream(t, "acme", http.StatusOK),
+		"globex": proxyUpstream(t, "globex", http.StatusOK),
+	}
+	p := &ProxyWithContext[tenantContext]{
+		Director: func(r *RequestWithContext[tenantContext]) (*url.URL, error) {
+			u, ok := upstreams[r.Context().(tenantContext).Tenant()]
+			if !ok {
+				return nil, ErrNoUpstream
+			}
+			return u, nil
+		},
+		Rewrite: func(out, in *RequestWithContext[tenantContext]) {
+			out.Header.Set("X-Tenant", in.Context().(tenantContext).Tenant())
+		},
+		ModifyResponse: func(resp *http.Response, r *RequestWithContext[tenantContext]) error {
+			resp.Header.Set("X-Proxied", "1")
+			return nil
+		},
+	}
+
+	rec := proxyRequest(p, "globex")
+	if rec.Body.String() != "globex /orders" || rec.Header().Get("X-Seen-Tenant") != "globex" {
+		t.Fatalf("unexpected response %q %v", rec.Body.String(), rec.Header())
+	}
+	if rec.Header().Get("X-Proxied") != "1" {
+		t.Fatal("ModifyResponse should run")
+	}
+	if rec := proxyRequest(p, "initech"); rec.Code != http.StatusBadGateway {
+		t.Fatalf("unknown tenant should get 502, got %d", rec.Code)
+	}
+}
+
+type recordingBalancer struct {
+	target *url.URL
+	errs   []error
+}
+
+func (b *recordingBalancer) Pick(context.Context) (*url.URL, func(error)) {
+	return b.target, func(err error) { b.errs = append(b.errs, err) }
+}
+
+func TestProxyWithContext_BalancerFeedback(t *testing.T) {
+	b := &recordingBalancer{target: proxyUpstream(t, "sick", http.StatusServiceUnavailable)}
+	p := &ProxyWithContext[tenantContext]{Balancer: b}
+	if rec := proxyRequest(p, "acme"); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("upstream status should pass through, got %d", rec.Code)
+	}
+
+	b.target = proxyUpstream(t, "ok", http.StatusOK)
+	proxyRequest(p, "acme")
+
+	dead := httptest.NewServer(http.NotFoundHandler())
+	b.target, _ = url.Parse(dead.URL)
+	dead.Close()
+	var handled error
+	p.ErrorHandler = func(w http.ResponseWriter, r *RequestWithContext[tenantContext], err error) {
+		handled = err
+		w.WriteHeader(http.StatusGatewayTimeout)
+	}
+	if rec := proxyRequest(p, "acme"); rec.Code != http.StatusGatewayTimeout || handled == nil {
+		t.Fatalf("expected custom error handler, got %d", rec.Code)
+	}
+
+	if len(b.errs) != 3 || b.errs[0] == nil || b.errs[1] != nil || b.errs[2] == nil {
+		t.Fatalf("unexpected balancer feedback %v", b.errs)
+	}
+}
+
+func TestProxyWithContext_NoUpstream(t *testing.T) {
+	var got error
+	p := &ProxyWithContext[tenantContext]{
+		ErrorHandler: func(w http.ResponseWriter, r *RequestWithContext[tenantContext], err error) {
+			got = err
+			w.WriteHeader(http.StatusServiceUnavailable)
+		},
+	}
+	proxyRequest(p, "acme")
+	if !errors.Is(got, ErrNoUpstream) {
+		t.Fatalf("expected ErrNoUpstream, got %v", got)
+	}
+}
@@ -0,0 +1,122 @@
+package generic
+
+import (
+	"context"
+	"sync"
+)
+
+// PubSub routes messages to subscribers by topic: every subscriber of a
+// topic receives every message published to it after subscribing, on its
+// own FiFo. Each topic is a Broadcast, created by its first subscriber and
+// removed with its last, so topics without subscribers use no memory and
+// publishing to them is a no-op.
+type PubSub[K comparable, T any] struct {
+	capacity int
+	policy   BroadcastPolicy
+	mu       sync.Mutex
+	topics   map[K]*Broadcast[T]
+	closed   bool
+}
+
+// NewPubSub returns a PubSub whose subscriber queues hold up to capacity
+// items each, handling a full one according to policy, as NewBroadcast
+// does.
+func NewPubSub[K comparable, T any](capacity int, policy BroadcastPolicy) *PubSub[K, T] {
+	return &PubSub[K, T]{capacity: capacity, policy: policy, topics: make(map[K]*Broadcast[T])}
+}
+
+// Subscribe returns a queue receiving the messages published to topic
+// from now on, and a function that ends the subscription. The subscription
+// also ends once ctx is done or the PubSub is closed; either way the queue
+// is closed, so its consumer drains it and then sees ErrClosed.
+func (ps *PubSub[K, T]) Subscribe(ctx context.Context, topic K) (Queue[T], func()) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	b := ps.topics[topic]
+	if b == nil {
+		b = NewBroadcast[T](ps.capacity, ps.policy)
+		if ps.closed {
+			b.Close()
+		} else {
+			b.onEmpty = func() { ps.removeIfEmpty(topic, b) }
+			ps.topics[topic] = b
+		}
+	}
+	q := b.subscribe(ctx)
+	return q, func() { b.unsubscribe(q) }
+}
+
+// removeIfEmpty drops topic if b still serves it and has no subscribers,
+// which a concurrent Subscribe may have changed.
+func (ps *PubSub[K, T]) removeIfEmpty(topic K, b *Broadcast[T]) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.topics[topic] == b && b.Subscribers() == 0 {
+		delete(ps.topics, topic)
+	}
+}
+
+// Publish delivers msg to every subscriber of topic, applying the slow
+// subscriber policy as Broadcast.Put does. It returns ErrClosed once the
+// PubSub is closed.
+func (ps *PubSub[K, T]) Publish(ctx context.Context, topic K, msg T) error {
+	ps.mu.Lock()
+	b, closed := ps.topics[topic], ps.closed
+	ps.mu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+	if b == nil {
+		return nil
+	}
+	return b.Put(ctx, msg)
+}
+
+// Topics returns the number of topics with subscribers.
+func (ps *PubSub[K, T]) Topics() int {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return len(ps.topics)
+}
+
+// Subscribers returns the number of subscribers of topic.
+func (ps *PubSub[K, T]) Subscribers(topic K) int {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if b := ps.topics[topic]; b != nil {
+		return b.Subscribers()
+	}
+	return 0
+}
+
+// Close ends every subscription. Later Publishes fail with ErrClosed and
+// later Subscribes return a closed queue.
+func (ps *PubSub[K, T]) Close() error {
+	ps.mu.Lock()
+	topics := ps.topics
+	ps.topics = make(map[K]*Broadcast[T])
+	ps.closed = true
+	ps.mu.Unlock()
+	for _, b := range topics {
+		b.Close()
+	}
+	return nil
+}
+
+// PubSubStats is the debug view of a PubSub returned by Inspect.
+type PubSubStats struct {
+	Topics      int  `json:"topics"`
+	Subscribers int  `json:"subscribers"`
+	Closed      bool `json:"closed,omitempty"`
+}
+
+// Inspect reports the PubSub state for DebugHandler.
+func (ps *PubSub[K, T]) Inspect() any {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	s := PubSubStats{Topics: len(ps.topics), Closed: ps.closed}
+	for _, b := range ps.topics {
+		s.Subscribers += b.Subscribers()
+	}
+	return s
+}
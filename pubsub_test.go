@@ -0,0 +1,71 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPubSub_Topics(t *testing.T) {
+	ctx := context.Background()
+	ps := NewPubSub[string, int](0, BroadcastBlock)
+	if err := ps.Publish(ctx, "jobs", 0); err != nil {
+		t.Fatalf("Publish without subscribers = %v", err)
+	}
+	jobs1, unsub1 := ps.Subscribe(ctx, "jobs")
+	jobs2, _ := ps.Subscribe(ctx, "jobs")
+	alerts, unsubAlerts := ps.Subscribe(ctx, "alerts")
+	ps.Publish(ctx, "jobs", 1)
+	ps.Publish(ctx, "alerts", 2)
+
+	for _, q := range []Queue[int]{jobs1, jobs2} {
+		if x, _ := q.Get(ctx); x != 1 || !q.IsEmpty() {
+			t.Fatalf("jobs subscriber got %d, size %d", x, q.Size())
+		}
+	}
+	if x, _ := alerts.Get(ctx); x != 2 {
+		t.Fatalf("alerts subscriber got %d", x)
+	}
+
+	unsub1()
+	unsub1() // idempotent
+	if _, err := jobs1.Get(ctx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Get after unsubscribe = %v", err)
+	}
+	if ps.Subscribers("jobs") != 1 {
+		t.Fatalf("jobs subscribers = %d", ps.Subscribers("jobs"))
+	}
+	unsubAlerts()
+	if st := ps.Inspect().(PubSubStats); st.Topics != 1 || st.Subscribers != 1 {
+		t.Fatalf("Inspect = %+v", st)
+	}
+}
+
+func TestPubSub_ContextAndClose(t *testing.T) {
+	ctx := context.Background()
+	ps := NewPubSub[int, string](4, BroadcastDrop)
+	subCtx, cancel := context.WithCancel(ctx)
+	q, _ := ps.Subscribe(subCtx, 1)
+	cancel()
+	waitFor(t, func() bool { return ps.Topics() == 0 })
+	if _, err := q.Get(ctx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Get after cancel = %v", err)
+	}
+
+	q, _ = ps.Subscribe(ctx, 2)
+	ps.Publish(ctx, 2, "last")
+	ps.Close()
+	if x, err := q.Get(ctx); err != nil || x != "last" {
+		t.Fatalf("Get = %q, %v", x, err)
+	}
+	if _, err := q.Get(ctx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Get after Close = %v", err)
+	}
+	if err := ps.Publish(ctx, 2, "x"); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Publish after Close = %v", err)
+	}
+	q, _ = ps.Subscribe(ctx, 3)
+	if _, err := q.Get(ctx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Get on a queue subscribed after Close = %v", err)
+	}
+}
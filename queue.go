@@ -3,20 +3,49 @@ package generic
 import (
 	"context"
 	"errors"
+	"iter"
+	"slices"
+	"sync/atomic"
 )
 
-var ErrEmptyQueue = errors.New("queue is empty")
+var (
+	ErrEmptyQueue = errors.New("queue is empty")
+	// ErrClosed is returned by Put on a closed FiFo, and by Get once a
+	// closed FiFo has been drained.
+	ErrClosed = errors.New("queue is closed")
+)
 
 // FiFo is a generic, channel-token queue that preserves FIFO ordering
 // and supports context-aware Enqueue/Dequeue plus a stop-the-world Snapshot.
-// It uses two single-slot channels:
-//   - items: holds a non-empty slice when queue has elements
+// It uses single-slot channels:
+//   - items: holds a token when queue has elements
 //   - empty: holds a token when queue is empty
+//   - full: holds a token when a bounded queue is at capacity
 //
-// No mutexes are required; synchronization is via token ownership.
+// Whoever holds a token owns the ring buffer holding the elements, so no
+// mutexes are required; synchronization is via token ownership. The ring
+// reuses its storage, so steady-state Put and Get do not allocate. The
+// optional WakeupOrder keeps blocked Get callers in a small mutex-guarded heap.
 type FiFo[T any] struct {
-	items chan []T      // cap=1; present when non-empty
-	empty chan struct{} // cap=1; present when empty
+	items   chan struct{} // cap=1; present when non-empty and not full
+	empty   chan struct{} // cap=1; present when empty
+	full    chan struct{} // cap=1; present when at bound; nil if unbounded
+	putFull chan struct{} // full, or nil if Put blocks when full
+	closed  chan struct{} // closed by Close while holding a token
+	bound   int           // 0 means unbounded
+	policy  OverflowPolicy
+	dropped atomic.Int64
+	compact func(prev, next T) (T, bool) // nil unless made by NewCompactingFiFo
+	merged  atomic.Int64
+	ring    ringBuffer[T] // owned by the holder of any token
+	// reserved counts room claimed by Reserve but not yet committed or
+	// aborted, and is owned like ring. roomFreed wakes Reserves, and Puts
+	// held back by reservations, when room is freed.
+	reserved  int
+	roomFreed atomicNotifier
+	waiters   *fifoWaiters[T] // nil unless an explicit WakeupOrder is set
+	wait      WaitStrategy
+	spins     int // poll budget for WaitSpin and WaitYield
 }
 
 type Queue[T any] interface {
@@ -28,53 +57,206 @@ type Queue[T any] interface {
 	Size() int
 }
 
-func NewFiFo[T any]() *FiFo[T] {
+func NewFiFo[T any](opts ...FiFoOption) *FiFo[T] {
+	o := NewOptions(fifoOptions{}, opts...)
 	q := &FiFo[T]{
-		items: make(chan []T, 1),
-		empty: make(chan struct{}, 1),
+		items:  make(chan struct{}, 1),
+		empty:  make(chan struct{}, 1),
+		closed: make(chan struct{}),
+		ring:   newRingBuffer[T](o.capacity),
+		wait:   o.wait,
+	}
+	if o.wait != WaitPark {
+		q.spins = o.spins
+		if q.spins <= 0 {
+			q.spins = defaultWaitSpins
+		}
+	}
+	if o.bound > 0 {
+		q.bound = o.bound
+		q.policy = o.policy
+		q.full = make(chan struct{}, 1)
+		if o.policy != OverflowBlock {
+			q.putFull = q.full
+		}
+	}
+	if o.wakeup != WakeupAny {
+		q.waiters = &fifoWaiters[T]{order: o.wakeup}
 	}
 	q.empty <- struct{}{} // start empty
 	return q
 }
 
-func (q *FiFo[T]) Size() int {
+// OverflowPolicy decides what Put does when a bounded FiFo is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock makes Put wait for room; TryPut fails. It is the
+	// default.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest discards the head of the queue to make room.
+	OverflowDropOldest
+	// OverflowDropNewest discards the item being put; TryPut reports false.
+	OverflowDropNewest
+)
+
+// NewBoundedFiFo returns a FiFo holding at most capacity items, handling a
+// Put on a full queue according to policy. Dropped items are counted in
+// Dropped. A capacity of zero or less means unbounded.
+func NewBoundedFiFo[T any](capacity int, policy OverflowPolicy, opts ...FiFoOption) *FiFo[T] {
+	return NewFiFo[T](append([]FiFoOption{WithBound(capacity, policy)}, opts...)...)
+}
+
+// WithBound is the option form of NewBoundedFiFo, for constructors such as
+// LoadFiFo that take FiFoOptions.
+func WithBound(capacity int, policy OverflowPolicy) FiFoOption {
+	return func(o *fifoOptions) {
+		o.bound = max(capacity, 0)
+		o.policy = policy
+	}
+}
+
+// NewCompactingFiFo returns a FiFo that merges each put item into the item
+// at the tail of the queue when fn reports ok, so that repeated updates to
+// the same entity occupy one slot. fn must be a pure function of its
+// arguments; it runs while the queue's token is held. Items handed
+// straight to a waiting Get are not compacted, and a full queue whose
+// policy is OverflowBlock still blocks before fn is consulted.
+func NewCompactingFiFo[T any](fn func(prev, next T) (merged T, ok bool), opts ...FiFoOption) *FiFo[T] {
+	q := NewFiFo[T](opts...)
+	q.compact = fn
+	return q
+}
+
+// Compacted returns how many puts were merged into an earlier item by the
+// queue's compactor.
+func (q *FiFo[T]) Compacted() int64 {
+	return q.merged.Load()
+}
+
+// Cap returns the queue's bound, or zero if it is unbounded.
+func (q *FiFo[T]) Cap() int {
+	return q.bound
+}
+
+// Dropped returns how many items a bounded queue has discarded on
+// overflow.
+func (q *FiFo[T]) Dropped() int64 {
+	return q.dropped.Load()
+}
+
+// acquire takes whichever token is present, or returns ctx.Err().
+func (q *FiFo[T]) acquire(ctx context.Context) error {
 	select {
-	case items := <-q.items:
-		defer func() { q.items <- items }()
-		return len(items)
+	case <-q.items:
 	case <-q.empty:
-		defer func() { q.empty <- struct{}{} }()
-		return 0
+	case <-q.full:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+// release returns the token matching the ring's state.
+func (q *FiFo[T]) release() {
+	switch n := q.ring.len(); {
+	case n == 0:
+		q.empty <- struct{}{}
+	case q.bound > 0 && n >= q.bound:
+		q.full <- struct{}{}
+	default:
+		q.items <- struct{}{}
 	}
 }
 
-// Enqueue appends x, respecting ctx cancellation.
+func (q *FiFo[T]) Size() int {
+	q.acquire(context.Background())
+	defer q.release()
+	return q.ring.len()
+}
+
+// Enqueue appends x, respecting ctx cancellation. It returns ErrClosed
+// once the queue is closed.
 //
 //go:inline
 func (q *FiFo[T]) Put(ctx context.Context, x T) error {
-	var s []T
-	select {
-	case s = <-q.items:
-		// Prioritize cancellation if it happened
-		select {
-		case <-ctx.Done():
-			q.items <- s
-			return ctx.Err()
-		default:
+	if err := q.acquirePut(ctx); err != nil {
+		return err
+	}
+	q.putLocked(x)
+	q.release()
+	return nil
+}
+
+// PutAll appends items in order with a single token acquisition. On a
+// bounded queue that blocks when full, items that do not fit wait for room
+// as Put does; if ctx is done or the queue is closed meanwhile, the items
+// already added stay queued.
+func (q *FiFo[T]) PutAll(ctx context.Context, items ...T) error {
+	for len(items) > 0 {
+		if err := q.acquirePut(ctx); err != nil {
+			return err
 		}
-	case <-q.empty:
-		// Prioritize cancellation if it happened
+		n := len(items)
+		if q.bound > 0 && q.policy == OverflowBlock {
+			n = min(n, q.bound-q.ring.len()-q.reserved)
+		}
+		for _, x := range items[:n] {
+			q.putLocked(x)
+		}
+		items = items[n:]
+		q.release()
+	}
+	return nil
+}
+
+// acquirePut takes a token that allows adding an item. Under
+// OverflowBlock it also waits while reservations take the remaining room.
+func (q *FiFo[T]) acquirePut(ctx context.Context) error {
+	for {
+		if err := q.acquirePutToken(ctx); err != nil {
+			return err
+		}
+		if q.policy != OverflowBlock || q.hasRoom() {
+			return nil
+		}
+		changed := q.roomFreed.wait()
+		q.release()
 		select {
+		case <-changed:
+		case <-q.closed:
 		case <-ctx.Done():
-			q.empty <- struct{}{}
 			return ctx.Err()
-		default:
 		}
+	}
+}
+
+// hasRoom reports whether an item fits besides the queued and reserved
+// ones. The caller must hold a token.
+func (q *FiFo[T]) hasRoom() bool {
+	return q.bound == 0 || q.ring.len()+q.reserved < q.bound
+}
+
+func (q *FiFo[T]) acquirePutToken(ctx context.Context) error {
+	select {
+	case <-q.items:
+	case <-q.empty:
+	case <-q.putFull:
+	case <-q.closed:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	// Prioritize cancellation if it happened
+	select {
 	case <-ctx.Done():
+		q.release()
 		return ctx.Err()
+	case <-q.closed:
+		q.release()
+		return ErrClosed
+	default:
 	}
-	s = append(s, x)
-	q.items <- s
 	return nil
 }
 
@@ -83,43 +265,151 @@ func (q *FiFo[T]) Put(ctx context.Context, x T) error {
 //go:inline
 func (q *FiFo[T]) TryPut(x T) bool {
 	select {
-	case s := <-q.items:
-		s = append(s, x)
-		q.items <- s
-		return true
+	case <-q.items:
 	case <-q.empty:
-		s := []T{x}
-		q.items <- s
-		return true
+	case <-q.putFull:
 	default:
 		return false
 	}
+	if q.closing() || (q.policy == OverflowBlock && !q.hasRoom()) {
+		q.release()
+		return false
+	}
+	ok := q.putLocked(x)
+	q.release()
+	return ok
 }
 
-// Dequeue removes and returns the next item, or ctx error if cancelled.
+// putLocked adds x while the caller holds a token, handing it straight to a
+// waiter if there is one, and reports whether x was kept.
+func (q *FiFo[T]) putLocked(x T) bool {
+	switch n := q.ring.len(); {
+	case n == 0 && q.waiters != nil && q.waiters.handoff(x):
+		return true
+	case n > 0 && q.compact != nil && q.compactLocked(x):
+		return true
+	case q.bound > 0 && n+q.reserved >= q.bound:
+		return q.overflowLocked(x)
+	}
+	q.ring.push(x)
+	return true
+}
+
+// Dequeue removes and returns the next item, or ctx error if cancelled. On a
+// closed queue it keeps returning items until the queue is empty, then
+// ErrClosed.
 //
 //go:inline
 func (q *FiFo[T]) Get(ctx context.Context) (T, error) {
+	if q.spins > 0 {
+		if x, ok := q.spinGet(ctx); ok {
+			return x, nil
+		}
+	}
+	if q.waiters != nil {
+		return q.getOrdered(ctx)
+	}
 	var zero T
-	var s []T
 	select {
-	case s = <-q.items:
+	case <-q.items:
+	case <-q.full:
+	case <-q.closed:
+		return q.getClosed()
 	case <-ctx.Done():
 		// Context cancelled, but check if we can still get an item (prioritize data)
 		select {
-		case s = <-q.items:
+		case <-q.items:
+		case <-q.full:
 		default:
 			return zero, ctx.Err()
 		}
 	}
-	x := s[0]
-	s = s[1:]
-	if len(s) == 0 {
-		q.empty <- struct{}{}
-	} else {
-		q.items <- s
+	return q.popLocked(), nil
+}
+
+// GetBatch blocks until at least one item is available, then removes up
+// to max items in a single token acquisition. Like Get, it drains a closed
+// queue before returning ErrClosed.
+func (q *FiFo[T]) GetBatch(ctx context.Context, max int) ([]T, error) {
+	if max < 1 {
+		max = 1
 	}
-	return x, nil
+	if q.spins > 0 || q.waiters != nil {
+		// These paths may hand over the first item without a token.
+		x, err := q.Get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		batch := []T{x}
+		select {
+		case <-q.items:
+		case <-q.full:
+		default:
+			return batch, nil
+		}
+		return q.popBatchLocked(batch, max), nil
+	}
+	select {
+	case <-q.items:
+	case <-q.full:
+	case <-q.closed:
+		q.acquire(context.Background())
+		if q.ring.len() == 0 {
+			q.release()
+			return nil, ErrClosed
+		}
+	case <-ctx.Done():
+		select {
+		case <-q.items:
+		case <-q.full:
+		default:
+			return nil, ctx.Err()
+		}
+	}
+	return q.popBatchLocked(nil, max), nil
+}
+
+// TryGetN removes up to max items without blocking, in a single token
+// acquisition. It returns nil if the queue is empty.
+func (q *FiFo[T]) TryGetN(max int) []T {
+	if max < 1 {
+		return nil
+	}
+	select {
+	case <-q.items:
+	case <-q.full:
+	default:
+		return nil
+	}
+	return q.popBatchLocked(nil, max)
+}
+
+// GetUntil removes items, waiting for each as Get does, until done reports
+// true for the items taken so far; done is called before each item. If
+// ctx is done or the queue is closed and drained first, it returns the
+// items taken with the error.
+func (q *FiFo[T]) GetUntil(ctx context.Context, done func(batch []T) bool) ([]T, error) {
+	var batch []T
+	for !done(batch) {
+		x, err := q.Get(ctx)
+		if err != nil {
+			return batch, err
+		}
+		batch = append(batch, x)
+	}
+	return batch, nil
+}
+
+// popBatchLocked appends items to batch until it holds max or the ring is
+// empty, then releases the token.
+func (q *FiFo[T]) popBatchLocked(batch []T, max int) []T {
+	n := min(max-len(batch), q.ring.len())
+	batch = slices.Grow(batch, n)
+	for range n {
+		batch = append(batch, q.ring.pop())
+	}
+	q.releaseRoom()
+	return batch
 }
 
 // TryDequeue attempts to dequeue without blocking; returns (zero,false) if empty.
@@ -128,24 +418,131 @@ func (q *FiFo[T]) Get(ctx context.Context) (T, error) {
 func (q *FiFo[T]) TryGet() (T, bool) {
 	var zero T
 	select {
-	case s := <-q.items:
-		x := s[0]
-		s = s[1:]
-		if len(s) == 0 {
-			select {
-			case q.empty <- struct{}{}:
-			default:
+	case <-q.items:
+		return q.popLocked(), true
+	case <-q.full:
+		return q.popLocked(), true
+	default:
+		return zero, false
+	}
+}
+
+// getClosed removes the next item from a closed queue, or reports
+// ErrClosed if it has been drained.
+func (q *FiFo[T]) getClosed() (T, error) {
+	q.acquire(context.Background())
+	if q.ring.len() > 0 {
+		return q.popLocked(), nil
+	}
+	q.release()
+	var zero T
+	return zero, ErrClosed
+}
+
+func (q *FiFo[T]) closing() bool {
+	select {
+	case <-q.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close marks the queue closed. Later Puts fail with ErrClosed, while Gets
+// drain the remaining items and then return ErrClosed instead of blocking.
+// Closing a closed queue has no effect.
+func (q *FiFo[T]) Close() error {
+	q.acquire(context.Background())
+	defer q.release()
+	if !q.closing() {
+		close(q.closed)
+	}
+	return nil
+}
+
+// compactLocked tries to merge x into the tail of the ring.
+func (q *FiFo[T]) compactLocked(x T) bool {
+	i := q.ring.len() - 1
+	merged, ok := q.compact(q.ring.at(i), x)
+	if ok {
+		q.ring.set(i, merged)
+		q.merged.Add(1)
+	}
+	return ok
+}
+
+// overflowLocked applies the overflow policy to x while the caller holds
+// the full token, reporting whether x was added.
+func (q *FiFo[T]) overflowLocked(x T) bool {
+	q.dropped.Add(1)
+	// With all room reserved there is nothing older to drop.
+	if q.policy == OverflowDropNewest || q.ring.len() == 0 {
+		return false
+	}
+	q.ring.pop()
+	q.ring.push(x)
+	return true
+}
+
+// Subscribe delivers items taken from the queue on the returned channel,
+// so the queue can be consumed in a select alongside other channels. One
+// goroutine per subscription blocks in Get; it closes the channel once ctx
+// is done or the queue is closed and drained. An item taken from the queue
+// that cannot be delivered before ctx is done is put back at the head.
+func (q *FiFo[T]) Subscribe(ctx context.Context) <-chan T {
+	ch := make(chan T)
+	go func() {
+		defer close(ch)
+		for {
+			x, err := q.Get(ctx)
+			if err != nil {
+				return
 			}
-		} else {
 			select {
-			case q.items <- s:
-			default:
+			case ch <- x:
+			case <-ctx.Done():
+				q.requeueFront(x)
+				return
 			}
 		}
-		return x, true
-	default:
+	}()
+	return ch
+}
+
+// requeueFront puts x back at the head, even past the bound or after
+// Close, so an item taken but not consumed is not lost.
+func (q *FiFo[T]) requeueFront(x T) {
+	q.acquire(context.Background())
+	if q.ring.len() > 0 || q.waiters == nil || !q.waiters.handoff(x) {
+		q.ring.pushFront(x)
+	}
+	q.release()
+}
+
+// popLocked removes the head of the ring, which must be non-empty, while
+// the caller holds the items or full token, then releases the token.
+func (q *FiFo[T]) popLocked() T {
+	x := q.ring.pop()
+	q.releaseRoom()
+	return x
+}
+
+// releaseRoom releases the token after room was freed, waking Reserves
+// and producers waiting behind reservations.
+func (q *FiFo[T]) releaseRoom() {
+	q.release()
+	q.roomFreed.notify()
+}
+
+// peek returns the head without removing it.
+func (q *FiFo[T]) peek() (T, bool) {
+	var zero T
+	q.acquire(context.Background())
+	defer q.release()
+	if q.ring.len() == 0 {
 		return zero, false
 	}
+	return q.ring.at(0), true
 }
 
 // IsEmpty returns true if the queue is empty. This is a non-blocking hint.
@@ -156,23 +553,76 @@ func (q *FiFo[T]) IsEmpty() bool {
 }
 
 // Snapshot performs a brief stop-the-world capture of the current queue contents.
-// It acquires the token (items or empty), clones the slice, and restores the token.
+// It acquires the token (items, empty or full), clones the contents, and restores the token.
 func (q *FiFo[T]) Snapshot(ctx context.Context) ([]T, error) {
-	var s []T
-	tookItems := false
-	select {
-	case s = <-q.items:
-		tookItems = true
-	case <-q.empty:
-		s = nil
-	case <-ctx.Done():
-		return nil, ctx.Err()
+	if err := q.acquire(ctx); err != nil {
+		return nil, err
 	}
-	cp := append([]T(nil), s...)
-	if tookItems {
-		q.items <- s
-	} else {
-		q.empty <- struct{}{}
+	defer q.release()
+	if q.ring.len() == 0 {
+		return nil, nil
+	}
+	return q.ring.appendTo(make([]T, 0, q.ring.len())), nil
+}
+
+// Clear discards every queued item in a single token acquisition and
+// returns how many were removed, for recovery paths where stale work must
+// not be processed. Producers blocked on a full queue are released.
+func (q *FiFo[T]) Clear(ctx context.Context) (removed int, err error) {
+	if err := q.acquire(ctx); err != nil {
+		return 0, err
+	}
+	removed = q.ring.len()
+	q.ring.reset()
+	q.releaseRoom()
+	return removed, nil
+}
+
+// All yields the items queued at the time of the call, in order, without
+// removing them. It iterates over a snapshot, so the queue may change
+// meanwhile.
+func (q *FiFo[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		items, _ := q.Snapshot(context.Background())
+		for _, x := range items {
+			if !yield(x) {
+				return
+			}
+		}
 	}
-	return cp, nil
+}
+
+// Drain removes and yields items until the queue is empty, without waiting
+// for more. If ctx is done first it yields ctx.Err() and stops; items not
+// yet reached stay queued.
+func (q *FiFo[T]) Drain(ctx context.Context) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for {
+			if err := Checkpoint(ctx); err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			x, ok := q.TryGet()
+			if !ok || !yield(x, nil) {
+				return
+			}
+		}
+	}
+}
+
+// FiFoStats is the debug view of a FiFo returned by Inspect.
+type FiFoStats struct {
+	Size      int   `json:"size"`
+	Empty     bool  `json:"empty"`
+	Capacity  int   `json:"capacity,omitempty"`
+	Dropped   int64 `json:"dropped,omitempty"`
+	Closed    bool  `json:"closed,omitempty"`
+	Compacted int64 `json:"compacted,omitempty"`
+}
+
+// Inspect reports the queue state for DebugHandler.
+func (q *FiFo[T]) Inspect() any {
+	n := q.Size()
+	return FiFoStats{Size: n, Empty: n == 0, Capacity: q.bound, Dropped: q.dropped.Load(), Closed: q.closing(), Compacted: q.merged.Load()}
 }
@@ -2,6 +2,9 @@ package generic
 
 import (
 	"context"
+	"errors"
+	"slices"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -545,3 +548,580 @@ func BenchmarkChanQueue_Concurrent(b *testing.B) {
 		}
 	})
 }
+
+func TestFiFo_SteadyStateDoesNotAllocate(t *testing.T) {
+	q := NewFiFo[int](WithInitialCapacity(16))
+	ctx := context.Background()
+	allocs := testing.AllocsPerRun(1000, func() {
+		q.Put(ctx, 1)
+		q.Put(ctx, 2)
+		q.Get(ctx)
+		q.Get(ctx)
+		q.TryPut(3)
+		q.TryGet()
+	})
+	if allocs != 0 {
+		t.Fatalf("expected 0 allocs per steady-state Put/Get, got %v", allocs)
+	}
+}
+
+func TestFiFo_GrowsAndWraps(t *testing.T) {
+	q := NewFiFo[int]()
+	ctx := context.Background()
+	next := 0
+	// Interleave puts and gets so the ring wraps while growing and shrinking.
+	for round := range 5 {
+		for i := range 100 * (round + 1) {
+			q.Put(ctx, round*1000+i)
+		}
+		for range 50 * (round + 1) {
+			x, _ := q.Get(ctx)
+			if x < next {
+				t.Fatalf("out of order: %d after %d", x, next)
+			}
+			next = x
+		}
+	}
+	items, _ := q.Snapshot(ctx)
+	if len(items) != q.Size() {
+		t.Fatalf("snapshot has %d items, size is %d", len(items), q.Size())
+	}
+	for i := 1; i < len(items); i++ {
+		if items[i] < items[i-1] {
+			t.Fatalf("snapshot out of order at %d", i)
+		}
+	}
+}
+
+func BenchmarkFiFo_PutGet_SteadyState(b *testing.B) {
+	q := NewFiFo[int]()
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q.Put(ctx, i)
+		q.Get(ctx)
+	}
+}
+
+func TestBoundedFiFo_Block(t *testing.T) {
+	q := NewBoundedFiFo[int](2, OverflowBlock)
+	ctx := context.Background()
+	q.Put(ctx, 1)
+	q.Put(ctx, 2)
+	if q.TryPut(3) {
+		t.Fatal("TryPut on full queue succeeded")
+	}
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := q.Put(short, 3); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Put on full queue = %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- q.Put(ctx, 3) }()
+	if x, _ := q.Get(ctx); x != 1 {
+		t.Fatalf("Get = %d, want 1", x)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	got, _ := q.Snapshot(ctx)
+	if len(got) != 2 || got[0] != 2 || got[1] != 3 {
+		t.Fatalf("contents = %v, want [2 3]", got)
+	}
+	if q.Dropped() != 0 || q.Cap() != 2 {
+		t.Fatalf("dropped %d, cap %d", q.Dropped(), q.Cap())
+	}
+}
+
+func TestBoundedFiFo_DropPolicies(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		policy OverflowPolicy
+		want   []int
+	}{
+		{OverflowDropOldest, []int{3, 4}},
+		{OverflowDropNewest, []int{1, 2}},
+	} {
+		q := NewBoundedFiFo[int](2, tc.policy)
+		for i := 1; i <= 4; i++ {
+			if err := q.Put(ctx, i); err != nil {
+				t.Fatal(err)
+			}
+		}
+		got, _ := q.Snapshot(ctx)
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("policy %d: contents = %v, want %v", tc.policy, got, tc.want)
+		}
+		if q.Dropped() != 2 {
+			t.Errorf("policy %d: dropped = %d, want 2", tc.policy, q.Dropped())
+		}
+		if ok := q.TryPut(5); ok != (tc.policy == OverflowDropOldest) {
+			t.Errorf("policy %d: TryPut = %v", tc.policy, ok)
+		}
+	}
+}
+
+func TestBoundedFiFo_Concurrent(t *testing.T) {
+	q := NewBoundedFiFo[int](4, OverflowBlock)
+	ctx := context.Background()
+	const n = 10000
+	go func() {
+		for i := range n {
+			q.Put(ctx, i)
+		}
+	}()
+	for i := range n {
+		x, err := q.Get(ctx)
+		if err != nil || x != i {
+			t.Fatalf("Get = %d, %v; want %d", x, err, i)
+		}
+		if s := q.Size(); s > 4 {
+			t.Fatalf("size %d exceeds bound", s)
+		}
+	}
+}
+
+func TestFiFo_Close(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []FiFoOption
+	}{
+		{"default", nil},
+		{"ordered", []FiFoOption{WithWakeupOrder(WakeupFIFO)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			q := NewFiFo[int](tc.opts...)
+			ctx := context.Background()
+			q.Put(ctx, 1)
+			q.Put(ctx, 2)
+			if err := q.Close(); err != nil {
+				t.Fatal(err)
+			}
+			q.Close()
+			if err := q.Put(ctx, 3); !errors.Is(err, ErrClosed) {
+				t.Fatalf("Put after Close = %v", err)
+			}
+			if q.TryPut(3) {
+				t.Fatal("TryPut after Close succeeded")
+			}
+			for want := 1; want <= 2; want++ {
+				if x, err := q.Get(ctx); err != nil || x != want {
+					t.Fatalf("Get = %d, %v; want %d", x, err, want)
+				}
+			}
+			if _, err := q.Get(ctx); !errors.Is(err, ErrClosed) {
+				t.Fatalf("Get on drained queue = %v", err)
+			}
+		})
+	}
+}
+
+func TestFiFo_CloseWakesBlocked(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		q    *FiFo[int]
+	}{
+		{"default", NewFiFo[int]()},
+		{"ordered", NewFiFo[int](WithWakeupOrder(WakeupFIFO))},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := make(chan error, 2)
+			for range 2 {
+				go func() {
+					_, err := tc.q.Get(context.Background())
+					errs <- err
+				}()
+			}
+			time.Sleep(10 * time.Millisecond)
+			tc.q.Close()
+			for range 2 {
+				if err := <-errs; !errors.Is(err, ErrClosed) {
+					t.Fatalf("blocked Get = %v", err)
+				}
+			}
+		})
+	}
+
+	full := NewBoundedFiFo[int](1, OverflowBlock)
+	full.Put(context.Background(), 1)
+	done := make(chan error, 1)
+	go func() { done <- full.Put(context.Background(), 2) }()
+	time.Sleep(10 * time.Millisecond)
+	full.Close()
+	if err := <-done; !errors.Is(err, ErrClosed) {
+		t.Fatalf("blocked Put = %v", err)
+	}
+	if x, _ := full.Get(context.Background()); x != 1 {
+		t.Fatalf("Get = %d, want 1", x)
+	}
+}
+
+func TestFiFo_CloseConcurrentDrain(t *testing.T) {
+	q := NewFiFo[int]()
+	ctx := context.Background()
+	const n = 1000
+	for i := range n {
+		q.Put(ctx, i)
+	}
+	q.Close()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	got := 0
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if _, err := q.Get(ctx); err != nil {
+					if !errors.Is(err, ErrClosed) {
+						t.Error(err)
+					}
+					return
+				}
+				mu.Lock()
+				got++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if got != n {
+		t.Fatalf("drained %d items, want %d", got, n)
+	}
+}
+
+func TestFiFo_GetBatch(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []FiFoOption
+	}{
+		{"default", nil},
+		{"ordered", []FiFoOption{WithWakeupOrder(WakeupFIFO)}},
+		{"spin", []FiFoOption{WithWaitStrategy(WaitSpin, 0)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			q := NewFiFo[int](tc.opts...)
+			ctx := context.Background()
+			for i := range 5 {
+				q.Put(ctx, i)
+			}
+			if got, err := q.GetBatch(ctx, 3); err != nil || !slices.Equal(got, []int{0, 1, 2}) {
+				t.Fatalf("GetBatch = %v, %v", got, err)
+			}
+			if got, _ := q.GetBatch(ctx, 10); !slices.Equal(got, []int{3, 4}) {
+				t.Fatalf("GetBatch = %v, want [3 4]", got)
+			}
+
+			done := make(chan []int)
+			go func() {
+				got, _ := q.GetBatch(ctx, 10)
+				done <- got
+			}()
+			time.Sleep(10 * time.Millisecond)
+			q.Put(ctx, 5)
+			if got := <-done; len(got) == 0 || got[0] != 5 {
+				t.Fatalf("blocked GetBatch = %v", got)
+			}
+
+			short, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+			defer cancel()
+			if _, err := q.GetBatch(short, 1); !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("GetBatch on empty queue = %v", err)
+			}
+			q.Put(ctx, 6)
+			q.Close()
+			if got, _ := q.GetBatch(ctx, 0); !slices.Equal(got, []int{6}) {
+				t.Fatalf("GetBatch after Close = %v", got)
+			}
+			if _, err := q.GetBatch(ctx, 1); !errors.Is(err, ErrClosed) {
+				t.Fatalf("GetBatch on drained queue = %v", err)
+			}
+		})
+	}
+}
+
+func BenchmarkFiFo_GetBatch(b *testing.B) {
+	q := NewFiFo[int](WithInitialCapacity(64))
+	ctx := context.Background()
+	for b.Loop() {
+		for i := range 64 {
+			q.TryPut(i)
+		}
+		q.GetBatch(ctx, 64)
+	}
+}
+
+func TestFiFo_PutAll(t *testing.T) {
+	ctx := context.Background()
+	q := NewFiFo[int]()
+	q.Put(ctx, 0)
+	if err := q.PutAll(ctx, 1, 2, 3); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := q.Snapshot(ctx); !slices.Equal(got, []int{0, 1, 2, 3}) {
+		t.Fatalf("contents = %v", got)
+	}
+
+	ordered := NewFiFo[int](WithWakeupOrder(WakeupFIFO))
+	first := make(chan int)
+	go func() {
+		x, _ := ordered.Get(ctx)
+		first <- x
+	}()
+	time.Sleep(10 * time.Millisecond)
+	ordered.PutAll(ctx, 1, 2)
+	if x := <-first; x != 1 {
+		t.Fatalf("waiter got %d, want 1", x)
+	}
+	if x, _ := ordered.TryGet(); x != 2 {
+		t.Fatalf("queued %d, want 2", x)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := q.PutAll(canceled, 9); !errors.Is(err, context.Canceled) {
+		t.Fatalf("PutAll with canceled ctx = %v", err)
+	}
+	q.Close()
+	if err := q.PutAll(ctx, 9); !errors.Is(err, ErrClosed) {
+		t.Fatalf("PutAll after Close = %v", err)
+	}
+}
+
+func TestFiFo_PutAllBounded(t *testing.T) {
+	ctx := context.Background()
+	q := NewBoundedFiFo[int](2, OverflowBlock)
+	done := make(chan error, 1)
+	go func() { done <- q.PutAll(ctx, 1, 2, 3, 4) }()
+	var got []int
+	for range 4 {
+		x, _ := q.Get(ctx)
+		got = append(got, x)
+	}
+	if err := <-done; err != nil || !slices.Equal(got, []int{1, 2, 3, 4}) {
+		t.Fatalf("got %v, %v", got, err)
+	}
+
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := q.PutAll(short, 5, 6, 7); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("PutAll over bound = %v", err)
+	}
+	if got, _ := q.Snapshot(ctx); !slices.Equal(got, []int{5, 6}) {
+		t.Fatalf("contents = %v, want [5 6]", got)
+	}
+
+	drop := NewBoundedFiFo[int](2, OverflowDropOldest)
+	drop.PutAll(ctx, 1, 2, 3)
+	if got, _ := drop.Snapshot(ctx); !slices.Equal(got, []int{2, 3}) {
+		t.Fatalf("drop-oldest contents = %v", got)
+	}
+}
+
+func TestFiFo_Compactor(t *testing.T) {
+	type update struct {
+		id, version int
+	}
+	ctx := context.Background()
+	q := NewCompactingFiFo(func(prev, next update) (update, bool) {
+		return next, prev.id == next.id
+	})
+	q.Put(ctx, update{1, 1})
+	q.Put(ctx, update{1, 2})
+	q.Put(ctx, update{2, 1})
+	q.PutAll(ctx, update{2, 2}, update{2, 3}, update{1, 3})
+	got, _ := q.Snapshot(ctx)
+	want := []update{{1, 2}, {2, 3}, {1, 3}}
+	if !slices.Equal(got, want) {
+		t.Fatalf("contents = %v, want %v", got, want)
+	}
+	if n := q.Compacted(); n != 3 {
+		t.Fatalf("Compacted = %d, want 3", n)
+	}
+	if s := q.Inspect().(FiFoStats); s.Compacted != 3 {
+		t.Fatalf("Inspect = %+v", s)
+	}
+
+	full := NewCompactingFiFo(func(prev, next int) (int, bool) {
+		return prev + next, true
+	}, WithBound(1, OverflowDropNewest))
+	full.TryPut(1)
+	if !full.TryPut(2) || full.Dropped() != 0 {
+		t.Fatal("compactable put into a full queue was dropped")
+	}
+	if x, _ := full.TryGet(); x != 3 {
+		t.Fatalf("merged item = %d, want 3", x)
+	}
+}
+
+func TestFiFo_Subscribe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q := NewFiFo[int]()
+	ch := q.Subscribe(ctx)
+	other := make(chan string, 1)
+	q.Put(ctx, 1)
+	other <- "tick"
+	var got []string
+	for len(got) < 2 {
+		select {
+		case x := <-ch:
+			got = append(got, strconv.Itoa(x))
+		case s := <-other:
+			got = append(got, s)
+		}
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, []string{"1", "tick"}) {
+		t.Fatalf("got %v", got)
+	}
+
+	q.Put(ctx, 2)
+	q.Put(ctx, 3)
+	cancel()
+	// Nothing reads ch any more, so the subscriber puts back what it took.
+	waitFor(t, func() bool { return q.Size() == 2 })
+	if _, ok := <-ch; ok {
+		t.Fatal("channel still open after cancel")
+	}
+	if got, _ := q.Snapshot(context.Background()); !slices.Equal(got, []int{2, 3}) {
+		t.Fatalf("contents after cancel = %v, want [2 3]", got)
+	}
+}
+
+func TestFiFo_SubscribeRequeue(t *testing.T) {
+	q := NewBoundedFiFo[int](1, OverflowBlock)
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := q.Subscribe(ctx)
+	q.Put(context.Background(), 1)
+	waitFor(t, func() bool { return q.Size() == 0 })
+	q.Put(context.Background(), 2)
+	cancel()
+	for range ch {
+	}
+	got, _ := q.Snapshot(context.Background())
+	if !slices.Equal(got, []int{1, 2}) {
+		t.Fatalf("contents after cancel = %v, want [1 2]", got)
+	}
+	if q.TryPut(3) {
+		t.Fatal("TryPut succeeded on a queue over its bound")
+	}
+
+	closed := NewFiFo[int]()
+	closed.Put(context.Background(), 1)
+	closed.Close()
+	var drained []int
+	for x := range closed.Subscribe(context.Background()) {
+		drained = append(drained, x)
+	}
+	if !slices.Equal(drained, []int{1}) {
+		t.Fatalf("drained %v", drained)
+	}
+}
+
+func TestFiFo_AllAndDrain(t *testing.T) {
+	ctx := context.Background()
+	q := NewFiFo[int]()
+	q.PutAll(ctx, 1, 2, 3)
+	var seen []int
+	for x := range q.All() {
+		seen = append(seen, x)
+		q.Put(ctx, x*10) // changes after the snapshot are not seen
+	}
+	if !slices.Equal(seen, []int{1, 2, 3}) || q.Size() != 6 {
+		t.Fatalf("All = %v, size %d", seen, q.Size())
+	}
+
+	var drained []int
+	for x, err := range q.Drain(ctx) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		drained = append(drained, x)
+		if len(drained) == 4 {
+			break
+		}
+	}
+	if !slices.Equal(drained, []int{1, 2, 3, 10}) || q.Size() != 2 {
+		t.Fatalf("Drain = %v, size %d", drained, q.Size())
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	for _, err := range q.Drain(cancelled) {
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Drain on cancelled ctx = %v", err)
+		}
+	}
+	if q.Size() != 2 {
+		t.Fatalf("cancelled Drain removed items: size %d", q.Size())
+	}
+}
+
+func TestFiFo_TryGetN(t *testing.T) {
+	ctx := context.Background()
+	q := NewFiFo[int]()
+	if got := q.TryGetN(3); got != nil {
+		t.Fatalf("TryGetN on empty queue = %v", got)
+	}
+	q.PutAll(ctx, 1, 2, 3, 4, 5)
+	if got := q.TryGetN(3); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Fatalf("TryGetN(3) = %v", got)
+	}
+	if got := q.TryGetN(10); !slices.Equal(got, []int{4, 5}) || !q.IsEmpty() {
+		t.Fatalf("TryGetN(10) = %v", got)
+	}
+	q.Put(ctx, 6)
+	if got := q.TryGetN(0); got != nil || q.Size() != 1 {
+		t.Fatalf("TryGetN(0) = %v", got)
+	}
+}
+
+func TestFiFo_GetUntil(t *testing.T) {
+	ctx := context.Background()
+	q := NewFiFo[int]()
+	go func() {
+		for i := range 5 {
+			q.Put(ctx, i)
+		}
+	}()
+	got, err := q.GetUntil(ctx, func(batch []int) bool { return len(batch) == 3 })
+	if err != nil || !slices.Equal(got, []int{0, 1, 2}) {
+		t.Fatalf("GetUntil = %v, %v", got, err)
+	}
+	got, err = q.GetUntil(ctx, func(batch []int) bool { return len(batch) > 0 && batch[len(batch)-1] == 4 })
+	if err != nil || !slices.Equal(got, []int{3, 4}) {
+		t.Fatalf("GetUntil = %v, %v", got, err)
+	}
+
+	q.Put(ctx, 9)
+	q.Close()
+	got, err = q.GetUntil(ctx, func([]int) bool { return false })
+	if !errors.Is(err, ErrClosed) || !slices.Equal(got, []int{9}) {
+		t.Fatalf("GetUntil on closed queue = %v, %v", got, err)
+	}
+}
+
+func TestFiFo_Clear(t *testing.T) {
+	ctx := context.Background()
+	q := NewBoundedFiFo[int](2, OverflowBlock)
+	if n, err := q.Clear(ctx); n != 0 || err != nil {
+		t.Fatalf("Clear on empty queue = %d, %v", n, err)
+	}
+	q.PutAll(ctx, 1, 2)
+	blocked := make(chan error, 1)
+	go func() { blocked <- q.Put(ctx, 3) }()
+	time.Sleep(10 * time.Millisecond)
+
+	if n, err := q.Clear(ctx); n != 2 || err != nil {
+		t.Fatalf("Clear = %d, %v", n, err)
+	}
+	if err := <-blocked; err != nil {
+		t.Fatalf("blocked Put = %v", err)
+	}
+	if x, _ := q.Get(ctx); x != 3 || !q.IsEmpty() {
+		t.Fatalf("after Clear got %d, size %d", x, q.Size())
+	}
+}
@@ -0,0 +1,245 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var errQueueRetired = errors.New("queue retired")
+
+type groupQueue[T any] struct {
+	q        *FiFo[T]
+	lastUsed time.Time // guarded by QueueGroup.mu
+	inflight int       // Puts in progress; guarded by QueueGroup.mu
+	retired  context.Context
+	retire   context.CancelFunc
+}
+
+// QueueGroup is a set of FiFo queues addressed by key. Queues are created on
+// first use and, when IdleTimeout is set, removed by Collect once they are
+// empty and unused for that long. Consumers blocked on a removed queue follow
+// it transparently when it is recreated. The zero value is ready to use.
+type QueueGroup[K comparable, T any] struct {
+	// Clock supplies the current time. Defaults to SystemClock.
+	Clock Clock
+	// IdleTimeout is how long an empty queue must go unused before Collect
+	// removes it. Zero disables collection.
+	IdleTimeout time.Duration
+
+	mu        sync.Mutex
+	queues    map[K]*groupQueue[T]
+	created   atomicNotifier
+	consumers consumerSet
+}
+
+func (g *QueueGroup[K, T]) now() time.Time {
+	if g.Clock == nil {
+		return time.Now()
+	}
+	return g.Clock.Now()
+}
+
+func (g *QueueGroup[K, T]) clock() Clock {
+	if g.Clock == nil {
+		return SystemClock
+	}
+	return g.Clock
+}
+
+// acquire returns the queue for key, creating it if needed. The caller must
+// hold g.mu.
+func (g *QueueGroup[K, T]) acquire(key K) *groupQueue[T] {
+	if e, ok := g.queues[key]; ok {
+		e.lastUsed = g.now()
+		return e
+	}
+	if g.queues == nil {
+		g.queues = make(map[K]*groupQueue[T])
+	}
+	e := &groupQueue[T]{q: NewFiFo[T](), lastUsed: g.now()}
+	e.retired, e.retire = context.WithCancel(context.Background())
+	g.queues[key] = e
+	g.created.notify()
+	return e
+}
+
+// Put appends x to the queue for key, creating the queue if needed.
+func (g *QueueGroup[K, T]) Put(ctx context.Context, key K, x T) error {
+	g.mu.Lock()
+	e := g.acquire(key)
+	e.inflight++
+	g.mu.Unlock()
+	err := e.q.Put(ctx, x)
+	g.mu.Lock()
+	e.inflight--
+	g.mu.Unlock()
+	return err
+}
+
+// Get removes the next item from the queue for key, blocking until one
+// arrives or ctx is done.
+func (g *QueueGroup[K, T]) Get(ctx context.Context, key K) (T, error) {
+	for {
+		g.mu.Lock()
+		e := g.acquire(key)
+		g.mu.Unlock()
+		x, err := g.getFrom(ctx, e)
+		if err != errQueueRetired {
+			return x, err
+		}
+	}
+}
+
+func (g *QueueGroup[K, T]) getFrom(ctx context.Context, e *groupQueue[T]) (T, error) {
+	qctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer context.AfterFunc(e.retired, cancel)()
+	x, err := e.q.Get(qctx)
+	if err != nil && ctx.Err() == nil && e.retired.Err() != nil {
+		return x, errQueueRetired
+	}
+	if err == nil {
+		g.mu.Lock()
+		e.lastUsed = g.now()
+		g.mu.Unlock()
+	}
+	return x, err
+}
+
+// TryGet removes the next item for key without blocking or creating a queue.
+func (g *QueueGroup[K, T]) TryGet(key K) (T, bool) {
+	g.mu.Lock()
+	e, ok := g.queues[key]
+	if ok {
+		e.lastUsed = g.now()
+	}
+	g.mu.Unlock()
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return e.q.TryGet()
+}
+
+// Size returns the number of items queued for key.
+func (g *QueueGroup[K, T]) Size(key K) int {
+	g.mu.Lock()
+	e, ok := g.queues[key]
+	g.mu.Unlock()
+	if !ok {
+		return 0
+	}
+	return e.q.Size()
+}
+
+// Keys returns the keys of the live queues.
+func (g *QueueGroup[K, T]) Keys() []K {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	keys := make([]K, 0, len(g.queues))
+	for k := range g.queues {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Collect removes queues that are empty and have been idle for at least
+// IdleTimeout, returning how many were removed.
+func (g *QueueGroup[K, T]) Collect() int {
+	if g.IdleTimeout <= 0 {
+		return 0
+	}
+	now := g.now()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	removed := 0
+	for k, e := range g.queues {
+		if e.inflight > 0 || now.Sub(e.lastUsed) < g.IdleTimeout || !e.q.IsEmpty() {
+			continue
+		}
+		delete(g.queues, k)
+		e.retire()
+		removed++
+	}
+	return removed
+}
+
+// RunJanitor calls Collect every interval until ctx is done.
+func (g *QueueGroup[K, T]) RunJanitor(ctx context.Context, interval time.Duration) error {
+	clock := g.clock()
+	for {
+		select {
+		case <-clock.After(interval):
+			g.Collect()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ConsumeEach runs one consumer goroutine per queue, including queues created
+// later, calling handler for every item until ctx is done. Items of one key
+// are handled sequentially; different keys are handled concurrently. Each
+// consumer is named after its key in ConsumerStats.
+func (g *QueueGroup[K, T]) ConsumeEach(ctx context.Context, handler func(ctx context.Context, key K, x T)) error {
+	running := make(map[K]*groupQueue[T])
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		changed := g.created.wait()
+		g.mu.Lock()
+		for k, e := range g.queues {
+			if running[k] == e {
+				continue
+			}
+			running[k] = e
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c := g.consumers.start(fmt.Sprint(k), g.clock())
+				defer g.consumers.stop(c)
+				for {
+					x, err := g.getFrom(ctx, e)
+					if err != nil {
+						return
+					}
+					c.handle(ctx, func(ctx context.Context) { handler(ctx, k, x) })
+				}
+			}()
+		}
+		for k, e := range running {
+			if e.retired.Err() != nil {
+				delete(running, k)
+			}
+		}
+		g.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ConsumerStats reports on the consumers started by ConsumeEach.
+func (g *QueueGroup[K, T]) ConsumerStats() []ConsumerStats {
+	return g.consumers.stats()
+}
+
+// QueueGroupStats is the debug view of a QueueGroup returned by Inspect.
+type QueueGroupStats struct {
+	Queues    int             `json:"queues"`
+	Consumers []ConsumerStats `json:"consumers,omitempty"`
+}
+
+// Inspect reports the number of live queues and their consumers for
+// DebugHandler.
+func (g *QueueGroup[K, T]) Inspect() any {
+	g.mu.Lock()
+	n := len(g.queues)
+	g.mu.Unlock()
+	return QueueGroupStats{Queues: n, Consumers: g.ConsumerStats()}
+}
@@ -0,0 +1,140 @@
+package generic
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQueueGroup_PutGetPerKey(t *testing.T) {
+	var g QueueGroup[string, int]
+	ctx := context.Background()
+
+	g.Put(ctx, "a", 1)
+	g.Put(ctx, "b", 10)
+	g.Put(ctx, "a", 2)
+
+	if n := g.Size("a"); n != 2 {
+		t.Fatalf("expected 2 items for a, got %d", n)
+	}
+	for _, want := range []int{1, 2} {
+		if x, err := g.Get(ctx, "a"); err != nil || x != want {
+			t.Fatalf("expected %d, got %d (err %v)", want, x, err)
+		}
+	}
+	if x, ok := g.TryGet("b"); !ok || x != 10 {
+		t.Fatalf("expected 10, got %d (ok %v)", x, ok)
+	}
+	if _, ok := g.TryGet("missing"); ok {
+		t.Fatal("expected no item for missing key")
+	}
+	if keys := g.Keys(); len(keys) != 2 {
+		t.Fatalf("expected 2 queues, got %v", keys)
+	}
+}
+
+func TestQueueGroup_CollectIdle(t *testing.T) {
+	clock := newTestClock()
+	g := &QueueGroup[string, int]{Clock: clock, IdleTimeout: time.Minute}
+	ctx := context.Background()
+
+	g.Put(ctx, "idle", 1)
+	g.Get(ctx, "idle")
+	g.Put(ctx, "busy", 1) // non-empty queues are never collected
+
+	clock.Advance(30 * time.Second)
+	if n := g.Collect(); n != 0 {
+		t.Fatalf("expected nothing collected before timeout, got %d", n)
+	}
+	clock.Advance(30 * time.Second)
+	if n := g.Collect(); n != 1 {
+		t.Fatalf("expected 1 queue collected, got %d", n)
+	}
+	if keys := g.Keys(); len(keys) != 1 || keys[0] != "busy" {
+		t.Fatalf("expected only busy to remain, got %v", keys)
+	}
+}
+
+func TestQueueGroup_GetFollowsCollectedQueue(t *testing.T) {
+	clock := newTestClock()
+	g := &QueueGroup[string, int]{Clock: clock, IdleTimeout: time.Minute}
+
+	got := make(chan int, 1)
+	go func() {
+		x, err := g.Get(context.Background(), "k")
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		got <- x
+	}()
+	for len(g.Keys()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	clock.Advance(time.Minute)
+	if n := g.Collect(); n != 1 {
+		t.Fatalf("expected the idle queue to be collected, got %d", n)
+	}
+	// The blocked Get recreates the queue and keeps waiting.
+	for len(g.Keys()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	g.Put(context.Background(), "k", 42)
+	select {
+	case x := <-got:
+		if x != 42 {
+			t.Fatalf("expected 42, got %d", x)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("blocked Get did not follow the recreated queue")
+	}
+}
+
+func TestQueueGroup_ConsumeEach(t *testing.T) {
+	var g QueueGroup[int, int]
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	got := map[int][]int{}
+	total := make(chan struct{}, 100)
+	done := make(chan error, 1)
+	go func() {
+		done <- g.ConsumeEach(ctx, func(_ context.Context, k, x int) {
+			mu.Lock()
+			got[k] = append(got[k], x)
+			mu.Unlock()
+			total <- struct{}{}
+		})
+	}()
+
+	for i := range 30 {
+		g.Put(ctx, i%3, i)
+	}
+	for range 30 {
+		select {
+		case <-total:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for items")
+		}
+	}
+
+	mu.Lock()
+	for k, xs := range got {
+		for i := 1; i < len(xs); i++ {
+			if xs[i] < xs[i-1] {
+				t.Errorf("key %d handled out of order: %v", k, xs)
+			}
+		}
+		if len(xs) != 10 {
+			t.Errorf("key %d: expected 10 items, got %d", k, len(xs))
+		}
+	}
+	mu.Unlock()
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
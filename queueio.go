@@ -0,0 +1,176 @@
+package generic
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrFrameTooLarge is returned by QueueWriter for a frame header announcing
+// more than maxFrameSize bytes.
+var ErrFrameTooLarge = errors.New("frame too large")
+
+// maxFrameSize bounds the buffer a single frame header can make QueueWriter
+// allocate.
+const maxFrameSize = 64 << 20
+
+// Frames on the wire are a 4-byte big-endian length followed by the
+// Codec-encoded item, the same layout OverflowBuffer uses on disk.
+const frameHeaderSize = 4
+
+// QueueWriter is an io.Writer that decodes framed items with a Codec and
+// Puts them on a queue. Frames may be split across Write calls arbitrarily.
+// After a decode or Put error every later Write returns the same error.
+type QueueWriter[T any] struct {
+	ctx   context.Context
+	q     Queue[T]
+	codec Codec[T]
+	buf   []byte
+	err   error
+}
+
+// NewQueueWriter returns a writer that Puts decoded items on q, using ctx
+// for each Put.
+func NewQueueWriter[T any](ctx context.Context, q Queue[T], codec Codec[T]) *QueueWriter[T] {
+	return &QueueWriter[T]{ctx: ctx, q: q, codec: codec}
+}
+
+func (w *QueueWriter[T]) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	w.buf = append(w.buf, p...)
+	off := 0
+	for len(w.buf)-off >= frameHeaderSize {
+		n := binary.BigEndian.Uint32(w.buf[off:])
+		if n > maxFrameSize {
+			w.err = fmt.Errorf("%w: %d bytes", ErrFrameTooLarge, n)
+			return len(p), w.err
+		}
+		end := off + frameHeaderSize + int(n)
+		if len(w.buf) < end {
+			break
+		}
+		x, err := w.codec.Unmarshal(w.buf[off+frameHeaderSize : end])
+		if err == nil {
+			err = w.q.Put(w.ctx, x)
+		}
+		if err != nil {
+			w.err = err
+			return len(p), err
+		}
+		off = end
+	}
+	// Keep the partial frame at the front of buf so its storage is reused.
+	w.buf = w.buf[:copy(w.buf, w.buf[off:])]
+	return len(p), nil
+}
+
+// Close reports io.ErrUnexpectedEOF if a partial frame is still buffered.
+func (w *QueueWriter[T]) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.buf) > 0 {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// QueueReader is an io.Reader that Gets items from a queue and encodes them
+// as frames with a Codec. Read blocks for the next item only when nothing is
+// buffered, and returns ctx's error once ctx is done.
+type QueueReader[T any] struct {
+	ctx   context.Context
+	q     Queue[T]
+	codec Codec[T]
+	buf   []byte
+	off   int
+}
+
+// NewQueueReader returns a reader that Gets items from q, using ctx for each
+// Get.
+func NewQueueReader[T any](ctx context.Context, q Queue[T], codec Codec[T]) *QueueReader[T] {
+	return &QueueReader[T]{ctx: ctx, q: q, codec: codec}
+}
+
+func (r *QueueReader[T]) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if r.off == len(r.buf) {
+		x, err := r.q.Get(r.ctx)
+		if err != nil {
+			return 0, err
+		}
+		if err := r.fill(x); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.buf[r.off:])
+	r.off += n
+	return n, nil
+}
+
+// fill encodes x as the next frame, reusing the buffer.
+func (r *QueueReader[T]) fill(x T) error {
+	data, err := r.codec.Marshal(x)
+	if err != nil {
+		return err
+	}
+	r.buf = binary.BigEndian.AppendUint32(r.buf[:0], uint32(len(data)))
+	r.buf = append(r.buf, data...)
+	r.off = 0
+	return nil
+}
+
+// Save writes the queue's current contents to w as codec-encoded frames,
+// in the layout QueueWriter reads, without removing them. Items put or
+// taken meanwhile may or may not be included.
+func (q *FiFo[T]) Save(ctx context.Context, w io.Writer, codec Codec[T]) error {
+	items, err := q.Snapshot(ctx)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	for _, x := range items {
+		if err := Checkpoint(ctx); err != nil {
+			return err
+		}
+		data, err := codec.Marshal(x)
+		if err != nil {
+			return err
+		}
+		if err := writeFrame(bw, data); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// LoadFiFo returns a FiFo holding the items saved to r by Save. A bounded
+// queue that blocks when full fails to load more items than it can hold;
+// the drop policies apply as for Put.
+func LoadFiFo[T any](r io.Reader, codec Codec[T], opts ...FiFoOption) (*FiFo[T], error) {
+	q := NewFiFo[T](opts...)
+	br := bufio.NewReader(r)
+	for {
+		data, err := readFrame(br)
+		if errors.Is(err, io.EOF) {
+			return q, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		x, err := codec.Unmarshal(data)
+		if err != nil {
+			return nil, err
+		}
+		if !q.TryPut(x) && q.policy == OverflowBlock {
+			return nil, fmt.Errorf("generic: saved queue holds more than %d items", q.bound)
+		}
+	}
+}
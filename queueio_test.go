@@ -0,0 +1,146 @@
+package generic
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"testing"
+	"time"
+)
+
+type ioRecord struct {
+	ID   int
+	Name string
+}
+
+func TestQueueWriter_SplitFrames(t *testing.T) {
+	ctx := context.Background()
+	src := NewFiFo[ioRecord]()
+	dst := NewFiFo[ioRecord]()
+	src.Put(ctx, ioRecord{1, "a"})
+	src.Put(ctx, ioRecord{2, "b"})
+
+	r := NewQueueReader[ioRecord](ctx, src, JSONCodec[ioRecord]{})
+	w := NewQueueWriter[ioRecord](ctx, dst, JSONCodec[ioRecord]{})
+
+	// Move the stream one byte at a time so frames straddle Writes.
+	var b [1]byte
+	for dst.Size() < 2 {
+		if _, err := r.Read(b[:]); err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if _, err := w.Write(b[:]); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	for _, want := range []ioRecord{{1, "a"}, {2, "b"}} {
+		if got, _ := dst.TryGet(); got != want {
+			t.Fatalf("expected %+v, got %+v", want, got)
+		}
+	}
+}
+
+func TestQueueReader_ContextDone(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	r := NewQueueReader[int](ctx, NewFiFo[int](), JSONCodec[int]{})
+	if _, err := r.Read(make([]byte, 8)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestQueueWriter_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	w := NewQueueWriter[int](ctx, NewFiFo[int](), JSONCodec[int]{})
+	w.Write([]byte{0, 0})
+	if err := w.Close(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected unexpected EOF for partial frame, got %v", err)
+	}
+
+	w = NewQueueWriter[int](ctx, NewFiFo[int](), JSONCodec[int]{})
+	hdr := binary.BigEndian.AppendUint32(nil, maxFrameSize+1)
+	if _, err := w.Write(hdr); !errors.Is(err, ErrFrameTooLarge) {
+		t.Fatalf("expected ErrFrameTooLarge, got %v", err)
+	}
+
+	w = NewQueueWriter[int](ctx, NewFiFo[int](), JSONCodec[int]{})
+	frame := binary.BigEndian.AppendUint32(nil, 3)
+	frame = append(frame, "bad"...)
+	_, err := w.Write(frame)
+	if err == nil {
+		t.Fatal("expected decode error")
+	}
+	if _, again := w.Write([]byte{0}); again != err {
+		t.Fatalf("expected sticky error %v, got %v", err, again)
+	}
+}
+
+func TestQueueIO_NetBridge(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	src := NewFiFo[ioRecord]()
+	dst := NewFiFo[ioRecord]()
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	go io.Copy(a, NewQueueReader[ioRecord](ctx, src, GobCodec[ioRecord]{}))
+	go io.Copy(NewQueueWriter[ioRecord](ctx, dst, GobCodec[ioRecord]{}), b)
+
+	for i := range 10 {
+		src.Put(ctx, ioRecord{i, "x"})
+	}
+	getCtx, stop := context.WithTimeout(ctx, time.Second)
+	defer stop()
+	for i := range 10 {
+		got, err := dst.Get(getCtx)
+		if err != nil || got.ID != i {
+			t.Fatalf("expected item %d, got %+v, %v", i, got, err)
+		}
+	}
+}
+
+func TestFiFo_SaveLoad(t *testing.T) {
+	ctx := context.Background()
+	q := NewFiFo[string]()
+	q.PutAll(ctx, "a", "b", "c")
+	var buf bytes.Buffer
+	if err := q.Save(ctx, &buf, JSONCodec[string]{}); err != nil {
+		t.Fatal(err)
+	}
+	if q.Size() != 3 {
+		t.Fatalf("Save removed items: size %d", q.Size())
+	}
+	saved := buf.Bytes()
+
+	loaded, err := LoadFiFo(bytes.NewReader(saved), JSONCodec[string]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := loaded.Snapshot(ctx); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Fatalf("loaded %v", got)
+	}
+
+	if _, err := LoadFiFo(bytes.NewReader(saved), JSONCodec[string]{}, WithBound(2, OverflowBlock)); err == nil {
+		t.Fatal("loading 3 items into a blocking queue of 2 succeeded")
+	}
+	dropped, err := LoadFiFo(bytes.NewReader(saved), JSONCodec[string]{}, WithBound(2, OverflowDropOldest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := dropped.Snapshot(ctx); !slices.Equal(got, []string{"b", "c"}) {
+		t.Fatalf("drop-oldest load = %v", got)
+	}
+
+	if _, err := LoadFiFo(bytes.NewReader(saved[:len(saved)-1]), JSONCodec[string]{}); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("truncated load = %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package generic
+
+import (
+	"cmp"
+	"container/heap"
+	"context"
+	"slices"
+	"time"
+)
+
+// QueueState is a queue's contents in a form any StatefulQueue can import,
+// for migrating between queue types or persisting across restarts. Items
+// are listed in the order the exporting queue would hand them out, so a
+// FiFo importing a PriorityQueue's state serves them in priority order.
+type QueueState[T any] struct {
+	Items []QueueStateItem[T] `json:"items"`
+}
+
+// QueueStateItem is one exported item with the metadata richer queues
+// track. Queues leave fields they don't track zero on export and ignore
+// them on import. Exported Envelopes fill in Attempts and ReadyAt from
+// their own fields whatever the queue.
+type QueueStateItem[T any] struct {
+	Value T `json:"value"`
+	// Priority is a numeric priority, lower first. PriorityQueue exports
+	// each item's rank, with items that compare equal sharing one.
+	Priority int `json:"priority,omitempty"`
+	// ReadyAt is when a delayed item becomes available.
+	ReadyAt time.Time `json:"ready_at,omitzero"`
+	// Attempts counts failed deliveries of the item.
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// StatefulQueue is implemented by queues that can export and import their
+// contents as a QueueState. ImportState adds the items as if each had been
+// put in order; it does not clear the queue first.
+type StatefulQueue[T any] interface {
+	ExportState(ctx context.Context) (QueueState[T], error)
+	ImportState(ctx context.Context, s QueueState[T]) error
+}
+
+// StateOf returns a state holding values with only the metadata they
+// carry themselves.
+func StateOf[T any](values ...T) QueueState[T] {
+	s := QueueState[T]{Items: make([]QueueStateItem[T], len(values))}
+	for i, x := range values {
+		s.Items[i] = stateItem(x)
+	}
+	return s
+}
+
+// stateMeta is implemented by values that carry their own delivery
+// metadata, such as Envelope.
+type stateMeta interface {
+	stateMeta() (attempts int, readyAt time.Time)
+}
+
+func stateItem[T any](x T) QueueStateItem[T] {
+	it := QueueStateItem[T]{Value: x}
+	if m, ok := any(x).(stateMeta); ok {
+		it.Attempts, it.ReadyAt = m.stateMeta()
+	}
+	return it
+}
+
+// Values returns the item values in order.
+func (s QueueState[T]) Values() []T {
+	values := make([]T, len(s.Items))
+	for i, it := range s.Items {
+		values[i] = it.Value
+	}
+	return values
+}
+
+// ExportState returns the queue contents in FIFO order.
+func (q *FiFo[T]) ExportState(ctx context.Context) (QueueState[T], error) {
+	items, err := q.Snapshot(ctx)
+	if err != nil {
+		return QueueState[T]{}, err
+	}
+	return StateOf(items...), nil
+}
+
+// ImportState appends the items of s as PutAll does.
+func (q *FiFo[T]) ImportState(ctx context.Context, s QueueState[T]) error {
+	return q.PutAll(ctx, s.Values()...)
+}
+
+// ExportState returns the queue contents in the order Get would return
+// them.
+func (q *PriorityQueue[T]) ExportState(ctx context.Context) (QueueState[T], error) {
+	if err := ctx.Err(); err != nil {
+		return QueueState[T]{}, err
+	}
+	q.mu.Lock()
+	items := slices.Clone(q.h.items)
+	q.mu.Unlock()
+	slices.SortFunc(items, func(a, b priorityItem[T]) int {
+		switch {
+		case q.h.less(a.x, b.x):
+			return -1
+		case q.h.less(b.x, a.x):
+			return 1
+		}
+		return cmp.Compare(a.seq, b.seq)
+	})
+	s := QueueState[T]{Items: make([]QueueStateItem[T], len(items))}
+	rank := 0
+	for i, it := range items {
+		if i > 0 && q.h.less(items[i-1].x, it.x) {
+			rank++
+		}
+		s.Items[i] = stateItem(it.x)
+		s.Items[i].Priority = rank
+	}
+	return s, nil
+}
+
+// ImportState adds the items of s. Items are ordered by the less function;
+// those that compare equal come out in order of Priority, then of their
+// position in s.
+func (q *PriorityQueue[T]) ImportState(ctx context.Context, s QueueState[T]) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return ErrClosed
+	}
+	items := slices.Clone(s.Items)
+	slices.SortStableFunc(items, func(a, b QueueStateItem[T]) int { return cmp.Compare(a.Priority, b.Priority) })
+	for _, it := range items {
+		q.seq++
+		q.h.items = append(q.h.items, priorityItem[T]{x: it.Value, seq: q.seq})
+	}
+	heap.Init(&q.h)
+	q.mu.Unlock()
+	q.notify.notify()
+	return nil
+}
+
+var (
+	_ StatefulQueue[int] = (*FiFo[int])(nil)
+	_ StatefulQueue[int] = (*PriorityQueue[int])(nil)
+)
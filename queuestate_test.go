@@ -0,0 +1,109 @@
+package generic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestQueueState_PriorityToFiFo(t *testing.T) {
+	ctx := context.Background()
+	type job struct {
+		Name string `json:"name"`
+		Pri  int    `json:"pri"`
+	}
+	pq := NewPriorityQueue(func(a, b job) bool { return a.Pri < b.Pri })
+	for _, j := range []job{{"c", 2}, {"a", 1}, {"d", 2}, {"b", 1}} {
+		pq.Put(ctx, j)
+	}
+	s, err := pq.ExportState(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pq.Size() != 4 {
+		t.Fatalf("ExportState consumed items: size %d", pq.Size())
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restored QueueState[job]
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+
+	q := NewFiFo[job]()
+	if err := q.ImportState(ctx, restored); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for !q.IsEmpty() {
+		j, _ := q.Get(ctx)
+		names = append(names, j.Name)
+	}
+	if !slices.Equal(names, []string{"a", "b", "c", "d"}) {
+		t.Fatalf("FiFo order = %v", names)
+	}
+}
+
+func TestQueueState_FiFoToPriority(t *testing.T) {
+	ctx := context.Background()
+	q := NewFiFo[int]()
+	q.PutAll(ctx, 3, 1, 2)
+	s, _ := q.ExportState(ctx)
+	if got := s.Values(); !slices.Equal(got, []int{3, 1, 2}) {
+		t.Fatalf("FiFo state = %v", got)
+	}
+
+	pq := NewPriorityQueue(func(a, b int) bool { return a < b })
+	pq.Put(ctx, 0)
+	if err := pq.ImportState(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	exported, _ := pq.ExportState(ctx)
+	if got := exported.Values(); !slices.Equal(got, []int{0, 1, 2, 3}) {
+		t.Fatalf("PriorityQueue state = %v", got)
+	}
+
+	pq.Close()
+	if err := pq.ImportState(ctx, StateOf(4)); !errors.Is(err, ErrClosed) {
+		t.Fatalf("ImportState after Close = %v", err)
+	}
+}
+
+func TestQueueState_Metadata(t *testing.T) {
+	ctx := context.Background()
+	pq := NewPriorityQueue(func(a, b int) bool { return a/10 < b/10 })
+	pq.Put(ctx, 21)
+	pq.Put(ctx, 5)
+	pq.Put(ctx, 7)
+	s, _ := pq.ExportState(ctx)
+	var pris []int
+	for _, it := range s.Items {
+		pris = append(pris, it.Priority)
+	}
+	if got := s.Values(); !slices.Equal(got, []int{5, 7, 21}) || !slices.Equal(pris, []int{0, 0, 1}) {
+		t.Fatalf("exported %v with priorities %v", got, pris)
+	}
+
+	// Among items that compare equal, Priority decides the order.
+	pq = NewPriorityQueue(func(a, b int) bool { return a/10 < b/10 })
+	pq.ImportState(ctx, QueueState[int]{Items: []QueueStateItem[int]{{Value: 1, Priority: 2}, {Value: 2, Priority: 1}, {Value: 30}, {Value: 3, Priority: 1}}})
+	exported, _ := pq.ExportState(ctx)
+	if got := exported.Values(); !slices.Equal(got, []int{2, 3, 1, 30}) {
+		t.Fatalf("PriorityQueue state = %v", got)
+	}
+
+	// Envelopes carry their attempts through any queue.
+	retryAt := time.Unix(1_000_000, 0)
+	q := NewFiFo[Envelope[string]]()
+	q.Put(ctx, Envelope[string]{Item: "job", Attempts: 2, RetryAt: retryAt})
+	es, _ := q.ExportState(ctx)
+	if it := es.Items[0]; it.Attempts != 2 || !it.ReadyAt.Equal(retryAt) {
+		t.Fatalf("exported envelope %+v", it)
+	}
+}
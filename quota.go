@@ -0,0 +1,193 @@
+package generic
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// QuotaError reports that a request can never fit a key's limit.
+type QuotaError[K comparable] struct {
+	Key   K
+	N     int64
+	Limit int64
+}
+
+func (e *QuotaError[K]) Error() string {
+	return fmt.Sprintf("quota: %d exceeds limit %d for %v", e.N, e.Limit, e.Key)
+}
+
+// Quota enforces hierarchical limits, such as global → tenant → user. Each
+// Acquire names a path of keys and takes n units from every key on it at
+// once, so a tenant can't exceed its share even when its users are each
+// under theirs. Keys without a limit are unbounded.
+//
+// Every Interval all usage is refilled. A zero Interval never refills, and
+// units come back only through Release, making Quota a hierarchical
+// semaphore. The zero value is ready to use.
+type Quota[K comparable] struct {
+	Interval time.Duration
+	// Clock supplies the current time. Defaults to SystemClock.
+	Clock Clock
+
+	mu     sync.Mutex
+	limits map[K]int64
+	used   map[K]int64
+	window time.Time
+	freed  atomicNotifier
+
+	granted ShardedCounter
+	denied  ShardedCounter
+}
+
+// QuotaStats is the debug view of a Quota.
+type QuotaStats struct {
+	Keys    int   `json:"keys"`
+	Granted int64 `json:"granted"`
+	Denied  int64 `json:"denied"`
+}
+
+func (q *Quota[K]) clock() Clock {
+	if q.Clock == nil {
+		return SystemClock
+	}
+	return q.Clock
+}
+
+// SetLimit sets the limit for key. A negative limit removes it.
+func (q *Quota[K]) SetLimit(key K, limit int64) {
+	q.mu.Lock()
+	if limit < 0 {
+		delete(q.limits, key)
+	} else {
+		if q.limits == nil {
+			q.limits = make(map[K]int64)
+		}
+		q.limits[key] = limit
+	}
+	q.mu.Unlock()
+	q.freed.notify()
+}
+
+// refill resets usage once the current window has passed and returns the
+// start of the next window. The caller must hold q.mu.
+func (q *Quota[K]) refill(now time.Time) time.Time {
+	if q.Interval <= 0 {
+		return time.Time{}
+	}
+	if q.window.IsZero() {
+		q.window = now
+	}
+	if elapsed := now.Sub(q.window); elapsed >= q.Interval {
+		q.window = q.window.Add(elapsed - elapsed%q.Interval)
+		clear(q.used)
+	}
+	return q.window.Add(q.Interval)
+}
+
+// take claims n units on every key in keys, or none of them. The caller must
+// hold q.mu.
+func (q *Quota[K]) take(n int64, keys []K) (bool, error) {
+	for _, k := range keys {
+		limit, ok := q.limits[k]
+		if !ok {
+			continue
+		}
+		if n > limit {
+			return false, &QuotaError[K]{Key: k, N: n, Limit: limit}
+		}
+		if q.used[k]+n > limit {
+			return false, nil
+		}
+	}
+	if q.used == nil {
+		q.used = make(map[K]int64)
+	}
+	for _, k := range keys {
+		if _, ok := q.limits[k]; ok {
+			q.used[k] += n
+		}
+	}
+	return true, nil
+}
+
+// TryAcquire takes n units from every key in keys without blocking,
+// reporting whether it succeeded.
+func (q *Quota[K]) TryAcquire(n int64, keys ...K) bool {
+	q.mu.Lock()
+	q.refill(q.clock().Now())
+	ok, _ := q.take(n, keys)
+	q.mu.Unlock()
+	if ok {
+		q.granted.Add(1)
+	} else {
+		q.denied.Add(1)
+	}
+	return ok
+}
+
+// Acquire takes n units from every key in keys, blocking until all of them
+// have room, the next refill, or ctx is done. It returns a *QuotaError if n
+// exceeds a key's limit outright.
+func (q *Quota[K]) Acquire(ctx context.Context, n int64, keys ...K) error {
+	for {
+		freed := q.freed.wait()
+		q.mu.Lock()
+		next := q.refill(q.clock().Now())
+		ok, err := q.take(n, keys)
+		q.mu.Unlock()
+		if ok {
+			q.granted.Add(1)
+			return nil
+		}
+		if err != nil {
+			q.denied.Add(1)
+			return err
+		}
+		var refilled <-chan time.Time
+		if !next.IsZero() {
+			refilled = q.clock().After(next.Sub(q.clock().Now()))
+		}
+		select {
+		case <-freed:
+		case <-refilled:
+		case <-ctx.Done():
+			q.denied.Add(1)
+			return ctx.Err()
+		}
+	}
+}
+
+// Release returns n units to every key in keys, waking blocked Acquires.
+func (q *Quota[K]) Release(n int64, keys ...K) {
+	q.mu.Lock()
+	for _, k := range keys {
+		if u, ok := q.used[k]; ok {
+			q.used[k] = max(u-n, 0)
+		}
+	}
+	q.mu.Unlock()
+	q.freed.notify()
+}
+
+// Remaining returns the units left on key in the current window, or -1 if
+// key has no limit.
+func (q *Quota[K]) Remaining(key K) int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.refill(q.clock().Now())
+	limit, ok := q.limits[key]
+	if !ok {
+		return -1
+	}
+	return max(limit-q.used[key], 0)
+}
+
+// Inspect reports QuotaStats for DebugHandler.
+func (q *Quota[K]) Inspect() any {
+	q.mu.Lock()
+	keys := len(q.limits)
+	q.mu.Unlock()
+	return QuotaStats{Keys: keys, Granted: q.granted.Load(), Denied: q.denied.Load()}
+}
@@ -0,0 +1,108 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQuota_Hierarchy(t *testing.T) {
+	var q Quota[string]
+	q.SetLimit("global", 10)
+	q.SetLimit("tenant:a", 5)
+	q.SetLimit("user:a1", 3)
+
+	if !q.TryAcquire(3, "global", "tenant:a", "user:a1") {
+		t.Fatal("first acquire should fit every level")
+	}
+	if q.TryAcquire(1, "global", "tenant:a", "user:a1") {
+		t.Fatal("user limit should reject")
+	}
+	if !q.TryAcquire(2, "global", "tenant:a", "user:a2") {
+		t.Fatal("sibling user should fit the tenant's remaining share")
+	}
+	if q.TryAcquire(1, "global", "tenant:a", "user:a2") {
+		t.Fatal("tenant limit should reject even though the user has no limit")
+	}
+	if got := q.Remaining("global"); got != 5 {
+		t.Fatalf("failed acquires must not consume units, global remaining %d", got)
+	}
+	if got := q.Remaining("user:a2"); got != -1 {
+		t.Fatalf("unlimited key should report -1, got %d", got)
+	}
+	if !q.TryAcquire(5, "global", "tenant:b") {
+		t.Fatal("other tenant should use the global remainder")
+	}
+	if q.TryAcquire(1, "global", "tenant:b") {
+		t.Fatal("global limit should reject")
+	}
+}
+
+func TestQuota_TooLarge(t *testing.T) {
+	var q Quota[string]
+	q.SetLimit("tenant", 2)
+	err := q.Acquire(context.Background(), 3, "tenant")
+	var qe *QuotaError[string]
+	if !errors.As(err, &qe) || qe.Key != "tenant" || qe.Limit != 2 {
+		t.Fatalf("expected QuotaError, got %v", err)
+	}
+}
+
+func TestQuota_Refill(t *testing.T) {
+	clock := newTestClock()
+	q := &Quota[string]{Interval: time.Second, Clock: clock}
+	q.SetLimit("k", 2)
+	if !q.TryAcquire(2, "k") {
+		t.Fatal("acquire within limit failed")
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- q.Acquire(context.Background(), 1, "k") }()
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("Acquire should block until refill, got %v", err)
+	default:
+	}
+	clock.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Fatalf("Acquire after refill: %v", err)
+	}
+	if got := q.Remaining("k"); got != 1 {
+		t.Fatalf("expected 1 remaining after refill, got %d", got)
+	}
+}
+
+func TestQuota_Release(t *testing.T) {
+	var q Quota[string]
+	q.SetLimit("tenant", 1)
+	ctx := context.Background()
+	if err := q.Acquire(ctx, 1, "tenant", "user"); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- q.Acquire(ctx, 1, "tenant", "user") }()
+	time.Sleep(10 * time.Millisecond)
+	q.Release(1, "tenant", "user")
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Release should wake a blocked Acquire")
+	}
+
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := q.Acquire(cctx, 1, "tenant"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline, got %v", err)
+	}
+	if s := q.Inspect().(QuotaStats); s.Granted != 2 || s.Denied != 1 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
@@ -0,0 +1,43 @@
+package generic
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+// ContentProvider is random-access content served by ServeRanges. A
+// provider may also implement ETag() string, ModTime() time.Time and
+// ContentType() string to enable conditional requests and skip type
+// sniffing.
+type ContentProvider interface {
+	io.ReaderAt
+	Size() int64
+}
+
+// ServeRanges serves content with full HTTP range support: single and
+// multiple ranges (as multipart/byteranges), If-Range, and the
+// If-Match/If-None-Match/If-Modified-Since preconditions when the provider
+// supplies an ETag or ModTime. Without a ContentType the type is sniffed
+// from the first bytes. HEAD requests get headers only.
+func ServeRanges(w http.ResponseWriter, r *http.Request, content ContentProvider) {
+	h := w.Header()
+	if e, ok := content.(interface{ ETag() string }); ok {
+		if tag := e.ETag(); tag != "" {
+			if tag[0] != '"' && tag[0] != 'W' {
+				tag = `"` + tag + `"`
+			}
+			h.Set("Etag", tag)
+		}
+	}
+	if ct, ok := content.(interface{ ContentType() string }); ok {
+		if t := ct.ContentType(); t != "" {
+			h.Set("Content-Type", t)
+		}
+	}
+	var modTime time.Time
+	if m, ok := content.(interface{ ModTime() time.Time }); ok {
+		modTime = m.ModTime()
+	}
+	http.ServeContent(w, r, "", modTime, io.NewSectionReader(content, 0, content.Size()))
+}
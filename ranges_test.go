@@ -0,0 +1,134 @@
+package generic
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type rangeContent struct {
+	*bytes.Reader
+	etag    string
+	modTime time.Time
+	reads   int
+}
+
+func (c *rangeContent) ReadAt(p []byte, off int64) (int, error) {
+	c.reads++
+	return c.Reader.ReadAt(p, off)
+}
+
+func (c *rangeContent) ETag() string        { return c.etag }
+func (c *rangeContent) ModTime() time.Time  { return c.modTime }
+func (c *rangeContent) ContentType() string { return "application/octet-stream" }
+
+func serveRange(c ContentProvider, method string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/artifact", nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	ServeRanges(rec, req, c)
+	return rec
+}
+
+func newRangeContent() *rangeContent {
+	return &rangeContent{
+		Reader:  bytes.NewReader([]byte("0123456789abcdefghij")),
+		etag:    "v1",
+		modTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestServeRanges_Full(t *testing.T) {
+	rec := serveRange(newRangeContent(), http.MethodGet)
+	if rec.Code != http.StatusOK || rec.Body.String() != "0123456789abcdefghij" {
+		t.Fatalf("full: %d %q", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Etag") != `"v1"` || rec.Header().Get("Accept-Ranges") != "bytes" {
+		t.Fatalf("unexpected headers %v", rec.Header())
+	}
+	if rec.Header().Get("Content-Type") != "application/octet-stream" {
+		t.Fatalf("provider content type should win, got %q", rec.Header().Get("Content-Type"))
+	}
+}
+
+func TestServeRanges_Single(t *testing.T) {
+	rec := serveRange(newRangeContent(), http.MethodGet, "Range", "bytes=10-14")
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != "abcde" {
+		t.Fatalf("range: %d %q", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Content-Range") != "bytes 10-14/20" {
+		t.Fatalf("unexpected Content-Range %q", rec.Header().Get("Content-Range"))
+	}
+	if rec := serveRange(newRangeContent(), http.MethodGet, "Range", "bytes=-3"); rec.Body.String() != "hij" {
+		t.Fatalf("suffix range: %q", rec.Body.String())
+	}
+	if rec := serveRange(newRangeContent(), http.MethodGet, "Range", "bytes=30-"); rec.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Fatalf("unsatisfiable range: %d", rec.Code)
+	}
+}
+
+func TestServeRanges_Multipart(t *testing.T) {
+	rec := serveRange(newRangeContent(), http.MethodGet, "Range", "bytes=0-1,18-19")
+	if rec.Code != http.StatusPartialContent {
+		t.Fatalf("multi range: %d", rec.Code)
+	}
+	mt, params, err := mime.ParseMediaType(rec.Header().Get("Content-Type"))
+	if err != nil || mt != "multipart/byteranges" {
+		t.Fatalf("unexpected content type %q", rec.Header().Get("Content-Type"))
+	}
+	mr := multipart.NewReader(rec.Body, params["boundary"])
+	var parts []string
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(p)
+		parts = append(parts, string(b))
+	}
+	if len(parts) != 2 || parts[0] != "01" || parts[1] != "ij" {
+		t.Fatalf("unexpected parts %q", parts)
+	}
+}
+
+func TestServeRanges_Conditional(t *testing.T) {
+	c := newRangeContent()
+	if rec := serveRange(c, http.MethodGet, "If-None-Match", `"v1"`); rec.Code != http.StatusNotModified {
+		t.Fatalf("matching If-None-Match: %d", rec.Code)
+	}
+	if rec := serveRange(c, http.MethodGet, "If-Match", `"v2"`); rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("mismatched If-Match: %d", rec.Code)
+	}
+	rec := serveRange(c, http.MethodGet, "Range", "bytes=0-1", "If-Range", `"v1"`)
+	if rec.Code != http.StatusPartialContent {
+		t.Fatalf("If-Range with current ETag should honor the range, got %d", rec.Code)
+	}
+	rec = serveRange(c, http.MethodGet, "Range", "bytes=0-1", "If-Range", `"v0"`)
+	if rec.Code != http.StatusOK || rec.Body.Len() != 20 {
+		t.Fatalf("If-Range with stale ETag should send everything, got %d", rec.Code)
+	}
+	if rec := serveRange(c, http.MethodGet, "If-Modified-Since", c.modTime.Format(http.TimeFormat)); rec.Code != http.StatusNotModified {
+		t.Fatalf("If-Modified-Since: %d", rec.Code)
+	}
+}
+
+func TestServeRanges_Head(t *testing.T) {
+	c := newRangeContent()
+	rec := serveRange(c, http.MethodHead)
+	if rec.Body.Len() != 0 || rec.Header().Get("Content-Length") != "20" {
+		t.Fatalf("head: %q %v", rec.Body.String(), rec.Header())
+	}
+	if c.reads != 0 {
+		t.Fatalf("HEAD should not read content, read %d times", c.reads)
+	}
+}
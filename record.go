@@ -0,0 +1,156 @@
+package generic
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"sync"
+	"time"
+)
+
+// Recorded is one item captured by a Recorder, with its offset from the
+// start of the recording.
+type Recorded[T any] struct {
+	At   time.Duration
+	Item T
+}
+
+// Recorder wraps a Queue and writes every item accepted by Put or TryPut to
+// w, with its arrival time, as a stream of length-prefixed frames. A failed
+// write doesn't affect the queue; it stops the recording and is reported by
+// Err.
+type Recorder[T any] struct {
+	Queue[T]
+	// Clock timestamps items. Defaults to SystemClock.
+	Clock Clock
+
+	codec Codec[T]
+	mu    sync.Mutex
+	w     io.Writer
+	start time.Time
+	err   error
+}
+
+// NewRecorder returns a Recorder writing the items put into q to w.
+func NewRecorder[T any](q Queue[T], w io.Writer, codec Codec[T]) *Recorder[T] {
+	return &Recorder[T]{Queue: q, w: w, codec: codec}
+}
+
+func (r *Recorder[T]) Put(ctx context.Context, x T) error {
+	if err := r.Queue.Put(ctx, x); err != nil {
+		return err
+	}
+	r.record(x)
+	return nil
+}
+
+func (r *Recorder[T]) TryPut(x T) bool {
+	if !r.Queue.TryPut(x) {
+		return false
+	}
+	r.record(x)
+	return true
+}
+
+func (r *Recorder[T]) record(x T) {
+	clock := r.Clock
+	if clock == nil {
+		clock = SystemClock
+	}
+	now := clock.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	if r.start.IsZero() {
+		r.start = now
+	}
+	data, err := r.codec.Marshal(x)
+	if err != nil {
+		r.err = err
+		return
+	}
+	frame := binary.BigEndian.AppendUint64(make([]byte, 0, 8+len(data)), uint64(now.Sub(r.start)))
+	r.err = writeFrame(r.w, append(frame, data...))
+}
+
+// Err returns the error that stopped the recording, if any.
+func (r *Recorder[T]) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// ReadRecording iterates over the items in a recording written by a
+// Recorder, stopping after the first error.
+func ReadRecording[T any](r io.Reader, codec Codec[T]) iter.Seq2[Recorded[T], error] {
+	return func(yield func(Recorded[T], error) bool) {
+		for {
+			frame, err := readFrame(r)
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err == nil && len(frame) < 8 {
+				err = fmt.Errorf("generic: recording frame too short: %d bytes", len(frame))
+			}
+			if err != nil {
+				yield(Recorded[T]{}, err)
+				return
+			}
+			rec := Recorded[T]{At: time.Duration(binary.BigEndian.Uint64(frame))}
+			if rec.Item, err = codec.Unmarshal(frame[8:]); err != nil {
+				yield(Recorded[T]{}, err)
+				return
+			}
+			if !yield(rec, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Replayer re-injects a recording into a queue.
+type Replayer[T any] struct {
+	Codec Codec[T]
+	// Speed scales the original timing: 1 replays in real time, 10 ten
+	// times faster. Zero or less puts items as fast as the queue accepts
+	// them.
+	Speed float64
+	// Clock paces the replay. Defaults to SystemClock.
+	Clock Clock
+}
+
+// Replay puts every recorded item from r into q, keeping their relative
+// timing scaled by Speed. It returns the number of items put.
+func (p *Replayer[T]) Replay(ctx context.Context, r io.Reader, q Queue[T]) (int, error) {
+	clock := p.Clock
+	if clock == nil {
+		clock = SystemClock
+	}
+	start := clock.Now()
+	n := 0
+	for rec, err := range ReadRecording(r, p.Codec) {
+		if err != nil {
+			return n, err
+		}
+		if p.Speed > 0 {
+			due := start.Add(time.Duration(float64(rec.At) / p.Speed))
+			if wait := due.Sub(clock.Now()); wait > 0 {
+				select {
+				case <-clock.After(wait):
+				case <-ctx.Done():
+					return n, ctx.Err()
+				}
+			}
+		}
+		if err := q.Put(ctx, rec.Item); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
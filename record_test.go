@@ -0,0 +1,114 @@
+package generic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRecorder_RoundTrip(t *testing.T) {
+	clock := newTestClock()
+	var buf bytes.Buffer
+	inner := NewFiFo[ioRecord]()
+	rec := NewRecorder[ioRecord](inner, &buf, JSONCodec[ioRecord]{})
+	rec.Clock = clock
+	ctx := context.Background()
+
+	rec.Put(ctx, ioRecord{ID: 1, Name: "a"})
+	clock.Advance(2 * time.Second)
+	rec.TryPut(ioRecord{ID: 2, Name: "b"})
+	clock.Advance(time.Second)
+	rec.Put(ctx, ioRecord{ID: 3, Name: "c"})
+	if err := rec.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if inner.Size() != 3 {
+		t.Fatalf("recorder must pass items through, size %d", inner.Size())
+	}
+
+	var got []Recorded[ioRecord]
+	for r, err := range ReadRecording(bytes.NewReader(buf.Bytes()), JSONCodec[ioRecord]{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, r)
+	}
+	want := []time.Duration{0, 2 * time.Second, 3 * time.Second}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d records, got %d", len(want), len(got))
+	}
+	for i, r := range got {
+		if r.At != want[i] || r.Item.ID != i+1 {
+			t.Fatalf("record %d = %+v", i, r)
+		}
+	}
+}
+
+func TestReplayer_Timing(t *testing.T) {
+	var buf bytes.Buffer
+	clock := newTestClock()
+	rec := NewRecorder[ioRecord](NewFiFo[ioRecord](), &buf, JSONCodec[ioRecord]{})
+	rec.Clock = clock
+	ctx := context.Background()
+	rec.Put(ctx, ioRecord{ID: 1})
+	clock.Advance(4 * time.Second)
+	rec.Put(ctx, ioRecord{ID: 2})
+
+	replayClock := newTestClock()
+	p := &Replayer[ioRecord]{Codec: JSONCodec[ioRecord]{}, Speed: 2, Clock: replayClock}
+	q := NewFiFo[ioRecord]()
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.Replay(ctx, &buf, q)
+		done <- err
+	}()
+	for replayClock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if q.Size() != 1 {
+		t.Fatalf("first item should be replayed immediately, size %d", q.Size())
+	}
+	replayClock.Advance(time.Second)
+	select {
+	case <-done:
+		t.Fatal("second item is due after 2s at double speed")
+	case <-time.After(10 * time.Millisecond):
+	}
+	replayClock.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if q.Size() != 2 {
+		t.Fatalf("expected 2 items replayed, got %d", q.Size())
+	}
+}
+
+func TestReplayer_Unpaced(t *testing.T) {
+	var buf bytes.Buffer
+	rec := NewRecorder[ioRecord](NewFiFo[ioRecord](), &buf, JSONCodec[ioRecord]{})
+	for i := range 5 {
+		rec.Put(context.Background(), ioRecord{ID: i})
+	}
+	q := NewFiFo[ioRecord]()
+	n, err := (&Replayer[ioRecord]{Codec: JSONCodec[ioRecord]{}}).Replay(context.Background(), &buf, q)
+	if err != nil || n != 5 {
+		t.Fatalf("replayed %d, %v", n, err)
+	}
+}
+
+func TestReadRecording_Truncated(t *testing.T) {
+	var buf bytes.Buffer
+	rec := NewRecorder[ioRecord](NewFiFo[ioRecord](), &buf, JSONCodec[ioRecord]{})
+	rec.Put(context.Background(), ioRecord{ID: 1})
+	data := buf.Bytes()[:buf.Len()-1]
+	for _, err := range ReadRecording(bytes.NewReader(data), JSONCodec[ioRecord]{}) {
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("expected unexpected EOF, got %v", err)
+		}
+		return
+	}
+	t.Fatal("expected an error")
+}
@@ -0,0 +1,312 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrPoolSaturated is returned by ResourcePool.Acquire when the estimated
+// wait would outlast the context's deadline.
+var ErrPoolSaturated = errors.New("pool saturated")
+
+// QueuePosition describes where a blocked Acquire stands in line.
+type QueuePosition struct {
+	// Position is 1 for the next waiter to be served.
+	Position int `json:"position"`
+	// Waiting is the total number of blocked Acquires.
+	Waiting int `json:"waiting"`
+	// EstimatedWait is Position leases' worth of the average hold time,
+	// spread across the pool's resources. It is zero until a lease has
+	// been returned.
+	EstimatedWait time.Duration `json:"estimated_wait"`
+}
+
+// AcquireOption configures ResourcePool.Acquire.
+type AcquireOption = Option[acquireOptions]
+
+type acquireOptions struct {
+	priority int
+	feedback func(QueuePosition) error
+}
+
+// WithAcquirePriority lets the caller jump ahead of waiters with a lower
+// priority. Waiters of equal priority are served in arrival order.
+func WithAcquirePriority(p int) AcquireOption {
+	return func(o *acquireOptions) { o.priority = p }
+}
+
+// WithQueueFeedback calls fn when Acquire has to wait and again whenever
+// its position changes. Returning an error abandons the Acquire with that
+// error, so callers can fail fast or degrade when the line is too long.
+func WithQueueFeedback(fn func(QueuePosition) error) AcquireOption {
+	return func(o *acquireOptions) { o.feedback = fn }
+}
+
+// ResourcePoolStats is a point-in-time view of a ResourcePool.
+type ResourcePoolStats struct {
+	Max      int           `json:"max"`
+	Open     int           `json:"open"`
+	Idle     int           `json:"idle"`
+	Waiting  int           `json:"waiting"`
+	AvgHold  time.Duration `json:"avg_hold"`
+	Acquired int64         `json:"acquired"`
+	Rejected int64         `json:"rejected"`
+}
+
+// ResourcePool hands out up to Max expensive resources, such as
+// connections, creating them on demand and blocking callers once all are
+// leased. Unlike Pool, a blocked Acquire is visible: it can report its
+// place in line, be prioritized, and fail early when its deadline cannot be
+// met.
+type ResourcePool[T any] struct {
+	// Clock measures hold times. Defaults to SystemClock.
+	Clock Clock
+
+	max     int
+	newFn   func(ctx context.Context) (T, error)
+	mu      sync.Mutex
+	idle    []T
+	open    int
+	waiters []*poolWaiter[T] // by priority, then arrival
+	avgHold time.Duration
+
+	acquired ShardedCounter
+	rejected ShardedCounter
+}
+
+type poolWaiter[T any] struct {
+	priority int
+	grant    chan poolGrant[T]
+	moved    chan struct{}
+}
+
+// poolGrant hands a waiter either an idle resource or a free slot to
+// create one in.
+type poolGrant[T any] struct {
+	x      T
+	create bool
+}
+
+// NewResourcePool returns a pool of at most max resources made by newFn.
+// A max of zero or less means no limit.
+func NewResourcePool[T any](max int, newFn func(ctx context.Context) (T, error)) *ResourcePool[T] {
+	return &ResourcePool[T]{max: max, newFn: newFn}
+}
+
+func (p *ResourcePool[T]) clock() Clock {
+	if p.Clock == nil {
+		return SystemClock
+	}
+	return p.Clock
+}
+
+// PoolLease is a resource taken from a ResourcePool. Return it with
+// Release, or with Discard if it is broken.
+type PoolLease[T any] struct {
+	Value T
+
+	pool *ResourcePool[T]
+	at   time.Time
+	once sync.Once
+}
+
+// Release returns the resource to the pool. Only the first call to Release
+// or Discard has an effect.
+func (l *PoolLease[T]) Release() {
+	l.once.Do(func() { l.pool.release(l, false) })
+}
+
+// Discard drops the resource, freeing its slot for a new one.
+func (l *PoolLease[T]) Discard() {
+	l.once.Do(func() { l.pool.release(l, true) })
+}
+
+// Acquire leases a resource, creating one if the pool has room, or else
+// waits for one to be returned. It fails with ErrPoolSaturated, without
+// waiting, if the estimated wait exceeds ctx's deadline.
+func (p *ResourcePool[T]) Acquire(ctx context.Context, opts ...AcquireOption) (*PoolLease[T], error) {
+	o := NewOptions(acquireOptions{}, opts...)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	if len(p.waiters) == 0 {
+		if n := len(p.idle); n > 0 {
+			x := p.idle[n-1]
+			p.idle = p.idle[:n-1]
+			p.mu.Unlock()
+			return p.lease(x), nil
+		}
+		if p.max <= 0 || p.open < p.max {
+			p.open++
+			p.mu.Unlock()
+			return p.create(ctx)
+		}
+	}
+	w := &poolWaiter[T]{priority: o.priority, grant: make(chan poolGrant[T], 1), moved: make(chan struct{}, 1)}
+	i, _ := slices.BinarySearchFunc(p.waiters, w.priority, func(w *poolWaiter[T], prio int) int {
+		if w.priority >= prio {
+			return -1
+		}
+		return 1
+	})
+	p.waiters = slices.Insert(p.waiters, i, w)
+	p.movedLocked(i + 1)
+	pos := p.positionLocked(i)
+	p.mu.Unlock()
+
+	if deadline, ok := ctx.Deadline(); ok && pos.EstimatedWait > 0 && pos.EstimatedWait > time.Until(deadline) {
+		p.rejected.Add(1)
+		return nil, p.abandon(w, fmt.Errorf("%w: estimated wait %v exceeds deadline", ErrPoolSaturated, pos.EstimatedWait))
+	}
+	for {
+		if o.feedback != nil {
+			if err := o.feedback(pos); err != nil {
+				p.rejected.Add(1)
+				return nil, p.abandon(w, err)
+			}
+		}
+		select {
+		case g := <-w.grant:
+			return p.granted(ctx, g)
+		case <-w.moved:
+			p.mu.Lock()
+			i := slices.Index(p.waiters, w)
+			if i >= 0 {
+				pos = p.positionLocked(i)
+			}
+			p.mu.Unlock()
+			if i < 0 {
+				// Served between the signal and the lock.
+				return p.granted(ctx, <-w.grant)
+			}
+		case <-ctx.Done():
+			return nil, p.abandon(w, ctx.Err())
+		}
+	}
+}
+
+func (p *ResourcePool[T]) granted(ctx context.Context, g poolGrant[T]) (*PoolLease[T], error) {
+	if g.create {
+		return p.create(ctx)
+	}
+	return p.lease(g.x), nil
+}
+
+// abandon removes w from the line. If w was served meanwhile, whatever it
+// was granted is passed on.
+func (p *ResourcePool[T]) abandon(w *poolWaiter[T], err error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if i := slices.Index(p.waiters, w); i >= 0 {
+		p.waiters = slices.Delete(p.waiters, i, i+1)
+		p.movedLocked(i)
+		return err
+	}
+	if g := <-w.grant; g.create {
+		p.freeSlotLocked()
+	} else {
+		p.putLocked(g.x)
+	}
+	return err
+}
+
+func (p *ResourcePool[T]) create(ctx context.Context) (*PoolLease[T], error) {
+	x, err := p.newFn(ctx)
+	if err != nil {
+		p.mu.Lock()
+		p.freeSlotLocked()
+		p.mu.Unlock()
+		return nil, err
+	}
+	return p.lease(x), nil
+}
+
+func (p *ResourcePool[T]) lease(x T) *PoolLease[T] {
+	p.acquired.Add(1)
+	return &PoolLease[T]{Value: x, pool: p, at: p.clock().Now()}
+}
+
+func (p *ResourcePool[T]) release(l *PoolLease[T], discard bool) {
+	held := p.clock().Now().Sub(l.at)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.avgHold == 0 {
+		p.avgHold = held
+	} else {
+		p.avgHold += (held - p.avgHold) / 5
+	}
+	if discard {
+		p.freeSlotLocked()
+		return
+	}
+	p.putLocked(l.Value)
+}
+
+// putLocked hands x to the first waiter or makes it idle.
+func (p *ResourcePool[T]) putLocked(x T) {
+	if len(p.waiters) == 0 {
+		p.idle = append(p.idle, x)
+		return
+	}
+	p.popWaiterLocked().grant <- poolGrant[T]{x: x}
+}
+
+// freeSlotLocked gives an unused slot to the first waiter, or closes it.
+func (p *ResourcePool[T]) freeSlotLocked() {
+	if len(p.waiters) == 0 {
+		p.open--
+		return
+	}
+	p.popWaiterLocked().grant <- poolGrant[T]{create: true}
+}
+
+func (p *ResourcePool[T]) popWaiterLocked() *poolWaiter[T] {
+	w := p.waiters[0]
+	p.waiters = slices.Delete(p.waiters, 0, 1)
+	p.movedLocked(0)
+	return w
+}
+
+// movedLocked tells waiters from index i on that their position changed.
+func (p *ResourcePool[T]) movedLocked(i int) {
+	for _, w := range p.waiters[i:] {
+		select {
+		case w.moved <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (p *ResourcePool[T]) positionLocked(i int) QueuePosition {
+	pos := QueuePosition{Position: i + 1, Waiting: len(p.waiters)}
+	if p.max > 0 {
+		rounds := (i + p.max) / p.max
+		pos.EstimatedWait = time.Duration(rounds) * p.avgHold
+	}
+	return pos
+}
+
+// Stats returns the pool's current size, line length and counters.
+func (p *ResourcePool[T]) Stats() ResourcePoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return ResourcePoolStats{
+		Max:      p.max,
+		Open:     p.open,
+		Idle:     len(p.idle),
+		Waiting:  len(p.waiters),
+		AvgHold:  p.avgHold,
+		Acquired: p.acquired.Load(),
+		Rejected: p.rejected.Load(),
+	}
+}
+
+// Inspect reports Stats for DebugHandler.
+func (p *ResourcePool[T]) Inspect() any {
+	return p.Stats()
+}
@@ -0,0 +1,146 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newCountingPool(max int) (*ResourcePool[int], *atomic.Int32) {
+	var made atomic.Int32
+	return NewResourcePool(max, func(context.Context) (int, error) {
+		return int(made.Add(1)), nil
+	}), &made
+}
+
+func TestResourcePool_ReusesResources(t *testing.T) {
+	p, made := newCountingPool(2)
+	ctx := context.Background()
+	a, err := p.Acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Release()
+	a.Release() // no effect
+	b, _ := p.Acquire(ctx)
+	if b.Value != a.Value || made.Load() != 1 {
+		t.Fatalf("got %d after %d creations, want reuse", b.Value, made.Load())
+	}
+	c, _ := p.Acquire(ctx)
+	if c.Value != 2 {
+		t.Fatalf("second lease = %d, want new resource", c.Value)
+	}
+	if s := p.Stats(); s.Open != 2 || s.Idle != 0 || s.Acquired != 3 {
+		t.Fatalf("stats = %+v", s)
+	}
+}
+
+func TestResourcePool_PriorityAndFeedback(t *testing.T) {
+	p, _ := newCountingPool(1)
+	ctx := context.Background()
+	held, _ := p.Acquire(ctx)
+
+	order := make(chan string, 2)
+	positions := make(chan QueuePosition, 10)
+	acquire := func(name string, opts ...AcquireOption) {
+		l, err := p.Acquire(ctx, opts...)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		order <- name
+		l.Release()
+	}
+	go acquire("low", WithQueueFeedback(func(q QueuePosition) error {
+		positions <- q
+		return nil
+	}))
+	if q := <-positions; q.Position != 1 || q.Waiting != 1 {
+		t.Fatalf("first position = %+v", q)
+	}
+	go acquire("high", WithAcquirePriority(10))
+	if q := <-positions; q.Position != 2 || q.Waiting != 2 {
+		t.Fatalf("position after high-priority arrival = %+v", q)
+	}
+	held.Release()
+	if first, second := <-order, <-order; first != "high" || second != "low" {
+		t.Fatalf("served %s then %s, want high then low", first, second)
+	}
+}
+
+func TestResourcePool_FeedbackAborts(t *testing.T) {
+	p, _ := newCountingPool(1)
+	held, _ := p.Acquire(context.Background())
+	errBusy := errors.New("busy")
+	_, err := p.Acquire(context.Background(), WithQueueFeedback(func(QueuePosition) error { return errBusy }))
+	if !errors.Is(err, errBusy) {
+		t.Fatalf("err = %v, want errBusy", err)
+	}
+	if s := p.Stats(); s.Waiting != 0 || s.Rejected != 1 {
+		t.Fatalf("stats = %+v", s)
+	}
+	held.Release()
+}
+
+func TestResourcePool_DeadlineAware(t *testing.T) {
+	clock := newTestClock()
+	p, _ := newCountingPool(1)
+	p.Clock = clock
+	l, _ := p.Acquire(context.Background())
+	clock.Advance(time.Minute)
+	l.Release()
+	held, _ := p.Acquire(context.Background())
+	defer held.Release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := p.Acquire(ctx); !errors.Is(err, ErrPoolSaturated) {
+		t.Fatalf("err = %v, want ErrPoolSaturated", err)
+	}
+}
+
+func TestResourcePool_DiscardFreesSlot(t *testing.T) {
+	p, made := newCountingPool(1)
+	ctx := context.Background()
+	held, _ := p.Acquire(ctx)
+	got := make(chan int)
+	go func() {
+		l, err := p.Acquire(ctx)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		got <- l.Value
+	}()
+	for p.Stats().Waiting == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	held.Discard()
+	if v := <-got; v != 2 || made.Load() != 2 {
+		t.Fatalf("waiter got %d, want a new resource", v)
+	}
+}
+
+func TestResourcePool_Cancel(t *testing.T) {
+	p, _ := newCountingPool(1)
+	held, _ := p.Acquire(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.Acquire(ctx)
+		done <- err
+	}()
+	for p.Stats().Waiting == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v", err)
+	}
+	held.Release()
+	if s := p.Stats(); s.Idle != 1 || s.Waiting != 0 {
+		t.Fatalf("stats = %+v", s)
+	}
+}
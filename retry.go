@@ -0,0 +1,172 @@
+package generic
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// RetryingConsumer delivers envelopes from a queue to a handler that can
+// fail. A failed envelope is put back on the queue after an exponential
+// backoff, with the attempt count and error recorded in it; once it has
+// failed MaxAttempts times it goes to DeadLetter instead.
+//
+// Envelopes waiting out their backoff are held in memory. When Run
+// returns they are put back on the queue early if it has room, and
+// otherwise dead-lettered. Envelopes whose deadline passes, in the queue or
+// waiting to be retried, are dead-lettered with ErrEnvelopeExpired instead
+// of delivered.
+type RetryingConsumer[T any] struct {
+	// MaxAttempts is how many failed deliveries an envelope gets before it
+	// is dead-lettered. Defaults to 5.
+	MaxAttempts int
+	// BaseDelay is the wait after the first failure; each further failure
+	// doubles it. Defaults to 100ms.
+	BaseDelay time.Duration
+	// MaxDelay caps the wait. Defaults to one minute.
+	MaxDelay time.Duration
+	// DeadLetter receives envelopes that are out of attempts. If nil they
+	// are logged and dropped.
+	DeadLetter Queue[Envelope[T]]
+	// Carrier, if set, restores each envelope's trace context before fn
+	// is called.
+	Carrier Carrier
+	// Clock times the backoff. Defaults to SystemClock.
+	Clock Clock
+	// Logger records dropped envelopes and failed re-enqueues. Defaults to
+	// DefaultLogger.
+	Logger Logger
+
+	q  Queue[Envelope[T]]
+	fn func(ctx context.Context, x T) error
+}
+
+func NewRetryingConsumer[T any](q Queue[Envelope[T]], fn func(ctx context.Context, x T) error) *RetryingConsumer[T] {
+	return &RetryingConsumer[T]{q: q, fn: fn}
+}
+
+func (r *RetryingConsumer[T]) maxAttempts() int {
+	if r.MaxAttempts > 0 {
+		return r.MaxAttempts
+	}
+	return 5
+}
+
+// backoff returns the wait before the given attempt is retried.
+func (r *RetryingConsumer[T]) backoff(attempts int) time.Duration {
+	d, limit := r.BaseDelay, r.MaxDelay
+	if d <= 0 {
+		d = 100 * time.Millisecond
+	}
+	if limit <= 0 {
+		limit = time.Minute
+	}
+	for range attempts - 1 {
+		if d >= limit/2 {
+			return limit
+		}
+		d *= 2
+	}
+	return min(d, limit)
+}
+
+// Run consumes the queue until ctx is done or Get fails, then returns that
+// error. Panics in fn count as failures. Before returning it hands off the
+// envelopes still waiting to be retried and waits for its re-enqueues.
+func (r *RetryingConsumer[T]) Run(ctx context.Context) error {
+	clock := r.Clock
+	if clock == nil {
+		clock = SystemClock
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	var (
+		puts     sync.WaitGroup
+		mu       sync.Mutex
+		leftover []Envelope[T]
+	)
+	wheel := NewTimerWheel(10*time.Millisecond, clock, func(e Envelope[T]) {
+		if r.q.TryPut(e) {
+			return
+		}
+		puts.Add(1)
+		go func() {
+			defer puts.Done()
+			if r.q.Put(ctx, e) != nil {
+				mu.Lock()
+				leftover = append(leftover, e)
+				mu.Unlock()
+			}
+		}()
+	})
+	wheelDone := make(chan struct{})
+	go func() {
+		defer close(wheelDone)
+		wheel.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-wheelDone
+		puts.Wait()
+		for _, e := range append(leftover, wheel.Drain()...) {
+			r.handOff(ctx, e)
+		}
+	}()
+
+	for {
+		e, err := r.q.Get(ctx)
+		if err != nil {
+			return err
+		}
+		if e.Expired(clock.Now()) {
+			e.LastError = ErrEnvelopeExpired.Error()
+			e.RetryAt = time.Time{}
+			r.deadLetter(ctx, e)
+			continue
+		}
+		hctx := ctx
+		if r.Carrier != nil {
+			hctx = e.Restore(ctx, r.Carrier)
+		}
+		hctx, hcancel := e.WithDeadline(hctx)
+		err = recoverError(func() error { return r.fn(hctx, e.Item) })
+		hcancel()
+		if err == nil {
+			continue
+		}
+		e.Attempts++
+		e.LastError = err.Error()
+		d := r.backoff(e.Attempts)
+		e.RetryAt = clock.Now().Add(d)
+		if e.Attempts >= r.maxAttempts() || e.Expired(e.RetryAt) {
+			e.RetryAt = time.Time{}
+			r.deadLetter(ctx, e)
+			continue
+		}
+		wheel.Schedule(d, e)
+	}
+}
+
+// handOff puts a pending retry back on the queue if it has room, and
+// otherwise dead-letters it without waiting.
+func (r *RetryingConsumer[T]) handOff(ctx context.Context, e Envelope[T]) {
+	if r.q.TryPut(e) {
+		return
+	}
+	e.RetryAt = time.Time{}
+	if r.DeadLetter != nil && r.DeadLetter.TryPut(e) {
+		return
+	}
+	loggerOrDefault(r.Logger).Error(ctx, "generic: dropping envelope pending retry", slog.Int("attempts", e.Attempts), slog.String("last_error", e.LastError))
+}
+
+func (r *RetryingConsumer[T]) deadLetter(ctx context.Context, e Envelope[T]) {
+	attrs := []slog.Attr{slog.Int("attempts", e.Attempts), slog.String("last_error", e.LastError)}
+	if r.DeadLetter == nil {
+		loggerOrDefault(r.Logger).Error(ctx, "generic: dropping envelope out of retries", attrs...)
+		return
+	}
+	if err := r.DeadLetter.Put(ctx, e); err != nil {
+		loggerOrDefault(r.Logger).Error(ctx, "generic: dead-letter failed", append(attrs, slog.Any("error", err))...)
+	}
+}
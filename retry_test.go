@@ -0,0 +1,153 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryingConsumer_RetriesThenSucceeds(t *testing.T) {
+	q := NewFiFo[Envelope[string]]()
+	var attempts []time.Time
+	done := make(chan struct{})
+	r := NewRetryingConsumer(q, func(_ context.Context, x string) error {
+		attempts = append(attempts, time.Now())
+		if len(attempts) < 3 {
+			return errors.New("not yet")
+		}
+		close(done)
+		return nil
+	})
+	r.BaseDelay = 20 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errc := make(chan error, 1)
+	go func() { errc <- r.Run(ctx) }()
+	q.Put(ctx, Envelope[string]{Item: "job"})
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("item never succeeded")
+	}
+	if gap := attempts[2].Sub(attempts[1]); gap < 40*time.Millisecond {
+		t.Fatalf("second retry after %v, want at least doubled backoff", gap)
+	}
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run = %v", err)
+	}
+}
+
+func TestRetryingConsumer_DeadLetter(t *testing.T) {
+	q := NewFiFo[Envelope[int]]()
+	dead := NewFiFo[Envelope[int]]()
+	r := NewRetryingConsumer(q, func(context.Context, int) error { return errors.New("boom") })
+	r.MaxAttempts = 3
+	r.BaseDelay = time.Millisecond
+	r.DeadLetter = dead
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Run(ctx)
+	q.Put(ctx, Envelope[int]{Item: 7})
+
+	wait, stop := context.WithTimeout(ctx, 2*time.Second)
+	defer stop()
+	e, err := dead.Get(wait)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Item != 7 || e.Attempts != 3 || e.LastError != "boom" || !e.RetryAt.IsZero() {
+		t.Fatalf("dead-lettered %+v", e)
+	}
+}
+
+func TestRetryingConsumer_Backoff(t *testing.T) {
+	r := &RetryingConsumer[int]{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 40: 5 * time.Second} {
+		if got := r.backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func TestRetryingConsumer_Expired(t *testing.T) {
+	q := NewFiFo[Envelope[int]]()
+	dead := NewFiFo[Envelope[int]]()
+	var calls int
+	r := NewRetryingConsumer(q, func(ctx context.Context, x int) error {
+		calls++
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("handler context has no deadline")
+		}
+		return errors.New("boom")
+	})
+	r.BaseDelay = time.Hour
+	r.DeadLetter = dead
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Run(ctx)
+
+	// Already expired: dead-lettered without a delivery.
+	q.Put(ctx, Envelope[int]{Item: 1, Deadline: time.Now().Add(-time.Second)})
+	// Its first retry would come after the deadline.
+	q.Put(ctx, Envelope[int]{Item: 2, Deadline: time.Now().Add(time.Minute)})
+
+	wait, stop := context.WithTimeout(ctx, 2*time.Second)
+	defer stop()
+	for _, want := range []struct {
+		item, attempts int
+		err            string
+	}{{1, 0, ErrEnvelopeExpired.Error()}, {2, 1, "boom"}} {
+		e, err := dead.Get(wait)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Item != want.item || e.Attempts != want.attempts || e.LastError != want.err {
+			t.Fatalf("dead-lettered %+v, want %+v", e, want)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+}
+
+func TestRetryingConsumer_HandsOffPendingRetries(t *testing.T) {
+	for _, closeQueue := range []bool{false, true} {
+		q := NewFiFo[Envelope[int]]()
+		dead := NewFiFo[Envelope[int]]()
+		failed := make(chan struct{}, 1)
+		r := NewRetryingConsumer(q, func(context.Context, int) error {
+			failed <- struct{}{}
+			return errors.New("boom")
+		})
+		r.BaseDelay = time.Hour
+		r.DeadLetter = dead
+		ctx, cancel := context.WithCancel(context.Background())
+		errc := make(chan error, 1)
+		go func() { errc <- r.Run(ctx) }()
+		q.Put(ctx, Envelope[int]{Item: 7})
+		<-failed
+		if closeQueue {
+			q.Close()
+		} else {
+			cancel()
+		}
+		<-errc
+		cancel()
+
+		// An open queue takes the retry back early; a closed one can't, so
+		// it is dead-lettered.
+		got, other := q, dead
+		if closeQueue {
+			got, other = dead, q
+		}
+		e, ok := got.TryGet()
+		if !ok || e.Item != 7 || e.Attempts != 1 || e.LastError != "boom" {
+			t.Fatalf("closed=%v: handed off %+v, %v", closeQueue, e, ok)
+		}
+		if other.Size() != 0 {
+			t.Fatalf("closed=%v: envelope handed off twice", closeQueue)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package generic
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Ring is a fixed-capacity Queue over a circular buffer allocated once by
+// NewRing, so Put and Get never allocate. When full, Put and TryPut follow
+// the ring's OverflowPolicy: OverflowBlock waits for room (TryPut fails),
+// OverflowDropOldest overwrites the oldest item and OverflowDropNewest
+// rejects the new one.
+type Ring[T any] struct {
+	mu      sync.Mutex
+	buf     []T
+	head    int
+	n       int
+	policy  OverflowPolicy
+	closed  bool
+	dropped atomic.Int64
+	notify  atomicNotifier // signalled on every Put, Get and Close
+}
+
+// NewRing returns a ring holding at most capacity items.
+func NewRing[T any](capacity int, policy OverflowPolicy) *Ring[T] {
+	if capacity < 1 {
+		panic("generic: Ring needs a positive capacity")
+	}
+	return &Ring[T]{buf: make([]T, capacity), policy: policy}
+}
+
+// tryPut applies the overflow policy to x, reporting whether it was added
+// and whether the caller must wait for room. The caller must hold r.mu.
+func (r *Ring[T]) tryPut(x T) (added, wait bool) {
+	if r.n == len(r.buf) {
+		switch r.policy {
+		case OverflowBlock:
+			return false, true
+		case OverflowDropNewest:
+			r.dropped.Add(1)
+			return false, false
+		}
+		r.dropped.Add(1)
+		r.buf[r.head] = x
+		r.head = (r.head + 1) % len(r.buf)
+		return true, false
+	}
+	r.buf[(r.head+r.n)%len(r.buf)] = x
+	r.n++
+	return true, false
+}
+
+// Put adds x, waiting for room if the ring is full and its policy is
+// OverflowBlock. It returns ErrClosed once the ring is closed; an item
+// rejected by OverflowDropNewest is counted in Dropped, not reported.
+func (r *Ring[T]) Put(ctx context.Context, x T) error {
+	// The notifier is only armed once the ring is found full, so the fast
+	// path doesn't allocate.
+	var changed <-chan struct{}
+	for {
+		r.mu.Lock()
+		if r.closed {
+			r.mu.Unlock()
+			return ErrClosed
+		}
+		added, wait := r.tryPut(x)
+		r.mu.Unlock()
+		if !wait {
+			if added {
+				r.notify.notify()
+			}
+			return nil
+		}
+		if changed == nil {
+			changed = r.notify.wait()
+			continue
+		}
+		select {
+		case <-changed:
+			changed = nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// TryPut adds x without blocking, reporting whether it was kept.
+func (r *Ring[T]) TryPut(x T) bool {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return false
+	}
+	added, _ := r.tryPut(x)
+	r.mu.Unlock()
+	if added {
+		r.notify.notify()
+	}
+	return added
+}
+
+// pop removes the oldest item. The caller must hold r.mu.
+func (r *Ring[T]) pop() (T, bool) {
+	var zero T
+	if r.n == 0 {
+		return zero, false
+	}
+	x := r.buf[r.head]
+	r.buf[r.head] = zero
+	r.head = (r.head + 1) % len(r.buf)
+	r.n--
+	return x, true
+}
+
+// Get removes the oldest item, blocking until one is available. On a
+// closed ring it returns the remaining items, then ErrClosed.
+func (r *Ring[T]) Get(ctx context.Context) (T, error) {
+	var changed <-chan struct{}
+	for {
+		r.mu.Lock()
+		x, ok := r.pop()
+		closed := r.closed
+		r.mu.Unlock()
+		if ok {
+			r.notify.notify()
+			return x, nil
+		}
+		if closed {
+			return x, ErrClosed
+		}
+		if changed == nil {
+			changed = r.notify.wait()
+			continue
+		}
+		select {
+		case <-changed:
+			changed = nil
+		case <-ctx.Done():
+			return x, ctx.Err()
+		}
+	}
+}
+
+// TryGet removes the oldest item without blocking.
+func (r *Ring[T]) TryGet() (T, bool) {
+	r.mu.Lock()
+	x, ok := r.pop()
+	r.mu.Unlock()
+	if ok {
+		r.notify.notify()
+	}
+	return x, ok
+}
+
+func (r *Ring[T]) Size() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.n
+}
+
+func (r *Ring[T]) IsEmpty() bool {
+	return r.Size() == 0
+}
+
+// Cap returns the ring's capacity.
+func (r *Ring[T]) Cap() int {
+	return len(r.buf)
+}
+
+// Dropped returns how many items were overwritten or rejected because the
+// ring was full.
+func (r *Ring[T]) Dropped() int64 {
+	return r.dropped.Load()
+}
+
+// Snapshot returns the items from oldest to newest.
+func (r *Ring[T]) Snapshot() []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.n == 0 {
+		return nil
+	}
+	items := make([]T, r.n)
+	for i := range items {
+		items[i] = r.buf[(r.head+i)%len(r.buf)]
+	}
+	return items
+}
+
+// Close stops the ring accepting items, with the same semantics as
+// FiFo.Close.
+func (r *Ring[T]) Close() error {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+	r.notify.notify()
+	return nil
+}
+
+// Inspect reports the ring state for DebugHandler.
+func (r *Ring[T]) Inspect() any {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return FiFoStats{Size: r.n, Empty: r.n == 0, Capacity: len(r.buf), Dropped: r.dropped.Load(), Closed: r.closed}
+}
+
+var _ Queue[int] = (*Ring[int])(nil)
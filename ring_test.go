@@ -0,0 +1,90 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRing_Policies(t *testing.T) {
+	over := NewRing[int](3, OverflowDropOldest)
+	for i := range 5 {
+		if !over.TryPut(i) {
+			t.Fatalf("TryPut(%d) failed on an overwriting ring", i)
+		}
+	}
+	if got := over.Snapshot(); !slices.Equal(got, []int{2, 3, 4}) || over.Dropped() != 2 {
+		t.Fatalf("overwrite contents = %v, dropped %d", got, over.Dropped())
+	}
+
+	reject := NewRing[int](2, OverflowDropNewest)
+	reject.TryPut(1)
+	reject.TryPut(2)
+	if reject.TryPut(3) {
+		t.Fatal("TryPut on a full rejecting ring succeeded")
+	}
+	if err := reject.Put(context.Background(), 3); err != nil {
+		t.Fatalf("Put on a full rejecting ring = %v", err)
+	}
+	if got := reject.Snapshot(); !slices.Equal(got, []int{1, 2}) || reject.Dropped() != 2 {
+		t.Fatalf("reject contents = %v, dropped %d", got, reject.Dropped())
+	}
+}
+
+func TestRing_BlockingPutAndGet(t *testing.T) {
+	ctx := context.Background()
+	r := NewRing[int](1, OverflowBlock)
+	r.Put(ctx, 1)
+	if r.TryPut(2) {
+		t.Fatal("TryPut on a full blocking ring succeeded")
+	}
+	done := make(chan error, 1)
+	go func() { done <- r.Put(ctx, 2) }()
+	time.Sleep(10 * time.Millisecond)
+	if x, _ := r.Get(ctx); x != 1 {
+		t.Fatalf("Get = %d", x)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("blocked Put = %v", err)
+	}
+
+	short, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	if err := r.Put(short, 3); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Put on full ring = %v", err)
+	}
+
+	r.Close()
+	if err := r.Put(ctx, 3); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Put after Close = %v", err)
+	}
+	if x, err := r.Get(ctx); err != nil || x != 2 {
+		t.Fatalf("Get = %d, %v", x, err)
+	}
+	if _, err := r.Get(ctx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Get on drained ring = %v", err)
+	}
+}
+
+func TestRing_NoAllocs(t *testing.T) {
+	ctx := context.Background()
+	r := NewRing[int](64, OverflowBlock)
+	allocs := testing.AllocsPerRun(1000, func() {
+		r.Put(ctx, 1)
+		r.Get(ctx)
+	})
+	if allocs != 0 {
+		t.Fatalf("Put/Get allocated %v times", allocs)
+	}
+}
+
+func BenchmarkRing_PutGet(b *testing.B) {
+	ctx := context.Background()
+	r := NewRing[int](1024, OverflowBlock)
+	for b.Loop() {
+		r.Put(ctx, 1)
+		r.Get(ctx)
+	}
+}
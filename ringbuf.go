@@ -0,0 +1,112 @@
+package generic
+
+// minRingCap is the smallest backing array a ringBuffer allocates.
+const minRingCap = 8
+
+// ringBuffer is an unsynchronized growable circular buffer. Its capacity is
+// always a power of two so indexes wrap with a mask. It shrinks by half when
+// it falls to a quarter full, but never below its initial capacity.
+type ringBuffer[T any] struct {
+	buf     []T
+	head    int
+	n       int
+	initCap int
+}
+
+func newRingBuffer[T any](capacity int) ringBuffer[T] {
+	c := minRingCap
+	for c < capacity {
+		c <<= 1
+	}
+	return ringBuffer[T]{buf: make([]T, c), initCap: c}
+}
+
+func (r *ringBuffer[T]) len() int { return r.n }
+
+func (r *ringBuffer[T]) push(x T) {
+	if r.n == len(r.buf) {
+		r.resize(max(2*len(r.buf), minRingCap))
+	}
+	r.buf[(r.head+r.n)&(len(r.buf)-1)] = x
+	r.n++
+}
+
+// pushFront inserts x before the head.
+func (r *ringBuffer[T]) pushFront(x T) {
+	if r.n == len(r.buf) {
+		r.resize(max(2*len(r.buf), minRingCap))
+	}
+	r.head = (r.head - 1) & (len(r.buf) - 1)
+	r.buf[r.head] = x
+	r.n++
+}
+
+// pop removes the head. The buffer must not be empty.
+func (r *ringBuffer[T]) pop() T {
+	var zero T
+	x := r.buf[r.head]
+	r.buf[r.head] = zero
+	r.head = (r.head + 1) & (len(r.buf) - 1)
+	r.n--
+	r.maybeShrink()
+	return x
+}
+
+// popBack removes the tail. The buffer must not be empty.
+func (r *ringBuffer[T]) popBack() T {
+	var zero T
+	i := (r.head + r.n - 1) & (len(r.buf) - 1)
+	x := r.buf[i]
+	r.buf[i] = zero
+	r.n--
+	r.maybeShrink()
+	return x
+}
+
+// at returns the i-th element from the head.
+func (r *ringBuffer[T]) at(i int) T {
+	return r.buf[(r.head+i)&(len(r.buf)-1)]
+}
+
+// set replaces the i-th element from the head.
+func (r *ringBuffer[T]) set(i int, x T) {
+	r.buf[(r.head+i)&(len(r.buf)-1)] = x
+}
+
+func (r *ringBuffer[T]) maybeShrink() {
+	if r.n == 0 {
+		r.head = 0
+	}
+	if len(r.buf) > r.initCap && len(r.buf) > minRingCap && r.n <= len(r.buf)/4 {
+		r.resize(len(r.buf) / 2)
+	}
+}
+
+func (r *ringBuffer[T]) resize(c int) {
+	buf := make([]T, c)
+	r.appendTo(buf[:0])
+	r.buf = buf
+	r.head = 0
+}
+
+// appendTo appends the elements in order to dst.
+func (r *ringBuffer[T]) appendTo(dst []T) []T {
+	if r.n == 0 {
+		return dst
+	}
+	end := r.head + r.n
+	if end <= len(r.buf) {
+		return append(dst, r.buf[r.head:end]...)
+	}
+	dst = append(dst, r.buf[r.head:]...)
+	return append(dst, r.buf[:end-len(r.buf)]...)
+}
+
+// reset empties the buffer, zeroing the elements it held.
+func (r *ringBuffer[T]) reset() {
+	clear(r.buf)
+	r.head, r.n = 0, 0
+	if len(r.buf) > r.initCap {
+		r.buf = make([]T, r.initCap)
+	}
+}
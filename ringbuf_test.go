@@ -0,0 +1,65 @@
+package generic
+
+import "testing"
+
+func TestRingBuffer_PushPop(t *testing.T) {
+	r := newRingBuffer[int](0)
+	for i := range 20 {
+		r.push(i)
+	}
+	if r.len() != 20 || len(r.buf) != 32 {
+		t.Fatalf("expected len 20 cap 32, got len %d cap %d", r.len(), len(r.buf))
+	}
+	for i := range 20 {
+		if x := r.pop(); x != i {
+			t.Fatalf("expected %d, got %d", i, x)
+		}
+	}
+	if len(r.buf) != minRingCap {
+		t.Errorf("expected ring to shrink back to %d, got %d", minRingCap, len(r.buf))
+	}
+}
+
+func TestRingBuffer_Deque(t *testing.T) {
+	r := newRingBuffer[int](4)
+	r.push(2)
+	r.pushFront(1)
+	r.push(3)
+	r.pushFront(0)
+	if got := r.appendTo(nil); len(got) != 4 || got[0] != 0 || got[3] != 3 {
+		t.Fatalf("unexpected contents %v", got)
+	}
+	if x := r.popBack(); x != 3 {
+		t.Fatalf("expected 3 from back, got %d", x)
+	}
+	if x := r.at(1); x != 1 {
+		t.Fatalf("expected 1 at index 1, got %d", x)
+	}
+}
+
+func TestRingBuffer_KeepsInitialCapacity(t *testing.T) {
+	r := newRingBuffer[int](100)
+	if len(r.buf) != 128 {
+		t.Fatalf("expected capacity rounded to 128, got %d", len(r.buf))
+	}
+	for i := range 300 {
+		r.push(i)
+	}
+	for range 300 {
+		r.pop()
+	}
+	if len(r.buf) != 128 {
+		t.Errorf("expected shrink to stop at initial capacity, got %d", len(r.buf))
+	}
+}
+
+func TestRingBuffer_ResetZeroes(t *testing.T) {
+	r := newRingBuffer[*int](0)
+	x := 1
+	r.push(&x)
+	buf := r.buf
+	r.reset()
+	if r.len() != 0 || buf[0] != nil {
+		t.Fatal("expected reset to empty and zero the buffer")
+	}
+}
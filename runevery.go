@@ -0,0 +1,106 @@
+package generic
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+)
+
+// RunEveryOption configures RunEvery and RunCron.
+type RunEveryOption = Option[runEveryOptions]
+
+type runEveryOptions struct {
+	clock      Clock
+	jitter     float64
+	immediate  bool
+	maxBackoff time.Duration
+	onError    func(error)
+	logger     Logger
+}
+
+// WithJitter randomizes each wait by up to ±fraction of the interval, so
+// many instances started together don't fire in lockstep.
+func WithJitter(fraction float64) RunEveryOption {
+	return func(o *runEveryOptions) { o.jitter = min(max(fraction, 0), 1) }
+}
+
+// WithImmediate runs fn once as soon as RunEvery is called instead of
+// waiting one interval first.
+func WithImmediate() RunEveryOption {
+	return func(o *runEveryOptions) { o.immediate = true }
+}
+
+// WithErrorBackoff doubles the wait after each consecutive failure, up to
+// max, returning to the normal interval after a success.
+func WithErrorBackoff(max time.Duration) RunEveryOption {
+	return func(o *runEveryOptions) { o.maxBackoff = max }
+}
+
+// WithOnError receives every error returned by fn, including recovered
+// panics as *PanicError, instead of the logger.
+func WithOnError(fn func(error)) RunEveryOption {
+	return func(o *runEveryOptions) { o.onError = fn }
+}
+
+// WithLogger sets where errors are logged when there is no WithOnError
+// callback. Defaults to DefaultLogger.
+func WithLogger(l Logger) RunEveryOption {
+	return func(o *runEveryOptions) { o.logger = l }
+}
+
+// report hands a failed run to the error callback, or else logs it.
+func (o *runEveryOptions) report(ctx context.Context, err error) {
+	if o.onError != nil {
+		o.onError(err)
+		return
+	}
+	loggerOrDefault(o.logger).Error(ctx, "generic: scheduled run failed", slog.Any("error", err))
+}
+
+// WithRunClock sets the clock used for waiting. Defaults to SystemClock.
+func WithRunClock(c Clock) RunEveryOption {
+	return func(o *runEveryOptions) { o.clock = c }
+}
+
+// RunEvery calls fn every interval until ctx is done, then returns
+// ctx.Err(). Runs never overlap: the next wait starts when fn returns, so a
+// slow run delays the schedule instead of piling up. Panics in fn are
+// recovered and treated as errors; errors don't stop the loop.
+func RunEvery(ctx context.Context, interval time.Duration, fn func(ctx context.Context) error, opts ...RunEveryOption) error {
+	o := NewOptions(runEveryOptions{clock: SystemClock}, opts...)
+	failures := 0
+	first := true
+	for {
+		if !first || !o.immediate {
+			wait := interval
+			if o.maxBackoff > 0 {
+				for range failures {
+					if wait >= o.maxBackoff/2 {
+						wait = max(o.maxBackoff, interval)
+						break
+					}
+					wait *= 2
+				}
+			}
+			if o.jitter > 0 {
+				wait += time.Duration((rand.Float64()*2 - 1) * o.jitter * float64(wait))
+			}
+			select {
+			case <-o.clock.After(wait):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		first = false
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := recoverError(func() error { return fn(ctx) }); err != nil {
+			failures++
+			o.report(ctx, err)
+		} else {
+			failures = 0
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// advanceWhenParked advances clock by d once RunEvery is waiting on it.
+func advanceWhenParked(t *testing.T, clock *testClock, d time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for clock.Waiters() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("RunEvery never waited on the clock")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(d)
+}
+
+func TestRunEvery_RunsOnInterval(t *testing.T) {
+	clock := newTestClock()
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := make(chan struct{}, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- RunEvery(ctx, time.Second, func(context.Context) error {
+			runs <- struct{}{}
+			return nil
+		}, WithRunClock(clock))
+	}()
+
+	select {
+	case <-runs:
+		t.Fatal("ran before the first interval without WithImmediate")
+	case <-time.After(10 * time.Millisecond):
+	}
+	for range 3 {
+		advanceWhenParked(t, clock, time.Second)
+		<-runs
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestRunEvery_Immediate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ran := make(chan struct{}, 1)
+	go RunEvery(ctx, time.Hour, func(context.Context) error {
+		ran <- struct{}{}
+		return nil
+	}, WithImmediate())
+
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("expected an immediate first run")
+	}
+}
+
+func TestRunEvery_BackoffAndPanicRecovery(t *testing.T) {
+	clock := newTestClock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls atomic.Int32
+	errs := make(chan error, 10)
+	go RunEvery(ctx, time.Second, func(context.Context) error {
+		switch calls.Add(1) {
+		case 1:
+			return errors.New("first")
+		case 2:
+			panic("second")
+		}
+		return nil
+	}, WithRunClock(clock), WithImmediate(), WithErrorBackoff(3*time.Second), WithOnError(func(err error) { errs <- err }))
+
+	if err := <-errs; err.Error() != "first" {
+		t.Fatalf("expected first error, got %v", err)
+	}
+	// One failure doubles the wait to 2s.
+	advanceWhenParked(t, clock, time.Second)
+	if calls.Load() != 1 {
+		t.Fatal("expected backoff to delay the second run")
+	}
+	clock.Advance(time.Second)
+
+	var pe *PanicError
+	if err := <-errs; !errors.As(err, &pe) || pe.Value != "second" {
+		t.Fatalf("expected recovered panic, got %v", err)
+	}
+	// Two failures would be 4s, capped at 3s.
+	advanceWhenParked(t, clock, 3*time.Second)
+	deadline := time.Now().Add(time.Second)
+	for calls.Load() != 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected third run after capped backoff, calls=%d", calls.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRunEvery_NoOverlap(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var running, overlaps atomic.Int32
+	var runs atomic.Int32
+	go RunEvery(ctx, time.Millisecond, func(context.Context) error {
+		if running.Add(1) > 1 {
+			overlaps.Add(1)
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+		runs.Add(1)
+		return nil
+	}, WithJitter(0.5))
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	if overlaps.Load() != 0 {
+		t.Fatalf("runs overlapped %d times", overlaps.Load())
+	}
+	if runs.Load() == 0 {
+		t.Fatal("expected at least one run")
+	}
+}
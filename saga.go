@@ -0,0 +1,128 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// SagaStep is one step of a Saga. Do advances the state; Compensate undoes
+// a completed Do and may be nil for steps with nothing to undo. Both should
+// be idempotent, since a resumed saga repeats the step it crashed in.
+type SagaStep[S any] struct {
+	Name       string
+	Do         func(ctx context.Context, state S) (S, error)
+	Compensate func(ctx context.Context, state S) error
+}
+
+// SagaState is the progress record a Saga persists before each step.
+type SagaState[S any] struct {
+	// Next is the step to run next, or while compensating, the next step
+	// to compensate.
+	Next         int    `json:"next"`
+	Compensating bool   `json:"compensating,omitempty"`
+	Err          string `json:"err,omitempty"`
+	Data         S      `json:"data"`
+}
+
+// Saga runs Steps in order. If a step fails, the steps already completed
+// are compensated in reverse order and Run returns the step's error joined
+// with any compensation errors. Compensation ignores cancellation of the
+// caller's context, since abandoning it half way is worse than finishing.
+//
+// When Save is set, the saga encodes its SagaState with Codec (JSON by
+// default) and passes it to Save before every step and compensation, and
+// once more when it finishes. Resume continues from a saved record after a
+// crash.
+type Saga[S any] struct {
+	Steps []SagaStep[S]
+	Save  func(ctx context.Context, record []byte) error
+	Codec Codec[SagaState[S]]
+}
+
+// Run executes the saga from the first step and returns the final state.
+func (s *Saga[S]) Run(ctx context.Context, state S) (S, error) {
+	return s.run(ctx, SagaState[S]{Data: state})
+}
+
+// Resume continues a saga from a record passed to Save. A saga that was
+// compensating reports the original failure as a plain error message.
+func (s *Saga[S]) Resume(ctx context.Context, record []byte) (S, error) {
+	st, err := s.codec().Unmarshal(record)
+	if err != nil {
+		var zero S
+		return zero, err
+	}
+	lo, hi := 0, len(s.Steps)
+	if st.Compensating {
+		lo, hi = -1, len(s.Steps)-1
+	}
+	if st.Next < lo || st.Next > hi {
+		return st.Data, fmt.Errorf("saga: record step %d out of range", st.Next)
+	}
+	return s.run(ctx, st)
+}
+
+func (s *Saga[S]) codec() Codec[SagaState[S]] {
+	if s.Codec != nil {
+		return s.Codec
+	}
+	return JSONCodec[SagaState[S]]{}
+}
+
+func (s *Saga[S]) save(ctx context.Context, st SagaState[S]) error {
+	if s.Save == nil {
+		return nil
+	}
+	b, err := s.codec().Marshal(st)
+	if err != nil {
+		return err
+	}
+	return s.Save(ctx, b)
+}
+
+func (s *Saga[S]) run(ctx context.Context, st SagaState[S]) (S, error) {
+	var failure error
+	if st.Compensating {
+		failure = errors.New(st.Err)
+	}
+	for !st.Compensating && st.Next < len(s.Steps) {
+		step := s.Steps[st.Next]
+		err := s.save(ctx, st)
+		if err == nil {
+			var data S
+			data, err = step.Do(ctx, st.Data)
+			if err == nil {
+				st.Data = data
+				st.Next++
+				continue
+			}
+		}
+		failure = fmt.Errorf("saga step %q: %w", step.Name, err)
+		st.Compensating = true
+		st.Err = failure.Error()
+		st.Next--
+	}
+	if !st.Compensating {
+		return st.Data, s.save(ctx, st)
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	errs := []error{failure}
+	for ; st.Next >= 0; st.Next-- {
+		step := s.Steps[st.Next]
+		if step.Compensate == nil {
+			continue
+		}
+		if err := s.save(ctx, st); err != nil {
+			errs = append(errs, err)
+		}
+		if err := step.Compensate(ctx, st.Data); err != nil {
+			errs = append(errs, fmt.Errorf("saga compensate %q: %w", step.Name, err))
+		}
+	}
+	if err := s.save(ctx, st); err != nil {
+		errs = append(errs, err)
+	}
+	return st.Data, errors.Join(errs...)
+}
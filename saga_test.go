@@ -0,0 +1,127 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+type sagaOrder struct {
+	Reserved bool
+	Charged  bool
+	Log      []string
+}
+
+func orderSaga(failAt string, log *[]string) *Saga[sagaOrder] {
+	step := func(name string) SagaStep[sagaOrder] {
+		return SagaStep[sagaOrder]{
+			Name: name,
+			Do: func(ctx context.Context, o sagaOrder) (sagaOrder, error) {
+				*log = append(*log, "do "+name)
+				if name == failAt {
+					return o, errors.New(name + " failed")
+				}
+				o.Log = append(o.Log, name)
+				return o, nil
+			},
+			Compensate: func(ctx context.Context, o sagaOrder) error {
+				*log = append(*log, "undo "+name)
+				return nil
+			},
+		}
+	}
+	return &Saga[sagaOrder]{Steps: []SagaStep[sagaOrder]{step("reserve"), step("charge"), step("ship")}}
+}
+
+func TestSaga_Success(t *testing.T) {
+	var log []string
+	got, err := orderSaga("", &log).Run(context.Background(), sagaOrder{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(got.Log, []string{"reserve", "charge", "ship"}) {
+		t.Fatalf("unexpected state %v", got.Log)
+	}
+}
+
+func TestSaga_CompensatesCompletedSteps(t *testing.T) {
+	var log []string
+	saga := orderSaga("ship", &log)
+	saga.Steps[0].Compensate = nil // nothing to undo
+	_, err := saga.Run(context.Background(), sagaOrder{})
+	if err == nil || err.Error() != `saga step "ship": ship failed` {
+		t.Fatalf("unexpected error %v", err)
+	}
+	want := []string{"do reserve", "do charge", "do ship", "undo charge"}
+	if !slices.Equal(log, want) {
+		t.Fatalf("expected %v, got %v", want, log)
+	}
+}
+
+func TestSaga_CompensationErrors(t *testing.T) {
+	var log []string
+	saga := orderSaga("ship", &log)
+	undoErr := errors.New("refund failed")
+	saga.Steps[1].Compensate = func(context.Context, sagaOrder) error { return undoErr }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	saga.Steps[2].Do = func(context.Context, sagaOrder) (sagaOrder, error) {
+		cancel() // compensation must still run
+		return sagaOrder{}, context.Canceled
+	}
+	_, err := saga.Run(ctx, sagaOrder{})
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, undoErr) {
+		t.Fatalf("expected step and compensation errors, got %v", err)
+	}
+	if !slices.Contains(log, "undo reserve") {
+		t.Fatalf("expected remaining steps to be compensated, got %v", log)
+	}
+}
+
+func TestSaga_Resume(t *testing.T) {
+	var records [][]byte
+	save := func(ctx context.Context, b []byte) error {
+		records = append(records, b)
+		return nil
+	}
+
+	// Crash inside "charge": the last record says it was about to run.
+	var log []string
+	saga := orderSaga("", &log)
+	saga.Save = save
+	crash := errors.New("crash")
+	saga.Steps[1].Do = func(context.Context, sagaOrder) (sagaOrder, error) { panic(crash) }
+	func() {
+		defer func() { recover() }()
+		saga.Run(context.Background(), sagaOrder{})
+	}()
+	last := records[len(records)-1]
+
+	log = nil
+	got, err := orderSaga("", &log).Resume(context.Background(), last)
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if !slices.Equal(log, []string{"do charge", "do ship"}) {
+		t.Fatalf("expected resume from charge, got %v", log)
+	}
+	if !slices.Equal(got.Log, []string{"reserve", "charge", "ship"}) {
+		t.Fatalf("unexpected state %v", got.Log)
+	}
+
+	// Resume a saga that crashed while compensating.
+	rec, _ := JSONCodec[SagaState[sagaOrder]]{}.Marshal(SagaState[sagaOrder]{Next: 1, Compensating: true, Err: "boom"})
+	log = nil
+	if _, err := orderSaga("", &log).Resume(context.Background(), rec); err == nil || err.Error() != "boom" {
+		t.Fatalf("expected original failure, got %v", err)
+	}
+	if !slices.Equal(log, []string{"undo charge", "undo reserve"}) {
+		t.Fatalf("expected compensation to continue, got %v", log)
+	}
+
+	rec, _ = JSONCodec[SagaState[sagaOrder]]{}.Marshal(SagaState[sagaOrder]{Next: 9})
+	if _, err := orderSaga("", &log).Resume(context.Background(), rec); err == nil {
+		t.Fatal("expected out of range error")
+	}
+}
@@ -0,0 +1,84 @@
+package generic
+
+import "context"
+
+// Scope is one level in a stack of T values carried in a context, such as
+// request settings overridden by a handler and again by one of its
+// sub-calls. Reads see the innermost scope; pushing a scope never changes
+// the ones below it, so a caller's context keeps its own value. Scopes are
+// keyed by T, so define a type per kind of value (type Settings struct) to
+// carry several at once.
+//
+// Values are copied shallowly: an override that changes a map or slice
+// held in T must replace it rather than modify it in place.
+type Scope[T any] struct {
+	parent *Scope[T]
+	value  T
+	depth  int
+}
+
+type scopeKey[T any] struct{}
+
+// Value returns the scope's value.
+func (s *Scope[T]) Value() T {
+	return s.value
+}
+
+// Parent returns the enclosing scope, or nil for the outermost one.
+func (s *Scope[T]) Parent() *Scope[T] {
+	return s.parent
+}
+
+// Depth returns the number of enclosing scopes.
+func (s *Scope[T]) Depth() int {
+	return s.depth
+}
+
+// ScopeFromContext returns the innermost scope of T in ctx, or nil.
+func ScopeFromContext[T any](ctx context.Context) *Scope[T] {
+	s, _ := ctx.Value(scopeKey[T]{}).(*Scope[T])
+	return s
+}
+
+// ScopeValue returns the value of the innermost scope of T in ctx, and
+// false if there is none.
+func ScopeValue[T any](ctx context.Context) (T, bool) {
+	if s := ScopeFromContext[T](ctx); s != nil {
+		return s.value, true
+	}
+	var zero T
+	return zero, false
+}
+
+// PushScope returns a context whose innermost scope of T holds v.
+func PushScope[T any](ctx context.Context, v T) context.Context {
+	parent := ScopeFromContext[T](ctx)
+	s := &Scope[T]{parent: parent, value: v}
+	if parent != nil {
+		s.depth = parent.depth + 1
+	}
+	return context.WithValue(ctx, scopeKey[T]{}, s)
+}
+
+// OverrideScope pushes a scope holding a copy of the innermost value of T,
+// or the zero T, with fn applied, so only the fields fn sets are
+// overridden.
+func OverrideScope[T any](ctx context.Context, fn func(v *T)) context.Context {
+	v, _ := ScopeValue[T](ctx)
+	fn(&v)
+	return PushScope(ctx, v)
+}
+
+// PopScope returns a context in which the scope enclosing the innermost
+// scope of T is innermost again. Popping the outermost scope leaves none;
+// popping a context without a scope of T returns it unchanged.
+func PopScope[T any](ctx context.Context) context.Context {
+	s := ScopeFromContext[T](ctx)
+	if s == nil {
+		return ctx
+	}
+	if s.parent == nil {
+		return context.WithValue(ctx, scopeKey[T]{}, (*Scope[T])(nil))
+	}
+	return context.WithValue(ctx, scopeKey[T]{}, s.parent)
+}
@@ -0,0 +1,69 @@
+package generic
+
+import (
+	"context"
+	"maps"
+	"testing"
+	"time"
+)
+
+type scopeSettings struct {
+	Timeout time.Duration
+	Tags    map[string]string
+}
+
+func TestScope_OverrideAndPop(t *testing.T) {
+	req := PushScope(context.Background(), scopeSettings{
+		Timeout: time.Second,
+		Tags:    map[string]string{"route": "/jobs"},
+	})
+	handler := OverrideScope(req, func(s *scopeSettings) {
+		s.Tags = maps.Clone(s.Tags)
+		s.Tags["handler"] = "list"
+	})
+	sub := OverrideScope(handler, func(s *scopeSettings) { s.Timeout = 100 * time.Millisecond })
+
+	got, ok := ScopeValue[scopeSettings](sub)
+	if !ok || got.Timeout != 100*time.Millisecond || got.Tags["handler"] != "list" || got.Tags["route"] != "/jobs" {
+		t.Fatalf("sub-call settings = %+v", got)
+	}
+	if s := ScopeFromContext[scopeSettings](sub); s.Depth() != 2 || s.Parent().Parent().Parent() != nil {
+		t.Fatalf("depth = %d", s.Depth())
+	}
+
+	// Parents are untouched.
+	if got, _ := ScopeValue[scopeSettings](handler); got.Timeout != time.Second {
+		t.Fatalf("handler timeout = %v", got.Timeout)
+	}
+	if got, _ := ScopeValue[scopeSettings](req); len(got.Tags) != 1 {
+		t.Fatalf("request tags = %v", got.Tags)
+	}
+
+	popped := PopScope[scopeSettings](sub)
+	if got, _ := ScopeValue[scopeSettings](popped); got.Timeout != time.Second || got.Tags["handler"] != "list" {
+		t.Fatalf("after pop = %+v", got)
+	}
+	empty := PopScope[scopeSettings](PopScope[scopeSettings](popped))
+	if _, ok := ScopeValue[scopeSettings](empty); ok {
+		t.Fatal("scope left after popping the outermost one")
+	}
+	if PopScope[scopeSettings](empty) != empty {
+		t.Fatal("popping an empty scope changed the context")
+	}
+}
+
+func TestScope_KeyedByType(t *testing.T) {
+	type a int
+	type b int
+	ctx := PushScope(PushScope(context.Background(), a(1)), b(2))
+	ctx = OverrideScope(ctx, func(v *a) { *v++ })
+	if v, _ := ScopeValue[a](ctx); v != 2 {
+		t.Fatalf("a = %d", v)
+	}
+	if v, _ := ScopeValue[b](ctx); v != 2 {
+		t.Fatalf("b = %d", v)
+	}
+	if v, ok := ScopeValue[string](OverrideScope(ctx, func(s *string) { *s += "x" })); !ok || v != "x" {
+		t.Fatalf("override without a scope = %q, %v", v, ok)
+	}
+}
@@ -0,0 +1,252 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+)
+
+var ErrResharded = errors.New("queue was resharded")
+
+// ShardedQueue spreads items across several FiFo shards chosen by a key, so
+// producers and consumers of different keys don't contend on one token.
+// Items with the same key always land in the same shard and keep their
+// relative order, except across a Reshard.
+//
+// Shards are chosen with jump consistent hashing, or with a HashRing given
+// WithHashRing, so Reshard moves only about the minimum number of keys.
+type ShardedQueue[T any] struct {
+	key  func(T) uint64
+	ring *HashRing[uint64, int] // nodes are shard indices; nil for jump hashing
+
+	mu        sync.RWMutex // write-locked while resharding
+	set       *shardSet[T]
+	consumers consumerSet
+}
+
+type shardSet[T any] struct {
+	shards []*FiFo[T]
+	// retired is cancelled when this set is replaced so blocked consumers
+	// can move on to the new shards.
+	retired context.Context
+	retire  context.CancelFunc
+}
+
+func newShardSet[T any](n int) *shardSet[T] {
+	set := &shardSet[T]{shards: make([]*FiFo[T], n)}
+	set.retired, set.retire = context.WithCancel(context.Background())
+	for i := range set.shards {
+		set.shards[i] = NewFiFo[T]()
+	}
+	return set
+}
+
+// ShardedQueueOption configures NewShardedQueue.
+type ShardedQueueOption = Option[shardedQueueOptions]
+
+type shardedQueueOptions struct {
+	virtualNodes int
+}
+
+// WithHashRing routes items with a HashRing placing each shard at
+// virtualNodes points, instead of jump hashing. Reshard adds or removes the
+// highest-numbered shards on the ring.
+func WithHashRing(virtualNodes int) ShardedQueueOption {
+	return func(o *shardedQueueOptions) { o.virtualNodes = max(virtualNodes, 1) }
+}
+
+// NewShardedQueue returns a queue with n shards, routing each item by the hash
+// returned from key. Use hash/maphash or a similar function to turn string or
+// struct keys into a uint64.
+func NewShardedQueue[T any](n int, key func(T) uint64, opts ...ShardedQueueOption) *ShardedQueue[T] {
+	if n < 1 {
+		panic("generic: ShardedQueue needs at least one shard")
+	}
+	o := NewOptions(shardedQueueOptions{}, opts...)
+	q := &ShardedQueue[T]{key: key, set: newShardSet[T](n)}
+	if o.virtualNodes > 0 {
+		q.ring = NewHashRing[uint64, int](o.virtualNodes)
+		for i := range n {
+			q.ring.AddNode(i)
+		}
+	}
+	return q
+}
+
+// route returns the shard of n that x belongs in.
+func (q *ShardedQueue[T]) route(x T, n int) int {
+	if q.ring == nil {
+		return jumpHash(q.key(x), n)
+	}
+	shard, _ := q.ring.Locate(q.key(x))
+	return shard
+}
+
+// jumpHash maps key to a bucket in [0, n) (Lamping & Veach, 2014).
+func jumpHash(key uint64, n int) int {
+	var b, j int64 = -1, 0
+	for j < int64(n) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+
+func (q *ShardedQueue[T]) current() *shardSet[T] {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.set
+}
+
+// Shards returns the current number of shards.
+func (q *ShardedQueue[T]) Shards() int {
+	return len(q.current().shards)
+}
+
+// ShardFor returns the shard index x is routed to.
+func (q *ShardedQueue[T]) ShardFor(x T) int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.route(x, len(q.set.shards))
+}
+
+// Put appends x to the shard selected by its key.
+func (q *ShardedQueue[T]) Put(ctx context.Context, x T) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.set.shards[q.route(x, len(q.set.shards))].Put(ctx, x)
+}
+
+// TryPut appends x to its shard without blocking.
+func (q *ShardedQueue[T]) TryPut(x T) bool {
+	if !q.mu.TryRLock() {
+		return false
+	}
+	defer q.mu.RUnlock()
+	return q.set.shards[q.route(x, len(q.set.shards))].TryPut(x)
+}
+
+// Get removes the next item from the given shard. If the queue is resharded
+// while waiting, or shard is no longer valid, it returns ErrResharded and
+// the caller should consult Shards again.
+func (q *ShardedQueue[T]) Get(ctx context.Context, shard int) (T, error) {
+	var zero T
+	set := q.current()
+	if shard < 0 || shard >= len(set.shards) {
+		return zero, ErrResharded
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer context.AfterFunc(set.retired, cancel)()
+	x, err := set.shards[shard].Get(ctx)
+	if err != nil && set.retired.Err() != nil {
+		return zero, ErrResharded
+	}
+	return x, err
+}
+
+// Size returns the total number of queued items across all shards.
+func (q *ShardedQueue[T]) Size() int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	n := 0
+	for _, s := range q.set.shards {
+		n += s.Size()
+	}
+	return n
+}
+
+// Consume runs one consumer goroutine per shard, calling fn for every item,
+// until ctx is done. Consumers follow the queue across Reshard. fn is
+// called concurrently for items of different shards and sequentially within
+// a shard. Each consumer is named after its shard index in ConsumerStats.
+func (q *ShardedQueue[T]) Consume(ctx context.Context, fn func(ctx context.Context, x T)) error {
+	for {
+		set := q.current()
+		var wg sync.WaitGroup
+		for i := range set.shards {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c := q.consumers.start(strconv.Itoa(i), SystemClock)
+				defer q.consumers.stop(c)
+				for {
+					x, err := q.Get(ctx, i)
+					if err != nil {
+						return
+					}
+					c.handle(ctx, func(ctx context.Context) { fn(ctx, x) })
+				}
+			}()
+		}
+		wg.Wait()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// ConsumerStats reports on the consumers started by Consume. Counters
+// start over after a Reshard.
+func (q *ShardedQueue[T]) ConsumerStats() []ConsumerStats {
+	return q.consumers.stats()
+}
+
+// ShardedQueueStats is the debug view of a ShardedQueue returned by
+// Inspect.
+type ShardedQueueStats struct {
+	Shards    int             `json:"shards"`
+	Size      int             `json:"size"`
+	Consumers []ConsumerStats `json:"consumers,omitempty"`
+}
+
+// Inspect reports the shard count, size and consumers for DebugHandler.
+func (q *ShardedQueue[T]) Inspect() any {
+	return ShardedQueueStats{Shards: q.Shards(), Size: q.Size(), Consumers: q.ConsumerStats()}
+}
+
+// Reshard changes the number of shards to n while the queue is in use. Puts
+// are paused, every queued item is drained from the old shards and
+// redistributed by key, and consumers blocked on old shards are released with
+// ErrResharded. Relative order within each key is kept for drained items.
+func (q *ShardedQueue[T]) Reshard(ctx context.Context, n int) error {
+	if n < 1 {
+		panic("generic: ShardedQueue needs at least one shard")
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	old := q.set
+	next := newShardSet[T](n)
+	q.set = next
+	old.retire()
+	if q.ring != nil {
+		for i := len(old.shards); i < n; i++ {
+			q.ring.AddNode(i)
+		}
+		for i := n; i < len(old.shards); i++ {
+			q.ring.RemoveNode(i)
+		}
+	}
+	for _, s := range old.shards {
+		// Late consumers may still take items from s; whatever remains is
+		// moved in queue order, which preserves key order.
+		for {
+			x, ok := s.TryGet()
+			if !ok {
+				if s.Size() == 0 {
+					break
+				}
+				continue
+			}
+			if err := next.shards[q.route(x, n)].Put(context.Background(), x); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package generic
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type shardedItem struct {
+	key uint64
+	seq int
+}
+
+func shardedKey(x shardedItem) uint64 { return x.key }
+
+func TestJumpHash_Stable(t *testing.T) {
+	moved := 0
+	for k := uint64(0); k < 10000; k++ {
+		a, b := jumpHash(k, 10), jumpHash(k, 11)
+		if a < 0 || a >= 10 || b < 0 || b >= 11 {
+			t.Fatalf("bucket out of range: %d, %d", a, b)
+		}
+		if a != b {
+			if b != 10 {
+				t.Fatalf("key %d moved between existing buckets %d -> %d", k, a, b)
+			}
+			moved++
+		}
+	}
+	// Roughly 1/11 of keys should move to the new bucket.
+	if moved < 600 || moved > 1200 {
+		t.Errorf("expected ~909 keys to move, got %d", moved)
+	}
+}
+
+func TestShardedQueue_RoutesByKey(t *testing.T) {
+	q := NewShardedQueue(4, shardedKey)
+	ctx := context.Background()
+
+	for i := range 100 {
+		if err := q.Put(ctx, shardedItem{key: uint64(i % 10), seq: i}); err != nil {
+			t.Fatalf("put failed: %v", err)
+		}
+	}
+	if size := q.Size(); size != 100 {
+		t.Fatalf("expected size 100, got %d", size)
+	}
+
+	lastSeq := map[uint64]int{}
+	for shard := range q.Shards() {
+		for {
+			x, ok := q.current().shards[shard].TryGet()
+			if !ok {
+				break
+			}
+			if got := q.ShardFor(x); got != shard {
+				t.Errorf("item with key %d found in shard %d, expected %d", x.key, shard, got)
+			}
+			if prev, seen := lastSeq[x.key]; seen && prev > x.seq {
+				t.Errorf("key %d out of order: %d after %d", x.key, x.seq, prev)
+			}
+			lastSeq[x.key] = x.seq
+		}
+	}
+	if len(lastSeq) != 10 {
+		t.Errorf("expected 10 keys, got %d", len(lastSeq))
+	}
+}
+
+func TestShardedQueue_GetInvalidShard(t *testing.T) {
+	q := NewShardedQueue(2, shardedKey)
+	if _, err := q.Get(context.Background(), 5); err != ErrResharded {
+		t.Errorf("expected ErrResharded, got %v", err)
+	}
+}
+
+func TestShardedQueue_ReshardRedistributes(t *testing.T) {
+	q := NewShardedQueue(2, shardedKey)
+	ctx := context.Background()
+	for i := range 50 {
+		q.Put(ctx, shardedItem{key: uint64(i % 7), seq: i})
+	}
+
+	if err := q.Reshard(ctx, 5); err != nil {
+		t.Fatalf("reshard failed: %v", err)
+	}
+	if n := q.Shards(); n != 5 {
+		t.Fatalf("expected 5 shards, got %d", n)
+	}
+	if size := q.Size(); size != 50 {
+		t.Fatalf("expected 50 items after reshard, got %d", size)
+	}
+
+	lastSeq := map[uint64]int{}
+	for shard := range 5 {
+		items, _ := q.current().shards[shard].Snapshot(ctx)
+		for _, x := range items {
+			if got := q.ShardFor(x); got != shard {
+				t.Errorf("key %d in shard %d, expected %d", x.key, shard, got)
+			}
+			if prev, seen := lastSeq[x.key]; seen && prev > x.seq {
+				t.Errorf("key %d out of order after reshard", x.key)
+			}
+			lastSeq[x.key] = x.seq
+		}
+	}
+}
+
+func TestShardedQueue_HashRing(t *testing.T) {
+	q := NewShardedQueue(4, shardedKey, WithHashRing(64))
+	ctx := context.Background()
+	before := map[uint64]int{}
+	for i := range 200 {
+		x := shardedItem{key: uint64(i)}
+		before[x.key] = q.ShardFor(x)
+		q.Put(ctx, x)
+	}
+	if err := q.Reshard(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+	for shard := range 3 {
+		items, _ := q.current().shards[shard].Snapshot(ctx)
+		for _, x := range items {
+			if got := q.ShardFor(x); got != shard {
+				t.Errorf("key %d in shard %d, expected %d", x.key, shard, got)
+			}
+			// Only keys of the removed shard move.
+			if was := before[x.key]; was != 3 && was != shard {
+				t.Errorf("key %d moved from shard %d to %d", x.key, was, shard)
+			}
+		}
+	}
+	if q.Size() != 200 {
+		t.Fatalf("expected 200 items after reshard, got %d", q.Size())
+	}
+	q.Reshard(ctx, 6)
+	if n := len(q.ring.Nodes()); n != 6 {
+		t.Fatalf("ring has %d nodes after growing to 6 shards", n)
+	}
+}
+
+func TestShardedQueue_ReshardReleasesWaiters(t *testing.T) {
+	q := NewShardedQueue(3, shardedKey)
+	errs := make(chan error, 1)
+	go func() {
+		_, err := q.Get(context.Background(), 2)
+		errs <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	if err := q.Reshard(context.Background(), 1); err != nil {
+		t.Fatalf("reshard failed: %v", err)
+	}
+	select {
+	case err := <-errs:
+		if err != ErrResharded {
+			t.Fatalf("expected ErrResharded, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter was not released by reshard")
+	}
+}
+
+func TestShardedQueue_ConsumeAcrossReshard(t *testing.T) {
+	q := NewShardedQueue(2, shardedKey)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	seen := map[int]bool{}
+	done := make(chan struct{})
+	const total = 200
+
+	consumed := make(chan error, 1)
+	go func() {
+		consumed <- q.Consume(ctx, func(_ context.Context, x shardedItem) {
+			mu.Lock()
+			defer mu.Unlock()
+			if seen[x.seq] {
+				t.Errorf("item %d delivered twice", x.seq)
+			}
+			seen[x.seq] = true
+			if len(seen) == total {
+				close(done)
+			}
+		})
+	}()
+
+	for i := range total {
+		q.Put(ctx, shardedItem{key: uint64(i), seq: i})
+		if i == total/2 {
+			if err := q.Reshard(ctx, 4); err != nil {
+				t.Fatalf("reshard failed: %v", err)
+			}
+		}
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		mu.Lock()
+		t.Fatalf("only %d of %d items consumed", len(seen), total)
+	}
+	cancel()
+	if err := <-consumed; err != context.Canceled {
+		t.Errorf("expected context.Canceled from Consume, got %v", err)
+	}
+}
+
+func BenchmarkShardedQueue_Concurrent(b *testing.B) {
+	q := NewShardedQueue(8, func(x int) uint64 { return uint64(x) })
+	ctx := context.Background()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			q.Put(ctx, i)
+			q.current().shards[jumpHash(uint64(i), 8)].TryGet()
+			i++
+		}
+	})
+}
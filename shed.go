@@ -0,0 +1,187 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+// ErrShed matches every ShedError.
+var ErrShed = errors.New("load shed")
+
+// ShedError is returned for work rejected by a LoadShedder.
+type ShedError struct {
+	Priority int
+	InFlight int
+	Depth    int
+}
+
+func (e *ShedError) Error() string {
+	return fmt.Sprintf("load shed: priority %d rejected (in flight %d, depth %d)", e.Priority, e.InFlight, e.Depth)
+}
+
+func (e *ShedError) Unwrap() error { return ErrShed }
+
+// LoadShedder rejects low-priority work while the system is overloaded.
+// Overload starts when in-flight work reaches MaxInFlight or Depth reaches
+// MaxDepth, and ends only once both fall below the Recover fraction of
+// their limits, so admission doesn't flap around a threshold. While
+// overloaded, work with a priority below MinPriority is rejected with a
+// *ShedError. The zero value never sheds.
+type LoadShedder struct {
+	// MaxInFlight is the in-flight limit tracked by Acquire. Zero disables
+	// it.
+	MaxInFlight int
+	// MaxDepth is the queue depth limit, read from Depth. Zero disables it.
+	MaxDepth int
+	Depth    func() int
+	// Recover is the fraction of each limit load must drop below to end
+	// overload. Defaults to 0.8.
+	Recover float64
+	// MinPriority is the lowest priority admitted while overloaded.
+	// Defaults to 1, so priority 0 work is shed first.
+	MinPriority int
+
+	inFlight atomic.Int64
+	shedding atomic.Bool
+	shed     atomic.Int64
+}
+
+// LoadShedderStats is the debug view of a LoadShedder.
+type LoadShedderStats struct {
+	InFlight   int   `json:"in_flight"`
+	Depth      int   `json:"depth"`
+	Overloaded bool  `json:"overloaded"`
+	Shed       int64 `json:"shed"`
+}
+
+func (s *LoadShedder) depth() int {
+	if s.Depth == nil {
+		return 0
+	}
+	return s.Depth()
+}
+
+func (s *LoadShedder) minPriority() int {
+	if s.MinPriority == 0 {
+		return 1
+	}
+	return s.MinPriority
+}
+
+// update re-evaluates the overload state for the given load.
+func (s *LoadShedder) update(inFlight, depth int) bool {
+	recoverAt := s.Recover
+	if recoverAt <= 0 || recoverAt > 1 {
+		recoverAt = 0.8
+	}
+	over := (s.MaxInFlight > 0 && inFlight >= s.MaxInFlight) || (s.MaxDepth > 0 && depth >= s.MaxDepth)
+	if over {
+		s.shedding.Store(true)
+		return true
+	}
+	if !s.shedding.Load() {
+		return false
+	}
+	under := (s.MaxInFlight <= 0 || float64(inFlight) < recoverAt*float64(s.MaxInFlight)) &&
+		(s.MaxDepth <= 0 || float64(depth) < recoverAt*float64(s.MaxDepth))
+	if under {
+		s.shedding.Store(false)
+		return false
+	}
+	return true
+}
+
+// Admit checks whether work of the given priority may proceed without
+// tracking it as in flight, as for a queue Put.
+func (s *LoadShedder) Admit(priority int) error {
+	inFlight, depth := int(s.inFlight.Load()), s.depth()
+	if s.update(inFlight, depth) && priority < s.minPriority() {
+		s.shed.Add(1)
+		return &ShedError{Priority: priority, InFlight: inFlight, Depth: depth}
+	}
+	return nil
+}
+
+// Acquire admits work of the given priority and counts it as in flight
+// until release is called.
+func (s *LoadShedder) Acquire(priority int) (release func(), err error) {
+	n := int(s.inFlight.Add(1))
+	depth := s.depth()
+	// The new work only counts against the limit once admitted.
+	if s.update(n-1, depth) && priority < s.minPriority() {
+		s.inFlight.Add(-1)
+		s.shed.Add(1)
+		return nil, &ShedError{Priority: priority, InFlight: n - 1, Depth: depth}
+	}
+	var done atomic.Bool
+	return func() {
+		if done.CompareAndSwap(false, true) {
+			s.update(int(s.inFlight.Add(-1)), s.depth())
+		}
+	}, nil
+}
+
+// Overloaded reports whether low-priority work is currently being shed.
+func (s *LoadShedder) Overloaded() bool {
+	return s.update(int(s.inFlight.Load()), s.depth())
+}
+
+// Stats returns the current load and the number of rejections.
+func (s *LoadShedder) Stats() LoadShedderStats {
+	inFlight, depth := int(s.inFlight.Load()), s.depth()
+	return LoadShedderStats{
+		InFlight:   inFlight,
+		Depth:      depth,
+		Overloaded: s.update(inFlight, depth),
+		Shed:       s.shed.Load(),
+	}
+}
+
+// Inspect reports Stats for DebugHandler.
+func (s *LoadShedder) Inspect() any {
+	return s.Stats()
+}
+
+// Middleware returns HTTP middleware that runs each request through
+// Acquire with the priority returned by priority, answering shed requests
+// with 503 Service Unavailable and a Retry-After of one second.
+func (s *LoadShedder) Middleware(priority func(r *http.Request) int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			release, err := s.Acquire(priority(r))
+			if err != nil {
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+			defer release()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type shedQueue[T any] struct {
+	Queue[T]
+	s        *LoadShedder
+	priority func(T) int
+}
+
+// ShedQueue wraps q so Put and TryPut consult s with each item's priority
+// first. A shed Put returns a *ShedError; a shed TryPut returns false.
+func ShedQueue[T any](q Queue[T], s *LoadShedder, priority func(T) int) Queue[T] {
+	return shedQueue[T]{Queue: q, s: s, priority: priority}
+}
+
+func (q shedQueue[T]) Put(ctx context.Context, x T) error {
+	if err := q.s.Admit(q.priority(x)); err != nil {
+		return err
+	}
+	return q.Queue.Put(ctx, x)
+}
+
+func (q shedQueue[T]) TryPut(x T) bool {
+	return q.s.Admit(q.priority(x)) == nil && q.Queue.TryPut(x)
+}
@@ -0,0 +1,135 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLoadShedder_Hysteresis(t *testing.T) {
+	s := &LoadShedder{MaxInFlight: 5, Recover: 0.6}
+	var releases []func()
+	for i := range 5 {
+		release, err := s.Acquire(0)
+		if err != nil {
+			t.Fatalf("acquire %d below the limit: %v", i, err)
+		}
+		releases = append(releases, release)
+	}
+	_, err := s.Acquire(0)
+	var se *ShedError
+	if !errors.As(err, &se) || !errors.Is(err, ErrShed) {
+		t.Fatalf("expected ShedError at the limit, got %v", err)
+	}
+	if se.Priority != 0 || se.InFlight != 5 {
+		t.Fatalf("unexpected ShedError %+v", se)
+	}
+	release, err := s.Acquire(1)
+	if err != nil {
+		t.Fatalf("high priority work should be admitted while overloaded: %v", err)
+	}
+	release()
+
+	// 4 and 3 in flight are still above the 0.6 recovery point of 3.
+	releases[0]()
+	releases[1]()
+	if !s.Overloaded() {
+		t.Fatal("shedder should stay overloaded until load drops below Recover")
+	}
+	if _, err := s.Acquire(0); err == nil {
+		t.Fatal("low priority work should still be shed")
+	}
+	releases[2]()
+	if s.Overloaded() {
+		t.Fatal("shedder should recover below the Recover fraction")
+	}
+	if _, err := s.Acquire(0); err != nil {
+		t.Fatalf("low priority work should be admitted after recovery: %v", err)
+	}
+	if got := s.Stats().Shed; got != 2 {
+		t.Fatalf("expected 2 shed, got %d", got)
+	}
+}
+
+func TestLoadShedder_ReleaseIdempotent(t *testing.T) {
+	s := &LoadShedder{MaxInFlight: 2}
+	release, _ := s.Acquire(0)
+	release()
+	release()
+	if got := s.Stats().InFlight; got != 0 {
+		t.Fatalf("double release should count once, in flight %d", got)
+	}
+}
+
+func TestLoadShedder_ZeroValue(t *testing.T) {
+	var s LoadShedder
+	for range 100 {
+		if _, err := s.Acquire(0); err != nil {
+			t.Fatalf("zero LoadShedder should never shed: %v", err)
+		}
+	}
+}
+
+func TestShedQueue(t *testing.T) {
+	inner := NewFiFo[int]()
+	s := &LoadShedder{MaxDepth: 2, Depth: inner.Size}
+	q := ShedQueue[int](inner, s, func(x int) int { return x % 2 })
+	ctx := context.Background()
+
+	for _, x := range []int{0, 2} {
+		if err := q.Put(ctx, x); err != nil {
+			t.Fatalf("put %d: %v", x, err)
+		}
+	}
+	if err := q.Put(ctx, 4); !errors.Is(err, ErrShed) {
+		t.Fatalf("expected low priority put to be shed, got %v", err)
+	}
+	if q.TryPut(6) {
+		t.Fatal("expected low priority TryPut to be shed")
+	}
+	if err := q.Put(ctx, 1); err != nil {
+		t.Fatalf("high priority put should pass: %v", err)
+	}
+	if inner.Size() != 3 {
+		t.Fatalf("expected 3 items, got %d", inner.Size())
+	}
+	for !inner.IsEmpty() {
+		inner.TryGet()
+	}
+	if !q.TryPut(8) {
+		t.Fatal("low priority TryPut should pass once the queue drains")
+	}
+}
+
+func TestLoadShedder_Middleware(t *testing.T) {
+	s := &LoadShedder{MaxInFlight: 1}
+	hold, _ := s.Acquire(1)
+	defer hold()
+	h := s.Middleware(func(r *http.Request) int {
+		if r.Header.Get("X-Priority") == "high" {
+			return 1
+		}
+		return 0
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 503 with Retry-After, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Priority", "high")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected high priority request to pass, got %d", rec.Code)
+	}
+	if got := s.Stats().InFlight; got != 1 {
+		t.Fatalf("middleware should release its slot, in flight %d", got)
+	}
+}
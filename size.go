@@ -0,0 +1,164 @@
+package generic
+
+import (
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// Approximate runtime overheads for types whose storage is opaque.
+const (
+	mapHeaderSize  = 48
+	mapEntryExtra  = 1 // per-slot control byte in swiss tables
+	chanHeaderSize = 96
+)
+
+// sizeLayout caches what SizeOf needs to know about a type so repeated calls
+// don't re-derive it through reflection.
+type sizeLayout struct {
+	flat   bool  // no pointers reachable; Size() is the whole story
+	fields []int // struct fields that are not flat
+}
+
+var sizeLayouts sync.Map // reflect.Type -> *sizeLayout
+
+func layoutOf(t reflect.Type) *sizeLayout {
+	if l, ok := sizeLayouts.Load(t); ok {
+		return l.(*sizeLayout)
+	}
+	l := &sizeLayout{}
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		l.flat = true
+	case reflect.Array:
+		l.flat = t.Len() == 0 || layoutOf(t.Elem()).flat
+	case reflect.Struct:
+		for i := range t.NumField() {
+			if !layoutOf(t.Field(i).Type).flat {
+				l.fields = append(l.fields, i)
+			}
+		}
+		l.flat = len(l.fields) == 0
+	}
+	actual, _ := sizeLayouts.LoadOrStore(t, l)
+	return actual.(*sizeLayout)
+}
+
+// SizeOf estimates the number of bytes retained by v, following pointers,
+// slices, strings, maps, channels and interfaces. Shared memory is counted
+// once. Types without indirections are answered from a cached layout without
+// walking the value or allocating.
+//
+// The result is an estimate: allocator size classes, map bucket load factor
+// and string interning are not modelled. It is intended for cache weighers
+// and pool limits, not exact accounting.
+func SizeOf[T any](v T) int64 {
+	t := reflect.TypeFor[T]()
+	size := int64(t.Size())
+	if layoutOf(t).flat {
+		return size
+	}
+	// Boxing v only on this path keeps the flat path allocation-free. For
+	// interface types the box holds the dynamic value, so account for it the
+	// same way indirect does for interface fields.
+	var s sizer
+	rv := reflect.ValueOf(any(v))
+	if !rv.IsValid() {
+		return size
+	}
+	if t.Kind() == reflect.Interface {
+		size += int64(rv.Type().Size())
+	}
+	return size + s.indirect(rv)
+}
+
+type sizer struct {
+	seen map[uintptr]struct{}
+}
+
+// visit reports whether p is being seen for the first time.
+func (s *sizer) visit(p uintptr) bool {
+	if s.seen == nil {
+		s.seen = make(map[uintptr]struct{})
+	}
+	if _, ok := s.seen[p]; ok {
+		return false
+	}
+	s.seen[p] = struct{}{}
+	return true
+}
+
+// indirect returns the bytes reachable from v excluding v's own inline size.
+func (s *sizer) indirect(v reflect.Value) int64 {
+	t := v.Type()
+	l := layoutOf(t)
+	if l.flat {
+		return 0
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() || !s.visit(v.Pointer()) {
+			return 0
+		}
+		e := v.Elem()
+		return int64(e.Type().Size()) + s.indirect(e)
+	case reflect.String:
+		n := v.Len()
+		if n == 0 || !s.visit(uintptr(unsafe.Pointer(unsafe.StringData(v.String())))) {
+			return 0
+		}
+		return int64(n)
+	case reflect.Slice:
+		if v.Cap() == 0 || !s.visit(v.Pointer()) {
+			return 0
+		}
+		size := int64(v.Cap()) * int64(t.Elem().Size())
+		if !layoutOf(t.Elem()).flat {
+			for i := range v.Len() {
+				size += s.indirect(v.Index(i))
+			}
+		}
+		return size
+	case reflect.Array:
+		var size int64
+		for i := range v.Len() {
+			size += s.indirect(v.Index(i))
+		}
+		return size
+	case reflect.Struct:
+		var size int64
+		for _, i := range l.fields {
+			size += s.indirect(v.Field(i))
+		}
+		return size
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		e := v.Elem()
+		return int64(e.Type().Size()) + s.indirect(e)
+	case reflect.Map:
+		if v.IsNil() || !s.visit(v.Pointer()) {
+			return 0
+		}
+		kt, vt := t.Key(), t.Elem()
+		size := int64(mapHeaderSize) + int64(v.Len())*int64(kt.Size()+vt.Size()+mapEntryExtra)
+		if !layoutOf(kt).flat || !layoutOf(vt).flat {
+			iter := v.MapRange()
+			for iter.Next() {
+				size += s.indirect(iter.Key()) + s.indirect(iter.Value())
+			}
+		}
+		return size
+	case reflect.Chan:
+		if v.IsNil() || !s.visit(v.Pointer()) {
+			return 0
+		}
+		return chanHeaderSize + int64(v.Cap())*int64(t.Elem().Size())
+	default:
+		// Func and UnsafePointer targets are opaque.
+		return 0
+	}
+}
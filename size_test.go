@@ -0,0 +1,113 @@
+package generic
+
+import "testing"
+
+func TestSizeOf_Flat(t *testing.T) {
+	type point struct {
+		X, Y int64
+	}
+	if got := SizeOf(int64(1)); got != 8 {
+		t.Errorf("int64: expected 8, got %d", got)
+	}
+	if got := SizeOf(point{1, 2}); got != 16 {
+		t.Errorf("point: expected 16, got %d", got)
+	}
+	if got := SizeOf([4]int32{}); got != 16 {
+		t.Errorf("array: expected 16, got %d", got)
+	}
+}
+
+func TestSizeOf_Indirect(t *testing.T) {
+	if got := SizeOf("hello"); got != 16+5 {
+		t.Errorf("string: expected 21, got %d", got)
+	}
+
+	s := make([]int64, 2, 4)
+	if got := SizeOf(s); got != 24+32 {
+		t.Errorf("slice: expected 56, got %d", got)
+	}
+
+	x := int64(5)
+	if got := SizeOf(&x); got != 8+8 {
+		t.Errorf("pointer: expected 16, got %d", got)
+	}
+
+	var nilPtr *int64
+	if got := SizeOf(nilPtr); got != 8 {
+		t.Errorf("nil pointer: expected 8, got %d", got)
+	}
+
+	strs := []string{"ab", "cde"}
+	if got := SizeOf(strs); got != 24+2*16+2+3 {
+		t.Errorf("string slice: expected %d, got %d", 24+2*16+2+3, got)
+	}
+
+	var iface any = int64(1)
+	if got := SizeOf(iface); got != 16+8 {
+		t.Errorf("interface: expected 24, got %d", got)
+	}
+}
+
+func TestSizeOf_SharedAndCyclic(t *testing.T) {
+	type node struct {
+		Val  int64
+		Next *node
+	}
+	a := &node{Val: 1}
+	b := &node{Val: 2, Next: a}
+	a.Next = b
+	if got := SizeOf(a); got != 8+2*16 {
+		t.Errorf("cycle: expected %d, got %d", 8+2*16, got)
+	}
+
+	shared := &node{}
+	pair := [2]*node{shared, shared}
+	if got := SizeOf(pair); got != 16+16 {
+		t.Errorf("shared: expected 32, got %d", got)
+	}
+}
+
+func TestSizeOf_Map(t *testing.T) {
+	m := map[string]int{"a": 1, "bb": 2}
+	got := SizeOf(m)
+	min := int64(8 + mapHeaderSize + 2*(16+8) + 3)
+	if got < min {
+		t.Errorf("map: expected at least %d, got %d", min, got)
+	}
+}
+
+func TestSizeOf_UnexportedFields(t *testing.T) {
+	type inner struct {
+		name string
+		data []byte
+	}
+	v := inner{name: "abc", data: make([]byte, 10)}
+	if got := SizeOf(v); got != 16+24+3+10 {
+		t.Errorf("expected %d, got %d", 16+24+3+10, got)
+	}
+}
+
+func TestSizeOf_FlatDoesNotAllocate(t *testing.T) {
+	type point struct{ X, Y int64 }
+	SizeOf(point{}) // warm layout cache
+	allocs := testing.AllocsPerRun(100, func() {
+		SizeOf(point{1, 2})
+	})
+	if allocs != 0 {
+		t.Errorf("expected 0 allocs, got %v", allocs)
+	}
+}
+
+func BenchmarkSizeOf_Flat(b *testing.B) {
+	type point struct{ X, Y int64 }
+	for i := 0; i < b.N; i++ {
+		SizeOf(point{1, 2})
+	}
+}
+
+func BenchmarkSizeOf_Nested(b *testing.B) {
+	v := map[string][]string{"a": {"x", "y"}, "b": {"z"}}
+	for i := 0; i < b.N; i++ {
+		SizeOf(v)
+	}
+}
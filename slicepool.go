@@ -0,0 +1,60 @@
+package generic
+
+import "math/bits"
+
+// defaultSlicePoolMaxCap bounds retained slices when SlicePool.MaxCap is zero.
+const defaultSlicePoolMaxCap = 1 << 16
+
+// SlicePool recycles slices of T in power-of-two capacity classes, so a
+// request for a small slice never pins a huge backing array and a huge
+// slice returned once isn't handed out for every small request. The zero
+// value is ready to use.
+type SlicePool[T any] struct {
+	// MaxCap is the largest capacity Put retains; bigger slices are left to
+	// the garbage collector. Zero means 65536 elements.
+	MaxCap int
+	// Zero clears element memory on Put so pooled slices don't keep the
+	// values they held reachable. Enable it for T containing pointers.
+	Zero bool
+
+	classes [bits.UintSize]SyncPool[*[]T]
+}
+
+// Get returns a slice with length zero and capacity at least minCap,
+// rounded up to a power of two unless that would exceed MaxCap.
+func (p *SlicePool[T]) Get(minCap int) []T {
+	if minCap <= 0 {
+		minCap = 1
+	}
+	class := bits.Len(uint(minCap - 1))
+	if 1<<class > p.maxCap() {
+		// No pooled slice is this big, and one rounded up to the class
+		// would be too big for Put to keep.
+		return make([]T, 0, minCap)
+	}
+	if sp := p.classes[class].Get(); sp != nil {
+		return (*sp)[:0]
+	}
+	return make([]T, 0, 1<<class)
+}
+
+// Put returns s to the pool. s must not be used afterwards.
+func (p *SlicePool[T]) Put(s []T) {
+	c := cap(s)
+	if c == 0 || c > p.maxCap() {
+		return
+	}
+	if p.Zero {
+		clear(s[:c])
+	}
+	s = s[:0]
+	// Filed under the largest class it can fully satisfy.
+	p.classes[bits.Len(uint(c))-1].Put(&s)
+}
+
+func (p *SlicePool[T]) maxCap() int {
+	if p.MaxCap > 0 {
+		return p.MaxCap
+	}
+	return defaultSlicePoolMaxCap
+}
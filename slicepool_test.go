@@ -0,0 +1,93 @@
+package generic
+
+import "testing"
+
+func TestSlicePool_GetCapacity(t *testing.T) {
+	var p SlicePool[int]
+	for _, n := range []int{0, 1, 3, 4, 5, 1000} {
+		s := p.Get(n)
+		if len(s) != 0 {
+			t.Errorf("Get(%d): expected len 0, got %d", n, len(s))
+		}
+		if cap(s) < n {
+			t.Errorf("Get(%d): cap %d too small", n, cap(s))
+		}
+	}
+}
+
+func TestSlicePool_ReusesByClass(t *testing.T) {
+	var p SlicePool[int]
+	s := p.Get(100)
+	s = append(s, 1, 2, 3)
+	p.Put(s)
+
+	// Reuse is best-effort with sync.Pool; retry a few times.
+	for range 10 {
+		got := p.Get(128)
+		if len(got) != 0 || cap(got) < 128 {
+			t.Fatalf("unexpected slice len=%d cap=%d", len(got), cap(got))
+		}
+		if cap(got) == cap(s) {
+			return
+		}
+		p.Put(got)
+	}
+	t.Skip("pool did not return the slice; sync.Pool may have been cleared")
+}
+
+func TestSlicePool_SmallRequestNeverGetsLargeSlice(t *testing.T) {
+	var p SlicePool[byte]
+	p.Put(make([]byte, 0, 4096))
+	if s := p.Get(8); cap(s) > 8 {
+		t.Fatalf("expected a small slice, got cap %d", cap(s))
+	}
+}
+
+func TestSlicePool_DropsOversized(t *testing.T) {
+	p := SlicePool[int]{MaxCap: 16}
+	p.Put(make([]int, 0, 32)) // ignored
+	if s := p.Get(32); cap(s) != 32 {
+		t.Fatalf("expected fresh exact-size slice above MaxCap, got cap %d", cap(s))
+	}
+}
+
+func TestSlicePool_RoundingStaysUnderMaxCap(t *testing.T) {
+	p := SlicePool[int]{MaxCap: 100}
+	s := p.Get(70)
+	if cap(s) != 70 {
+		t.Fatalf("Get(70) with MaxCap 100 = cap %d, want 70", cap(s))
+	}
+	p.Put(s)
+
+	// Reuse is best-effort with sync.Pool; retry a few times.
+	for range 10 {
+		got := p.Get(60)
+		if cap(got) == 70 {
+			return
+		}
+		p.Put(got)
+	}
+	t.Skip("pool did not return the slice; sync.Pool may have been cleared")
+}
+
+func TestSlicePool_Zero(t *testing.T) {
+	p := SlicePool[*int]{Zero: true}
+	x := 1
+	s := p.Get(4)
+	s = append(s, &x, &x)
+	p.Put(s)
+	for _, v := range s[:cap(s)] {
+		if v != nil {
+			t.Fatal("expected pooled slice to be cleared")
+		}
+	}
+}
+
+func BenchmarkSlicePool_GetPut(b *testing.B) {
+	var p SlicePool[byte]
+	for i := 0; i < b.N; i++ {
+		s := p.Get(512)
+		s = append(s, 1)
+		p.Put(s)
+	}
+}
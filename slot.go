@@ -0,0 +1,105 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+var ErrSlotDone = errors.New("slot already committed or aborted")
+
+// Slot is capacity reserved in a queue by Reserve. Exactly one of Commit or
+// Abort should be called; further calls return ErrSlotDone or do nothing.
+// Slots are values but copies share state, so any copy may finish the slot.
+type Slot[T any] struct {
+	done   *atomic.Bool
+	commit func(x T) error
+	abort  func()
+}
+
+// Commit places x in the queue using the reserved capacity. It never blocks
+// on capacity. Items are ordered by commit time, not reservation time. It
+// returns ErrClosed, and x is not queued, if the queue was closed since
+// Reserve.
+func (s Slot[T]) Commit(x T) error {
+	if s.done == nil || !s.done.CompareAndSwap(false, true) {
+		return ErrSlotDone
+	}
+	return s.commit(x)
+}
+
+// Abort releases the reserved capacity without adding an item.
+func (s Slot[T]) Abort() {
+	if s.done == nil || !s.done.CompareAndSwap(false, true) {
+		return
+	}
+	if s.abort != nil {
+		s.abort()
+	}
+}
+
+// Reserve claims room for one item so a producer can find out whether the
+// queue will accept work before producing it. On a bounded queue it waits
+// for room whatever the overflow policy, and the claimed room counts
+// against the bound until the slot is committed or aborted. It returns
+// ErrClosed once the queue is closed.
+func (q *FiFo[T]) Reserve(ctx context.Context) (Slot[T], error) {
+	if err := ctx.Err(); err != nil {
+		return Slot[T]{}, err
+	}
+	for {
+		if err := q.acquire(ctx); err != nil {
+			return Slot[T]{}, err
+		}
+		if q.closing() {
+			q.release()
+			return Slot[T]{}, ErrClosed
+		}
+		if q.hasRoom() {
+			break
+		}
+		changed := q.roomFreed.wait()
+		q.release()
+		select {
+		case <-changed:
+		case <-q.closed:
+		case <-ctx.Done():
+			return Slot[T]{}, ctx.Err()
+		}
+	}
+	q.reserved++
+	q.release()
+	return Slot[T]{
+		done:   new(atomic.Bool),
+		commit: q.commitReserved,
+		abort:  q.abortReserved,
+	}, nil
+}
+
+// commitReserved adds x in room claimed by Reserve.
+func (q *FiFo[T]) commitReserved(x T) error {
+	q.acquire(context.Background())
+	q.reserved--
+	if q.closing() {
+		q.release()
+		q.roomFreed.notify()
+		return ErrClosed
+	}
+	switch n := q.ring.len(); {
+	case n == 0 && q.waiters != nil && q.waiters.handoff(x):
+	case n > 0 && q.compact != nil && q.compactLocked(x):
+	default:
+		q.ring.push(x)
+	}
+	q.release()
+	q.roomFreed.notify()
+	return nil
+}
+
+// abortReserved gives back room claimed by Reserve.
+func (q *FiFo[T]) abortReserved() {
+	q.acquire(context.Background())
+	q.reserved--
+	q.release()
+	q.roomFreed.notify()
+}
@@ -0,0 +1,156 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFiFo_Reserve(t *testing.T) {
+	q := NewFiFo[int]()
+	ctx := context.Background()
+
+	slot, err := q.Reserve(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error reserving: %v", err)
+	}
+	if size := q.Size(); size != 0 {
+		t.Fatalf("reservation should not add an item, size %d", size)
+	}
+	if err := slot.Commit(7); err != nil {
+		t.Fatalf("unexpected error committing: %v", err)
+	}
+	if err := slot.Commit(8); err != ErrSlotDone {
+		t.Errorf("expected ErrSlotDone on second commit, got %v", err)
+	}
+	if got, _ := q.TryGet(); got != 7 {
+		t.Errorf("expected 7, got %d", got)
+	}
+	if !q.IsEmpty() {
+		t.Errorf("expected queue to be empty")
+	}
+}
+
+func TestFiFo_ReserveAbort(t *testing.T) {
+	q := NewFiFo[int]()
+	slot, err := q.Reserve(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error reserving: %v", err)
+	}
+	slot.Abort()
+	slot.Abort() // no-op
+	if err := slot.Commit(1); err != ErrSlotDone {
+		t.Errorf("expected ErrSlotDone after abort, got %v", err)
+	}
+	if size := q.Size(); size != 0 {
+		t.Errorf("expected empty queue, got size %d", size)
+	}
+}
+
+func TestFiFo_ReserveCancelled(t *testing.T) {
+	q := NewFiFo[int]()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := q.Reserve(ctx); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestSlot_ZeroValue(t *testing.T) {
+	var s Slot[int]
+	if err := s.Commit(1); err != ErrSlotDone {
+		t.Errorf("expected ErrSlotDone from zero slot, got %v", err)
+	}
+	s.Abort()
+}
+
+func TestFiFo_ReserveBounded(t *testing.T) {
+	ctx := context.Background()
+	q := NewBoundedFiFo[int](2, OverflowBlock)
+	slot, err := q.Reserve(ctx)
+	if err != nil {
+		t.Fatalf("Reserve = %v", err)
+	}
+	q.Put(ctx, 1)
+	if q.TryPut(2) {
+		t.Fatal("TryPut took reserved room")
+	}
+	short, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	if _, err := q.Reserve(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Reserve on a full queue = %v", err)
+	}
+
+	// A Put waiting behind the reservation gets the room it frees.
+	put := make(chan error, 1)
+	go func() { put <- q.Put(ctx, 3) }()
+	time.Sleep(10 * time.Millisecond)
+	if err := slot.Commit(2); err != nil {
+		t.Fatalf("Commit = %v", err)
+	}
+	select {
+	case <-put:
+		t.Fatal("Put went over the bound")
+	case <-time.After(10 * time.Millisecond):
+	}
+	q.Get(ctx)
+	if err := <-put; err != nil {
+		t.Fatalf("Put = %v", err)
+	}
+	if got, _ := q.Snapshot(ctx); !slices.Equal(got, []int{2, 3}) {
+		t.Fatalf("queue = %v", got)
+	}
+}
+
+func TestFiFo_ReserveBoundedDrop(t *testing.T) {
+	ctx := context.Background()
+	for _, policy := range []OverflowPolicy{OverflowDropNewest, OverflowDropOldest} {
+		q := NewBoundedFiFo[int](1, policy)
+		slot, _ := q.Reserve(ctx)
+		if q.TryPut(1) || q.Dropped() != 1 {
+			t.Fatalf("policy %d: put into reserved room, dropped %d", policy, q.Dropped())
+		}
+		slot.Commit(2)
+		reserved := make(chan Slot[int], 1)
+		go func() {
+			s, _ := q.Reserve(ctx)
+			reserved <- s
+		}()
+		time.Sleep(5 * time.Millisecond)
+		if x, _ := q.Get(ctx); x != 2 {
+			t.Fatalf("policy %d: Get = %d, want the committed item", policy, x)
+		}
+		s := <-reserved
+		s.Abort()
+		if !q.TryPut(3) {
+			t.Fatalf("policy %d: aborted room not given back", policy)
+		}
+	}
+}
+
+func TestFiFo_ReserveClosed(t *testing.T) {
+	ctx := context.Background()
+	q := NewBoundedFiFo[int](1, OverflowBlock)
+	slot, _ := q.Reserve(ctx)
+	waiting := make(chan error, 1)
+	go func() {
+		_, err := q.Reserve(ctx)
+		waiting <- err
+	}()
+	time.Sleep(5 * time.Millisecond)
+	q.Close()
+	if err := <-waiting; !errors.Is(err, ErrClosed) {
+		t.Fatalf("Reserve woken by Close = %v", err)
+	}
+	if _, err := q.Reserve(ctx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Reserve after Close = %v", err)
+	}
+	if err := slot.Commit(1); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Commit after Close = %v", err)
+	}
+	if q.Size() != 0 {
+		t.Fatalf("size = %d", q.Size())
+	}
+}
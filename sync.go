@@ -5,6 +5,12 @@ import (
 	"sync"
 )
 
+// Pool hands out reusable values. *SyncPool satisfies it.
+type Pool[T any] interface {
+	Get() T
+	Put(x T)
+}
+
 type SyncPool[T any] sync.Pool
 
 func (p *SyncPool[T]) Get() T {
@@ -24,3 +30,5 @@ func (p *SyncPool[T]) Get() T {
 func (p *SyncPool[T]) Put(x T) {
 	(*sync.Pool)(p).Put(x)
 }
+
+var _ Pool[int] = (*SyncPool[int])(nil)
@@ -0,0 +1,89 @@
+package generic
+
+import (
+	"context"
+	"time"
+)
+
+type timedItem[T any] struct {
+	x  T
+	at time.Time
+}
+
+// TimedQueue is a FiFo that stamps items on Put and records how long each
+// waited in its TimeInQueue histogram on Get. Depth alone hides latency;
+// time in queue, and the age of the oldest item, is what an SLO alerts on.
+type TimedQueue[T any] struct {
+	// Clock stamps items. Defaults to SystemClock.
+	Clock Clock
+
+	q    *FiFo[timedItem[T]]
+	hist LatencyHistogram
+}
+
+// TimedQueueStats is the debug view of a TimedQueue.
+type TimedQueueStats struct {
+	Size        int             `json:"size"`
+	AgeOfHead   time.Duration   `json:"age_of_head"`
+	TimeInQueue LatencySnapshot `json:"time_in_queue"`
+}
+
+func NewTimedQueue[T any](opts ...FiFoOption) *TimedQueue[T] {
+	return &TimedQueue[T]{q: NewFiFo[timedItem[T]](opts...)}
+}
+
+func (q *TimedQueue[T]) now() time.Time {
+	if q.Clock == nil {
+		return SystemClock.Now()
+	}
+	return q.Clock.Now()
+}
+
+func (q *TimedQueue[T]) Put(ctx context.Context, x T) error {
+	return q.q.Put(ctx, timedItem[T]{x: x, at: q.now()})
+}
+
+func (q *TimedQueue[T]) TryPut(x T) bool {
+	return q.q.TryPut(timedItem[T]{x: x, at: q.now()})
+}
+
+func (q *TimedQueue[T]) Get(ctx context.Context) (T, error) {
+	it, err := q.q.Get(ctx)
+	if err != nil {
+		return it.x, err
+	}
+	q.hist.Observe(q.now().Sub(it.at))
+	return it.x, nil
+}
+
+func (q *TimedQueue[T]) TryGet() (T, bool) {
+	it, ok := q.q.TryGet()
+	if ok {
+		q.hist.Observe(q.now().Sub(it.at))
+	}
+	return it.x, ok
+}
+
+func (q *TimedQueue[T]) IsEmpty() bool { return q.q.IsEmpty() }
+
+func (q *TimedQueue[T]) Size() int { return q.q.Size() }
+
+// AgeOfHead returns how long the oldest item has been waiting, or zero if
+// the queue is empty. Pass it to Watchdog.WatchAge to alert on stalls.
+func (q *TimedQueue[T]) AgeOfHead() time.Duration {
+	it, ok := q.q.peek()
+	if !ok {
+		return 0
+	}
+	return q.now().Sub(it.at)
+}
+
+// TimeInQueue returns the histogram of completed waits.
+func (q *TimedQueue[T]) TimeInQueue() *LatencyHistogram {
+	return &q.hist
+}
+
+// Inspect reports TimedQueueStats for DebugHandler.
+func (q *TimedQueue[T]) Inspect() any {
+	return TimedQueueStats{Size: q.Size(), AgeOfHead: q.AgeOfHead(), TimeInQueue: q.hist.Snapshot()}
+}
@@ -0,0 +1,61 @@
+package generic
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTimedQueue(t *testing.T) {
+	clock := newTestClock()
+	q := NewTimedQueue[string]()
+	q.Clock = clock
+	ctx := context.Background()
+
+	if q.AgeOfHead() != 0 {
+		t.Fatal("empty queue should have no head age")
+	}
+	q.Put(ctx, "a")
+	clock.Advance(time.Second)
+	q.TryPut("b")
+	clock.Advance(2 * time.Second)
+	if got := q.AgeOfHead(); got != 3*time.Second {
+		t.Fatalf("age of head = %v, want 3s", got)
+	}
+
+	if x, err := q.Get(ctx); err != nil || x != "a" {
+		t.Fatalf("got %q %v", x, err)
+	}
+	if got := q.AgeOfHead(); got != 2*time.Second {
+		t.Fatalf("age of head after Get = %v, want 2s", got)
+	}
+	clock.Advance(time.Second)
+	if x, ok := q.TryGet(); !ok || x != "b" {
+		t.Fatalf("got %q %v", x, ok)
+	}
+
+	s := q.TimeInQueue().Snapshot()
+	if s.Count != 2 || s.Max != 3*time.Second || s.Mean != 3*time.Second {
+		t.Fatalf("unexpected time in queue %+v", s)
+	}
+	st := q.Inspect().(TimedQueueStats)
+	if st.Size != 0 || st.AgeOfHead != 0 || st.TimeInQueue.Count != 2 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+}
+
+func TestTimedQueue_Watchdog(t *testing.T) {
+	clock := newTestClock()
+	q := NewTimedQueue[int]()
+	q.Clock = clock
+	var alerts []WatchdogAlert
+	w := &Watchdog{Clock: clock, OnAlert: func(a WatchdogAlert) { alerts = append(alerts, a) }}
+	w.WatchAge("jobs", time.Minute, q.AgeOfHead)
+
+	q.TryPut(1)
+	clock.Advance(2 * time.Minute)
+	w.Check()
+	if len(alerts) != 1 || alerts[0].Kind != AlertAge {
+		t.Fatalf("stuck head should alert, got %+v", alerts)
+	}
+}
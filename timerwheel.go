@@ -0,0 +1,164 @@
+package generic
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// WheelTimer is a pending expiration in a TimerWheel.
+type WheelTimer[T any] struct {
+	w     *TimerWheel[T]
+	at    time.Time
+	seq   uint64
+	value T
+	index int // position in the heap, -1 once fired or stopped
+}
+
+// Stop cancels the timer, reporting false if it already fired or was
+// stopped.
+func (t *WheelTimer[T]) Stop() bool {
+	t.w.mu.Lock()
+	defer t.w.mu.Unlock()
+	if t.index < 0 {
+		return false
+	}
+	heap.Remove(&t.w.timers, t.index)
+	return true
+}
+
+type wheelHeap[T any] []*WheelTimer[T]
+
+func (h wheelHeap[T]) Len() int { return len(h) }
+
+func (h wheelHeap[T]) Less(i, j int) bool {
+	if !h[i].at.Equal(h[j].at) {
+		return h[i].at.Before(h[j].at)
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h wheelHeap[T]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *wheelHeap[T]) Push(x any) {
+	t := x.(*WheelTimer[T])
+	t.index = len(*h)
+	*h = append(*h, t)
+}
+
+func (h *wheelHeap[T]) Pop() any {
+	old := *h
+	t := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	t.index = -1
+	return t
+}
+
+// TimerWheel runs many timers from one goroutine. Deadlines are rounded up
+// to a multiple of the tick so timers due close together fire in one batch,
+// and the goroutine sleeps until the next batch instead of waking every
+// tick. Timers carry a value of type T handed to a single fire callback, so
+// scheduling doesn't allocate a closure per timer.
+type TimerWheel[T any] struct {
+	tick  time.Duration
+	clock Clock
+	fire  func(T)
+
+	mu     sync.Mutex
+	timers wheelHeap[T]
+	seq    uint64
+	wake   chan struct{}
+}
+
+// NewTimerWheel returns a wheel with the given tick that calls fire for each
+// expired timer. A nil clock means SystemClock. Call Run to start it.
+func NewTimerWheel[T any](tick time.Duration, clock Clock, fire func(T)) *TimerWheel[T] {
+	if tick <= 0 {
+		tick = time.Millisecond
+	}
+	if clock == nil {
+		clock = SystemClock
+	}
+	return &TimerWheel[T]{tick: tick, clock: clock, fire: fire, wake: make(chan struct{}, 1)}
+}
+
+// Schedule arranges for v to be passed to fire no earlier than d from now
+// and at most one tick later than that.
+func (w *TimerWheel[T]) Schedule(d time.Duration, v T) *WheelTimer[T] {
+	at := w.clock.Now().Add(d)
+	if rem := at.UnixNano() % int64(w.tick); rem != 0 {
+		at = at.Add(w.tick - time.Duration(rem))
+	}
+	w.mu.Lock()
+	w.seq++
+	t := &WheelTimer[T]{w: w, at: at, seq: w.seq, value: v}
+	heap.Push(&w.timers, t)
+	earliest := t.index == 0
+	w.mu.Unlock()
+	if earliest {
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}
+	return t
+}
+
+// Len returns the number of pending timers.
+func (w *TimerWheel[T]) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.timers)
+}
+
+// Drain stops every pending timer and returns their values in deadline
+// order, for handing off what a stopped wheel would never fire.
+func (w *TimerWheel[T]) Drain() []T {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	vs := make([]T, 0, len(w.timers))
+	for len(w.timers) > 0 {
+		vs = append(vs, heap.Pop(&w.timers).(*WheelTimer[T]).value)
+	}
+	return vs
+}
+
+// Run fires timers as they expire until ctx is done. Callbacks run on the
+// Run goroutine, in deadline order, and should be quick.
+func (w *TimerWheel[T]) Run(ctx context.Context) error {
+	var due []T
+	for {
+		w.mu.Lock()
+		var wait <-chan time.Time
+		if len(w.timers) > 0 {
+			wait = w.clock.After(w.timers[0].at.Sub(w.clock.Now()))
+		}
+		w.mu.Unlock()
+
+		select {
+		case <-wait:
+		case <-w.wake:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		now := w.clock.Now()
+		w.mu.Lock()
+		for len(w.timers) > 0 && !w.timers[0].at.After(now) {
+			due = append(due, heap.Pop(&w.timers).(*WheelTimer[T]).value)
+		}
+		w.mu.Unlock()
+		for i, v := range due {
+			w.fire(v)
+			var zero T
+			due[i] = zero
+		}
+		due = due[:0]
+	}
+}
@@ -0,0 +1,157 @@
+package generic
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func startTimerWheel(t *testing.T, tick time.Duration) (*TimerWheel[int], *testClock, chan int) {
+	t.Helper()
+	clock := newTestClock()
+	fired := make(chan int, 100)
+	w := NewTimerWheel(tick, clock, func(v int) { fired <- v })
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return w, clock, fired
+}
+
+// settle waits until the wheel goroutine is parked with n pending timers.
+func settle(t *testing.T, w *TimerWheel[int], clock *testClock) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		w.mu.Lock()
+		pending := len(w.timers)
+		w.mu.Unlock()
+		if pending == 0 || clock.Waiters() > 0 {
+			if len(w.wake) == 0 {
+				return
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timer wheel did not settle")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func expectFired(t *testing.T, fired chan int, want ...int) {
+	t.Helper()
+	for _, w := range want {
+		select {
+		case got := <-fired:
+			if got != w {
+				t.Fatalf("expected %d to fire, got %d", w, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %d", w)
+		}
+	}
+	select {
+	case got := <-fired:
+		t.Fatalf("unexpected fire %d", got)
+	case <-time.After(5 * time.Millisecond):
+	}
+}
+
+func TestTimerWheel_FiresInOrder(t *testing.T) {
+	w, clock, fired := startTimerWheel(t, 10*time.Millisecond)
+
+	w.Schedule(30*time.Millisecond, 3)
+	w.Schedule(10*time.Millisecond, 1)
+	w.Schedule(20*time.Millisecond, 2)
+	if w.Len() != 3 {
+		t.Fatalf("expected 3 pending timers, got %d", w.Len())
+	}
+
+	settle(t, w, clock)
+	clock.Advance(10 * time.Millisecond)
+	expectFired(t, fired, 1)
+
+	settle(t, w, clock)
+	clock.Advance(20 * time.Millisecond)
+	expectFired(t, fired, 2, 3)
+	if w.Len() != 0 {
+		t.Fatalf("expected no pending timers, got %d", w.Len())
+	}
+}
+
+func TestTimerWheel_BatchesWithinTick(t *testing.T) {
+	w, clock, fired := startTimerWheel(t, time.Second)
+
+	// All round up to the same one-second boundary.
+	w.Schedule(100*time.Millisecond, 1)
+	w.Schedule(500*time.Millisecond, 2)
+	w.Schedule(900*time.Millisecond, 3)
+
+	settle(t, w, clock)
+	clock.Advance(900 * time.Millisecond)
+	expectFired(t, fired)
+
+	settle(t, w, clock)
+	clock.Advance(100 * time.Millisecond)
+	expectFired(t, fired, 1, 2, 3)
+}
+
+func TestTimerWheel_Stop(t *testing.T) {
+	w, clock, fired := startTimerWheel(t, time.Millisecond)
+
+	keep := w.Schedule(5*time.Millisecond, 1)
+	drop := w.Schedule(5*time.Millisecond, 2)
+	if !drop.Stop() {
+		t.Fatal("expected Stop to cancel a pending timer")
+	}
+	if drop.Stop() {
+		t.Fatal("expected second Stop to report false")
+	}
+
+	settle(t, w, clock)
+	clock.Advance(5 * time.Millisecond)
+	expectFired(t, fired, 1)
+	if keep.Stop() {
+		t.Fatal("expected Stop after firing to report false")
+	}
+}
+
+func TestTimerWheel_EarlierTimerWakesRun(t *testing.T) {
+	w, clock, fired := startTimerWheel(t, time.Millisecond)
+
+	w.Schedule(time.Hour, 1)
+	settle(t, w, clock)
+	w.Schedule(time.Millisecond, 2)
+	settle(t, w, clock)
+
+	clock.Advance(time.Millisecond)
+	expectFired(t, fired, 2)
+}
+
+func TestTimerWheel_Drain(t *testing.T) {
+	w := NewTimerWheel(time.Millisecond, newTestClock(), func(int) { t.Error("drained timer fired") })
+	w.Schedule(20*time.Millisecond, 2)
+	stopped := w.Schedule(5*time.Millisecond, 0)
+	w.Schedule(10*time.Millisecond, 1)
+	stopped.Stop()
+	if got := w.Drain(); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("Drain = %v", got)
+	}
+	if w.Len() != 0 || len(w.Drain()) != 0 {
+		t.Fatal("expected nothing pending after Drain")
+	}
+}
+
+func BenchmarkTimerWheel_Schedule(b *testing.B) {
+	w := NewTimerWheel(time.Millisecond, nil, func(int) {})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		w.Schedule(time.Duration(i%1000)*time.Millisecond, i)
+	}
+}
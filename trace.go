@@ -0,0 +1,137 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrEnvelopeExpired is recorded against a consumer for each envelope
+// dropped because its deadline passed while it was queued.
+var ErrEnvelopeExpired = errors.New("envelope deadline expired")
+
+// Carrier moves trace context between a context.Context and string headers,
+// in the shape of OpenTelemetry's TextMapPropagator, so any tracing library
+// can be plugged in without this package depending on it.
+type Carrier interface {
+	// Inject writes the trace context of ctx into headers.
+	Inject(ctx context.Context, headers map[string]string)
+	// Extract returns ctx with the trace context from headers applied.
+	Extract(ctx context.Context, headers map[string]string) context.Context
+}
+
+// Envelope pairs a queued item with headers carrying the producer's trace
+// context across the queue hop.
+type Envelope[T any] struct {
+	Item    T                 `json:"item"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// EnqueuedAt is the wall-clock time the envelope was created.
+	EnqueuedAt time.Time `json:"enqueued_at,omitzero"`
+	// Attempts counts failed deliveries, as recorded by RetryingConsumer.
+	Attempts int `json:"attempts,omitempty"`
+	// RetryAt is when a failed envelope is due to be delivered again.
+	RetryAt time.Time `json:"retry_at,omitzero"`
+	// LastError is the error of the most recent failed delivery.
+	LastError string `json:"last_error,omitempty"`
+	// Deadline is when the producer's work is due. Consumers drop the
+	// envelope once it has passed and bound the handler's context by it.
+	// Across processes it is only as accurate as their clocks agree.
+	Deadline time.Time `json:"deadline,omitzero"`
+}
+
+// NewEnvelope wraps x with the trace context and deadline of ctx and the
+// current time.
+func NewEnvelope[T any](ctx context.Context, c Carrier, x T) Envelope[T] {
+	e := Envelope[T]{Item: x, Headers: make(map[string]string), EnqueuedAt: time.Now()}
+	e.Deadline, _ = ctx.Deadline()
+	c.Inject(ctx, e.Headers)
+	return e
+}
+
+// Expired reports whether the envelope's deadline has passed at now. An
+// envelope without a deadline never expires.
+func (e Envelope[T]) Expired(now time.Time) bool {
+	return !e.Deadline.IsZero() && !now.Before(e.Deadline)
+}
+
+// WithDeadline returns ctx bounded by the envelope's deadline, if it has
+// one.
+func (e Envelope[T]) WithDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if e.Deadline.IsZero() {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, e.Deadline)
+}
+
+// TimeInQueue returns how long ago the envelope was created, or zero if it
+// carries no timestamp. Across processes it is only as accurate as their
+// clocks agree.
+func (e Envelope[T]) TimeInQueue(now time.Time) time.Duration {
+	if e.EnqueuedAt.IsZero() {
+		return 0
+	}
+	return max(now.Sub(e.EnqueuedAt), 0)
+}
+
+// Restore returns ctx carrying the producer's trace context. Cancellation
+// and values of ctx are kept.
+func (e Envelope[T]) Restore(ctx context.Context, c Carrier) context.Context {
+	if len(e.Headers) == 0 {
+		return ctx
+	}
+	return c.Extract(ctx, e.Headers)
+}
+
+// stateMeta lets QueueState exports carry the delivery metadata.
+func (e Envelope[T]) stateMeta() (attempts int, readyAt time.Time) {
+	return e.Attempts, e.RetryAt
+}
+
+// TraceHandler adapts a handler of T to Envelopes, restoring each item's
+// trace context and deadline first. Expired envelopes are dropped and
+// counted with RecordConsumerError as ErrEnvelopeExpired. Use it with
+// ShardedQueue.Consume, QueueGroup or any other consumer of Envelope[T].
+func TraceHandler[T any](c Carrier, fn func(ctx context.Context, x T)) func(ctx context.Context, e Envelope[T]) {
+	return func(ctx context.Context, e Envelope[T]) {
+		if e.Expired(time.Now()) {
+			RecordConsumerError(ctx, ErrEnvelopeExpired)
+			return
+		}
+		ctx, cancel := e.WithDeadline(e.Restore(ctx, c))
+		defer cancel()
+		fn(ctx, e.Item)
+	}
+}
+
+// ConsumeEnvelopes calls fn for every item of q with the producer's trace
+// context and deadline restored, until ctx is done.
+func ConsumeEnvelopes[T any](ctx context.Context, q Queue[Envelope[T]], c Carrier, fn func(ctx context.Context, x T)) error {
+	h := TraceHandler(c, fn)
+	for {
+		e, err := q.Get(ctx)
+		if err != nil {
+			return err
+		}
+		h(ctx, e)
+	}
+}
+
+// ValueCarrier is a Carrier for a single string context value, such as a
+// request or correlation ID, stored under Header.
+type ValueCarrier struct {
+	Header string
+	Key    any
+}
+
+func (c ValueCarrier) Inject(ctx context.Context, headers map[string]string) {
+	if v, ok := ctx.Value(c.Key).(string); ok {
+		headers[c.Header] = v
+	}
+}
+
+func (c ValueCarrier) Extract(ctx context.Context, headers map[string]string) context.Context {
+	if v, ok := headers[c.Header]; ok {
+		return context.WithValue(ctx, c.Key, v)
+	}
+	return ctx
+}
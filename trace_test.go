@@ -0,0 +1,116 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type traceKey struct{}
+
+func TestEnvelope_PropagatesThroughQueue(t *testing.T) {
+	carrier := ValueCarrier{Header: "trace-id", Key: traceKey{}}
+	q := NewFiFo[Envelope[string]]()
+
+	producer := context.WithValue(context.Background(), traceKey{}, "abc123")
+	q.Put(producer, NewEnvelope(producer, carrier, "job"))
+	q.Put(context.Background(), NewEnvelope(context.Background(), carrier, "untraced"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var seen []string
+	err := ConsumeEnvelopes(ctx, q, carrier, func(ctx context.Context, x string) {
+		id, _ := ctx.Value(traceKey{}).(string)
+		seen = append(seen, x+"="+id)
+		if len(seen) == 2 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+	if len(seen) != 2 || seen[0] != "job=abc123" || seen[1] != "untraced=" {
+		t.Fatalf("unexpected trace contexts %v", seen)
+	}
+}
+
+func TestEnvelope_SurvivesCodec(t *testing.T) {
+	carrier := ValueCarrier{Header: "trace-id", Key: traceKey{}}
+	ctx := context.WithValue(context.Background(), traceKey{}, "xyz")
+	codec := JSONCodec[Envelope[int]]{}
+	b, err := codec.Marshal(NewEnvelope(ctx, carrier, 42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := codec.Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got string
+	TraceHandler(carrier, func(ctx context.Context, x int) {
+		got, _ = ctx.Value(traceKey{}).(string)
+	})(context.Background(), e)
+	if got != "xyz" || e.Item != 42 {
+		t.Fatalf("expected trace xyz on item 42, got %q on %d", got, e.Item)
+	}
+}
+
+func TestEnvelope_TimeInQueue(t *testing.T) {
+	e := NewEnvelope(context.Background(), ValueCarrier{Header: "x", Key: traceKey{}}, 1)
+	if e.EnqueuedAt.IsZero() {
+		t.Fatal("NewEnvelope should stamp the envelope")
+	}
+	if got := e.TimeInQueue(e.EnqueuedAt.Add(time.Second)); got != time.Second {
+		t.Fatalf("time in queue = %v", got)
+	}
+	if got := (Envelope[int]{}).TimeInQueue(time.Now()); got != 0 {
+		t.Fatalf("unstamped envelope should report zero, got %v", got)
+	}
+}
+
+func TestEnvelope_Deadline(t *testing.T) {
+	carrier := ValueCarrier{Header: "trace-id", Key: traceKey{}}
+	producer, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	e := NewEnvelope(producer, carrier, "job")
+	want, _ := producer.Deadline()
+	if !e.Deadline.Equal(want) {
+		t.Fatalf("Deadline = %v, want %v", e.Deadline, want)
+	}
+	if e.Expired(time.Now()) || !e.Expired(want) {
+		t.Fatal("Expired disagrees with Deadline")
+	}
+
+	var got time.Time
+	TraceHandler(carrier, func(ctx context.Context, _ string) {
+		got, _ = ctx.Deadline()
+	})(context.Background(), e)
+	if !got.Equal(want) {
+		t.Fatalf("handler deadline = %v, want %v", got, want)
+	}
+
+	if NewEnvelope(context.Background(), carrier, 1).Expired(time.Now().Add(time.Hour)) {
+		t.Fatal("envelope without a deadline expired")
+	}
+}
+
+func TestEnvelope_ExpiredDropped(t *testing.T) {
+	carrier := ValueCarrier{Header: "trace-id", Key: traceKey{}}
+	q := NewShardedQueue[Envelope[string]](1, func(Envelope[string]) uint64 { return 0 })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.Put(ctx, Envelope[string]{Item: "stale", Deadline: time.Now().Add(-time.Second)})
+	q.Put(ctx, Envelope[string]{Item: "fresh"})
+
+	handled := make(chan string, 2)
+	go q.Consume(ctx, TraceHandler(carrier, func(_ context.Context, x string) { handled <- x }))
+	if x := <-handled; x != "fresh" {
+		t.Fatalf("handled %q, want only fresh", x)
+	}
+	waitFor(t, func() bool {
+		s := q.ConsumerStats()
+		return len(s) == 1 && s[0].Errors == 1 && s[0].LastError == ErrEnvelopeExpired.Error()
+	})
+}
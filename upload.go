@@ -0,0 +1,261 @@
+package generic
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUploadNotFound = errors.New("upload not found")
+	ErrUploadOffset   = errors.New("upload offset mismatch")
+	ErrUploadTooLarge = errors.New("upload exceeds its declared length")
+	ErrUploadBusy     = errors.New("upload is being written")
+)
+
+// UploadSession is one resumable upload. Meta carries the caller's typed
+// description of it, such as the owner and file name.
+type UploadSession[T any] struct {
+	ID   string
+	Meta T
+	// Size is the declared length in bytes.
+	Size int64
+	// Blob is the content hash of a completed upload whose sink is a
+	// BlobWriter, set before OnComplete runs.
+	Blob Hash
+
+	mu     sync.Mutex
+	offset int64
+	sink   io.WriteCloser
+	closed bool
+}
+
+// Offset returns the number of bytes received so far.
+func (s *UploadSession[T]) Offset() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.offset
+}
+
+// abort releases the sink of an unfinished upload. Sinks with an Abort
+// method have it called instead of Close.
+func (s *UploadSession[T]) abort() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	if a, ok := s.sink.(interface{ Abort() error }); ok {
+		a.Abort()
+	} else {
+		s.sink.Close()
+	}
+}
+
+// UploadManager coordinates resumable uploads in the style of the tus
+// protocol. Each session streams its chunks, in order, into a sink opened
+// when it is created; a client that loses its connection asks for the
+// current offset and continues from there. Sessions idle for longer than
+// TTL are aborted.
+type UploadManager[T any] struct {
+	// TTL bounds how long a session may sit idle. Defaults to 24 hours.
+	TTL time.Duration
+	// OnComplete, if set, runs after the last byte is written and the sink
+	// closed. Its error is returned from the final Append.
+	OnComplete func(ctx context.Context, s *UploadSession[T]) error
+
+	open     func(ctx context.Context, meta T, size int64) (io.WriteCloser, error)
+	sessions ExpiringMap[string, *UploadSession[T]]
+}
+
+// NewUploadManager returns a manager that writes each upload to the sink
+// returned by open.
+func NewUploadManager[T any](open func(ctx context.Context, meta T, size int64) (io.WriteCloser, error)) *UploadManager[T] {
+	m := &UploadManager[T]{open: open}
+	m.sessions.OnEvict = func(_ string, s *UploadSession[T]) { s.abort() }
+	return m
+}
+
+func (m *UploadManager[T]) ttl() time.Duration {
+	if m.TTL <= 0 {
+		return 24 * time.Hour
+	}
+	return m.TTL
+}
+
+// Create starts a session for size bytes.
+func (m *UploadManager[T]) Create(ctx context.Context, meta T, size int64) (*UploadSession[T], error) {
+	if size < 0 {
+		return nil, errors.New("generic: negative upload length")
+	}
+	sink, err := m.open(ctx, meta, size)
+	if err != nil {
+		return nil, err
+	}
+	var id [16]byte
+	rand.Read(id[:])
+	s := &UploadSession[T]{ID: hex.EncodeToString(id[:]), Meta: meta, Size: size, sink: sink}
+	m.sessions.Set(s.ID, s, m.ttl())
+	return s, nil
+}
+
+// Session returns the live session with the given ID.
+func (m *UploadManager[T]) Session(id string) (*UploadSession[T], bool) {
+	return m.sessions.Load(id)
+}
+
+// Append writes the chunk read from r at offset, which must equal the
+// session's current offset, and returns the new offset. Bytes written
+// before an error, including a dropped connection, still count, so the
+// client can resume from the returned offset. Writing the final byte
+// closes the sink, runs OnComplete and ends the session.
+func (m *UploadManager[T]) Append(ctx context.Context, id string, offset int64, r io.Reader) (int64, error) {
+	s, ok := m.sessions.Load(id)
+	if !ok {
+		return 0, ErrUploadNotFound
+	}
+	if !s.mu.TryLock() {
+		return 0, ErrUploadBusy
+	}
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, ErrUploadNotFound
+	}
+	if offset != s.offset {
+		return s.offset, ErrUploadOffset
+	}
+	m.sessions.Set(id, s, m.ttl())
+
+	n, err := CopyContext(ctx, s.sink, io.LimitReader(r, s.Size-s.offset))
+	s.offset += n
+	if err == nil && s.offset == s.Size {
+		// Anything left in r doesn't fit the declared length.
+		var probe [1]byte
+		if k, _ := r.Read(probe[:]); k > 0 {
+			err = ErrUploadTooLarge
+		}
+	}
+	if err != nil || s.offset < s.Size {
+		m.sessions.Set(id, s, m.ttl())
+		return s.offset, err
+	}
+
+	s.closed = true
+	m.sessions.Delete(id)
+	if err := s.sink.Close(); err != nil {
+		return s.offset, err
+	}
+	if bw, ok := s.sink.(*BlobWriter); ok {
+		s.Blob = bw.Hash()
+	}
+	if m.OnComplete != nil {
+		return s.offset, m.OnComplete(ctx, s)
+	}
+	return s.offset, nil
+}
+
+// Abort ends a session early, releasing its sink.
+func (m *UploadManager[T]) Abort(id string) error {
+	s, ok := m.sessions.LoadAndDelete(id)
+	if !ok {
+		return ErrUploadNotFound
+	}
+	s.abort()
+	return nil
+}
+
+// RunJanitor aborts idle sessions every interval until ctx is done.
+// Without it, idle sessions are only aborted when next touched.
+func (m *UploadManager[T]) RunJanitor(ctx context.Context, interval time.Duration) error {
+	return m.sessions.RunJanitor(ctx, interval)
+}
+
+// Handler serves the manager over HTTP using the core of the tus protocol:
+// POST creates a session from the Upload-Length header and answers with
+// its Location under the request path, HEAD reports Upload-Offset, PATCH
+// appends an application/offset+octet-stream body at Upload-Offset and
+// DELETE aborts. The session ID is the last path segment. meta builds the
+// session metadata from the creating request.
+func (m *UploadManager[T]) Handler(meta func(r *http.Request) (T, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Tus-Resumable", "1.0.0")
+		if r.Method == http.MethodPost {
+			size, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+			if err != nil || size < 0 {
+				http.Error(w, "invalid Upload-Length", http.StatusBadRequest)
+				return
+			}
+			md, err := meta(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			s, err := m.Create(r.Context(), md, size)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Location", path.Join(r.URL.Path, s.ID))
+			w.Header().Set("Upload-Offset", "0")
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+
+		id := path.Base(r.URL.Path)
+		switch r.Method {
+		case http.MethodHead:
+			s, ok := m.Session(id)
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Upload-Offset", strconv.FormatInt(s.Offset(), 10))
+			w.Header().Set("Upload-Length", strconv.FormatInt(s.Size, 10))
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(http.StatusOK)
+		case http.MethodPatch:
+			if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+				http.Error(w, "expected application/offset+octet-stream", http.StatusUnsupportedMediaType)
+				return
+			}
+			offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+			if err != nil {
+				http.Error(w, "invalid Upload-Offset", http.StatusBadRequest)
+				return
+			}
+			n, err := m.Append(r.Context(), id, offset, r.Body)
+			w.Header().Set("Upload-Offset", strconv.FormatInt(n, 10))
+			switch {
+			case err == nil:
+				w.WriteHeader(http.StatusNoContent)
+			case errors.Is(err, ErrUploadNotFound):
+				http.Error(w, err.Error(), http.StatusNotFound)
+			case errors.Is(err, ErrUploadOffset):
+				http.Error(w, err.Error(), http.StatusConflict)
+			case errors.Is(err, ErrUploadBusy):
+				http.Error(w, err.Error(), http.StatusLocked)
+			case errors.Is(err, ErrUploadTooLarge):
+				http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			default:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+		case http.MethodDelete:
+			if err := m.Abort(id); err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Allow", "POST, HEAD, PATCH, DELETE")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -0,0 +1,189 @@
+package generic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type uploadMeta struct {
+	Owner string
+}
+
+type uploadSink struct {
+	bytes.Buffer
+	closed, aborted bool
+}
+
+func (s *uploadSink) Close() error { s.closed = true; return nil }
+func (s *uploadSink) Abort() error { s.aborted = true; return nil }
+
+type uploadSinks struct {
+	mu    sync.Mutex
+	sinks map[string]*uploadSink
+}
+
+func (u *uploadSinks) open(_ context.Context, meta uploadMeta, _ int64) (io.WriteCloser, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.sinks == nil {
+		u.sinks = make(map[string]*uploadSink)
+	}
+	s := &uploadSink{}
+	u.sinks[meta.Owner] = s
+	return s, nil
+}
+
+// failingReader returns its data followed by an error, like a dropped
+// connection.
+type failingReader struct {
+	data string
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.data == "" {
+		return 0, io.ErrUnexpectedEOF
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestUploadManager_Resume(t *testing.T) {
+	var sinks uploadSinks
+	var completed *UploadSession[uploadMeta]
+	m := NewUploadManager(sinks.open)
+	m.OnComplete = func(_ context.Context, s *UploadSession[uploadMeta]) error {
+		completed = s
+		return nil
+	}
+	ctx := context.Background()
+	s, err := m.Create(ctx, uploadMeta{Owner: "alice"}, 11)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := m.Append(ctx, s.ID, 0, &failingReader{data: "hello"})
+	if !errors.Is(err, io.ErrUnexpectedEOF) || n != 5 {
+		t.Fatalf("interrupted chunk should keep its bytes, got %d, %v", n, err)
+	}
+	if n, err := m.Append(ctx, s.ID, 0, strings.NewReader("hello")); !errors.Is(err, ErrUploadOffset) || n != 5 {
+		t.Fatalf("stale offset should be rejected with the current one, got %d, %v", n, err)
+	}
+	if n, err := m.Append(ctx, s.ID, 5, strings.NewReader(" world")); err != nil || n != 11 {
+		t.Fatalf("final chunk: %d, %v", n, err)
+	}
+
+	sink := sinks.sinks["alice"]
+	if sink.String() != "hello world" || !sink.closed || sink.aborted {
+		t.Fatalf("unexpected sink state %q closed=%v aborted=%v", sink.String(), sink.closed, sink.aborted)
+	}
+	if completed != s {
+		t.Fatal("OnComplete should run with the session")
+	}
+	if _, ok := m.Session(s.ID); ok {
+		t.Fatal("completed session should be removed")
+	}
+	if _, err := m.Append(ctx, s.ID, 11, strings.NewReader("")); !errors.Is(err, ErrUploadNotFound) {
+		t.Fatalf("expected ErrUploadNotFound, got %v", err)
+	}
+}
+
+func TestUploadManager_TooLarge(t *testing.T) {
+	var sinks uploadSinks
+	m := NewUploadManager(sinks.open)
+	s, _ := m.Create(context.Background(), uploadMeta{Owner: "bob"}, 3)
+	n, err := m.Append(context.Background(), s.ID, 0, strings.NewReader("abcdef"))
+	if !errors.Is(err, ErrUploadTooLarge) || n != 3 {
+		t.Fatalf("expected ErrUploadTooLarge after 3 bytes, got %d, %v", n, err)
+	}
+}
+
+func TestUploadManager_AbortAndExpiry(t *testing.T) {
+	var sinks uploadSinks
+	m := NewUploadManager(sinks.open)
+	m.TTL = 10 * time.Millisecond
+	ctx := context.Background()
+
+	a, _ := m.Create(ctx, uploadMeta{Owner: "a"}, 10)
+	if err := m.Abort(a.ID); err != nil || !sinks.sinks["a"].aborted {
+		t.Fatalf("Abort should abort the sink, %v", err)
+	}
+	if err := m.Abort(a.ID); !errors.Is(err, ErrUploadNotFound) {
+		t.Fatalf("second Abort should fail, got %v", err)
+	}
+
+	b, _ := m.Create(ctx, uploadMeta{Owner: "b"}, 10)
+	time.Sleep(20 * time.Millisecond)
+	m.sessions.Sweep()
+	if _, ok := m.Session(b.ID); ok || !sinks.sinks["b"].aborted {
+		t.Fatal("idle session should expire and abort its sink")
+	}
+}
+
+func TestUploadManager_Handler(t *testing.T) {
+	var sinks uploadSinks
+	m := NewUploadManager(sinks.open)
+	h := m.Handler(func(r *http.Request) (uploadMeta, error) {
+		return uploadMeta{Owner: r.Header.Get("X-Owner")}, nil
+	})
+	do := func(method, target string, body string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/files", "", "Upload-Length", "6", "X-Owner", "carol")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: %d", rec.Code)
+	}
+	loc := rec.Header().Get("Location")
+	if !strings.HasPrefix(loc, "/files/") {
+		t.Fatalf("unexpected Location %q", loc)
+	}
+
+	octet := "application/offset+octet-stream"
+	if rec := do(http.MethodPatch, loc, "abc", "Content-Type", octet, "Upload-Offset", "0"); rec.Code != http.StatusNoContent || rec.Header().Get("Upload-Offset") != "3" {
+		t.Fatalf("first patch: %d %q", rec.Code, rec.Header().Get("Upload-Offset"))
+	}
+	if rec := do(http.MethodPatch, loc, "abc", "Content-Type", octet, "Upload-Offset", "0"); rec.Code != http.StatusConflict {
+		t.Fatalf("stale patch should conflict, got %d", rec.Code)
+	}
+	rec = do(http.MethodHead, loc, "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Upload-Offset") != "3" || rec.Header().Get("Upload-Length") != "6" {
+		t.Fatalf("head: %d %v", rec.Code, rec.Header())
+	}
+	if rec := do(http.MethodPatch, loc, "def", "Content-Type", "text/plain", "Upload-Offset", "3"); rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("wrong content type should be rejected, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPatch, loc, "def", "Content-Type", octet, "Upload-Offset", "3"); rec.Code != http.StatusNoContent {
+		t.Fatalf("final patch: %d", rec.Code)
+	}
+	if got := sinks.sinks["carol"].String(); got != "abcdef" {
+		t.Fatalf("sink got %q", got)
+	}
+	if rec := do(http.MethodHead, loc, ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("completed upload should be gone, got %d", rec.Code)
+	}
+
+	rec = do(http.MethodPost, "/files", "", "Upload-Length", strconv.Itoa(4), "X-Owner", "dave")
+	loc = rec.Header().Get("Location")
+	if rec := do(http.MethodDelete, loc, ""); rec.Code != http.StatusNoContent || !sinks.sinks["dave"].aborted {
+		t.Fatalf("delete: %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/files", "", "Upload-Length", "x"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad length should be rejected, got %d", rec.Code)
+	}
+}
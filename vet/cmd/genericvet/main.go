@@ -0,0 +1,12 @@
+// Command genericvet runs the go-generic analyzer. Use it directly on
+// packages or through go vet:
+//
+//	go vet -vettool=$(which genericvet) ./...
+package main
+
+import (
+	"github.com/agentflare-ai/go-generic/vet"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() { singlechecker.Main(vet.Analyzer) }
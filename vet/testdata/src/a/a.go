@@ -0,0 +1,53 @@
+package a
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	generic "github.com/agentflare-ai/go-generic"
+)
+
+type appCtx struct{ context.Context }
+
+type tenantCtx interface {
+	context.Context
+	Tenant() string
+}
+
+func requests(r *http.Request, app *appCtx, ctx context.Context) {
+	bg := (*generic.RequestWithContext[*appCtx])(httptest.NewRequest("GET", "/", nil))
+	bg.Context() // want `RequestWithContext\[\*appCtx\]\.Context panics: request context is context\.Background`
+
+	ok := (*generic.RequestWithContext[*appCtx])(r.WithContext(app))
+	ok.Context()
+
+	wrong := (*generic.RequestWithContext[tenantCtx])(r.WithContext(app))
+	wrong.Context() // want `request context is \*appCtx`
+
+	req, _ := http.NewRequestWithContext(context.Background(), "GET", "/", nil)
+	(*generic.RequestWithContext[*appCtx])(req).Context() // want `request context is context\.Background`
+
+	// Unknown dynamic type: not reported.
+	(*generic.RequestWithContext[*appCtx])(r.WithContext(ctx)).Context()
+	(*generic.RequestWithContext[*appCtx])(r).Context()
+
+	// Interface C accepts the background context.
+	(*generic.RequestWithContext[context.Context])(httptest.NewRequest("GET", "/", nil)).Context()
+}
+
+func loops(q *generic.FiFo[int], ctx context.Context) {
+	for {
+		q.Get(context.Background()) // want `non-cancellable context`
+	}
+	bg := context.TODO()
+	for range 3 {
+		q.Get(bg)                         // want `non-cancellable context`
+		q.Get(context.WithoutCancel(ctx)) // want `non-cancellable context`
+		q.Get(ctx)
+		go func() {
+			q.Get(context.Background()) // not in a loop within this function
+		}()
+	}
+	q.Get(context.Background())
+}
@@ -0,0 +1,18 @@
+// Package generic is a stub of the real package for analyzer tests.
+package generic
+
+import (
+	"context"
+	"net/http"
+)
+
+type RequestWithContext[C context.Context] http.Request
+
+func (r *RequestWithContext[C]) Context() context.Context { return nil }
+
+type FiFo[T any] struct{}
+
+func (q *FiFo[T]) Get(ctx context.Context) (T, error) {
+	var zero T
+	return zero, nil
+}
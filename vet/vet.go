@@ -0,0 +1,248 @@
+// Package vet provides a go/analysis pass that catches common misuse of
+// github.com/agentflare-ai/go-generic at build time:
+//
+//   - RequestWithContext[C].Context called on a request whose context is
+//     provably not a C, which panics at run time.
+//   - FiFo.Get called in a loop with a context that can never be cancelled,
+//     which leaks the goroutine once the queue drains.
+//
+// It lives in its own module so the main package stays dependency-free.
+// Run it standalone with cmd/genericvet or via go vet -vettool.
+package vet
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const genericPath = "github.com/agentflare-ai/go-generic"
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "genericvet",
+	Doc:      "report misuse of typed request contexts and non-cancellable FiFo.Get loops",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	insp.WithStack([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		call := n.(*ast.CallExpr)
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		recv := genericNamed(pass.TypesInfo.TypeOf(sel.X))
+		if recv == nil {
+			return true
+		}
+		switch {
+		case recv.Obj().Name() == "RequestWithContext" && sel.Sel.Name == "Context":
+			checkRequestContext(pass, stack, sel, recv.TypeArgs().At(0))
+		case recv.Obj().Name() == "FiFo" && sel.Sel.Name == "Get" && len(call.Args) == 1:
+			checkFiFoGet(pass, stack, call)
+		}
+		return true
+	})
+	return nil, nil
+}
+
+// genericNamed returns the named type from this package behind t, looking
+// through one pointer, or nil.
+func genericNamed(t types.Type) *types.Named {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != genericPath {
+		return nil
+	}
+	return named
+}
+
+// checkRequestContext reports a Context call whose receiver was converted
+// from a request carrying a context that cannot be a C.
+func checkRequestContext(pass *analysis.Pass, stack []ast.Node, sel *ast.SelectorExpr, c types.Type) {
+	conv, ok := ast.Unparen(resolve(pass, stack, sel.X)).(*ast.CallExpr)
+	if !ok || len(conv.Args) != 1 || !isConversion(pass, conv) {
+		return
+	}
+	ctx, ok := requestContext(pass, stack, conv.Args[0])
+	if !ok {
+		return
+	}
+	if bad, desc := mismatch(pass, ctx, c); bad {
+		pass.Reportf(sel.Sel.Pos(), "RequestWithContext[%s].Context panics: request context is %s", types.TypeString(c, types.RelativeTo(pass.Pkg)), desc)
+	}
+}
+
+func isConversion(pass *analysis.Pass, call *ast.CallExpr) bool {
+	tv, ok := pass.TypesInfo.Types[call.Fun]
+	return ok && tv.IsType()
+}
+
+// requestContext finds the expression that set the context of the request
+// built by e. A nil expression means context.Background.
+func requestContext(pass *analysis.Pass, stack []ast.Node, e ast.Expr) (ast.Expr, bool) {
+	call, ok := ast.Unparen(resolve(pass, stack, e)).(*ast.CallExpr)
+	if !ok {
+		return nil, false
+	}
+	fn, _ := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if fn == nil || fn.Pkg() == nil {
+		return nil, false
+	}
+	switch fn.Pkg().Path() + "." + fn.Name() {
+	case "net/http.NewRequest", "net/http/httptest.NewRequest":
+		return nil, true
+	case "net/http.NewRequestWithContext":
+		return call.Args[0], true
+	case "net/http.WithContext", "net/http.Clone":
+		if fn.Type().(*types.Signature).Recv() != nil {
+			return call.Args[0], true
+		}
+	}
+	return nil, false
+}
+
+// mismatch reports whether ctx, or context.Background when nil, provably
+// holds something other than a c, with a description of what it holds.
+func mismatch(pass *analysis.Pass, ctx ast.Expr, c types.Type) (bool, string) {
+	if ctx == nil || isCall(pass, ctx, "context", "Background", "TODO") {
+		// The background context's type is unexported, so only an
+		// interface C can match it.
+		return !types.IsInterface(c), "context.Background"
+	}
+	t := pass.TypesInfo.TypeOf(ctx)
+	if t == nil || types.IsInterface(t) {
+		return false, ""
+	}
+	desc := types.TypeString(t, types.RelativeTo(pass.Pkg))
+	if iface, ok := c.Underlying().(*types.Interface); ok {
+		return !types.Implements(t, iface), desc
+	}
+	return !types.Identical(t, c), desc
+}
+
+// checkFiFoGet reports FiFo.Get inside a loop when its context can never be
+// cancelled.
+func checkFiFoGet(pass *analysis.Pass, stack []ast.Node, call *ast.CallExpr) {
+	if !inLoop(stack) {
+		return
+	}
+	ctx := ast.Unparen(resolve(pass, stack, call.Args[0]))
+	if isCall(pass, ctx, "context", "Background", "TODO", "WithoutCancel") {
+		pass.Reportf(call.Pos(), "FiFo.Get in a loop with a non-cancellable context blocks forever once the queue drains")
+	}
+}
+
+// inLoop reports whether the innermost function in stack contains the node
+// inside a for or range statement.
+func inLoop(stack []ast.Node) bool {
+	for i := len(stack) - 1; i >= 0; i-- {
+		switch stack[i].(type) {
+		case *ast.ForStmt, *ast.RangeStmt:
+			return true
+		case *ast.FuncLit, *ast.FuncDecl:
+			return false
+		}
+	}
+	return false
+}
+
+func isCall(pass *analysis.Pass, e ast.Expr, pkg string, names ...string) bool {
+	call, ok := ast.Unparen(e).(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	fn, _ := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != pkg {
+		return false
+	}
+	for _, name := range names {
+		if fn.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve follows a local variable to the expression it was assigned, as
+// long as it is assigned exactly once in the enclosing function. A variable
+// set from a multi-value call resolves to the call. Otherwise e is returned
+// unchanged.
+func resolve(pass *analysis.Pass, stack []ast.Node, e ast.Expr) ast.Expr {
+	id, ok := ast.Unparen(e).(*ast.Ident)
+	if !ok {
+		return e
+	}
+	v, ok := pass.TypesInfo.Uses[id].(*types.Var)
+	if !ok {
+		return e
+	}
+	var body *ast.BlockStmt
+	for i := len(stack) - 1; i >= 0 && body == nil; i-- {
+		switch fn := stack[i].(type) {
+		case *ast.FuncLit:
+			body = fn.Body
+		case *ast.FuncDecl:
+			body = fn.Body
+		}
+	}
+	if body == nil || v.Pos() < body.Pos() || v.Pos() >= body.End() {
+		return e
+	}
+
+	var value ast.Expr
+	assigns := 0
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range n.Lhs {
+				if lid, ok := lhs.(*ast.Ident); ok && objectOf(pass, lid) == v {
+					assigns++
+					switch {
+					case len(n.Lhs) == len(n.Rhs):
+						value = n.Rhs[i]
+					case len(n.Rhs) == 1:
+						value = n.Rhs[0] // multi-value call
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				if objectOf(pass, name) == v {
+					assigns++
+					if len(n.Names) == len(n.Values) {
+						value = n.Values[i]
+					}
+				}
+			}
+		case *ast.UnaryExpr:
+			// Taking the address lets the variable change behind our back.
+			if id, ok := n.X.(*ast.Ident); ok && objectOf(pass, id) == v {
+				assigns += 2
+			}
+		}
+		return true
+	})
+	if assigns != 1 || value == nil {
+		return e
+	}
+	return value
+}
+
+func objectOf(pass *analysis.Pass, id *ast.Ident) types.Object {
+	if obj := pass.TypesInfo.Defs[id]; obj != nil {
+		return obj
+	}
+	return pass.TypesInfo.Uses[id]
+}
@@ -0,0 +1,56 @@
+package generic
+
+import (
+	"context"
+	"runtime"
+)
+
+// WaitStrategy controls how Get waits on an empty FiFo. Spinning and
+// yielding trade CPU for lower wakeup latency; both fall back to parking
+// once their budget is spent, so an idle consumer does not burn a core.
+type WaitStrategy int
+
+const (
+	// WaitPark blocks on the queue's channel right away. It is the default.
+	WaitPark WaitStrategy = iota
+	// WaitYield polls the queue, calling runtime.Gosched between attempts.
+	WaitYield
+	// WaitSpin busy-polls the queue without yielding the processor.
+	WaitSpin
+)
+
+// defaultWaitSpins is the poll budget used when WithWaitStrategy is given a
+// non-positive count.
+const defaultWaitSpins = 128
+
+// WithWaitStrategy sets how Get waits for an item. Under WaitSpin and
+// WaitYield, Get polls up to spins times before parking; a non-positive
+// spins selects a small default.
+func WithWaitStrategy(strategy WaitStrategy, spins int) FiFoOption {
+	return func(o *fifoOptions) {
+		o.wait = strategy
+		o.spins = spins
+	}
+}
+
+// spinGet polls for an item according to the queue's wait strategy,
+// reporting false if none arrived within the budget or ctx is done.
+func (q *FiFo[T]) spinGet(ctx context.Context) (T, bool) {
+	for range q.spins {
+		select {
+		case <-q.items:
+			return q.popLocked(), true
+		case <-q.full:
+			return q.popLocked(), true
+		case <-ctx.Done():
+			var zero T
+			return zero, false
+		default:
+		}
+		if q.wait == WaitYield {
+			runtime.Gosched()
+		}
+	}
+	var zero T
+	return zero, false
+}
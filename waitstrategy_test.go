@@ -0,0 +1,92 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitStrategy_Get(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		strategy WaitStrategy
+	}{
+		{"park", WaitPark},
+		{"yield", WaitYield},
+		{"spin", WaitSpin},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			q := NewFiFo[int](WithWaitStrategy(tc.strategy, 4))
+
+			// Item already present.
+			q.Put(context.Background(), 1)
+			if x, err := q.Get(context.Background()); err != nil || x != 1 {
+				t.Fatalf("expected 1, got %d, %v", x, err)
+			}
+
+			// Item arrives after the spin budget is spent.
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				q.Put(context.Background(), 2)
+			}()
+			if x, err := q.Get(context.Background()); err != nil || x != 2 {
+				t.Fatalf("expected 2, got %d, %v", x, err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			if _, err := q.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("expected deadline exceeded, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWaitStrategy_Options(t *testing.T) {
+	if q := NewFiFo[int](); q.spins != 0 {
+		t.Fatalf("default queue should not spin, got %d", q.spins)
+	}
+	if q := NewFiFo[int](WithWaitStrategy(WaitSpin, 0)); q.spins != defaultWaitSpins {
+		t.Fatalf("expected default spins %d, got %d", defaultWaitSpins, q.spins)
+	}
+	if q := NewFiFo[int](WithWaitStrategy(WaitPark, 50)); q.spins != 0 {
+		t.Fatalf("WaitPark should ignore spins, got %d", q.spins)
+	}
+}
+
+func TestWaitStrategy_CancelledWhileSpinning(t *testing.T) {
+	q := NewFiFo[int](WithWaitStrategy(WaitSpin, 1<<30))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := q.Get(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+}
+
+// benchmarkPingPong measures a round trip between two goroutines through a
+// pair of queues, which is dominated by consumer wakeup latency.
+func benchmarkPingPong(b *testing.B, opts ...FiFoOption) {
+	ping, pong := NewFiFo[int](opts...), NewFiFo[int](opts...)
+	ctx := context.Background()
+	go func() {
+		for {
+			x, err := ping.Get(ctx)
+			if err != nil || x < 0 {
+				return
+			}
+			pong.Put(ctx, x)
+		}
+	}()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ping.Put(ctx, i)
+		pong.Get(ctx)
+	}
+	b.StopTimer()
+	ping.Put(ctx, -1)
+}
+
+func BenchmarkWaitStrategy_Park(b *testing.B)  { benchmarkPingPong(b) }
+func BenchmarkWaitStrategy_Yield(b *testing.B) { benchmarkPingPong(b, WithWaitStrategy(WaitYield, 0)) }
+func BenchmarkWaitStrategy_Spin(b *testing.B)  { benchmarkPingPong(b, WithWaitStrategy(WaitSpin, 0)) }
@@ -0,0 +1,177 @@
+package generic
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// WakeupOrder selects which blocked Get caller receives the next item when a
+// FiFo is empty.
+type WakeupOrder int
+
+const (
+	// WakeupAny lets the runtime pick a waiter. It is the cheapest policy and
+	// the default.
+	WakeupAny WakeupOrder = iota
+	// WakeupFIFO serves waiters in order of arrival.
+	WakeupFIFO
+	// WakeupDeadlineFirst serves the waiter whose context deadline is
+	// earliest. Waiters without a deadline are served last, in arrival order.
+	WakeupDeadlineFirst
+)
+
+// FiFoOption configures NewFiFo.
+type FiFoOption = Option[fifoOptions]
+
+type fifoOptions struct {
+	wakeup   WakeupOrder
+	capacity int
+	wait     WaitStrategy
+	spins    int
+	bound    int
+	policy   OverflowPolicy
+}
+
+// WithWakeupOrder sets the order in which blocked Get callers are woken.
+func WithWakeupOrder(order WakeupOrder) FiFoOption {
+	return func(o *fifoOptions) { o.wakeup = order }
+}
+
+// WithInitialCapacity preallocates room for n items so a queue that stays
+// below that size never allocates.
+func WithInitialCapacity(n int) FiFoOption {
+	return func(o *fifoOptions) { o.capacity = n }
+}
+
+type fifoWaiter[T any] struct {
+	ch    chan T            // cap=1; receives the handed-off item
+	key   MonotonicDeadline // zero orders by arrival
+	seq   uint64
+	index int
+}
+
+// fifoWaiters is a heap of blocked Get callers. An item is handed directly to
+// the head waiter instead of being appended, so whenever waiters exist the
+// queue itself is empty.
+type fifoWaiters[T any] struct {
+	mu       sync.Mutex
+	order    WakeupOrder
+	seq      uint64
+	waitList []*fifoWaiter[T]
+}
+
+func (w *fifoWaiters[T]) Len() int { return len(w.waitList) }
+
+func (w *fifoWaiters[T]) Less(i, j int) bool {
+	a, b := w.waitList[i], w.waitList[j]
+	if a.key != b.key {
+		return a.key.Before(b.key)
+	}
+	return a.seq < b.seq
+}
+
+func (w *fifoWaiters[T]) Swap(i, j int) {
+	w.waitList[i], w.waitList[j] = w.waitList[j], w.waitList[i]
+	w.waitList[i].index = i
+	w.waitList[j].index = j
+}
+
+func (w *fifoWaiters[T]) Push(x any) {
+	wt := x.(*fifoWaiter[T])
+	wt.index = len(w.waitList)
+	w.waitList = append(w.waitList, wt)
+}
+
+func (w *fifoWaiters[T]) Pop() any {
+	n := len(w.waitList)
+	wt := w.waitList[n-1]
+	w.waitList[n-1] = nil
+	w.waitList = w.waitList[:n-1]
+	wt.index = -1
+	return wt
+}
+
+// register adds a waiter for ctx. The caller must hold the empty token.
+func (w *fifoWaiters[T]) register(ctx context.Context) *fifoWaiter[T] {
+	wt := &fifoWaiter[T]{ch: make(chan T, 1)}
+	if w.order == WakeupDeadlineFirst {
+		wt.key, _ = DeadlineFromContext(ctx)
+	}
+	w.mu.Lock()
+	w.seq++
+	wt.seq = w.seq
+	heap.Push(w, wt)
+	w.mu.Unlock()
+	return wt
+}
+
+// cancel removes wt, reporting false if an item was already handed to it.
+func (w *fifoWaiters[T]) cancel(wt *fifoWaiter[T]) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if wt.index < 0 {
+		return false
+	}
+	heap.Remove(w, wt.index)
+	return true
+}
+
+// handoff gives x to the head waiter, reporting false if there is none. The
+// caller must hold the empty token.
+func (w *fifoWaiters[T]) handoff(x T) bool {
+	w.mu.Lock()
+	if len(w.waitList) == 0 {
+		w.mu.Unlock()
+		return false
+	}
+	wt := heap.Pop(w).(*fifoWaiter[T])
+	w.mu.Unlock()
+	wt.ch <- x
+	return true
+}
+
+// getOrdered is Get for queues with an explicit wakeup order. Callers that
+// find the queue empty queue up as waiters while holding the empty token.
+func (q *FiFo[T]) getOrdered(ctx context.Context) (T, error) {
+	var zero T
+	select {
+	case <-q.items:
+		return q.popLocked(), nil
+	case <-q.full:
+		return q.popLocked(), nil
+	case <-q.empty:
+		if q.closing() {
+			q.empty <- struct{}{}
+			return zero, ErrClosed
+		}
+	case <-q.closed:
+		return q.getClosed()
+	case <-ctx.Done():
+		select {
+		case <-q.items:
+			return q.popLocked(), nil
+		case <-q.full:
+			return q.popLocked(), nil
+		default:
+			return zero, ctx.Err()
+		}
+	}
+	wt := q.waiters.register(ctx)
+	q.empty <- struct{}{}
+	select {
+	case x := <-wt.ch:
+		return x, nil
+	case <-q.closed:
+		if q.waiters.cancel(wt) {
+			return q.getClosed()
+		}
+		return <-wt.ch, nil
+	case <-ctx.Done():
+		if q.waiters.cancel(wt) {
+			return zero, ctx.Err()
+		}
+		// An item was handed over while we were cancelling; keep it.
+		return <-wt.ch, nil
+	}
+}
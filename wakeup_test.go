@@ -0,0 +1,136 @@
+package generic
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// waitForWaiters blocks until q has n registered waiters.
+func waitForWaiters[T any](t *testing.T, q *FiFo[T], n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		q.waiters.mu.Lock()
+		got := q.waiters.Len()
+		q.waiters.mu.Unlock()
+		if got == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d waiters", n)
+}
+
+type wakeResult struct {
+	waiter int
+	item   int
+}
+
+func TestFiFo_WakeupFIFO(t *testing.T) {
+	q := NewFiFo[int](WithWakeupOrder(WakeupFIFO))
+	results := make(chan wakeResult, 3)
+
+	for i := range 3 {
+		go func() {
+			x, err := q.Get(context.Background())
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			results <- wakeResult{waiter: i, item: x}
+		}()
+		waitForWaiters(t, q, i+1)
+	}
+
+	for i := range 3 {
+		if err := q.Put(context.Background(), i*10); err != nil {
+			t.Fatalf("put failed: %v", err)
+		}
+		r := <-results
+		if r.waiter != i || r.item != i*10 {
+			t.Fatalf("expected waiter %d to get %d, got %+v", i, i*10, r)
+		}
+	}
+}
+
+func TestFiFo_WakeupDeadlineFirst(t *testing.T) {
+	q := NewFiFo[int](WithWakeupOrder(WakeupDeadlineFirst))
+	results := make(chan wakeResult, 4)
+
+	// Waiter 0 has no deadline, the others are registered latest-deadline first.
+	timeouts := []time.Duration{0, 30 * time.Second, 10 * time.Second, 20 * time.Second}
+	for i, d := range timeouts {
+		go func() {
+			ctx := context.Background()
+			if d > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, d)
+				defer cancel()
+			}
+			x, err := q.Get(ctx)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			results <- wakeResult{waiter: i, item: x}
+		}()
+		waitForWaiters(t, q, i+1)
+	}
+
+	want := []int{2, 3, 1, 0}
+	for i, w := range want {
+		if !q.TryPut(i) {
+			t.Fatalf("TryPut failed")
+		}
+		r := <-results
+		if r.waiter != w {
+			t.Fatalf("item %d: expected waiter %d, got waiter %d", i, w, r.waiter)
+		}
+	}
+}
+
+func TestFiFo_WakeupCancelledWaiterIsSkipped(t *testing.T) {
+	q := NewFiFo[int](WithWakeupOrder(WakeupFIFO))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := q.Get(ctx)
+		errs <- err
+	}()
+	waitForWaiters(t, q, 1)
+
+	got := make(chan int, 1)
+	go func() {
+		x, _ := q.Get(context.Background())
+		got <- x
+	}()
+	waitForWaiters(t, q, 2)
+
+	cancel()
+	if err := <-errs; err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	waitForWaiters(t, q, 1)
+
+	q.Put(context.Background(), 42)
+	if x := <-got; x != 42 {
+		t.Fatalf("expected remaining waiter to get 42, got %d", x)
+	}
+	if !q.IsEmpty() {
+		t.Errorf("expected queue to be empty after handoff")
+	}
+}
+
+func TestFiFo_WakeupBufferedItemsStillFIFO(t *testing.T) {
+	q := NewFiFo[int](WithWakeupOrder(WakeupFIFO))
+	ctx := context.Background()
+	for i := range 5 {
+		q.Put(ctx, i)
+	}
+	for i := range 5 {
+		x, err := q.Get(ctx)
+		if err != nil || x != i {
+			t.Fatalf("expected %d, got %d (err %v)", i, x, err)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package generic
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AlertKind says which check raised a WatchdogAlert.
+type AlertKind int
+
+const (
+	// AlertHeartbeat fires when a worker hasn't called Beat within its
+	// threshold.
+	AlertHeartbeat AlertKind = iota
+	// AlertAge fires when a watched age, such as the age of a queue's head,
+	// exceeds its threshold.
+	AlertAge
+	// AlertLatency fires when an observed latency, such as a pool acquire,
+	// exceeds its threshold.
+	AlertLatency
+)
+
+func (k AlertKind) String() string {
+	switch k {
+	case AlertHeartbeat:
+		return "heartbeat"
+	case AlertAge:
+		return "age"
+	case AlertLatency:
+		return "latency"
+	}
+	return "unknown"
+}
+
+// WatchdogAlert reports a check crossing its threshold, or recovering.
+type WatchdogAlert struct {
+	Kind      AlertKind
+	Name      string
+	Value     time.Duration
+	Threshold time.Duration
+	// Recovered is set on the alert sent when a firing check comes back
+	// under its threshold.
+	Recovered bool
+}
+
+// Watchdog detects silent stalls: workers that stop heartbeating, queues
+// whose oldest item keeps aging, and pools that take too long to hand out
+// resources. Checks run on Check or Run; each check alerts once when it
+// crosses its threshold and once more when it recovers.
+type Watchdog struct {
+	OnAlert func(WatchdogAlert)
+	// Logger records alerts when OnAlert is nil: crossings as warnings and
+	// recoveries as info. Defaults to DefaultLogger.
+	Logger Logger
+	// Clock supplies the current time. Defaults to SystemClock.
+	Clock Clock
+
+	mu       sync.Mutex
+	start    time.Time
+	checks   []*watchCheck
+	checking sync.Mutex // serializes Check, which owns watchCheck.firing
+}
+
+type watchCheck struct {
+	kind      AlertKind
+	name      string
+	threshold time.Duration
+	value     func(since time.Duration) time.Duration
+	firing    bool
+}
+
+func (w *Watchdog) clock() Clock {
+	if w.Clock == nil {
+		return SystemClock
+	}
+	return w.Clock
+}
+
+// since returns the time since the watchdog's first use, used as a compact
+// monotonic timestamp.
+func (w *Watchdog) since() time.Duration {
+	now := w.clock().Now()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.start.IsZero() {
+		w.start = now
+	}
+	return now.Sub(w.start)
+}
+
+func (w *Watchdog) add(c *watchCheck) {
+	w.mu.Lock()
+	w.checks = append(w.checks, c)
+	w.mu.Unlock()
+}
+
+// Heartbeat is a liveness signal from one worker.
+type Heartbeat struct {
+	w    *Watchdog
+	last atomic.Int64 // Watchdog.since at the last Beat
+}
+
+// Beat records that the worker is making progress.
+func (h *Heartbeat) Beat() {
+	h.last.Store(int64(h.w.since()))
+}
+
+// Heartbeat registers a worker that must Beat at least every threshold.
+// The returned Heartbeat starts out fresh.
+func (w *Watchdog) Heartbeat(name string, threshold time.Duration) *Heartbeat {
+	h := &Heartbeat{w: w}
+	h.Beat()
+	w.add(&watchCheck{kind: AlertHeartbeat, name: name, threshold: threshold, value: func(since time.Duration) time.Duration {
+		return since - time.Duration(h.last.Load())
+	}})
+	return h
+}
+
+// WatchAge alerts when age exceeds threshold. Pass a function reporting
+// how long the oldest item of a queue has been waiting, returning zero
+// when it is empty.
+func (w *Watchdog) WatchAge(name string, threshold time.Duration, age func() time.Duration) {
+	w.add(&watchCheck{kind: AlertAge, name: name, threshold: threshold, value: func(time.Duration) time.Duration {
+		return age()
+	}})
+}
+
+// LatencyProbe collects latency samples for a Watchdog. Each check looks at
+// the worst sample since the previous one.
+type LatencyProbe struct {
+	worst atomic.Int64
+}
+
+// Observe records one latency sample.
+func (p *LatencyProbe) Observe(d time.Duration) {
+	for {
+		old := p.worst.Load()
+		if int64(d) <= old || p.worst.CompareAndSwap(old, int64(d)) {
+			return
+		}
+	}
+}
+
+// WatchLatency returns a probe that alerts when a sample observed between
+// two checks exceeds threshold.
+func (w *Watchdog) WatchLatency(name string, threshold time.Duration) *LatencyProbe {
+	p := &LatencyProbe{}
+	w.add(&watchCheck{kind: AlertLatency, name: name, threshold: threshold, value: func(time.Duration) time.Duration {
+		return time.Duration(p.worst.Swap(0))
+	}})
+	return p
+}
+
+type watchedPool[T any] struct {
+	Pool[T]
+	clock Clock
+	probe *LatencyProbe
+}
+
+func (p watchedPool[T]) Get() T {
+	start := p.clock.Now()
+	x := p.Pool.Get()
+	p.probe.Observe(p.clock.Now().Sub(start))
+	return x
+}
+
+// WatchPool wraps p so slow Gets raise latency alerts.
+func WatchPool[T any](w *Watchdog, name string, p Pool[T], threshold time.Duration) Pool[T] {
+	return watchedPool[T]{Pool: p, clock: w.clock(), probe: w.WatchLatency(name, threshold)}
+}
+
+// Check evaluates every check once, sending alerts for those that crossed
+// or came back under their thresholds.
+func (w *Watchdog) Check() {
+	w.checking.Lock()
+	defer w.checking.Unlock()
+	since := w.since()
+	w.mu.Lock()
+	checks := append([]*watchCheck(nil), w.checks...)
+	w.mu.Unlock()
+	for _, c := range checks {
+		v := c.value(since)
+		firing := v > c.threshold
+		if firing == c.firing {
+			continue
+		}
+		c.firing = firing
+		a := WatchdogAlert{Kind: c.kind, Name: c.name, Value: v, Threshold: c.threshold, Recovered: !firing}
+		if w.OnAlert != nil {
+			w.OnAlert(a)
+			continue
+		}
+		attrs := []slog.Attr{
+			slog.String("check", a.Kind.String()),
+			slog.String("name", a.Name),
+			slog.Duration("value", a.Value),
+			slog.Duration("threshold", a.Threshold),
+		}
+		if firing {
+			loggerOrDefault(w.Logger).Warn(context.Background(), "generic: watchdog threshold exceeded", attrs...)
+		} else {
+			loggerOrDefault(w.Logger).Info(context.Background(), "generic: watchdog check recovered", attrs...)
+		}
+	}
+}
+
+// Run calls Check every interval until ctx is done.
+func (w *Watchdog) Run(ctx context.Context, interval time.Duration) error {
+	clock := w.clock()
+	for {
+		select {
+		case <-clock.After(interval):
+			w.Check()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
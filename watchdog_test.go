@@ -0,0 +1,106 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type sleepyPool struct {
+	clock *testClock
+	delay time.Duration
+}
+
+func (p *sleepyPool) Get() int {
+	p.clock.Advance(p.delay)
+	return 1
+}
+
+func (p *sleepyPool) Put(int) {}
+
+func TestWatchdog_Heartbeat(t *testing.T) {
+	clock := newTestClock()
+	var alerts []WatchdogAlert
+	w := &Watchdog{Clock: clock, OnAlert: func(a WatchdogAlert) { alerts = append(alerts, a) }}
+	hb := w.Heartbeat("worker-1", time.Second)
+
+	clock.Advance(500 * time.Millisecond)
+	w.Check()
+	if len(alerts) != 0 {
+		t.Fatalf("fresh heartbeat should not alert, got %v", alerts)
+	}
+	clock.Advance(time.Second)
+	w.Check()
+	w.Check()
+	if len(alerts) != 1 || alerts[0].Kind != AlertHeartbeat || alerts[0].Name != "worker-1" || alerts[0].Recovered {
+		t.Fatalf("expected one heartbeat alert, got %+v", alerts)
+	}
+	if alerts[0].Value != 1500*time.Millisecond {
+		t.Fatalf("expected value 1.5s, got %v", alerts[0].Value)
+	}
+	hb.Beat()
+	w.Check()
+	if len(alerts) != 2 || !alerts[1].Recovered {
+		t.Fatalf("expected recovery alert, got %+v", alerts)
+	}
+}
+
+func TestWatchdog_AgeAndPool(t *testing.T) {
+	clock := newTestClock()
+	var alerts []WatchdogAlert
+	w := &Watchdog{Clock: clock, OnAlert: func(a WatchdogAlert) { alerts = append(alerts, a) }}
+	var age time.Duration
+	w.WatchAge("orders", time.Minute, func() time.Duration { return age })
+	pool := &sleepyPool{clock: clock, delay: 10 * time.Millisecond}
+	p := WatchPool[int](w, "conns", pool, 50*time.Millisecond)
+
+	p.Get()
+	w.Check()
+	if len(alerts) != 0 {
+		t.Fatalf("unexpected alerts %+v", alerts)
+	}
+	age = 2 * time.Minute
+	pool.delay = 100 * time.Millisecond
+	p.Get()
+	w.Check()
+	if len(alerts) != 2 || alerts[0].Kind != AlertAge || alerts[1].Kind != AlertLatency {
+		t.Fatalf("expected age and latency alerts, got %+v", alerts)
+	}
+	if alerts[1].Value != 100*time.Millisecond {
+		t.Fatalf("latency alert should carry the worst sample, got %v", alerts[1].Value)
+	}
+	// No acquires since the last check counts as healthy.
+	age = 0
+	w.Check()
+	if len(alerts) != 4 || !alerts[2].Recovered || !alerts[3].Recovered {
+		t.Fatalf("expected recoveries, got %+v", alerts)
+	}
+}
+
+func TestWatchdog_Run(t *testing.T) {
+	clock := newTestClock()
+	fired := make(chan WatchdogAlert, 1)
+	w := &Watchdog{Clock: clock, OnAlert: func(a WatchdogAlert) { fired <- a }}
+	w.Heartbeat("stalled", time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx, 2*time.Second) }()
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(2 * time.Second)
+	select {
+	case a := <-fired:
+		if a.Name != "stalled" || a.Kind.String() != "heartbeat" {
+			t.Fatalf("unexpected alert %+v", a)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run did not check")
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package generic
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"weak"
+)
+
+// Weak is a weak reference to a T. It does not keep its target alive; once
+// the target is collected Value returns nil.
+type Weak[T any] struct {
+	p weak.Pointer[T]
+}
+
+func MakeWeak[T any](p *T) Weak[T] {
+	return Weak[T]{p: weak.Make(p)}
+}
+
+// Value returns the target, or nil if it was collected or never set.
+func (w Weak[T]) Value() *T {
+	return w.p.Value()
+}
+
+// WeakCache is a read-through cache holding its values only weakly, so the
+// garbage collector may reclaim entries nobody else references and the cache
+// never prevents memory from being freed. A reclaimed entry is reloaded on
+// the next Get. Map slots for reclaimed values are removed automatically.
+type WeakCache[K comparable, V any] struct {
+	load    func(ctx context.Context, key K) (*V, error)
+	mu      sync.Mutex
+	entries map[K]weak.Pointer[V]
+}
+
+// NewWeakCache returns a cache that calls load for keys it doesn't hold.
+func NewWeakCache[K comparable, V any](load func(ctx context.Context, key K) (*V, error)) *WeakCache[K, V] {
+	return &WeakCache[K, V]{load: load, entries: make(map[K]weak.Pointer[V])}
+}
+
+// Get returns the cached value for key, loading it if it is absent or was
+// collected. Concurrent loads of the same key may both run; the first to
+// finish wins and the others receive its value.
+func (c *WeakCache[K, V]) Get(ctx context.Context, key K) (*V, error) {
+	if v := c.Peek(key); v != nil {
+		return v, nil
+	}
+	v, err := c.load(ctx, key)
+	if err != nil || v == nil {
+		return v, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if existing := c.entries[key].Value(); existing != nil {
+		return existing, nil
+	}
+	wp := weak.Make(v)
+	c.entries[key] = wp
+	runtime.AddCleanup(v, c.remove, weakCacheSlot[K, V]{key: key, wp: wp})
+	return v, nil
+}
+
+type weakCacheSlot[K comparable, V any] struct {
+	key K
+	wp  weak.Pointer[V]
+}
+
+// remove drops a collected entry unless the key was reloaded since.
+func (c *WeakCache[K, V]) remove(s weakCacheSlot[K, V]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries[s.key] == s.wp {
+		delete(c.entries, s.key)
+	}
+}
+
+// Peek returns the cached value for key without loading it.
+func (c *WeakCache[K, V]) Peek(key K) *V {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[key].Value()
+}
+
+// Delete forgets key. Holders of the value are unaffected.
+func (c *WeakCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// Len returns the number of map slots, which may include values collected
+// but not yet cleaned up.
+func (c *WeakCache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
@@ -0,0 +1,96 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+type weakPayload struct {
+	data [1024]byte
+	id   int
+}
+
+func TestWeak(t *testing.T) {
+	var zero Weak[int]
+	if zero.Value() != nil {
+		t.Fatal("expected zero Weak to be nil")
+	}
+
+	p := &weakPayload{id: 1}
+	w := MakeWeak(p)
+	if got := w.Value(); got != p {
+		t.Fatalf("expected live target, got %p", got)
+	}
+	runtime.KeepAlive(p)
+
+	w = MakeWeak(&weakPayload{id: 2})
+	for range 10 {
+		runtime.GC()
+		if w.Value() == nil {
+			return
+		}
+	}
+	t.Fatal("expected unreferenced target to be collected")
+}
+
+func TestWeakCache_LoadsAndCaches(t *testing.T) {
+	loads := 0
+	c := NewWeakCache(func(_ context.Context, id int) (*weakPayload, error) {
+		loads++
+		return &weakPayload{id: id}, nil
+	})
+	ctx := context.Background()
+
+	a, err := c.Get(ctx, 7)
+	if err != nil || a.id != 7 {
+		t.Fatalf("unexpected result %+v (err %v)", a, err)
+	}
+	b, _ := c.Get(ctx, 7)
+	if a != b || loads != 1 {
+		t.Fatalf("expected cached value, loads=%d", loads)
+	}
+	if c.Peek(7) != a {
+		t.Fatal("expected Peek to return cached value")
+	}
+	runtime.KeepAlive(a)
+}
+
+func TestWeakCache_ReloadsAfterCollection(t *testing.T) {
+	loads := 0
+	c := NewWeakCache(func(_ context.Context, id int) (*weakPayload, error) {
+		loads++
+		return &weakPayload{id: id}, nil
+	})
+	ctx := context.Background()
+
+	if _, err := c.Get(ctx, 1); err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for c.Len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("collected entry was not cleaned up")
+		}
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+
+	v, err := c.Get(ctx, 1)
+	if err != nil || v.id != 1 || loads != 2 {
+		t.Fatalf("expected reload, got %+v loads=%d err=%v", v, loads, err)
+	}
+}
+
+func TestWeakCache_LoadError(t *testing.T) {
+	boom := errors.New("boom")
+	c := NewWeakCache(func(context.Context, string) (*int, error) { return nil, boom })
+	if _, err := c.Get(context.Background(), "x"); err != boom {
+		t.Fatalf("expected boom, got %v", err)
+	}
+	if c.Len() != 0 {
+		t.Fatal("failed load should not be cached")
+	}
+}
@@ -0,0 +1,174 @@
+package generic
+
+import (
+	"context"
+	"hash/maphash"
+	"sync"
+)
+
+// Future is the result of a job submitted to a WorkerPool. Done is closed
+// once it is available.
+type Future[T any] struct {
+	done chan struct{}
+	v    T
+	err  error
+}
+
+func (f *Future[T]) resolve(v T, err error) {
+	f.v, f.err = v, err
+	close(f.done)
+}
+
+// Done returns a channel closed once the result is available.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait returns the job's result, waiting for it until ctx is done.
+func (f *Future[T]) Wait(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.v, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// WorkerPool runs jobs on a fixed number of worker goroutines, handing each
+// submitter a Future for its result. By default any idle worker takes the
+// next job; with WithAffinity every job of a key runs on the same worker,
+// in the order submitted, so workers can keep per-key state.
+type WorkerPool[In, Out any] struct {
+	fn       func(ctx context.Context, x In) (Out, error)
+	workers  int
+	affinity func(In) uint64
+	// queues has one FiFo per worker with affinity, else one shared FiFo.
+	queues []*FiFo[workerJob[In, Out]]
+}
+
+type workerJob[In, Out any] struct {
+	ctx context.Context
+	x   In
+	f   *Future[Out]
+}
+
+// WorkerPoolOption configures NewWorkerPool.
+type WorkerPoolOption[In any] = Option[workerPoolOptions[In]]
+
+type workerPoolOptions[In any] struct {
+	affinity func(In) uint64
+}
+
+// WithAffinity pins the jobs of each key to one worker. Keys are spread
+// over the workers by hash, so a worker serves many keys.
+func WithAffinity[In any, K comparable](key func(In) K) WorkerPoolOption[In] {
+	seed := maphash.MakeSeed()
+	return func(o *workerPoolOptions[In]) {
+		o.affinity = func(x In) uint64 { return maphash.Comparable(seed, key(x)) }
+	}
+}
+
+// NewWorkerPool returns a pool of workers calling fn. Call Run to start
+// them; jobs submitted before then wait in the queue.
+func NewWorkerPool[In, Out any](workers int, fn func(ctx context.Context, x In) (Out, error), opts ...WorkerPoolOption[In]) *WorkerPool[In, Out] {
+	if workers < 1 {
+		panic("generic: WorkerPool needs at least one worker")
+	}
+	o := NewOptions(workerPoolOptions[In]{}, opts...)
+	p := &WorkerPool[In, Out]{fn: fn, workers: workers, affinity: o.affinity}
+	n := 1
+	if o.affinity != nil {
+		n = workers
+	}
+	p.queues = make([]*FiFo[workerJob[In, Out]], n)
+	for i := range p.queues {
+		p.queues[i] = NewFiFo[workerJob[In, Out]]()
+	}
+	return p
+}
+
+// Submit queues x and returns a Future for fn's result. fn is called with
+// ctx, and a job whose ctx is done before it starts resolves with
+// ctx.Err() instead. Submit fails with ErrClosed once Run has returned.
+func (p *WorkerPool[In, Out]) Submit(ctx context.Context, x In) (*Future[Out], error) {
+	q := p.queues[0]
+	if p.affinity != nil {
+		q = p.queues[jumpHash(p.affinity(x), len(p.queues))]
+	}
+	f := &Future[Out]{done: make(chan struct{})}
+	if err := q.Put(ctx, workerJob[In, Out]{ctx: ctx, x: x, f: f}); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Run starts the workers and runs jobs until ctx is done, then waits for
+// the running jobs and returns ctx.Err(). Jobs still queued resolve with
+// ErrClosed. A pool runs once.
+func (p *WorkerPool[In, Out]) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for i := range p.workers {
+		q := p.queues[i%len(p.queues)]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				j, err := q.Get(ctx)
+				if err != nil {
+					return
+				}
+				p.run(i, j)
+			}
+		}()
+	}
+	wg.Wait()
+	var zero Out
+	for _, q := range p.queues {
+		q.Close()
+		for j, ok := q.TryGet(); ok; j, ok = q.TryGet() {
+			j.f.resolve(zero, ErrClosed)
+		}
+	}
+	return ctx.Err()
+}
+
+func (p *WorkerPool[In, Out]) run(worker int, j workerJob[In, Out]) {
+	if err := j.ctx.Err(); err != nil {
+		var zero Out
+		j.f.resolve(zero, err)
+		return
+	}
+	ctx := context.WithValue(j.ctx, workerKey{}, worker)
+	j.f.resolve(CatchPanic(func() (Out, error) { return p.fn(ctx, j.x) }))
+}
+
+// Size returns the number of jobs waiting for a worker.
+func (p *WorkerPool[In, Out]) Size() int {
+	n := 0
+	for _, q := range p.queues {
+		n += q.Size()
+	}
+	return n
+}
+
+type workerKey struct{}
+
+// WorkerIndex returns the index of the WorkerPool worker running the job
+// whose context is ctx, for keeping per-worker state under WithAffinity.
+func WorkerIndex(ctx context.Context) (int, bool) {
+	i, ok := ctx.Value(workerKey{}).(int)
+	return i, ok
+}
+
+// WorkerPoolStats is the debug view of a WorkerPool returned by Inspect.
+type WorkerPoolStats struct {
+	Workers  int  `json:"workers"`
+	Queued   int  `json:"queued"`
+	Affinity bool `json:"affinity,omitempty"`
+}
+
+// Inspect reports the pool's workers and queued jobs for DebugHandler.
+func (p *WorkerPool[In, Out]) Inspect() any {
+	return WorkerPoolStats{Workers: p.workers, Queued: p.Size(), Affinity: p.affinity != nil}
+}
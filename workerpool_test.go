@@ -0,0 +1,141 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func startWorkerPool[In, Out any](t *testing.T, p *WorkerPool[In, Out]) context.CancelFunc {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return cancel
+}
+
+func TestWorkerPool_Futures(t *testing.T) {
+	p := NewWorkerPool(3, func(_ context.Context, x int) (int, error) {
+		if x < 0 {
+			return 0, errors.New("negative")
+		}
+		if x == 0 {
+			panic("zero")
+		}
+		return x * x, nil
+	})
+	startWorkerPool(t, p)
+	ctx := context.Background()
+	var futures []*Future[int]
+	for x := 1; x <= 10; x++ {
+		f, err := p.Submit(ctx, x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		futures = append(futures, f)
+	}
+	for i, f := range futures {
+		if v, err := f.Wait(ctx); err != nil || v != (i+1)*(i+1) {
+			t.Fatalf("job %d = %d, %v", i+1, v, err)
+		}
+	}
+	f, _ := p.Submit(ctx, -1)
+	if _, err := f.Wait(ctx); err == nil || err.Error() != "negative" {
+		t.Fatalf("failing job = %v", err)
+	}
+	f, _ = p.Submit(ctx, 0)
+	if _, err := f.Wait(ctx); err == nil {
+		t.Fatal("panicking job reported no error")
+	}
+}
+
+func TestWorkerPool_Affinity(t *testing.T) {
+	type job struct{ key, seq int }
+	var (
+		mu      sync.Mutex
+		seen    = make(map[int][]int)
+		workers = make(map[int]map[int]bool)
+	)
+	p := NewWorkerPool(4, func(ctx context.Context, j job) (int, error) {
+		w, _ := WorkerIndex(ctx)
+		mu.Lock()
+		defer mu.Unlock()
+		seen[j.key] = append(seen[j.key], j.seq)
+		if workers[j.key] == nil {
+			workers[j.key] = make(map[int]bool)
+		}
+		workers[j.key][w] = true
+		return w, nil
+	}, WithAffinity(func(j job) int { return j.key }))
+	startWorkerPool(t, p)
+
+	ctx := context.Background()
+	var futures []*Future[int]
+	for seq := range 50 {
+		for key := range 8 {
+			f, err := p.Submit(ctx, job{key, seq})
+			if err != nil {
+				t.Fatal(err)
+			}
+			futures = append(futures, f)
+		}
+	}
+	for _, f := range futures {
+		if _, err := f.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for key := range 8 {
+		if !slices.IsSorted(seen[key]) || len(seen[key]) != 50 {
+			t.Fatalf("key %d ran out of order: %v", key, seen[key])
+		}
+		if len(workers[key]) != 1 {
+			t.Fatalf("key %d ran on workers %v", key, workers[key])
+		}
+	}
+}
+
+func TestWorkerPool_Shutdown(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	p := NewWorkerPool(1, func(context.Context, string) (string, error) {
+		started <- struct{}{}
+		<-release
+		return "done", nil
+	})
+	cancel := startWorkerPool(t, p)
+	ctx := context.Background()
+	running, _ := p.Submit(ctx, "running")
+	<-started
+	queued, _ := p.Submit(ctx, "queued")
+
+	expired, stop := context.WithCancel(ctx)
+	stop()
+	if _, err := p.Submit(expired, "expired"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Submit with a done ctx = %v", err)
+	}
+
+	cancel()
+	time.Sleep(5 * time.Millisecond)
+	close(release)
+	if v, err := running.Wait(ctx); err != nil || v != "done" {
+		t.Fatalf("running job = %q, %v", v, err)
+	}
+	if _, err := queued.Wait(ctx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("queued job = %v", err)
+	}
+	waitFor(t, func() bool {
+		_, err := p.Submit(ctx, "late")
+		return errors.Is(err, ErrClosed)
+	})
+}